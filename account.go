@@ -0,0 +1,16 @@
+package whatapi
+
+import "net/url"
+
+// GetAccountInfo retrieves the current user's account, including their stats and unread counts. It always goes to the site, since cached stats are of little use.
+func (w *ClientStruct) GetAccountInfo() (Account, error) {
+	account := AccountResponse{}
+	requestURL, err := w.ajaxURL("index", url.Values{})
+	if err != nil {
+		return account.Response, err
+	}
+	if err = w.getJSON(w.context(), w, requestURL, &account); err != nil {
+		return account.Response, err
+	}
+	return account.Response, checkResponseStatus(account.Status, account.Error)
+}
@@ -0,0 +1,123 @@
+package whatapi
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultAlbumScore is the least score FindAlbum reports unless told
+// otherwise.
+const DefaultAlbumScore = 0.6
+
+// AlbumOptions are the options of FindAlbum.
+type AlbumOptions struct {
+	// ReleaseType, if set, only finds groups of that release type, such
+	// as 1 for albums.
+	ReleaseType int
+	// MinScore is the least score of the candidates returned. 0 means
+	// DefaultAlbumScore.
+	MinScore float64
+	// Limit is the most candidates returned. 0 means all of them.
+	Limit int
+}
+
+// AlbumCandidate is a torrent group that may be the album searched for,
+// with how confident FindAlbum is that it is, from 0 to 1.
+type AlbumCandidate struct {
+	Group TorrentSearchResultStruct
+	Score float64
+}
+
+// editionWords are the words that, at the end of a title, describe the
+// edition rather than name the album, as in "OK Computer - Collector's
+// Edition" or "Dummy 20th Anniversary Remastered".
+var editionWords = map[string]bool{
+	"edition": true, "version": true, "remaster": true, "remastered": true,
+	"reissue": true, "deluxe": true, "expanded": true, "special": true,
+	"limited": true, "collector": true, "collectors": true, "s": true,
+	"anniversary": true, "bonus": true, "track": true, "tracks": true,
+	"super": true, "legacy": true, "mono": true, "stereo": true,
+}
+
+// albumTitle normalises s as similarity does, and drops the words at the
+// end that describe the edition, along with any years or ordinals among
+// them, so that the album's name is left.
+func albumTitle(s string) string {
+	words := strings.Fields(normalise(s))
+	n, edition := len(words), false
+	for n > 1 && (editionWords[words[n-1]] || isNumbering(words[n-1])) {
+		edition = edition || editionWords[words[n-1]]
+		n--
+	}
+	if !edition {
+		// just numbers, as in "Vol 2", are part of the name
+		n = len(words)
+	}
+	return strings.Join(words[:n], " ")
+}
+
+// isNumbering reports whether w is a number or an ordinal such as "20th".
+func isNumbering(w string) bool {
+	digits := strings.TrimRightFunc(w, unicode.IsLetter)
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return !unicode.IsDigit(r) }) >= 0 {
+		return false
+	}
+	switch w[len(digits):] {
+	case "", "st", "nd", "rd", "th":
+		return true
+	}
+	return false
+}
+
+// albumScore weighs the title over the artist, and the year least, as
+// reissues are often filed under the original year.
+func albumScore(g TorrentSearchResultStruct, artist, album string, year int) float64 {
+	a := 1.0
+	if artist != "" {
+		a = similarity(g.Artist(), artist)
+	}
+	return 0.35*a + 0.5*similarity(albumTitle(g.Name()), albumTitle(album)) + 0.15*yearScore(g.Year(), year)
+}
+
+// FindAlbum searches for the torrent groups that may be album by artist, released in year, and returns them with their scores, best first. Titles are compared ignoring case, punctuation and edition suffixes such as "(Deluxe Edition)" or "- 2011 Remaster". A year of 0 matches any year.
+func (w *ClientStruct) FindAlbum(artist, album string, year int, opts AlbumOptions) ([]AlbumCandidate, error) {
+	minScore := opts.MinScore
+	if minScore == 0 {
+		minScore = DefaultAlbumScore
+	}
+	title := albumTitle(album)
+	searches := []struct {
+		searchStr string
+		opts      TorrentSearchOptions
+	}{
+		{"", TorrentSearchOptions{ArtistName: artist, GroupName: title, ReleaseType: opts.ReleaseType}},
+		// the site's search is literal, so fall back to words anywhere
+		{strings.TrimSpace(normalise(artist) + " " + title), TorrentSearchOptions{ReleaseType: opts.ReleaseType}},
+	}
+	seen := map[int]bool{}
+	candidates := []AlbumCandidate{}
+	for _, s := range searches {
+		results, err := w.SearchTorrentsWith(s.searchStr, s.opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range results.Results {
+			if !g.IsMusic() || seen[g.ID()] {
+				continue
+			}
+			seen[g.ID()] = true
+			if score := albumScore(g, artist, album, year); score >= minScore {
+				candidates = append(candidates, AlbumCandidate{Group: g, Score: score})
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if opts.Limit > 0 && len(candidates) > opts.Limit {
+		candidates = candidates[:opts.Limit]
+	}
+	return candidates, nil
+}
@@ -0,0 +1,65 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAlbumTitle(t *testing.T) {
+	for _, c := range []struct{ in, want string }{
+		{"OK Computer", "ok computer"},
+		{"OK Computer (Collector's Edition)", "ok computer"},
+		{"OK Computer - Collector's Edition", "ok computer"},
+		{"Dummy 20th Anniversary Remastered", "dummy"},
+		{"Abbey Road - 2019 Remaster", "abbey road"},
+		{"The Bends", "bends"},
+		{"Greatest Hits Vol. 2", "greatest hits vol 2"},
+		{"1999", "1999"},
+		{"Deluxe", "deluxe"},
+	} {
+		if got := albumTitle(c.in); got != c.want {
+			t.Errorf("albumTitle(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestFindAlbum(t *testing.T) {
+	var queries []url.Values
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		if r.URL.Query().Get("groupname") != "" {
+			// the literal search finds nothing
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[]}}`))
+			return
+		}
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[
+{"groupId":1,"groupName":"OK Computer OKNOTOK 1997 2017","artist":"Radiohead","groupYear":2017,"torrents":[{"torrentId":11}]},
+{"groupId":2,"groupName":"OK Computer","artist":"Radiohead","groupYear":1997,"torrents":[{"torrentId":21}]},
+{"groupId":3,"groupName":"Kid A","artist":"Radiohead","groupYear":2000,"torrents":[{"torrentId":31}]}]}}`))
+	})
+	found, err := c.FindAlbum("Radiohead", "OK Computer [Remastered]", 1997, AlbumOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected a fallback search, got %v", queries)
+	}
+	if q := queries[0]; q.Get("artistname") != "Radiohead" || q.Get("groupname") != "ok computer" {
+		t.Errorf("expected artist and group filters, got %v", q)
+	}
+	if q := queries[1]; q.Get("searchstr") != "radiohead ok computer" || q.Get("groupname") != "" {
+		t.Errorf("expected a plain search, got %v", q)
+	}
+	if len(found) != 2 || found[0].Group.ID() != 2 || found[0].Score != 1 || found[1].Group.ID() != 1 {
+		t.Errorf("bad candidates %+v", found)
+	}
+
+	found, err = c.FindAlbum("Radiohead", "OK Computer", 1997, AlbumOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Group.ID() != 2 {
+		t.Errorf("expected only the best candidate, got %+v", found)
+	}
+}
@@ -0,0 +1,30 @@
+package whatapi
+
+// WithAPIKey authenticates every request with key, an API token made in
+// the user's settings on sites with the CapAPIKey capability, instead of
+// a login session. The client still needs Login to be called, to fetch
+// the account's keys, but no password is sent.
+func WithAPIKey(key string) Option {
+	return func(w *ClientStruct) error {
+		w.apiKey = key
+		return nil
+	}
+}
+
+// LoginWithAPIKey logs in as username with key, as Login does for a client
+// made with WithAPIKey, for keys that are only known once the client has
+// been made. Call it before the client makes any other requests.
+func (w *ClientStruct) LoginWithAPIKey(username, key string) error {
+	w.apiKey = key
+	return w.Login(username, "")
+}
+
+// authorization returns the Authorization header for the client's API
+// key, with the scheme the site wants. Sites without the CapAPIKey
+// capability are sent the bare key.
+func (w *ClientStruct) authorization() string {
+	if scheme, err := w.profile.action(CapAPIKey); err == nil && scheme != "" {
+		return scheme + " " + w.apiKey
+	}
+	return w.apiKey
+}
@@ -0,0 +1,35 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoginWithAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login.php" {
+			t.Error("expected no password login")
+		}
+		if r.Header.Get("Authorization") != "key" {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, "agent", WithRateLimit(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.LoginWithAPIKey("user", "key"); err != nil {
+		t.Fatal(err)
+	}
+	w := c.(*ClientStruct)
+	if authkey, passkey := w.session.keys(); !w.session.isLoggedIn() || authkey != "a" || passkey != "p" {
+		t.Errorf("expected to be logged in with the account's keys, got %q %q", authkey, passkey)
+	}
+	if w.Namespace() != "user@"+srv.URL {
+		t.Errorf("expected the client named for the user, got %q", w.Namespace())
+	}
+}
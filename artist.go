@@ -0,0 +1,105 @@
+package whatapi
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ArtistSection names a part of the artist page that some sites can leave
+// out of the response.
+type ArtistSection string
+
+const (
+	ArtistSectionTorrents ArtistSection = "torrentgroup"
+	ArtistSectionRequests ArtistSection = "requests"
+	ArtistSectionSimilar  ArtistSection = "similar"
+	ArtistSectionComments ArtistSection = "comments"
+)
+
+// ArtistOptions are the typed parameters of GetArtistLazy.
+type ArtistOptions struct {
+	// ArtistReleases limits the discography to releases where the
+	// artist is credited as a main artist.
+	ArtistReleases bool
+	// Sections lists the sections to include, on sites with the
+	// CapArtistSections capability. Other sites always return the whole
+	// page. Nil means all sections.
+	Sections []ArtistSection
+}
+
+func (o ArtistOptions) params(p SiteProfile) url.Values {
+	params := url.Values{}
+	if o.ArtistReleases {
+		params.Set("artistreleases", "1")
+	}
+	if name, err := p.action(CapArtistSections); err == nil && o.Sections != nil {
+		s := make([]string, len(o.Sections))
+		for i, sec := range o.Sections {
+			s[i] = string(sec)
+		}
+		params.Set(name, strings.Join(s, ","))
+	}
+	return params
+}
+
+// GetArtistByName retrieves the artist with the provided name, or with an alias of it, and returns the id of the artist the name resolved to. Names are tried as given and, if the site finds no such artist, HTML escaped, as the site stores them. params is not modified.
+func (w *ClientStruct) GetArtistByName(name string, params url.Values) (Artist, int, error) {
+	p := url.Values{}
+	for k, v := range params {
+		p[k] = v
+	}
+	p.Del("id")
+	p.Set("artistname", name)
+	a, err := w.GetArtist(0, p)
+	if failedStatus(err) && html.EscapeString(name) != name {
+		p.Set("artistname", html.EscapeString(name))
+		if escaped, escErr := w.GetArtist(0, p); escErr == nil {
+			a, err = escaped, nil
+		}
+	}
+	if err != nil {
+		return a, 0, err
+	}
+	return a, a.ID, nil
+}
+
+// LazyArtist is an artist page that can fetch more about the artist on
+// demand, each in its own cached request.
+type LazyArtist struct {
+	Artist
+	client   *ClientStruct
+	requests *RequestsSearch
+}
+
+// GetArtistLazy retrieves artist information using the provided artist id, fetching only the sections in opts where the site supports it.
+func (w *ClientStruct) GetArtistLazy(id int, opts ArtistOptions) (*LazyArtist, error) {
+	a, err := w.GetArtist(id, opts.params(w.profile))
+	if err != nil {
+		return nil, err
+	}
+	return &LazyArtist{Artist: a, client: w}, nil
+}
+
+// Requests returns the first page of requests for the artist, found with
+// a request search rather than the artist page, which only lists some.
+func (a *LazyArtist) Requests() (RequestsSearch, error) {
+	if a.requests != nil {
+		return *a.requests, nil
+	}
+	params := url.Values{}
+	params.Set("artistid", strconv.Itoa(a.ID))
+	r, err := a.client.SearchRequests("", params)
+	if err != nil {
+		return r, err
+	}
+	a.requests = &r
+	return r, nil
+}
+
+// Comments returns a page of comments on the artist, on sites that
+// expose them.
+func (a *LazyArtist) Comments(page int) (Comments, error) {
+	return a.client.GetArtistComments(a.ID, page)
+}
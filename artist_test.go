@@ -0,0 +1,121 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestGetArtistLazy(t *testing.T) {
+	var queries []string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("action") {
+		case "artist":
+			rw.Write([]byte(`{"status":"success","response":{"id":3,"name":"Artist"}}`))
+		case "requests":
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[{"requestId":8}]}}`))
+		}
+	}, WithSiteProfile(SiteProfile{
+		Name:    "test",
+		Actions: map[Capability]string{CapArtistSections: "sections"},
+	}))
+	a, err := c.GetArtistLazy(3, ArtistOptions{
+		ArtistReleases: true,
+		Sections:       []ArtistSection{ArtistSectionTorrents, ArtistSectionSimilar},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != 3 || a.Name() != "Artist" {
+		t.Errorf("bad artist %+v", a.Artist)
+	}
+	if exp := "action=artist&artistreleases=1&id=3&sections=torrentgroup%2Csimilar"; queries[0] != exp {
+		t.Errorf("expected query %s, got %s", exp, queries[0])
+	}
+	for i := 0; i < 2; i++ {
+		r, err := a.Requests()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Results) != 1 || r.Results[0].RequestID != 8 {
+			t.Errorf("bad requests %+v", r)
+		}
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected the requests to be fetched once, got %v", queries)
+	}
+	if exp := "action=requests&artistid=3&search="; queries[1] != exp {
+		t.Errorf("expected query %s, got %s", exp, queries[1])
+	}
+}
+
+func TestGetArtistLazyWithoutSections(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"id":3,"name":"Artist"}}`))
+	}, WithSiteProfile(GazelleProfile))
+	if _, err := c.GetArtistLazy(3, ArtistOptions{Sections: []ArtistSection{ArtistSectionTorrents}}); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=artist&id=3"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+}
+
+func TestGetArtistIDOverridesName(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"id":3,"name":"Artist"}}`))
+	})
+	if _, err := c.GetArtist(3, url.Values{"artistname": {"Other"}}); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=artist&id=3"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if _, err := c.GetArtist(0, url.Values{"artistname": {"Artist"}}); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=artist&artistname=Artist"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+}
+
+func TestGetArtistByName(t *testing.T) {
+	var names []string
+	fail := false
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("artistname")
+		names = append(names, name)
+		switch {
+		case fail:
+			rw.WriteHeader(http.StatusInternalServerError)
+		case name == "Simon &amp; Garfunkel":
+			rw.Write([]byte(`{"status":"success","response":{"id":7,"name":"Simon &amp; Garfunkel"}}`))
+		default:
+			rw.Write([]byte(`{"status":"failure","error":"no artist found"}`))
+		}
+	})
+	params := url.Values{"id": {"1"}}
+	_, id, err := c.GetArtistByName("Simon & Garfunkel", params)
+	if err != nil || id != 7 {
+		t.Fatalf("expected the escaped name to be found, got %d, %v", id, err)
+	}
+	if len(names) != 2 || names[0] != "Simon & Garfunkel" {
+		t.Errorf("expected the name as given and then escaped, got %q", names)
+	}
+	if params.Get("id") != "1" || params.Get("artistname") != "" {
+		t.Errorf("expected params to be left alone, got %v", params)
+	}
+
+	names, fail = nil, true
+	if _, _, err = c.GetArtistByName("Simon & Garfunkel", url.Values{}); err == nil {
+		t.Fatal("expected the failed request to be returned")
+	}
+	if len(names) != 1 {
+		t.Errorf("expected no retry when the site doesn't answer, got %q", names)
+	}
+}
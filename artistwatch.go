@@ -0,0 +1,86 @@
+package whatapi
+
+import (
+	"context"
+	"html"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ArtistRelease is the payload of an EventArtistRelease: a torrent group
+// new to a bookmarked artist.
+type ArtistRelease struct {
+	ArtistID   int               `json:"artistId"`
+	ArtistName string            `json:"artistName"`
+	Group      ArtistGroupStruct `json:"group"`
+}
+
+// WatchBookmarkedArtists makes the watcher also raise an
+// EventArtistRelease for each torrent group added to an artist the user
+// has bookmarked, and returns the watcher. Each poll gets the bookmarks
+// and then every bookmarked artist, so it makes a request per bookmark.
+// Each artist has its own cursor, the largest group id delivered, as a
+// new group has a larger id than any before it. The groups an artist
+// already had when the watcher first found the bookmark are not raised,
+// and an artist no longer bookmarked is no longer polled. The site doesn't
+// say when groups were added, so a release's Time is when the watcher
+// found it.
+func (wa *Watcher) WatchBookmarkedArtists() *Watcher {
+	wa.artists = true
+	return wa
+}
+
+// pollBookmarkedArtists delivers the groups added to bookmarked artists
+// since the last poll.
+func (wa *Watcher) pollBookmarkedArtists(ctx context.Context) error {
+	b, err := wa.client.GetArtistBookmarks()
+	if err != nil {
+		return err
+	}
+	for _, a := range b.Artists {
+		id, err := strconv.Atoi(a.ID)
+		if err != nil {
+			return err
+		}
+		if err = wa.pollArtist(ctx, id, html.UnescapeString(a.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollArtist delivers the groups added to artist id since the last poll,
+// or only notes its latest group if it has never been polled.
+func (wa *Watcher) pollArtist(ctx context.Context, id int, name string) error {
+	a, err := wa.client.GetArtist(id, url.Values{})
+	if err != nil {
+		return err
+	}
+	cursor := EventArtistRelease + "/" + strconv.Itoa(id)
+	_, seen, err := wa.loadCursor(ctx, cursor)
+	if err != nil {
+		return err
+	}
+	if !seen {
+		latest := 0
+		for _, g := range a.TorrentGroup {
+			if g.GroupID > latest {
+				latest = g.GroupID
+			}
+		}
+		return wa.saveCursor(ctx, cursor, latest)
+	}
+	now := time.Now()
+	events := []Event{}
+	for _, g := range a.TorrentGroup {
+		events = append(events, Event{
+			Kind:    EventArtistRelease,
+			ID:      g.GroupID,
+			Title:   g.Name(),
+			Time:    now,
+			Payload: ArtistRelease{ArtistID: id, ArtistName: name, Group: g},
+		})
+	}
+	return wa.deliver(ctx, cursor, events)
+}
@@ -0,0 +1,435 @@
+// Package bbcode renders the BBCode bodies of announcements, forum posts,
+// comments and wikis, as in their bbBody fields, to safe HTML or to plain
+// text.
+package bbcode
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pages makes links to a site's pages. A whatapi.Client is one.
+type Pages interface {
+	PageURL(endpoint string, params url.Values) (string, error)
+}
+
+// node is a run of text, if tag is empty, or a tag and what it encloses.
+type node struct {
+	tag      string
+	arg      string
+	text     string
+	raw      string // the opening tag as written
+	children []*node
+}
+
+// tags are the tags that are parsed, and whether their content is taken as
+// written rather than parsed for more tags. Any other tag is left as text.
+var tags = map[string]bool{
+	"b": false, "i": false, "u": false, "s": false,
+	"quote": false, "hide": false, "spoiler": false, "mature": false,
+	"url": false, "size": false, "color": false, "colour": false,
+	"align": false, "list": false, "*": false,
+	"code": true, "pre": true, "plain": true, "img": true,
+	"artist": true, "torrent": true, "user": true,
+}
+
+// parse parses s into a tree of nodes. Closing tags with no opening tag,
+// and opening tags that are never closed, are kept as text.
+func parse(s string) []*node {
+	root := &node{}
+	stack := []*node{root}
+	top := func() *node { return stack[len(stack)-1] }
+	text := func(t string) {
+		n := top()
+		if k := len(n.children); k > 0 && n.children[k-1].tag == "" && n.children[k-1].children == nil {
+			n.children[k-1].text += t
+			return
+		}
+		n.children = append(n.children, &node{text: t})
+	}
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '[')
+		if i < 0 {
+			text(s)
+			break
+		}
+		text(s[:i])
+		s = s[i:]
+		j := strings.IndexByte(s, ']')
+		if j < 0 {
+			text(s)
+			break
+		}
+		raw, inner := s[:j+1], s[1:j]
+		s = s[j+1:]
+		if strings.HasPrefix(inner, "/") {
+			name := strings.ToLower(inner[1:])
+			k := len(stack) - 1
+			for k > 0 && stack[k].tag != name {
+				k--
+			}
+			if k == 0 {
+				text(raw)
+				continue
+			}
+			stack = stack[:k]
+			continue
+		}
+		name, arg := inner, ""
+		if k := strings.IndexByte(inner, '='); k >= 0 {
+			name, arg = inner[:k], inner[k+1:]
+		}
+		name = strings.ToLower(name)
+		verbatim, ok := tags[name]
+		if !ok {
+			text(raw)
+			continue
+		}
+		n := &node{tag: name, arg: arg, raw: raw}
+		if name == "*" {
+			if top().tag == "*" {
+				stack = stack[:len(stack)-1]
+			}
+			if top().tag != "list" {
+				text(raw)
+				continue
+			}
+		}
+		if verbatim || name == "url" && arg == "" {
+			end := closing(s, name)
+			if end < 0 {
+				text(raw)
+				continue
+			}
+			n.children = []*node{{text: s[:end]}}
+			s = s[end+len(name)+3:]
+			top().children = append(top().children, n)
+			continue
+		}
+		top().children = append(top().children, n)
+		stack = append(stack, n)
+	}
+	// what was never closed goes back to being text
+	for k := len(stack) - 1; k > 0; k-- {
+		if n := stack[k]; n.tag != "*" {
+			n.children = append([]*node{{text: n.raw}}, n.children...)
+			n.tag = ""
+		}
+	}
+	return root.children
+}
+
+// closing returns the index in s of the closing tag of name, matched
+// case insensitively, or -1 if there isn't one. It compares in place, as
+// lower casing s could change its length and so the index.
+func closing(s, name string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "[/")
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if end := i + 2 + len(name); end < len(s) && s[end] == ']' && strings.EqualFold(s[i+2:end], name) {
+			return i
+		}
+		i += 2
+	}
+}
+
+// content returns the text a verbatim tag encloses.
+func (n *node) content() string {
+	if len(n.children) == 0 {
+		return ""
+	}
+	return n.children[0].text
+}
+
+// Renderer renders BBCode with links to a site.
+type Renderer struct {
+	pages    Pages
+	resolver *Resolver
+	linker   func(Ref) string
+}
+
+// Option configures a Renderer as it is created by NewRenderer.
+type Option func(*Renderer)
+
+// WithResolver resolves references as they are rendered, so that torrents
+// are shown by name and artists and users are linked to by id. References
+// that don't resolve are rendered as written.
+func WithResolver(res *Resolver) Option {
+	return func(r *Renderer) {
+		r.resolver = res
+	}
+}
+
+// WithLinker links references to what link returns for them rather than
+// to the site, so that apps can link to their own pages for artists,
+// torrents and users. Where link returns "", the reference links to the
+// site.
+func WithLinker(link func(Ref) string) Option {
+	return func(r *Renderer) {
+		r.linker = link
+	}
+}
+
+// NewRenderer returns a renderer that links to the pages p makes links to.
+// With a nil p, links to the site are relative.
+func NewRenderer(p Pages, opts ...Option) *Renderer {
+	r := &Renderer{pages: p}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// resolve returns the reference n makes, resolved if the renderer has a
+// resolver, and the text to show for it.
+func (r *Renderer) resolve(n *node) (Ref, string, bool) {
+	ref, ok := ref(n)
+	if !ok {
+		return ref, n.content(), false
+	}
+	if r.resolver != nil {
+		if resolved, err := r.resolver.Resolve(ref); err == nil {
+			ref = resolved
+		}
+	}
+	if ref.Kind == Torrent && ref.Name == "" {
+		return ref, strings.TrimSpace(n.content()), true
+	}
+	return ref, ref.Name, true
+}
+
+// refLink returns the link for ref.
+func (r *Renderer) refLink(ref Ref) string {
+	if r.linker != nil {
+		if l := r.linker(ref); l != "" {
+			return l
+		}
+	}
+	switch {
+	case ref.Kind == Artist && ref.ID != 0:
+		return r.link("artist.php", url.Values{"id": {strconv.Itoa(ref.ID)}})
+	case ref.Kind == Artist:
+		return r.link("artist.php", url.Values{"artistname": {ref.Name}})
+	case ref.Kind == User && ref.ID != 0:
+		return r.link("user.php", url.Values{"id": {strconv.Itoa(ref.ID)}})
+	case ref.Kind == User:
+		return r.link("user.php", url.Values{"action": {"search"}, "search": {ref.Name}})
+	}
+	params := url.Values{"id": {strconv.Itoa(ref.ID)}}
+	if ref.TorrentID != 0 {
+		params.Set("torrentid", strconv.Itoa(ref.TorrentID))
+	}
+	return r.link("torrents.php", params)
+}
+
+// link returns the URL of the site's page at endpoint with params.
+func (r *Renderer) link(endpoint string, params url.Values) string {
+	if r.pages != nil {
+		if u, err := r.pages.PageURL(endpoint, params); err == nil {
+			return u
+		}
+	}
+	if len(params) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + params.Encode()
+}
+
+// href returns the URL a [url] or [img] tag points to, resolving links to
+// the site's own pages, or "" if it isn't a http or https URL.
+func (r *Renderer) href(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+	switch {
+	case u.Scheme == "" && u.Host == "" && u.Path != "":
+		return r.link(strings.TrimPrefix(u.Path, "/"), u.Query())
+	case u.Scheme == "http" || u.Scheme == "https":
+		return u.String()
+	}
+	return ""
+}
+
+var (
+	colorRE = regexp.MustCompile(`^#?[0-9A-Za-z]+$`)
+	aligns  = map[string]bool{"left": true, "center": true, "right": true, "justify": true}
+)
+
+// HTML renders the BBCode s to HTML. Everything in s is escaped, only the
+// markup for known tags is let through, and only http and https links are
+// kept, so the result is safe to show.
+func (r *Renderer) HTML(s string) string {
+	var b strings.Builder
+	r.html(&b, parse(s))
+	return b.String()
+}
+
+func (r *Renderer) html(b *strings.Builder, nodes []*node) {
+	for _, n := range nodes {
+		r.htmlNode(b, n)
+	}
+}
+
+func (r *Renderer) htmlNode(b *strings.Builder, n *node) {
+	wrap := func(open, close string) {
+		b.WriteString(open)
+		r.html(b, n.children)
+		b.WriteString(close)
+	}
+	esc := html.EscapeString
+	switch n.tag {
+	case "":
+		b.WriteString(strings.ReplaceAll(esc(n.text), "\n", "<br />\n"))
+		r.html(b, n.children)
+	case "b":
+		wrap("<strong>", "</strong>")
+	case "i":
+		wrap("<em>", "</em>")
+	case "u":
+		wrap(`<span style="text-decoration: underline;">`, "</span>")
+	case "s":
+		wrap("<s>", "</s>")
+	case "code":
+		b.WriteString("<code>" + esc(n.content()) + "</code>")
+	case "pre":
+		b.WriteString("<pre>" + esc(n.content()) + "</pre>")
+	case "plain":
+		b.WriteString(esc(n.content()))
+	case "quote":
+		if name := quoted(n.arg); name != "" {
+			b.WriteString(`<strong class="quoteheader">` + esc(name) + "</strong> wrote: ")
+		}
+		wrap("<blockquote>", "</blockquote>")
+	case "hide", "spoiler", "mature":
+		summary := n.arg
+		if summary == "" {
+			summary = "Hidden text"
+			if n.tag == "mature" {
+				summary = "Mature content"
+			}
+		}
+		wrap(`<details class="hide"><summary>`+esc(summary)+"</summary>", "</details>")
+	case "url":
+		if n.arg == "" {
+			if h := r.href(n.content()); h != "" {
+				b.WriteString(`<a href="` + esc(h) + `" rel="noreferrer nofollow">` + esc(n.content()) + "</a>")
+			} else {
+				b.WriteString(esc(n.content()))
+			}
+			return
+		}
+		if h := r.href(n.arg); h != "" {
+			wrap(`<a href="`+esc(h)+`" rel="noreferrer nofollow">`, "</a>")
+			return
+		}
+		r.html(b, n.children)
+	case "img":
+		if h := r.href(n.content()); h != "" {
+			b.WriteString(`<img class="scale_image" src="` + esc(h) + `" alt="" />`)
+			return
+		}
+		b.WriteString(esc(n.content()))
+	case "size":
+		if size, err := strconv.Atoi(n.arg); err == nil && size >= 1 && size <= 10 {
+			wrap(`<span class="size`+strconv.Itoa(size)+`">`, "</span>")
+			return
+		}
+		r.html(b, n.children)
+	case "color", "colour":
+		if colorRE.MatchString(n.arg) {
+			wrap(`<span style="color: `+n.arg+`;">`, "</span>")
+			return
+		}
+		r.html(b, n.children)
+	case "align":
+		if a := strings.ToLower(n.arg); aligns[a] {
+			wrap(`<div style="text-align: `+a+`;">`, "</div>")
+			return
+		}
+		r.html(b, n.children)
+	case "list":
+		if n.arg != "" {
+			wrap("<ol>", "</ol>")
+			return
+		}
+		wrap("<ul>", "</ul>")
+	case "*":
+		wrap("<li>", "</li>")
+	case "artist", "user", "torrent":
+		ref, text, ok := r.resolve(n)
+		if !ok {
+			b.WriteString(esc(text))
+			return
+		}
+		b.WriteString(`<a href="` + esc(r.refLink(ref)) + `">` + esc(text) + "</a>")
+	}
+}
+
+// quoted returns the name a [quote=name|postid] quotes.
+func quoted(arg string) string {
+	if i := strings.IndexByte(arg, '|'); i >= 0 {
+		arg = arg[:i]
+	}
+	return strings.TrimSpace(arg)
+}
+
+// Text renders the BBCode s to plain text. Quotes are marked with "> ",
+// list items with "* ", and links keep their URL after their text.
+func (r *Renderer) Text(s string) string {
+	var b strings.Builder
+	r.text(&b, parse(s))
+	return b.String()
+}
+
+func (r *Renderer) text(b *strings.Builder, nodes []*node) {
+	for _, n := range nodes {
+		r.textNode(b, n)
+	}
+}
+
+func (r *Renderer) textNode(b *strings.Builder, n *node) {
+	switch n.tag {
+	case "":
+		b.WriteString(n.text)
+		r.text(b, n.children)
+	case "code", "pre", "plain":
+		b.WriteString(n.content())
+	case "artist", "user", "torrent":
+		_, text, _ := r.resolve(n)
+		b.WriteString(text)
+	case "img":
+		b.WriteString(r.href(n.content()))
+	case "quote":
+		var q strings.Builder
+		r.text(&q, n.children)
+		if name := quoted(n.arg); name != "" {
+			b.WriteString(name + " wrote:\n")
+		}
+		lines := strings.Split(strings.Trim(q.String(), "\n"), "\n")
+		b.WriteString("> " + strings.Join(lines, "\n> ") + "\n")
+	case "url":
+		if n.arg == "" {
+			b.WriteString(n.content())
+			return
+		}
+		var t strings.Builder
+		r.text(&t, n.children)
+		b.WriteString(t.String())
+		if h := r.href(n.arg); h != "" && h != t.String() {
+			b.WriteString(" (" + h + ")")
+		}
+	case "*":
+		var t strings.Builder
+		r.text(&t, n.children)
+		b.WriteString("* " + strings.TrimSpace(t.String()) + "\n")
+	default:
+		r.text(b, n.children)
+	}
+}
@@ -0,0 +1,79 @@
+package bbcode
+
+import (
+	"net/url"
+	"testing"
+)
+
+// site makes links to pages under https://example.com/.
+type site struct{}
+
+func (site) PageURL(endpoint string, params url.Values) (string, error) {
+	u := "https://example.com/" + endpoint
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u, nil
+}
+
+func TestHTML(t *testing.T) {
+	r := NewRenderer(site{})
+	for _, c := range []struct{ in, want string }{
+		{"plain <text> & more", "plain &lt;text&gt; &amp; more"},
+		{"one\ntwo", "one<br />\ntwo"},
+		{"[b]bold[/b] [I]italic[/I] [u]under[/u] [s]struck[/s]",
+			`<strong>bold</strong> <em>italic</em> <span style="text-decoration: underline;">under</span> <s>struck</s>`},
+		{"[b]unclosed", "[b]unclosed"},
+		{"stray[/b]", "stray[/b]"},
+		{"[unknown]x[/unknown]", "[unknown]x[/unknown]"},
+		{"[quote=alice|123]hi [b]there[/b][/quote]",
+			`<strong class="quoteheader">alice</strong> wrote: <blockquote>hi <strong>there</strong></blockquote>`},
+		{"[quote]a[quote]b[/quote][/quote]", "<blockquote>a<blockquote>b</blockquote></blockquote>"},
+		{"[hide]secret[/hide]", `<details class="hide"><summary>Hidden text</summary>secret</details>`},
+		{"[hide=Tracklist]1. Airbag[/hide]", `<details class="hide"><summary>Tracklist</summary>1. Airbag</details>`},
+		{"[code][b]not bold[/b] <x>[/code]", "<code>[b]not bold[/b] &lt;x&gt;</code>"},
+		{"[url]https://example.org/?a=1&b=2[/url]",
+			`<a href="https://example.org/?a=1&amp;b=2" rel="noreferrer nofollow">https://example.org/?a=1&amp;b=2</a>`},
+		{"[url=https://example.org/][b]site[/b][/url]",
+			`<a href="https://example.org/" rel="noreferrer nofollow"><strong>site</strong></a>`},
+		{"[url=javascript:alert(1)]click[/url]", "click"},
+		{"[url=/forums.php?action=viewthread&threadid=5]thread[/url]",
+			`<a href="https://example.com/forums.php?action=viewthread&amp;threadid=5" rel="noreferrer nofollow">thread</a>`},
+		{`[img]https://example.org/a.jpg[/img]`, `<img class="scale_image" src="https://example.org/a.jpg" alt="" />`},
+		{`[img]javascript:alert(1)[/img]`, "javascript:alert(1)"},
+		{"[size=4]big[/size] [size=99]huge[/size]", `<span class="size4">big</span> huge`},
+		{`[color=red]red[/color] [color=red;background:url(x)]no[/color]`, `<span style="color: red;">red</span> no`},
+		{"[align=center]mid[/align]", `<div style="text-align: center;">mid</div>`},
+		{"[list][*]one[*]two[/list]", "<ul><li>one</li><li>two</li></ul>"},
+		{"[artist]Sigur Rós[/artist]",
+			`<a href="https://example.com/artist.php?artistname=Sigur+R%C3%B3s">Sigur Rós</a>`},
+		{"[user]alice[/user]", `<a href="https://example.com/user.php?action=search&amp;search=alice">alice</a>`},
+		{"[torrent]42[/torrent]", `<a href="https://example.com/torrents.php?id=42">42</a>`},
+		{`[b onclick="x"]x[/b]`, `[b onclick=&#34;x&#34;]x[/b]`},
+	} {
+		if got := r.HTML(c.in); got != c.want {
+			t.Errorf("HTML(%q)\n got %q\nwant %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestHTMLRelative(t *testing.T) {
+	if got, want := NewRenderer(nil).HTML("[torrent]42[/torrent]"), `<a href="torrents.php?id=42">42</a>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestText(t *testing.T) {
+	r := NewRenderer(site{})
+	for _, c := range []struct{ in, want string }{
+		{"[b]bold[/b] and [artist]Radiohead[/artist]", "bold and Radiohead"},
+		{"[quote=alice]one\ntwo[/quote]", "alice wrote:\n> one\n> two\n"},
+		{"[url=https://example.org/]site[/url]", "site (https://example.org/)"},
+		{"[list][*]one\n[*]two\n[/list]", "* one\n* two\n"},
+		{"[hide]secret[/hide]", "secret"},
+	} {
+		if got := r.Text(c.in); got != c.want {
+			t.Errorf("Text(%q)\n got %q\nwant %q", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build go1.18
+// +build go1.18
+
+package bbcode
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzHTML checks that rendering never panics, and that valid UTF-8 in
+// renders as valid UTF-8 out, however the site's text is marked up.
+func FuzzHTML(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"[b]bold[/b] [url=https://example.com/]link[/url]",
+		"[code]ȺȺȺȺȺ[/CODE]tail",
+		"[code]İİİİİİİİ[/code]x",
+		"[quote=a][list][*]one[*]two[/list]",
+		"[/[/code",
+	} {
+		f.Add(s)
+	}
+	r := NewRenderer(site{})
+	f.Fuzz(func(t *testing.T, s string) {
+		h := r.HTML(s)
+		_ = r.Text(s)
+		if utf8.ValidString(s) && !utf8.ValidString(h) {
+			t.Errorf("HTML(%q) = %q, which isn't valid UTF-8", s, h)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package bbcode
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// ErrNotFound is returned when a reference names nothing on the site.
+var ErrNotFound = errors.New("bbcode: reference not found")
+
+// Kind is what a reference refers to.
+type Kind int
+
+// The kinds of reference.
+const (
+	Artist Kind = iota + 1
+	Torrent
+	User
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Artist:
+		return "artist"
+	case Torrent:
+		return "torrent"
+	case User:
+		return "user"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Ref is an [artist], [torrent] or [user] tag. Artists and users are
+// referred to by name and torrents by id, and Resolver fills in the rest.
+type Ref struct {
+	Kind Kind
+	// Name is the artist's or user's name, or the torrent group's as
+	// "Artist - Album (Year)".
+	Name string
+	// ID is the artist's, user's or torrent group's id.
+	ID int
+	// TorrentID is the torrent in the group, if a [torrent] tag's link
+	// names one.
+	TorrentID int
+}
+
+// ref returns the reference n, an [artist], [torrent] or [user] tag, makes.
+// The second result is false for a [torrent] tag without an id.
+func ref(n *node) (Ref, bool) {
+	t := strings.TrimSpace(n.content())
+	switch n.tag {
+	case "artist":
+		return Ref{Kind: Artist, Name: t}, true
+	case "user":
+		return Ref{Kind: User, Name: t}, true
+	}
+	if id, err := strconv.Atoi(t); err == nil {
+		return Ref{Kind: Torrent, ID: id}, true
+	}
+	u, err := url.Parse(t)
+	if err != nil {
+		return Ref{}, false
+	}
+	id, _ := strconv.Atoi(u.Query().Get("id"))
+	torrentID, _ := strconv.Atoi(u.Query().Get("torrentid"))
+	return Ref{Kind: Torrent, ID: id, TorrentID: torrentID}, id != 0
+}
+
+// Refs returns the references in the BBCode s, in the order they appear.
+func Refs(s string) []Ref {
+	var refs []Ref
+	var walk func([]*node)
+	walk = func(nodes []*node) {
+		for _, n := range nodes {
+			switch n.tag {
+			case "artist", "torrent", "user":
+				if r, ok := ref(n); ok {
+					refs = append(refs, r)
+				}
+			default:
+				walk(n.children)
+			}
+		}
+	}
+	walk(parse(s))
+	return refs
+}
+
+// Site looks up what references refer to. A whatapi.Client is one, and
+// going through one wrapped by whatapi.Cache keeps repeated lookups off
+// the site.
+type Site interface {
+	GetArtistByName(name string, params url.Values) (whatapi.Artist, int, error)
+	GetTorrentGroup(id int, params url.Values) (whatapi.TorrentGroup, error)
+	SearchUsers(searchStr string, params url.Values) (whatapi.UserSearch, error)
+}
+
+// Resolver fills in references by looking them up on a site, and
+// remembers what it has looked up. It is safe for concurrent use.
+type Resolver struct {
+	site Site
+
+	mu    sync.Mutex
+	known map[Ref]Ref
+}
+
+// NewResolver returns a resolver that looks references up on s.
+func NewResolver(s Site) *Resolver {
+	return &Resolver{site: s, known: map[Ref]Ref{}}
+}
+
+// Resolve returns ref with its id, for an artist or user, or its name,
+// for a torrent, filled in. It returns ErrNotFound for a user that no
+// user search finds.
+func (r *Resolver) Resolve(ref Ref) (Ref, error) {
+	r.mu.Lock()
+	known, ok := r.known[ref]
+	r.mu.Unlock()
+	if ok {
+		return known, nil
+	}
+	resolved, err := r.resolve(ref)
+	if err != nil {
+		return ref, err
+	}
+	r.mu.Lock()
+	r.known[ref] = resolved
+	r.mu.Unlock()
+	return resolved, nil
+}
+
+func (r *Resolver) resolve(ref Ref) (Ref, error) {
+	switch ref.Kind {
+	case Artist:
+		a, id, err := r.site.GetArtistByName(ref.Name, url.Values{})
+		if err != nil {
+			return ref, err
+		}
+		ref.ID, ref.Name = id, a.Name()
+	case Torrent:
+		g, err := r.site.GetTorrentGroup(ref.ID, url.Values{})
+		if err != nil {
+			return ref, err
+		}
+		ref.Name = g.Group.String()
+	case User:
+		s, err := r.site.SearchUsers(ref.Name, url.Values{})
+		if err != nil {
+			return ref, err
+		}
+		for _, u := range s.Results {
+			if strings.EqualFold(u.Username, ref.Name) {
+				ref.ID, ref.Name = u.UserID, u.Username
+				return ref, nil
+			}
+		}
+		return ref, ErrNotFound
+	}
+	return ref, nil
+}
@@ -0,0 +1,106 @@
+package bbcode
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// lookups is a site with one artist, one torrent group and one user, that
+// counts the lookups it answers.
+type lookups struct {
+	t     *testing.T
+	asked int
+}
+
+func (l *lookups) GetArtistByName(name string, params url.Values) (whatapi.Artist, int, error) {
+	l.asked++
+	return whatapi.Artist{ID: 5, NameF: "Radiohead"}, 5, nil
+}
+
+func (l *lookups) GetTorrentGroup(id int, params url.Values) (whatapi.TorrentGroup, error) {
+	l.asked++
+	var g whatapi.TorrentGroup
+	err := json.Unmarshal([]byte(`{"group":{"id":`+strconv.Itoa(id)+`,"name":"OK Computer","year":1997,"releaseType":1,
+"musicInfo":{"artists":[{"id":5,"name":"Radiohead"}]}}}`), &g)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return g, nil
+}
+
+func (l *lookups) SearchUsers(searchStr string, params url.Values) (whatapi.UserSearch, error) {
+	l.asked++
+	return whatapi.UserSearch{Results: []whatapi.UserSearchResult{
+		{UserID: 8, Username: "alicia"},
+		{UserID: 7, Username: "Alice"},
+	}}, nil
+}
+
+func TestRefs(t *testing.T) {
+	got := Refs("[artist]Radiohead[/artist] [quote][user]alice[/user][/quote] [torrent]42[/torrent]" +
+		" [torrent]https://example.com/torrents.php?id=43&torrentid=9[/torrent] [torrent]nonsense[/torrent]" +
+		" [code][artist]not a ref[/artist][/code]")
+	want := []Ref{
+		{Kind: Artist, Name: "Radiohead"},
+		{Kind: User, Name: "alice"},
+		{Kind: Torrent, ID: 42},
+		{Kind: Torrent, ID: 43, TorrentID: 9},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	l := &lookups{t: t}
+	res := NewResolver(l)
+	for _, c := range []struct{ in, want Ref }{
+		{Ref{Kind: Artist, Name: "radiohead"}, Ref{Kind: Artist, Name: "Radiohead", ID: 5}},
+		{Ref{Kind: User, Name: "alice"}, Ref{Kind: User, Name: "Alice", ID: 7}},
+		{Ref{Kind: Torrent, ID: 42}, Ref{Kind: Torrent, Name: "Radiohead - OK Computer (1997)", ID: 42}},
+		{Ref{Kind: Artist, Name: "radiohead"}, Ref{Kind: Artist, Name: "Radiohead", ID: 5}},
+	} {
+		got, err := res.Resolve(c.in)
+		if err != nil || got != c.want {
+			t.Errorf("Resolve(%+v) = %+v, %v, want %+v", c.in, got, err, c.want)
+		}
+	}
+	if l.asked != 3 {
+		t.Errorf("expected repeated references to be remembered, asked %d times", l.asked)
+	}
+	if _, err := res.Resolve(Ref{Kind: User, Name: "bob"}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRenderResolved(t *testing.T) {
+	r := NewRenderer(site{}, WithResolver(NewResolver(&lookups{t: t})))
+	in := "[artist]radiohead[/artist] [torrent]42[/torrent] [user]bob[/user]"
+	want := `<a href="https://example.com/artist.php?id=5">Radiohead</a> ` +
+		`<a href="https://example.com/torrents.php?id=42">Radiohead - OK Computer (1997)</a> ` +
+		`<a href="https://example.com/user.php?action=search&amp;search=bob">bob</a>`
+	if got := r.HTML(in); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	if got, want := r.Text(in), "Radiohead Radiohead - OK Computer (1997) bob"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderLinker(t *testing.T) {
+	r := NewRenderer(site{}, WithLinker(func(ref Ref) string {
+		if ref.Kind == Torrent {
+			return "app://group/" + strconv.Itoa(ref.ID)
+		}
+		return ""
+	}))
+	want := `<a href="app://group/42">42</a> <a href="https://example.com/artist.php?artistname=Radiohead">Radiohead</a>`
+	if got := r.HTML("[torrent]42[/torrent] [artist]Radiohead[/artist]"); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package whatapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InfoHash returns the SHA-1 of the info dictionary of the bencoded
+// .torrent file in data, hex encoded in upper case as the API reports it.
+func InfoHash(data []byte) (string, error) {
+	if len(data) == 0 || data[0] != 'd' {
+		return "", fmt.Errorf("torrent is not a dictionary")
+	}
+	for i := 1; i < len(data) && data[i] != 'e'; {
+		key, next, err := bencodeString(data, i)
+		if err != nil {
+			return "", err
+		}
+		end, err := bencodeSkip(data, next)
+		if err != nil {
+			return "", err
+		}
+		if key == "info" {
+			if data[next] != 'd' {
+				return "", fmt.Errorf("torrent info is not a dictionary")
+			}
+			sum := sha1.Sum(data[next:end])
+			return strings.ToUpper(hex.EncodeToString(sum[:])), nil
+		}
+		i = end
+	}
+	return "", fmt.Errorf("torrent has no info dictionary")
+}
+
+// bencodeString decodes the string starting at data[i] and returns it
+// with the index just past it.
+func bencodeString(data []byte, i int) (string, int, error) {
+	colon := i
+	for colon < len(data) && data[colon] != ':' {
+		colon++
+	}
+	if colon == len(data) {
+		return "", 0, fmt.Errorf("unterminated string length at %d", i)
+	}
+	n, err := strconv.Atoi(string(data[i:colon]))
+	if err != nil || n < 0 || n > len(data)-colon-1 {
+		return "", 0, fmt.Errorf("bad string length at %d", i)
+	}
+	end := colon + 1 + n
+	return string(data[colon+1 : end]), end, nil
+}
+
+// bencodeSkip returns the index just past the value starting at data[i].
+func bencodeSkip(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, fmt.Errorf("unexpected end of torrent")
+	}
+	switch data[i] {
+	case 'i':
+		for j := i + 1; j < len(data); j++ {
+			if data[j] == 'e' {
+				return j + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated integer at %d", i)
+	case 'l', 'd':
+		j := i + 1
+		for j < len(data) && data[j] != 'e' {
+			var err error
+			if j, err = bencodeSkip(data, j); err != nil {
+				return 0, err
+			}
+		}
+		if j >= len(data) {
+			return 0, fmt.Errorf("unterminated container at %d", i)
+		}
+		return j + 1, nil
+	default:
+		_, end, err := bencodeString(data, i)
+		return end, err
+	}
+}
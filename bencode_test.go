@@ -0,0 +1,27 @@
+package whatapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestInfoHash(t *testing.T) {
+	info := "d6:lengthi12e4:name5:a.txt12:piece lengthi16384e6:pieces0:e"
+	torrent := "d8:announce3:url4:listl1:ai2ee4:info" + info + "e"
+	sum := sha1.Sum([]byte(info))
+	exp := strings.ToUpper(hex.EncodeToString(sum[:]))
+	h, err := InfoHash([]byte(torrent))
+	if err != nil {
+		t.Fatalf("InfoHash returned an error: %s", err)
+	}
+	if h != exp {
+		t.Errorf("expected info hash %s, got %s", exp, h)
+	}
+	for _, bad := range []string{"", "l1:ae", "d4:infoi1e", "d3:foo99:ae", "d3:fooi1ee"} {
+		if h, err := InfoHash([]byte(bad)); err == nil {
+			t.Errorf("expected InfoHash(%q) to fail, got %s", bad, h)
+		}
+	}
+}
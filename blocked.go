@@ -0,0 +1,102 @@
+package whatapi
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxBlockedPageSize is as much of an error page as is read looking for
+// the signs of a challenge or maintenance page.
+const maxBlockedPageSize = 64 << 10
+
+// BlockedError is returned when the site answers with a Cloudflare
+// challenge or a maintenance page rather than the response. Err is
+// ErrCloudflareChallenge or ErrSiteMaintenance, so errors.Is tells the
+// two apart.
+type BlockedError struct {
+	Err error
+	// RetryAfter is how long the site asked to be left alone for, or 0
+	// if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *BlockedError) Error() string {
+	if e.RetryAfter > 0 {
+		return e.Err.Error() + ", retry after " + e.RetryAfter.String()
+	}
+	return e.Err.Error()
+}
+
+func (e *BlockedError) Unwrap() error {
+	return e.Err
+}
+
+// blocked returns a BlockedError if resp, whose body starts with body, is
+// a challenge or maintenance page, otherwise nil.
+func blocked(resp *http.Response, body []byte) error {
+	var err error
+	switch {
+	case isChallengePage(resp, body):
+		err = ErrCloudflareChallenge
+	case isMaintenancePage(resp, body):
+		err = ErrSiteMaintenance
+	default:
+		return nil
+	}
+	return &BlockedError{Err: err, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+}
+
+func isChallengePage(resp *http.Response, body []byte) bool {
+	if resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	return bytes.Contains(body, []byte("cf-chl")) ||
+		bytes.Contains(body, []byte("challenge-platform")) ||
+		bytes.Contains(body, []byte("cf_chl_opt"))
+}
+
+// isMaintenancePage looks for maintenance in the title of a page, as the
+// body of an ordinary page such as a forum thread could mention it.
+func isMaintenancePage(resp *http.Response, body []byte) bool {
+	if !looksLikeHTML(body) {
+		return false
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(pageTitle(body)), []byte("maintenance"))
+}
+
+func pageTitle(body []byte) []byte {
+	lower := bytes.ToLower(body)
+	start := bytes.Index(lower, []byte("<title>"))
+	if start < 0 {
+		return nil
+	}
+	start += len("<title>")
+	end := bytes.Index(lower[start:], []byte("</title>"))
+	if end < 0 {
+		return nil
+	}
+	return body[start : start+end]
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(h string, now time.Time) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(h); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package whatapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		status int
+		header http.Header
+		body   string
+		exp    error
+	}{
+		{http.StatusForbidden, http.Header{"Cf-Mitigated": {"challenge"}}, "", ErrCloudflareChallenge},
+		{http.StatusServiceUnavailable, http.Header{},
+			`<html><script src="/cdn-cgi/challenge-platform/h/b/orchestrate/chl_page/v1"></script></html>`,
+			ErrCloudflareChallenge},
+		{http.StatusServiceUnavailable, http.Header{"Retry-After": {"120"}},
+			"<html><title>Down for Maintenance</title></html>", ErrSiteMaintenance},
+		{http.StatusOK, http.Header{}, "<html><title>Site Maintenance</title></html>", ErrSiteMaintenance},
+		{http.StatusOK, http.Header{}, "<html><title>Forums</title>scheduled maintenance</html>", nil},
+		{http.StatusOK, http.Header{}, `{"status":"success"}`, nil},
+		{http.StatusForbidden, http.Header{}, "<html>forbidden</html>", nil},
+	}
+	for i, tt := range tests {
+		err := blocked(&http.Response{StatusCode: tt.status, Header: tt.header}, []byte(tt.body))
+		if !errors.Is(err, tt.exp) || (tt.exp == nil && err != nil) {
+			t.Errorf("%d: expected %v, got %v", i, tt.exp, err)
+		}
+	}
+	var b *BlockedError
+	err := blocked(&http.Response{StatusCode: http.StatusServiceUnavailable,
+		Header: http.Header{"Retry-After": {"120"}}}, []byte("<title>maintenance</title>"))
+	if !errors.As(err, &b) || b.RetryAfter != 2*time.Minute {
+		t.Errorf("expected a retry after of 2m, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		h   string
+		exp time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"Wed, 01 Jan 2020 00:05:00 GMT", 5 * time.Minute},
+		{"Tue, 31 Dec 2019 00:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.h, now); got != tt.exp {
+			t.Errorf("%q: expected %v, got %v", tt.h, tt.exp, got)
+		}
+	}
+}
+
+func TestMaintenancePage(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Retry-After", "300")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		rw.Write([]byte("<html><title>Down for Maintenance</title></html>"))
+	})
+	_, err := c.GetTorrent(1, url.Values{})
+	if !errors.Is(err, ErrSiteMaintenance) {
+		t.Fatalf("expected %v, got %v", ErrSiteMaintenance, err)
+	}
+	var b *BlockedError
+	if !errors.As(err, &b) || b.RetryAfter != 5*time.Minute {
+		t.Errorf("expected a retry after of 5m, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// BonusItem is an item in a site's bonus point store.
+type BonusItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Price int64  `json:"price"`
+	// Amount is how many of the item, such as tokens, a purchase buys.
+	Amount int `json:"amount"`
+}
+
+// BonusStore is a site's bonus point store.
+type BonusStore interface {
+	// ListItems returns the items for sale.
+	ListItems() ([]BonusItem, error)
+	// Purchase buys the item with the provided item id.
+	Purchase(itemID string) error
+}
+
+type bonusStore struct {
+	client *ClientStruct
+	action string
+}
+
+// BonusStore returns the site's bonus point store, on sites with the CapBonusStore capability.
+func (w *ClientStruct) BonusStore() (BonusStore, error) {
+	action, err := w.profile.action(CapBonusStore)
+	if err != nil {
+		return nil, err
+	}
+	return bonusStore{client: w, action: action}, nil
+}
+
+func (s bonusStore) ListItems() ([]BonusItem, error) {
+	items := BonusItemsResponse{}
+	if err := s.client.Do(s.action, url.Values{}, &items); err != nil {
+		return nil, err
+	}
+	return items.Response, checkResponseStatus(items.Status, items.Error)
+}
+
+// Purchase is posted rather than fetched, so that it is never cached.
+func (s bonusStore) Purchase(itemID string) error {
+	params := url.Values{}
+	params.Set("action", s.action)
+	params.Set("item", itemID)
+	body, err := s.client.postForm("ajax.php", params)
+	if err != nil {
+		return err
+	}
+	var st GenericResponse
+	if err = json.Unmarshal(body, &st); err != nil {
+		return err
+	}
+	return checkResponseStatus(st.Status, st.Error)
+}
+
+// SeedingReportRow is the bonus points a seeded torrent earns.
+type SeedingReportRow struct {
+	TorrentID     int     `json:"torrentId"`
+	GroupID       int     `json:"groupId"`
+	Size          int64   `json:"size"`
+	Seeders       int     `json:"seeders"`
+	PointsPerHour float64 `json:"pointsPerHour"`
+}
+
+// SeedingReport is the bonus points earned by each torrent the user seeds.
+type SeedingReport struct {
+	PointsPerHour float64            `json:"pointsPerHour"`
+	Torrents      []SeedingReportRow `json:"torrents"`
+}
+
+// GetSeedingReport retrieves the bonus points earned by each torrent the current user seeds, on sites with the CapSeedingReport capability.
+func (w *ClientStruct) GetSeedingReport() (SeedingReport, error) {
+	report := SeedingReportResponse{}
+	action, err := w.profile.action(CapSeedingReport)
+	if err != nil {
+		return report.Response, err
+	}
+	if err = w.Do(action, url.Values{}, &report); err != nil {
+		return report.Response, err
+	}
+	return report.Response, checkResponseStatus(report.Status, report.Error)
+}
@@ -0,0 +1,95 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBonusStore(t *testing.T) {
+	var purchased string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") != "" && r.URL.Query().Get("action") != "bonus" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Method == "POST" {
+			r.ParseForm()
+			if r.PostForm.Get("action") != "bonus" || r.PostForm.Get("auth") != "authkey" {
+				t.Errorf("bad purchase %v", r.PostForm)
+			}
+			purchased = r.PostForm.Get("item")
+			if purchased == "token-100" {
+				rw.Write([]byte(`{"status":"failure","error":"not enough points"}`))
+				return
+			}
+			rw.Write([]byte(`{"status":"success","response":{}}`))
+			return
+		}
+		rw.Write([]byte(`{"status":"success","response":[{"id":"token-1","title":"1 token","price":1000,"amount":1},{"id":"token-100","title":"100 tokens","price":90000,"amount":100}]}`))
+	}, WithSiteProfile(SiteProfile{
+		Name:    "test",
+		Actions: map[Capability]string{CapBonusStore: "bonus"},
+	}))
+	c.session.setKeys("authkey", "")
+	s, err := c.BonusStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := s.ListItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[1] != (BonusItem{ID: "token-100", Title: "100 tokens", Price: 90000, Amount: 100}) {
+		t.Errorf("bad items %+v", items)
+	}
+	if err = s.Purchase("token-1"); err != nil || purchased != "token-1" {
+		t.Errorf("expected token-1 to be bought, got %q, %v", purchased, err)
+	}
+	if err = s.Purchase("token-100"); err == nil || err.Error() != "Request failed: not enough points" {
+		t.Errorf("expected the site's error, got %v", err)
+	}
+}
+
+func TestBonusStoreUnsupported(t *testing.T) {
+	c := loggedInClient(t, "http://example.com", WithSiteProfile(GazelleProfile))
+	if _, err := c.BonusStore(); err == nil {
+		t.Error("expected the bonus store to be unsupported")
+	}
+}
+
+func TestGetSeedingReport(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if a := r.URL.Query().Get("action"); a != "seeding" {
+			t.Errorf("unexpected action %s", a)
+		}
+		rw.Write([]byte(`{"status":"success","response":{"pointsPerHour":2.5,"torrents":[{"torrentId":5,"groupId":2,"size":1024,"seeders":3,"pointsPerHour":1.5},{"torrentId":6,"pointsPerHour":1}]}}`))
+	}, WithSiteProfile(SiteProfile{
+		Name:    "test",
+		Actions: map[Capability]string{CapSeedingReport: "seeding"},
+	}))
+	r, err := c.GetSeedingReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.PointsPerHour != 2.5 || len(r.Torrents) != 2 ||
+		r.Torrents[0] != (SeedingReportRow{TorrentID: 5, GroupID: 2, Size: 1024, Seeders: 3, PointsPerHour: 1.5}) {
+		t.Errorf("bad report %+v", r)
+	}
+
+	c.profile = GazelleProfile
+	if _, err = c.GetSeedingReport(); err == nil {
+		t.Error("expected the seeding report to be unsupported")
+	}
+}
+
+func TestGetAccountInfoBonusPoints(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{"username":"agent","userstats":{"uploaded":10,"downloaded":5,"bonusPoints":1200,"bonusPointsPerHour":3.5}}}`))
+	})
+	a, err := c.GetAccountInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.UserStats.BonusPoints != 1200 || a.UserStats.BonusPointsPerHour != 3.5 || a.UserStats.Uploaded != 10 {
+		t.Errorf("bad account %+v", a.UserStats)
+	}
+}
@@ -0,0 +1,625 @@
+package whatapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// busyRetryMax bounds the wait between attempts when the cache database is
+// locked by another writer.
+const busyRetryMax = 500 * time.Millisecond
+
+// busyTimeout is how long SQLite itself waits for another process's lock
+// on the connection MigrateCache migrates with.
+const busyTimeout = 5 * time.Second
+
+// isBusy reports whether err is SQLite refusing an operation because
+// another connection holds the lock. The check is on the message so that it
+// works with any SQLite driver.
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// busyWaitTimeout bounds how long an operation on the cache waits, for
+// this process's writer lock and for another process's lock, when its
+// context has no deadline of its own. A var so that tests can shorten it.
+var busyWaitTimeout = 30 * time.Second
+
+// withBusyTimeout returns ctx, with a deadline busyWaitTimeout away if it
+// has none.
+func withBusyTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, busyWaitTimeout)
+}
+
+// retryBusy runs op, and runs it again with backoff for as long as the
+// database is busy, until ctx is done or, if ctx has no deadline,
+// busyWaitTimeout has passed. A PRAGMA busy_timeout would only cover the
+// one pooled connection it was set on, so concurrent writers are handled
+// here instead.
+func retryBusy(ctx context.Context, op func() error) error {
+	ctx, cancel := withBusyTimeout(ctx)
+	defer cancel()
+	wait := 10 * time.Millisecond
+	for {
+		err := op()
+		if !isBusy(err) {
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		if wait *= 2; wait > busyRetryMax {
+			wait = busyRetryMax
+		}
+	}
+}
+
+// writers holds a lock for each cache database with writes in flight, so
+// that this process only ever has one write to a database in flight.
+// SQLite allows one writer at a time, and writers queued here don't spin
+// on a locked database. A database's lock is dropped once no writes are
+// waiting for it, so closed databases aren't kept.
+var (
+	writersMu sync.Mutex
+	writers   = map[*sql.DB]*writerLock{}
+)
+
+type writerLock struct {
+	ch chan struct{}
+	// refs counts the writes holding or waiting for the lock.
+	refs int
+}
+
+// acquireWriter returns db's writer lock, counting the caller as one of
+// its users until it calls releaseWriter.
+func acquireWriter(db *sql.DB) *writerLock {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	l, ok := writers[db]
+	if !ok {
+		l = &writerLock{ch: make(chan struct{}, 1)}
+		writers[db] = l
+	}
+	l.refs++
+	return l
+}
+
+func releaseWriter(db *sql.DB, l *writerLock) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if l.refs--; l.refs == 0 {
+		delete(writers, db)
+	}
+}
+
+// writeCache runs op, a write to db, while holding db's writer lock, and
+// retries it for as long as another process has the database locked,
+// until ctx is done or, if ctx has no deadline, busyWaitTimeout has
+// passed.
+func writeCache(ctx context.Context, db *sql.DB, op func() error) error {
+	ctx, cancel := withBusyTimeout(ctx)
+	defer cancel()
+	l := acquireWriter(db)
+	defer releaseWriter(db, l)
+	select {
+	case l.ch <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-l.ch }()
+	return retryBusy(ctx, op)
+}
+
+// cacheMigrations are the statements that bring the cache schema from each
+// version to the next; the schema is at version i+1 once cacheMigrations[i]
+// has been applied. Append to this list to change the schema, never edit an
+// entry that has shipped.
+var cacheMigrations = []string{
+	// 1: the tables Cache used to create directly, so existing caches
+	// pick up their version without losing anything.
+	`
+CREATE TABLE IF NOT EXISTS urlcache (
+    requesturl TEXT PRIMARY KEY NOT NULL,
+    body       TEXT NOT NULL,
+    timestamp  DATETIME NOT NULL
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS cookies (
+    url    TEXT PRIMARY KEY NOT NULL,
+    cookie TEXT NOT NULL
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS sessionkeys (
+    url  TEXT PRIMARY KEY NOT NULL,
+    keys BLOB NOT NULL
+) WITHOUT ROWID;
+`,
+	// 2: the Watcher's delivery cursors.
+	`
+CREATE TABLE IF NOT EXISTS watchcursors (
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    last INTEGER NOT NULL,
+    PRIMARY KEY (name, kind)
+) WITHOUT ROWID;
+`,
+	// 3: content hashes and per-response lifetimes for adaptive TTLs.
+	`
+ALTER TABLE urlcache ADD COLUMN hash BLOB;
+ALTER TABLE urlcache ADD COLUMN ttl INTEGER;
+`,
+	// 4: key responses, cookies and session keys by the namespace of
+	// the client that stored them. Rows stored before are dropped, as
+	// there's no telling which user they belong to.
+	`
+DROP TABLE urlcache;
+CREATE TABLE urlcache (
+    namespace  TEXT NOT NULL,
+    requesturl TEXT NOT NULL,
+    body       TEXT NOT NULL,
+    timestamp  DATETIME NOT NULL,
+    hash       BLOB,
+    ttl        INTEGER,
+    PRIMARY KEY (namespace, requesturl)
+) WITHOUT ROWID;
+
+DROP TABLE cookies;
+CREATE TABLE cookies (
+    namespace TEXT NOT NULL,
+    url       TEXT NOT NULL,
+    cookie    TEXT NOT NULL,
+    PRIMARY KEY (namespace, url)
+) WITHOUT ROWID;
+
+DROP TABLE sessionkeys;
+CREATE TABLE sessionkeys (
+    namespace TEXT NOT NULL,
+    url       TEXT NOT NULL,
+    keys      BLOB NOT NULL,
+    PRIMARY KEY (namespace, url)
+) WITHOUT ROWID;
+`,
+	// 5: failed logins to each site, for the login throttle.
+	`
+CREATE TABLE loginattempts (
+    url      TEXT PRIMARY KEY NOT NULL,
+    failures INTEGER NOT NULL,
+    last     DATETIME NOT NULL
+) WITHOUT ROWID;
+`,
+	// 6: requests being fetched, for WithInflightLocks. expires is in
+	// Unix nanoseconds.
+	`
+CREATE TABLE inflight (
+    namespace  TEXT NOT NULL,
+    requesturl TEXT NOT NULL,
+    expires    INTEGER NOT NULL,
+    PRIMARY KEY (namespace, requesturl)
+) WITHOUT ROWID;
+`,
+	// 7: objects stored with PutObject, as JSON.
+	`
+CREATE TABLE objects (
+    namespace TEXT NOT NULL,
+    key       TEXT NOT NULL,
+    value     TEXT NOT NULL,
+    updated   DATETIME NOT NULL,
+    PRIMARY KEY (namespace, key)
+) WITHOUT ROWID;
+`,
+	// 8: images fetched with FetchImage. They are public, so they are
+	// shared by every client using the cache.
+	`
+CREATE TABLE blobs (
+    url         TEXT PRIMARY KEY NOT NULL,
+    contenttype TEXT NOT NULL,
+    body        BLOB NOT NULL,
+    fetched     DATETIME NOT NULL
+) WITHOUT ROWID;
+`,
+}
+
+// cacheSchemaVersion is the cache schema version this package expects.
+var cacheSchemaVersion = len(cacheMigrations)
+
+// MigrateCache brings the cache schema in db to the current version,
+// applying each outstanding migration in its own transaction, and switches
+// the database to write-ahead logging so readers don't block the writer.
+// Cache calls it, so it only needs calling directly to upgrade a cache
+// without creating a client.
+//
+// Processes sharing a cache, such as a crawler and a tool reading from the
+// same file, each serialise their own writes and retry while another has
+// the database locked. MigrateCache sets a busy timeout on the connection
+// it migrates with, but the pool opens other connections itself, and this
+// package can't set one on those as it doesn't open db. Open the database
+// with a busy timeout as well, for example
+// "file:cache.db?_busy_timeout=5000" with mattn/go-sqlite3, so the driver
+// waits for the lock on every connection before giving up.
+func MigrateCache(db *sql.DB) error {
+	ctx := context.Background()
+	// one connection, as each migration's transaction is begun by hand
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA busy_timeout=%d`, busyTimeout.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		`PRAGMA journal_mode=WAL`,
+		`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`,
+	} {
+		err = writeCache(ctx, db, func() error {
+			_, err := conn.ExecContext(ctx, stmt)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for done := false; !done; {
+		err = writeCache(ctx, db, func() (err error) {
+			done, err = migrateCache(ctx, conn)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateCache applies the next outstanding migration in a transaction,
+// and reports whether there was none. The transaction takes the write
+// lock before reading the schema version, so that when two processes open
+// a cache at once only one of them applies each migration.
+func migrateCache(ctx context.Context, conn *sql.Conn) (done bool, err error) {
+	if _, err = conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return false, err
+	}
+	defer func() {
+		if err == nil {
+			_, err = conn.ExecContext(ctx, `COMMIT`)
+		}
+		if err != nil {
+			conn.ExecContext(ctx, `ROLLBACK`)
+		}
+	}()
+	var version int
+	err = conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return false, err
+	}
+	if version > cacheSchemaVersion {
+		return false, fmt.Errorf("cache schema version %d is newer than %d", version, cacheSchemaVersion)
+	}
+	if version == cacheSchemaVersion {
+		return true, nil
+	}
+	if _, err = conn.ExecContext(ctx, cacheMigrations[version]); err != nil {
+		return false, err
+	}
+	if _, err = conn.ExecContext(ctx, `DELETE FROM schema_version`); err != nil {
+		return false, err
+	}
+	_, err = conn.ExecContext(ctx, `INSERT INTO schema_version VALUES(?)`, version+1)
+	return false, err
+}
+
+// ResponseInfo describes where the response to a request came from.
+type ResponseInfo struct {
+	// FromCache is set when the response was served from the cache
+	// rather than the network.
+	FromCache bool
+	// Timestamp is when the response was fetched from the site.
+	Timestamp time.Time
+	// Age is how old the response was when it was returned.
+	Age time.Duration
+	// Retries counts the times the request was sent again, such as
+	// after passing a challenge.
+	Retries int
+	// CacheErr is why a response fetched from the site couldn't be
+	// written to the cache. The response itself is still good.
+	CacheErr error
+}
+
+type responseInfoKey struct{}
+
+// WithResponseInfo returns a context that records, in the returned
+// ResponseInfo, where the response to a GetJSONContext or DoContext call
+// made with it came from, or to a typed call, such as GetTorrent, made
+// through the view WithContext returns. Use a new context for each call.
+func WithResponseInfo(ctx context.Context) (context.Context, *ResponseInfo) {
+	info := &ResponseInfo{}
+	return context.WithValue(ctx, responseInfoKey{}, info), info
+}
+
+// ResponseInfoFrom returns the ResponseInfo a context from
+// WithResponseInfo records into, or nil if it doesn't have one.
+func ResponseInfoFrom(ctx context.Context) *ResponseInfo {
+	info, _ := ctx.Value(responseInfoKey{}).(*ResponseInfo)
+	return info
+}
+
+func setResponseInfo(ctx context.Context, fromCache bool, timestamp time.Time) {
+	if info := ResponseInfoFrom(ctx); info != nil {
+		info.FromCache = fromCache
+		info.Timestamp = timestamp
+		info.Age = time.Since(timestamp)
+	}
+}
+
+func countRetry(ctx context.Context) {
+	if info := ResponseInfoFrom(ctx); info != nil {
+		info.Retries++
+	}
+}
+
+// cachingClient is the decorator Cache wraps clients in. It only
+// intercepts FetchContext, so it can sit anywhere in a stack of
+// decorators.
+type cachingClient struct {
+	Decorator
+	db       *sql.DB
+	cacheFor time.Duration
+	// maxTTL and adaptive are set by WithAdaptiveTTL.
+	maxTTL   time.Duration
+	adaptive map[string]bool
+	// cachedPosts is set by WithCachedPosts.
+	cachedPosts map[string]bool
+	// inflightTTL and inflightWait are set by WithInflightLocks.
+	inflightTTL  time.Duration
+	inflightWait time.Duration
+	// defaults are set by WithDefaultDirectives.
+	defaults cacheDirectives
+}
+
+// CacheOption configures a client made by Cache.
+type CacheOption func(*cachingClient)
+
+// neverCache are the actions whose requests have side effects, so must
+// reach the site every time. Their responses are never cached, whatever
+// the options.
+var neverCache = map[string]bool{
+	"download":          true,
+	"upload":            true,
+	"send_message":      true,
+	"takecompose":       true,
+	"take_post":         true,
+	"add_torrent":       true,
+	"manage_handle":     true,
+	"new_handle":        true,
+	"notify_clear":      true,
+	"notify_clear_item": true,
+	"request_fill":      true,
+	"requestvote":       true,
+}
+
+// WithCachedPosts caches the responses to POSTs of the listed ajax.php
+// actions, which must be reads that happen to be sent as POSTs. Responses
+// are keyed by the URL and the posted form. Actions with side effects,
+// such as download, upload and send_message, are never cached.
+func WithCachedPosts(actions ...string) CacheOption {
+	return func(c *cachingClient) {
+		if c.cachedPosts == nil {
+			c.cachedPosts = map[string]bool{}
+		}
+		for _, a := range actions {
+			c.cachedPosts[a] = true
+		}
+	}
+}
+
+// WithAdaptiveTTL doubles how long a response is cached for, up to max,
+// each time it is refetched unchanged, and drops back to the cache
+// duration when it changes. Responses that never change, such as old
+// torrent groups, then only get refetched occasionally. It applies to the
+// responses to the listed ajax.php actions, or to all responses if none
+// are listed.
+func WithAdaptiveTTL(max time.Duration, actions ...string) CacheOption {
+	return func(c *cachingClient) {
+		c.maxTTL = max
+		c.adaptive = map[string]bool{}
+		for _, a := range actions {
+			c.adaptive[a] = true
+		}
+	}
+}
+
+// cacheEntry is a cached response.
+type cacheEntry struct {
+	body      []byte
+	timestamp time.Time
+	// ttl is how long the response is cached for, zero for the cache
+	// duration.
+	ttl  time.Duration
+	hash []byte
+}
+
+// FetchContext returns the cached response for requestURL if there is one
+// that hasn't expired, and otherwise fetches and caches it.
+func (c *cachingClient) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	if neverCache[actionOf(requestURL)] {
+		if c.directives(ctx).only {
+			return nil, ErrNotCached
+		}
+		return c.Decorator.FetchContext(ctx, requestURL)
+	}
+	return c.cached(ctx, requestURL, func() ([]byte, error) {
+		return c.Decorator.FetchContext(ctx, requestURL)
+	})
+}
+
+// PostContext caches the responses to POSTs of the actions opted in with
+// WithCachedPosts, and passes every other POST straight through.
+func (c *cachingClient) PostContext(ctx context.Context, requestURL string, form url.Values) ([]byte, error) {
+	action := actionOf(requestURL)
+	if !c.cachedPosts[action] || neverCache[action] {
+		return c.Decorator.PostContext(ctx, requestURL, form)
+	}
+	return c.cached(ctx, "POST "+requestURL+" "+form.Encode(), func() ([]byte, error) {
+		return c.Decorator.PostContext(ctx, requestURL, form)
+	})
+}
+
+// Cached returns the response cached for requestURL however old it is,
+// and when it was fetched, or a nil body if there isn't one.
+func (c *cachingClient) Cached(ctx context.Context, requestURL string) ([]byte, time.Time, error) {
+	e, err := c.lookup(ctx, requestURL)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return e.body, e.timestamp, nil
+}
+
+// cached returns the cached response for key if there is one that hasn't
+// expired, and otherwise gets it with fetch and caches it.
+func (c *cachingClient) cached(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	d := c.directives(ctx)
+	e, err := c.lookup(ctx, key)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, err
+	case d.acceptable(e, c.ttl(e)):
+		setResponseInfo(ctx, true, e.timestamp)
+		return e.body, nil
+	}
+	if d.only {
+		return nil, ErrNotCached
+	}
+	if c.inflightTTL > 0 && c.inflightWait > 0 && !d.noCache {
+		claimed, err := c.claim(ctx, key)
+		switch {
+		case err != nil:
+			// fetch it regardless
+		case claimed:
+			defer c.release(key)
+		default:
+			if other, ok := c.awaitOther(ctx, key, d); ok {
+				setResponseInfo(ctx, true, other.timestamp)
+				return other.body, nil
+			}
+		}
+	}
+	body, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	// the response is good whether or not it could be cached, so a
+	// failed write only costs a refetch next time, and is reported
+	// rather than returned
+	if err = c.updateCache(ctx, key, body, e); err != nil {
+		if info := ResponseInfoFrom(ctx); info != nil {
+			info.CacheErr = err
+		}
+	}
+	return body, nil
+}
+
+func (c *cachingClient) fresh(e cacheEntry) bool {
+	return cacheDirectives{}.acceptable(e, c.ttl(e))
+}
+
+// ttl returns how long e is cached for.
+func (c *cachingClient) ttl(e cacheEntry) time.Duration {
+	if e.ttl == 0 {
+		return c.cacheFor
+	}
+	return e.ttl
+}
+
+// nextTTL returns how long to cache body for, given the entry it replaces,
+// which is empty if there wasn't one.
+func (c *cachingClient) nextTTL(requestURL string, hash []byte, prev cacheEntry) time.Duration {
+	if c.adaptive == nil || (len(c.adaptive) > 0 && !c.adaptive[actionOf(requestURL)]) {
+		return 0
+	}
+	if prev.hash == nil || !bytes.Equal(hash, prev.hash) {
+		return 0
+	}
+	ttl := prev.ttl
+	if ttl == 0 {
+		ttl = c.cacheFor
+	}
+	if ttl *= 2; ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl
+}
+
+func (c *cachingClient) updateCache(ctx context.Context, requestURL string, body []byte, prev cacheEntry) error {
+	sum := sha256.Sum256(body)
+	hash := sum[:]
+	var ttl sql.NullInt64
+	if t := c.nextTTL(requestURL, hash, prev); t > 0 {
+		ttl = sql.NullInt64{Int64: int64(t / time.Second), Valid: true}
+	}
+	var res sql.Result
+	err := writeCache(ctx, c.db, func() (err error) {
+		res, err = c.db.ExecContext(ctx,
+			"REPLACE INTO urlcache (namespace, requesturl, body, timestamp, hash, ttl) "+
+				"VALUES(?,?,?, datetime('now'),?,?)",
+			c.Namespace(), requestURL, body, hash, ttl)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return fmt.Errorf(
+			"INSERT affected %d rows, expected 1", rows)
+	}
+	return nil
+}
+
+// lookup returns the cached response for requestURL, expired or not, or
+// sql.ErrNoRows if there isn't one.
+func (c *cachingClient) lookup(ctx context.Context, requestURL string) (cacheEntry, error) {
+	var (
+		e   cacheEntry
+		ttl sql.NullInt64
+	)
+	err := retryBusy(ctx, func() error {
+		return c.db.QueryRowContext(ctx,
+			"SELECT body, timestamp, hash, ttl FROM urlcache WHERE namespace = ? AND requesturl = ?",
+			c.Namespace(), requestURL).
+			Scan(&e.body, &e.timestamp, &e.hash, &ttl)
+	})
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	if ttl.Valid {
+		e.ttl = time.Duration(ttl.Int64) * time.Second
+	}
+	return e, nil
+}
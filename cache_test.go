@@ -0,0 +1,491 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNextTTL(t *testing.T) {
+	c := &cachingClient{cacheFor: time.Hour}
+	WithAdaptiveTTL(5*time.Hour, "torrentgroup")(c)
+	same, other := []byte{1}, []byte{2}
+	u := "https://example.com/ajax.php?action=torrentgroup&id=1"
+	for _, tc := range []struct {
+		url  string
+		hash []byte
+		prev cacheEntry
+		exp  time.Duration
+	}{
+		{u, same, cacheEntry{}, 0},
+		{u, same, cacheEntry{hash: same}, 2 * time.Hour},
+		{u, same, cacheEntry{hash: same, ttl: 2 * time.Hour}, 4 * time.Hour},
+		{u, same, cacheEntry{hash: same, ttl: 4 * time.Hour}, 5 * time.Hour},
+		{u, same, cacheEntry{hash: other, ttl: 4 * time.Hour}, 0},
+		{"https://example.com/ajax.php?action=index", same, cacheEntry{hash: same}, 0},
+	} {
+		if ttl := c.nextTTL(tc.url, tc.hash, tc.prev); ttl != tc.exp {
+			t.Errorf("nextTTL(%s, %v, %+v) = %s, expected %s", tc.url, tc.hash, tc.prev, ttl, tc.exp)
+		}
+	}
+	if ttl := (&cachingClient{cacheFor: time.Hour}).nextTTL(u, same, cacheEntry{hash: same}); ttl != 0 {
+		t.Errorf("expected no adaptive TTL without the option, got %s", ttl)
+	}
+}
+
+type passthroughClient struct {
+	Decorator
+	calls int
+}
+
+func (p *passthroughClient) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	p.calls++
+	return nil, nil
+}
+
+func (p *passthroughClient) PostContext(ctx context.Context, requestURL string, form url.Values) ([]byte, error) {
+	p.calls++
+	return nil, nil
+}
+
+func TestNeverCache(t *testing.T) {
+	// the cache has no database, so any request that reaches it panics
+	p := &passthroughClient{}
+	c := &cachingClient{Decorator: p}
+	WithCachedPosts("send_message", "browse")(c)
+	ctx := context.Background()
+	c.FetchContext(ctx, "https://example.com/ajax.php?action=download&id=1")
+	c.PostContext(ctx, "https://example.com/ajax.php?action=send_message", url.Values{})
+	c.PostContext(ctx, "https://example.com/ajax.php?action=user", url.Values{})
+	if p.calls != 3 {
+		t.Errorf("expected 3 requests to pass through the cache, got %d", p.calls)
+	}
+}
+
+func TestCacheDirectives(t *testing.T) {
+	e := cacheEntry{body: []byte("{}"), timestamp: time.Now().Add(-30 * time.Minute)}
+	for _, tc := range []struct {
+		name string
+		ds   []CacheDirective
+		exp  bool
+	}{
+		{"none", nil, true},
+		{"max age", []CacheDirective{MaxAge(10 * time.Minute)}, false},
+		{"long max age", []CacheDirective{MaxAge(2 * time.Hour)}, true},
+		{"min fresh", []CacheDirective{MinFresh(45 * time.Minute)}, false},
+		{"max stale", []CacheDirective{MaxAge(10 * time.Minute), MaxStale(time.Hour)}, true},
+		{"no cache", []CacheDirective{NoCache}, false},
+		{"zero max age", []CacheDirective{MaxAge(0)}, false},
+		{"cache only", []CacheDirective{MaxAge(time.Minute), CacheOnly}, true},
+	} {
+		d := directivesFrom(WithCacheDirectives(context.Background(), tc.ds...))
+		if got := d.acceptable(e, time.Hour); got != tc.exp {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.exp, got)
+		}
+	}
+	stale := cacheEntry{body: []byte("{}"), timestamp: time.Now().Add(-2 * time.Hour)}
+	if d := directivesFrom(WithCacheDirectives(context.Background(), MaxStale(2*time.Hour))); !d.acceptable(stale, time.Hour) {
+		t.Error("expected max stale to accept an expired response")
+	}
+}
+
+func TestMigrateCacheConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	// a database each, as two processes opening the cache would have
+	const n = 4
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		go func() { errs <- MigrateCache(db) }()
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("expected every migration to succeed, got %v", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int
+	if err = db.QueryRow(`SELECT version FROM schema_version`).Scan(&version); err != nil || version != cacheSchemaVersion {
+		t.Errorf("expected version %d, got %d, %v", cacheSchemaVersion, version, err)
+	}
+}
+
+// lockCache takes the write lock on the cache database at path from
+// another connection, as another process writing to it would, until the
+// test ends.
+func lockCache(t *testing.T, path string) {
+	t.Helper()
+	other, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { other.Close() })
+	tx, err := other.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	if _, err = tx.Exec(`DELETE FROM urlcache`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentCacheWriters(t *testing.T) {
+	db := openCache(t)
+	const n = 20
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			errs <- writeCache(context.Background(), db, func() error {
+				_, err := db.Exec(`INSERT INTO urlcache (namespace, requesturl, body, timestamp) VALUES('', ?, '{}', datetime('now'))`,
+					"https://example.com/ajax.php?action=torrent&id="+strconv.Itoa(i))
+				return err
+			})
+		}(i)
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("expected every write to succeed, got %v", err)
+		}
+	}
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM urlcache`).Scan(&count); err != nil || count != n {
+		t.Errorf("expected %d rows, got %d, %v", n, count, err)
+	}
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if _, ok := writers[db]; ok {
+		t.Error("expected the writer lock to be dropped once the writes were done")
+	}
+}
+
+func TestWriteCacheTimesOut(t *testing.T) {
+	defer func(d time.Duration) { busyWaitTimeout = d }(busyWaitTimeout)
+	busyWaitTimeout = 100 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "cache.db")
+	// so that the driver doesn't wait out the lock itself
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err = MigrateCache(db); err != nil {
+		t.Fatal(err)
+	}
+	// drop the connection MigrateCache gave its own busy timeout
+	db.SetMaxIdleConns(0)
+	lockCache(t, path)
+	start := time.Now()
+	err = writeCache(context.Background(), db, func() error {
+		_, err := db.Exec(`DELETE FROM urlcache`)
+		return err
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the write to give up, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected the write to give up after its timeout, took %v", d)
+	}
+}
+
+func TestFailedCacheWriteReported(t *testing.T) {
+	defer func(d time.Duration) { busyWaitTimeout = d }(busyWaitTimeout)
+	busyWaitTimeout = 100 * time.Millisecond
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	path := filepath.Join(t.TempDir(), "cache.db")
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cc, err := Cache(c, db, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxIdleConns(0)
+	lockCache(t, path)
+	u, _ := url.Parse(srv.URL)
+	requestURL, _ := buildURL(*u, "ajax.php", "torrent", url.Values{"id": {"1"}})
+	ctx, info := WithResponseInfo(context.Background())
+	var r GenericResponse
+	if err = cc.GetJSONContext(ctx, requestURL, &r); err != nil || r.Status != "success" {
+		t.Fatalf("expected the response despite the cache, got %v, %v", r, err)
+	}
+	if info.CacheErr == nil {
+		t.Error("expected the failed cache write to be reported")
+	}
+}
+
+func TestResponseInfo(t *testing.T) {
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	cc, err := Cache(c, openCache(t), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL)
+	requestURL, _ := buildURL(*u, "ajax.php", "torrent", url.Values{"id": {"1"}})
+
+	before := time.Now()
+	ctx, miss := WithResponseInfo(context.Background())
+	var r GenericResponse
+	if err = cc.GetJSONContext(ctx, requestURL, &r); err != nil {
+		t.Fatal(err)
+	}
+	if miss.FromCache {
+		t.Error("expected the first response to come from the site")
+	}
+	if miss.Timestamp.Before(before) || miss.Timestamp.After(time.Now()) {
+		t.Errorf("expected the response to be timestamped when it was fetched, got %v", miss.Timestamp)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	ctx, hit := WithResponseInfo(context.Background())
+	if err = cc.GetJSONContext(ctx, requestURL, &r); err != nil {
+		t.Fatal(err)
+	}
+	if !hit.FromCache {
+		t.Error("expected the second response to come from the cache")
+	}
+	if d := hit.Timestamp.Sub(miss.Timestamp); d < -time.Second || d > time.Second {
+		t.Errorf("expected the cached response to keep its fetch time %v, got %v", miss.Timestamp, hit.Timestamp)
+	}
+	if hit.Age < 10*time.Millisecond {
+		t.Errorf("expected the cached response to have aged, got %v", hit.Age)
+	}
+	if ResponseInfoFrom(context.Background()) != nil {
+		t.Error("expected no ResponseInfo in a plain context")
+	}
+}
+
+func TestTypedResponseInfo(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{"group":{"id":1},"torrent":{"id":2}}}`))
+	})
+	cc, err := Cache(c, openCache(t), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []bool{false, true} {
+		ctx, info := WithResponseInfo(context.Background())
+		if _, err := cc.WithContext(ctx).GetTorrent(2, url.Values{}); err != nil {
+			t.Fatal(err)
+		}
+		if info.FromCache != exp || info.Timestamp.IsZero() {
+			t.Errorf("expected GetTorrent to report FromCache %v with a timestamp, got %+v", exp, info)
+		}
+	}
+}
+
+func TestRetryBusyGivesUp(t *testing.T) {
+	defer func(d time.Duration) { busyWaitTimeout = d }(busyWaitTimeout)
+	busyWaitTimeout = 100 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "cache.db")
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err = MigrateCache(db); err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxIdleConns(0)
+	lockCache(t, path)
+	attempts := 0
+	start := time.Now()
+	err = retryBusy(context.Background(), func() error {
+		attempts++
+		_, err := db.Exec(`DELETE FROM urlcache`)
+		return err
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected retries to give up on the locked database, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected retries to give up after the timeout, took %v", d)
+	}
+	if attempts < 2 {
+		t.Errorf("expected the locked database to be retried, got %d attempts", attempts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err = retryBusy(ctx, func() error { return errors.New("database is locked") }); err != context.DeadlineExceeded {
+		t.Errorf("expected the context's deadline to be kept, got %v", err)
+	}
+}
+
+func TestIsBusy(t *testing.T) {
+	for _, c := range []struct {
+		err error
+		exp bool
+	}{
+		{nil, false},
+		{errors.New("database is locked"), true},
+		{errors.New("SQLITE_BUSY: cannot commit"), true},
+		{sql.ErrNoRows, false},
+	} {
+		if got := isBusy(c.err); got != c.exp {
+			t.Errorf("isBusy(%v) = %v, expected %v", c.err, got, c.exp)
+		}
+	}
+}
+
+// countingClient is a decorator counting the requests that reach it.
+type countingClient struct {
+	Decorator
+	fetches *int
+}
+
+func (c *countingClient) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	*c.fetches++
+	return c.Decorator.FetchContext(ctx, requestURL)
+}
+
+func (c *countingClient) Clone(opts ...Option) (Decorator, error) {
+	inner, err := c.Decorator.Clone(opts...)
+	if err != nil {
+		return nil, err
+	}
+	clone := &countingClient{inner, c.fetches}
+	inner.SetOuter(clone)
+	return clone, nil
+}
+
+func TestCacheWrapsDecorator(t *testing.T) {
+	w, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	fetches := 0
+	counting := &countingClient{w, &fetches}
+	w.SetOuter(counting)
+	cc, err := Cache(counting, openCache(t), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.outer != counting || w.db != nil {
+		t.Error("expected Cache to leave the client it was given as it was")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = cc.GetTorrent(1, url.Values{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected the decorator under the cache to see 1 request, got %d", fetches)
+	}
+	if _, err = counting.GetTorrent(1, url.Values{}); err != nil || fetches != 2 {
+		t.Errorf("expected the decorator to still work uncached, got %d requests, %v", fetches, err)
+	}
+	if _, err = Cache(struct{ Client }{cc}, openCache(t), time.Hour); err != errNotDecorator {
+		t.Errorf("expected a client that isn't a Decorator to be refused, got %v", err)
+	}
+}
+
+func TestDefaultDirectives(t *testing.T) {
+	hits := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		hits++
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	cc, err := Cache(c, openCache(t), time.Hour, WithDefaultDirectives(NoCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = cc.GetTorrent(1, url.Values{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("expected the typed method to follow the default directives, got %d requests", hits)
+	}
+	ctx := WithCacheDirectives(context.Background(), MaxAge(time.Hour))
+	var r GenericResponse
+	if err = cc.DoContext(ctx, "torrent", url.Values{"id": {"1"}}, &r); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 2 {
+		t.Errorf("expected the context's directives to override the defaults, got %d requests", hits)
+	}
+}
+
+func TestTypedCacheDirectives(t *testing.T) {
+	hits := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		hits++
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	cc, err := Cache(c, openCache(t), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cc.WithContext(WithCacheDirectives(context.Background(), CacheOnly)).GetTorrent(1, url.Values{}); err != ErrNotCached {
+		t.Errorf("expected CacheOnly to keep GetTorrent off the site, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = cc.GetTorrent(1, url.Values{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refresh := cc.WithContext(WithCacheDirectives(context.Background(), NoCache))
+	if _, err = refresh.GetTorrent(1, url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 2 {
+		t.Errorf("expected one request for the cached GetTorrent and one for NoCache, got %d", hits)
+	}
+}
+
+func TestNamespacesShareCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// each user sees a group named for them
+		rw.Write([]byte(`{"status":"success","response":{"group":{"id":2,"name":"` + r.Header.Get("Authorization") + `"},"torrent":{"id":5}}}`))
+	}))
+	defer srv.Close()
+	db := openCache(t)
+	clients := map[string]Client{}
+	for _, user := range []string{"alice", "bob"} {
+		w := loggedInClient(t, srv.URL, WithAPIKey(user))
+		w.session.setUser(user)
+		c, err := Cache(w, db, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients[user] = c
+	}
+	// twice, so that the second is answered from the cache
+	for i := 0; i < 2; i++ {
+		for user, c := range clients {
+			tor, err := c.GetTorrent(5, url.Values{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tor.Group.Name() != user {
+				t.Errorf("expected %s to see their own response, got %s's", user, tor.Group.Name())
+			}
+		}
+	}
+	var count int
+	if err := db.QueryRow(`SELECT count(DISTINCT namespace) FROM urlcache`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected a namespace each, got %d, %v", count, err)
+	}
+}
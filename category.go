@@ -0,0 +1,47 @@
+package whatapi
+
+// Category is a Gazelle torrent category, numbered as the API reports it
+// in categoryId fields.
+type Category int
+
+const (
+	CategoryMusic Category = iota + 1
+	CategoryApplications
+	CategoryEBooks
+	CategoryAudiobooks
+	CategoryELearningVideos
+	CategoryComedy
+	CategoryComics
+)
+
+func (c Category) String() string {
+	switch c {
+	case CategoryMusic:
+		return "Music"
+	case CategoryApplications:
+		return "Applications"
+	case CategoryEBooks:
+		return "E-Books"
+	case CategoryAudiobooks:
+		return "Audiobooks"
+	case CategoryELearningVideos:
+		return "E-Learning Videos"
+	case CategoryComedy:
+		return "Comedy"
+	case CategoryComics:
+		return "Comics"
+	default:
+		return "Invalid Category"
+	}
+}
+
+// ParseCategory returns the category with the name the site uses for it,
+// as in the category field of ungrouped search results.
+func ParseCategory(name string) (Category, bool) {
+	for c := CategoryMusic; c <= CategoryComics; c++ {
+		if c.String() == name {
+			return c, true
+		}
+	}
+	return 0, false
+}
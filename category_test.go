@@ -0,0 +1,34 @@
+package whatapi_test
+
+import (
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestParseCategory(t *testing.T) {
+	for c := whatapi.CategoryMusic; c <= whatapi.CategoryComics; c++ {
+		if got, ok := whatapi.ParseCategory(c.String()); !ok || got != c {
+			t.Errorf("expected %s to parse, got %v %v", c, got, ok)
+		}
+	}
+	if _, ok := whatapi.ParseCategory("Invalid Category"); ok {
+		t.Error("expected an unknown name not to parse")
+	}
+}
+
+func TestResultCategory(t *testing.T) {
+	for _, c := range []struct {
+		r     whatapi.TorrentSearchResultStruct
+		exp   whatapi.Category
+		music bool
+	}{
+		{whatapi.TorrentSearchResultStruct{GroupName: "OK Computer", Torrents: []whatapi.SearchTorrentStruct{{TorrentID: 1}}}, whatapi.CategoryMusic, true},
+		{whatapi.TorrentSearchResultStruct{GroupName: "SICP", TorrentID: 2, Category: "E-Books"}, whatapi.CategoryEBooks, false},
+		{whatapi.TorrentSearchResultStruct{TorrentID: 3, Category: "Holograms"}, 0, false},
+	} {
+		if got := c.r.ResultCategory(); got != c.exp || c.r.IsMusic() != c.music {
+			t.Errorf("%s: expected %v, got %v", c.r.GroupName, c.exp, got)
+		}
+	}
+}
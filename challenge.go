@@ -0,0 +1,117 @@
+package whatapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Clearance is what a ChallengeSolver got by passing a challenge: the
+// cookies that let the client through, and the user agent they were
+// issued to, if the client must send that instead of its own.
+type Clearance struct {
+	Cookies   []*http.Cookie
+	UserAgent string
+}
+
+// ChallengeSolver passes the Cloudflare challenge served in place of
+// pageURL, for instance with a FlareSolverr instance or with cookies
+// taken from a browser.
+type ChallengeSolver interface {
+	Solve(ctx context.Context, pageURL string) (Clearance, error)
+}
+
+// ChallengeSolverFunc adapts a function to a ChallengeSolver.
+type ChallengeSolverFunc func(ctx context.Context, pageURL string) (Clearance, error)
+
+// Solve calls f.
+func (f ChallengeSolverFunc) Solve(ctx context.Context, pageURL string) (Clearance, error) {
+	return f(ctx, pageURL)
+}
+
+// WithChallengeSolver calls s when a request fails with
+// ErrCloudflareChallenge, adds the cookies it returns to the client's
+// cookie jar, persisting them with the session if the client is cached,
+// and retries the request once.
+func WithChallengeSolver(s ChallengeSolver) Option {
+	return func(w *ClientStruct) error {
+		w.challenge = &challenge{solver: s}
+		return nil
+	}
+}
+
+type challenge struct {
+	mu     sync.Mutex
+	solver ChallengeSolver
+	// generation counts the challenges solved, so that requests
+	// that were challenged at the same time only solve it once.
+	generation int
+	// userAgent holds the user agent the clearance was issued to, if
+	// the client must send it instead of its own. It is read by every
+	// request, so it isn't guarded by mu, which is held while solving.
+	userAgent atomic.Value
+}
+
+func (c *challenge) current() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// agent returns the user agent to send: the one the challenge clearance
+// was issued to, if any, or else the client's own.
+func (w *ClientStruct) agent() string {
+	if w.challenge != nil {
+		if ua, _ := w.challenge.userAgent.Load().(string); ua != "" {
+			return ua
+		}
+	}
+	return w.userAgent
+}
+
+// solve passes the challenge served for req, unless another request
+// already did so since generation.
+func (c *challenge) solve(w *ClientStruct, req *http.Request, generation int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return nil
+	}
+	cl, err := c.solver.Solve(req.Context(), req.URL.String())
+	if err != nil {
+		return err
+	}
+	w.client.Jar.SetCookies(req.URL, cl.Cookies)
+	if cl.UserAgent != "" {
+		c.userAgent.Store(cl.UserAgent)
+	}
+	if err = w.saveCookies(); err != nil {
+		return err
+	}
+	c.generation++
+	return nil
+}
+
+// retryChallenged returns a copy of req to send again once the challenge
+// it met is solved, or nil if it can't be sent again.
+func retryChallenged(req *http.Request) *http.Request {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry
+	}
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	retry.Body = body
+	return retry
+}
+
+func isChallenge(err error) bool {
+	return errors.Is(err, ErrCloudflareChallenge)
+}
@@ -0,0 +1,104 @@
+package whatapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChallengeSolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("cf_clearance"); err != nil || c.Value != "ok" || r.UserAgent() != "solver" {
+			rw.Header().Set("Cf-Mitigated", "challenge")
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		r.ParseForm()
+		rw.Write([]byte(r.Form.Get("q")))
+	}))
+	defer srv.Close()
+
+	solves := 0
+	solver := ChallengeSolverFunc(func(ctx context.Context, pageURL string) (Clearance, error) {
+		solves++
+		return Clearance{
+			Cookies:   []*http.Cookie{{Name: "cf_clearance", Value: "ok"}},
+			UserAgent: "solver",
+		}, nil
+	})
+	c, err := NewClient(srv.URL, "agent", WithRateLimit(0, 0), WithChallengeSolver(solver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := c.(*ClientStruct)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", srv.URL+"/ajax.php", strings.NewReader("q=hi"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		body, err := w.doRequest(req, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "hi" {
+			t.Errorf("expected the retried post to keep its body, got %q", body)
+		}
+	}
+	if solves != 1 {
+		t.Errorf("expected 1 solve, got %d", solves)
+	}
+}
+
+func TestChallengeSolverConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ajax.php" && r.UserAgent() != "solver" {
+			rw.Header().Set("Cf-Mitigated", "challenge")
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		rw.Header().Set("Content-Type", "image/png")
+		rw.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	solver := ChallengeSolverFunc(func(ctx context.Context, pageURL string) (Clearance, error) {
+		time.Sleep(20 * time.Millisecond)
+		return Clearance{UserAgent: "solver"}, nil
+	})
+	c, err := NewClient(srv.URL, "agent", WithRateLimit(0, 0), WithChallengeSolver(solver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := c.(*ClientStruct)
+	done := make(chan struct{})
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		// image requests aren't challenged, and keep going while it is
+		// solved
+		go func() {
+			var err error
+			for err == nil {
+				select {
+				case <-done:
+					errs <- nil
+					return
+				default:
+					req, _ := http.NewRequest("GET", srv.URL+"/image.png", nil)
+					_, err = w.doRequest(req, false)
+				}
+			}
+			errs <- err
+		}()
+	}
+	req, _ := http.NewRequest("GET", srv.URL+"/ajax.php", nil)
+	if _, err := w.doRequest(req, false); err != nil {
+		t.Error(err)
+	}
+	close(done)
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
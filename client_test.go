@@ -0,0 +1,30 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient starts a test server serving h, closed when the test
+// ends, and returns it with a logged in client of it. See loggedInClient.
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...Option) (*ClientStruct, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return loggedInClient(t, srv.URL, opts...), srv
+}
+
+// loggedInClient returns a client of the site at baseURL with no rate
+// limit, made with opts, that takes itself to be logged in, so tests
+// needn't serve a login.
+func loggedInClient(t *testing.T, baseURL string, opts ...Option) *ClientStruct {
+	t.Helper()
+	c, err := NewClient(baseURL, "agent", append([]Option{WithRateLimit(0, 0)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := c.(*ClientStruct)
+	w.session.setLoggedIn(true)
+	return w
+}
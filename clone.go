@@ -0,0 +1,78 @@
+package whatapi
+
+import "time"
+
+// WithRateBudget makes the client share a budget of n requests in any
+// period with every clone made from it, on top of each one's own rate
+// limit, so that between them they stay within a site-wide limit.
+func WithRateBudget(n int, period time.Duration) Option {
+	return func(w *ClientStruct) error {
+		w.budget = nil
+		if n > 0 {
+			w.budget = newRateLimiter(n, period)
+		}
+		return nil
+	}
+}
+
+// WithBackgroundPriority makes every request the client sends yield to
+// the foreground requests of clients sharing its rate budget, as
+// prefetches do. It suits a clone dedicated to crawling.
+func WithBackgroundPriority() Option {
+	return func(w *ClientStruct) error {
+		w.background = true
+		return nil
+	}
+}
+
+// sharingRateLimit makes a clone keep the rate limit of the client it was
+// cloned from, rather than get its own, for decorators such as Cache that
+// wrap a clone without meaning to double the rate at which the two
+// clients between them send requests.
+func sharingRateLimit(w *ClientStruct) error {
+	if w.limiter != nil && w.limiter.origin != nil {
+		w.limiter = w.limiter.origin
+	}
+	return nil
+}
+
+// Clone returns a client that shares this client's session and cache
+// but has its own rate limit, the same as this client's to begin with,
+// and its own priority. opts, such as WithRateLimit and
+// WithBackgroundPriority, configure the clone. Clones share any budget
+// set with WithRateBudget. As the session is shared, logging in or out
+// of either client, or a relogin by either, does so for both.
+func (w *ClientStruct) Clone(opts ...Option) (Decorator, error) {
+	c := *w
+	c.limiter = w.limiter.clone()
+	c.outer = &c
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// Clone clones the client it wraps, and wraps the clone in a cache with
+// the same settings, sharing the same database.
+func (c *cachingClient) Clone(opts ...Option) (Decorator, error) {
+	inner, err := c.Decorator.Clone(opts...)
+	if err != nil {
+		return nil, err
+	}
+	clone := *c
+	clone.Decorator = inner
+	inner.SetOuter(&clone)
+	return &clone, nil
+}
+
+// Clone returns a clone of c, as ClientStruct.Clone describes, wrapped in
+// the same decorators as c. c must be a Decorator.
+func Clone(c Client, opts ...Option) (Client, error) {
+	d, ok := c.(Decorator)
+	if !ok {
+		return nil, errNotDecorator
+	}
+	return d.Clone(opts...)
+}
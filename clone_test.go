@@ -0,0 +1,97 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	c, err := NewClient("https://example.com/", "agent", WithRateBudget(10, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := c.(*ClientStruct)
+	cc, err := Clone(c, WithRateLimit(1, time.Minute), WithBackgroundPriority())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := cc.(*ClientStruct)
+	if clone.outer != clone || w.outer != w {
+		t.Error("expected the clone and the original to be their own outer clients")
+	}
+	if clone.limiter == w.limiter || clone.limiter.n != 1 || w.limiter.n != 5 {
+		t.Error("expected the clone to have its own rate limit")
+	}
+	if clone.budget != w.budget {
+		t.Error("expected the clone to share the rate budget")
+	}
+	if !clone.background || w.background {
+		t.Error("expected only the clone to have background priority")
+	}
+	if clone.client != w.client {
+		t.Error("expected the clone to share the session")
+	}
+}
+
+func TestCloneSharesLogin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("action") {
+		case "index":
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+		default:
+			rw.Write([]byte(`{"status":"success","response":{}}`))
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, "agent", WithRateLimit(0, 0), WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := Clone(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clone.GetTorrent(1, url.Values{}); err == nil {
+		t.Fatal("expected the clone to need a login")
+	}
+	if err = c.Login("user", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clone.GetTorrent(1, url.Values{}); err != nil {
+		t.Fatalf("expected the clone to use the original's login, got %v", err)
+	}
+	if authkey, _ := clone.(*ClientStruct).session.keys(); authkey != "a" {
+		t.Errorf("expected the clone to have the session's keys, got %q", authkey)
+	}
+	if err = clone.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetTorrent(1, url.Values{}); err == nil {
+		t.Error("expected logging the clone out to log the original out")
+	}
+	if ns := c.Namespace(); ns != "@"+srv.URL {
+		t.Errorf("expected logging out to forget the user, got %q", ns)
+	}
+}
+
+func TestCacheSharesRateLimit(t *testing.T) {
+	w := loggedInClient(t, "https://example.com/", WithRateLimit(1, time.Minute))
+	cc, err := Cache(w, openCache(t), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := cc.(*cachingClient).Decorator.(*ClientStruct)
+	if inner == w || inner.limiter != w.limiter {
+		t.Error("expected the cache to wrap a clone sharing the client's rate limit")
+	}
+	clone, err := Clone(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.(*cachingClient).Decorator.(*ClientStruct).limiter == w.limiter {
+		t.Error("expected a clone of the cache to have its own rate limit")
+	}
+}
@@ -0,0 +1,253 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GetCollage retrieves the collage with the provided collage id.
+func (w *ClientStruct) GetCollage(id int) (Collage, error) {
+	return w.getCollagePage(id, 0)
+}
+
+// getCollagePage retrieves a page of the collage, or the site's first page
+// if page is 0.
+func (w *ClientStruct) getCollagePage(id, page int) (Collage, error) {
+	collage := CollageResponse{}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(id))
+	if page > 0 {
+		params.Set("page", strconv.Itoa(page))
+	}
+	requestURL, err := w.ajaxURL("collage", params)
+	if err != nil {
+		return collage.Response, err
+	}
+	err = w.GetJSON(requestURL, &collage)
+	if err != nil {
+		return collage.Response, err
+	}
+	return collage.Response, checkResponseStatus(collage.Status, collage.Error)
+}
+
+// AddToCollage adds the torrent group with the provided group id to the collage with the provided collage id.
+func (w *ClientStruct) AddToCollage(collageID, groupID int) error {
+	groupURL, err := buildURL(w.baseURL, w.profile.path("torrents.php"), "",
+		url.Values{"id": {strconv.Itoa(groupID)}})
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("action", "add_torrent")
+	params.Set("collageid", strconv.Itoa(collageID))
+	params.Set("groupid", strconv.Itoa(groupID))
+	params.Set("url", groupURL)
+	_, err = w.postForm("collages.php", params)
+	return err
+}
+
+// RemoveFromCollage removes the torrent group with the provided group id from the collage with the provided collage id.
+func (w *ClientStruct) RemoveFromCollage(collageID, groupID int) error {
+	params := url.Values{}
+	params.Set("action", "manage_handle")
+	params.Set("collageid", strconv.Itoa(collageID))
+	params.Set("groupid", strconv.Itoa(groupID))
+	params.Set("submit", "Remove")
+	_, err := w.postForm("collages.php", params)
+	return err
+}
+
+// CreateCollage creates a collage in the provided collage category, and returns its id.
+func (w *ClientStruct) CreateCollage(name, description string, category int, tags []string) (int, error) {
+	params := url.Values{}
+	params.Set("action", "new_handle")
+	params.Set("name", name)
+	params.Set("description", description)
+	params.Set("category", strconv.Itoa(category))
+	params.Set("tags", strings.Join(tags, ", "))
+	_, u, err := w.postFormURL("collages.php", params)
+	if err != nil {
+		return 0, err
+	}
+	// the site redirects to the new collage
+	id, err := strconv.Atoi(u.Query().Get("id"))
+	if err != nil {
+		return 0, errRequestFailedReason("collage was not created")
+	}
+	return id, nil
+}
+
+// CollageEntry is an entry of a collage: a torrent group, or in an artists
+// collage an artist. Exactly one of Group and Artist is set.
+type CollageEntry struct {
+	Group  *CollageGroup
+	Artist *CollageArtist
+}
+
+// CollageIter steps through every entry of a collage, fetching pages as
+// needed.
+type CollageIter struct {
+	client *ClientStruct
+	id     int
+	first  Collage
+	page   []CollageEntry
+	pageNo int
+	seen   int // entries on the pages so far
+	i      int
+	done   bool
+	err    error
+}
+
+// CollageEntries returns an iterator over all of the entries of the collage with the provided collage id.
+func (w *ClientStruct) CollageEntries(id int) *CollageIter {
+	return &CollageIter{client: w, id: id}
+}
+
+// entries returns the entries of a page of a collage.
+func entries(c Collage) []CollageEntry {
+	e := make([]CollageEntry, 0, len(c.TorrentGroups)+len(c.Artists))
+	for i := range c.TorrentGroups {
+		e = append(e, CollageEntry{Group: &c.TorrentGroups[i]})
+	}
+	for i := range c.Artists {
+		e = append(e, CollageEntry{Artist: &c.Artists[i]})
+	}
+	return e
+}
+
+func sameEntry(a, b CollageEntry) bool {
+	if a.Group != nil && b.Group != nil {
+		return a.Group.ID == b.Group.ID
+	}
+	if a.Artist != nil && b.Artist != nil {
+		return a.Artist.ID == b.Artist.ID
+	}
+	return false
+}
+
+// Next advances to the next entry, and reports whether there is one.
+func (it *CollageIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.i++
+	if it.i < len(it.page) {
+		return true
+	}
+	// a torrents collage lists all of its groups' ids on every page
+	if it.done || it.pageNo > 0 && len(it.first.TorrentGroupIDList) > 0 && it.seen >= len(it.first.TorrentGroupIDList) {
+		return false
+	}
+	prev := it.page
+	it.pageNo++
+	var c Collage
+	c, it.err = it.client.getCollagePage(it.id, it.pageNo)
+	if it.err != nil {
+		return false
+	}
+	if it.pageNo == 1 {
+		it.first = c
+	}
+	it.page, it.i = entries(c), 0
+	// sites that ignore the page send the same entries again
+	if len(it.page) == 0 || len(prev) > 0 && sameEntry(prev[0], it.page[0]) {
+		it.done = true
+		return false
+	}
+	// a short page is the last
+	if it.pageNo > 1 && len(it.page) < len(entries(it.first)) {
+		it.done = true
+	}
+	it.seen += len(it.page)
+	return true
+}
+
+// Entry returns the current entry.
+func (it *CollageIter) Entry() CollageEntry {
+	return it.page[it.i]
+}
+
+// Collage returns the collage's details, as of its first page. Its
+// entries are only those of the first page.
+func (it *CollageIter) Collage() Collage {
+	return it.first
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *CollageIter) Err() error {
+	return it.err
+}
+
+// GetCollageAll retrieves the collage with the provided collage id with all of its entries, from every page.
+func (w *ClientStruct) GetCollageAll(id int) (Collage, error) {
+	it := w.CollageEntries(id)
+	var (
+		groups  []CollageGroup
+		artists []CollageArtist
+	)
+	for it.Next() {
+		if e := it.Entry(); e.Group != nil {
+			groups = append(groups, *e.Group)
+		} else {
+			artists = append(artists, *e.Artist)
+		}
+	}
+	c := it.Collage()
+	c.TorrentGroups, c.Artists = groups, artists
+	return c, it.Err()
+}
+
+// subscribeLink matches the link on a collage's page that subscribes to
+// the collage, or unsubscribes from it, and captures which.
+var subscribeLink = regexp.MustCompile(`id="subscribelink\d+"[^>]*>\s*(\w+)`)
+
+// SubscribeCollage subscribes the user to the collage with the provided collage id, so the site notifies them of additions to it.
+func (w *ClientStruct) SubscribeCollage(id int) error {
+	return w.setCollageSubscription(id, true)
+}
+
+// UnsubscribeCollage unsubscribes the user from the collage with the provided collage id.
+func (w *ClientStruct) UnsubscribeCollage(id int) error {
+	return w.setCollageSubscription(id, false)
+}
+
+// setCollageSubscription subscribes to the collage, or unsubscribes from
+// it. The site's action only toggles the subscription, so the collage's
+// page is read first to see whether there is anything to do.
+func (w *ClientStruct) setCollageSubscription(id int, subscribe bool) error {
+	if !w.session.isLoggedIn() {
+		return errRequestFailedLogin
+	}
+	get := func(endpoint string, params url.Values) ([]byte, error) {
+		pageURL, err := w.PageURL(endpoint, params)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest("GET", pageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		return w.doRequest(req, false)
+	}
+	page, err := get("collages.php", url.Values{"id": {strconv.Itoa(id)}})
+	if err != nil {
+		return err
+	}
+	m := subscribeLink.FindSubmatch(page)
+	if m == nil {
+		return errRequestFailedReason("no subscribe link on the collage's page")
+	}
+	if subscribed := string(m[1]) == "Unsubscribe"; subscribed == subscribe {
+		return nil
+	}
+	authkey, _ := w.session.keys()
+	_, err = get("userhistory.php", url.Values{
+		"action":    {"collage_subscribe"},
+		"collageid": {strconv.Itoa(id)},
+		"auth":      {authkey},
+	})
+	return err
+}
@@ -0,0 +1,165 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetCollage(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"id":4,"name":"Best of","collageCategoryID":1,"torrentGroupIDList":[10,20],"torrentgroups":[{"id":10,"name":"One","year":1999},{"id":20,"name":"Two"}]}}`))
+	})
+	col, err := c.GetCollage(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=collage&id=4"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if col.Name != "Best of" || len(col.TorrentGroupIDList) != 2 || len(col.TorrentGroups) != 2 || col.TorrentGroups[0].Year != 1999 {
+		t.Errorf("bad collage %+v", col)
+	}
+}
+
+func TestEditCollage(t *testing.T) {
+	var posts []map[string]string
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collages.php" && r.Method == "GET" {
+			rw.Write([]byte(`<html>collage</html>`))
+			return
+		}
+		if r.Method != "POST" || r.URL.Path != "/collages.php" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		r.ParseForm()
+		post := map[string]string{}
+		for k := range r.PostForm {
+			post[k] = r.PostForm.Get(k)
+		}
+		posts = append(posts, post)
+		if post["action"] == "new_handle" {
+			http.Redirect(rw, r, "/collages.php?id=77", http.StatusFound)
+		}
+	})
+	c.session.setKeys("authkey", "")
+
+	id, err := c.CreateCollage("Mine", "my favourites", 2, []string{"rock", "jazz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 77 {
+		t.Errorf("expected the new collage's id, got %d", id)
+	}
+	if err = c.AddToCollage(77, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.RemoveFromCollage(77, 10); err != nil {
+		t.Fatal(err)
+	}
+	exp := []map[string]string{
+		{"action": "new_handle", "auth": "authkey", "name": "Mine", "description": "my favourites", "category": "2", "tags": "rock, jazz"},
+		{"action": "add_torrent", "auth": "authkey", "collageid": "77", "groupid": "10", "url": srv.URL + "/torrents.php?id=10"},
+		{"action": "manage_handle", "auth": "authkey", "collageid": "77", "groupid": "10", "submit": "Remove"},
+	}
+	if len(posts) != len(exp) {
+		t.Fatalf("expected posts %v, got %v", exp, posts)
+	}
+	for i := range exp {
+		for k, v := range exp[i] {
+			if posts[i][k] != v {
+				t.Errorf("post %d: expected %s %q, got %q", i, k, v, posts[i][k])
+			}
+		}
+	}
+}
+
+func TestCreateCollageFailure(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		// the form again, with an error, rather than a redirect
+		rw.Write([]byte(`<html>name already taken</html>`))
+	})
+	if _, err := c.CreateCollage("Mine", "", 2, nil); err == nil {
+		t.Error("expected the collage not to be created")
+	}
+}
+
+func TestGetCollageAll(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"id":10,"name":"One"},{"id":20,"name":"Two"}`,
+		"2": `{"id":30,"name":"Three"}`,
+	}
+	var asked []string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		asked = append(asked, page)
+		rw.Write([]byte(`{"status":"success","response":{"id":4,"name":"Best of","collageCategoryID":1,"torrentGroupIDList":[10,20,30],"torrentgroups":[` + pages[page] + `]}}`))
+	})
+	col, err := c.GetCollageAll(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if col.Name != "Best of" || len(col.TorrentGroups) != 3 || col.TorrentGroups[2].Name != "Three" {
+		t.Errorf("bad collage %+v", col)
+	}
+	if len(asked) != 2 {
+		t.Errorf("expected the pages to stop once every group was seen, asked for %v", asked)
+	}
+}
+
+func TestCollageEntriesArtists(t *testing.T) {
+	var asked []string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		asked = append(asked, r.URL.Query().Get("page"))
+		// a site that ignores the page
+		rw.Write([]byte(`{"status":"success","response":{"id":5,"name":"Bands","collageCategoryID":7,"artists":[{"id":1,"name":"Radiohead"},{"id":2,"name":"Portishead"}]}}`))
+	})
+	it := c.CollageEntries(5)
+	var names []string
+	for it.Next() {
+		if e := it.Entry(); e.Artist != nil && e.Group == nil {
+			names = append(names, e.Artist.Name)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "Radiohead" || names[1] != "Portishead" || it.Collage().Name != "Bands" {
+		t.Errorf("bad entries %v of %+v", names, it.Collage())
+	}
+	if len(asked) != 2 {
+		t.Errorf("expected a repeated page to end the entries, asked for %v", asked)
+	}
+}
+
+func TestSubscribeCollage(t *testing.T) {
+	subscribed := false
+	toggles := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/collages.php":
+			link := "Subscribe"
+			if subscribed {
+				link = "Unsubscribe"
+			}
+			rw.Write([]byte(`<html><a href="#" id="subscribelink4" class="brackets" onclick="CollageSubscribe(4); return false;">` + link + `</a></html>`))
+		case "/userhistory.php":
+			q := r.URL.Query()
+			if q.Get("action") != "collage_subscribe" || q.Get("collageid") != "4" || q.Get("auth") != "authkey" {
+				t.Errorf("bad toggle %v", q)
+			}
+			toggles++
+			subscribed = !subscribed
+		}
+	})
+	c.session.setKeys("authkey", "")
+	for _, op := range []func(int) error{c.SubscribeCollage, c.SubscribeCollage, c.UnsubscribeCollage, c.UnsubscribeCollage} {
+		if err := op(4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if toggles != 2 || subscribed {
+		t.Errorf("expected one subscribe and one unsubscribe, got %d toggles", toggles)
+	}
+}
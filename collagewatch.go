@@ -0,0 +1,68 @@
+package whatapi
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"time"
+)
+
+// CollageAddition is the payload of an EventCollageAddition: an entry
+// added to a collage.
+type CollageAddition struct {
+	CollageID   int    `json:"collageId"`
+	CollageName string `json:"collageName"`
+	CollageEntry
+}
+
+// WatchCollages makes the watcher also raise an EventCollageAddition for
+// each entry added to the collages with ids, such as those the user has
+// subscribed to with SubscribeCollage, and returns the watcher. Each
+// collage has its own cursor, the position of the last entry delivered,
+// as entries are added to the end of a collage. An entry added between
+// polls in which an earlier one was removed is missed. The site doesn't
+// say when entries were added, so an addition's Time is when the watcher
+// found it.
+func (wa *Watcher) WatchCollages(ids ...int) *Watcher {
+	wa.collages = append(wa.collages, ids...)
+	return wa
+}
+
+// pollCollage delivers the entries added to the collage since the last
+// poll.
+func (wa *Watcher) pollCollage(ctx context.Context, id int) error {
+	c, err := wa.client.GetCollageAll(id)
+	if err != nil {
+		return err
+	}
+	cursor := EventCollageAddition + "/" + strconv.Itoa(id)
+	all := entries(c)
+	last, _, err := wa.loadCursor(ctx, cursor)
+	if err != nil {
+		return err
+	}
+	if last > len(all) {
+		// entries were removed, so start again from the end
+		if err = wa.saveCursor(ctx, cursor, len(all)); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	events := []Event{}
+	for i, e := range all {
+		title := ""
+		if e.Group != nil {
+			title = html.UnescapeString(e.Group.Name)
+		} else {
+			title = html.UnescapeString(e.Artist.Name)
+		}
+		events = append(events, Event{
+			Kind:    EventCollageAddition,
+			ID:      i + 1,
+			Title:   title,
+			Time:    now,
+			Payload: CollageAddition{CollageID: id, CollageName: c.Name, CollageEntry: e},
+		})
+	}
+	return wa.deliver(ctx, cursor, events)
+}
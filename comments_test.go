@@ -0,0 +1,84 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetTorrentComments(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"page":2,"pages":4,"comments":[{"postId":11,"bbBody":"[b]nice[/b]","userinfo":{"authorId":5,"authorName":"alice"}}]}}`))
+	})
+	cs, err := c.GetTorrentComments(9, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=tcomments&id=9&page=2"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if cs.Page != 2 || cs.Pages != 4 || len(cs.Comments) != 1 ||
+		cs.Comments[0].PostID != 11 || cs.Comments[0].BbBody != "[b]nice[/b]" ||
+		cs.Comments[0].UserInfo.AuthorName != "alice" {
+		t.Errorf("bad comments %+v", cs)
+	}
+}
+
+func TestGetArtistComments(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"page":1,"pages":1,"comments":[{"postId":12}]}}`))
+	}, WithSiteProfile(SiteProfile{
+		Name:    "test",
+		Actions: map[Capability]string{CapArtistComments: "artistcomments"},
+	}))
+	a := LazyArtist{client: c}
+	a.ID = 3
+	cs, err := a.Comments(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=artistcomments&id=3&page=1"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if len(cs.Comments) != 1 || cs.Comments[0].PostID != 12 {
+		t.Errorf("bad comments %+v", cs)
+	}
+
+	c.profile = GazelleProfile
+	if _, err = c.GetArtistComments(3, 1); err == nil {
+		t.Error("expected artist comments to be unsupported")
+	}
+}
+
+func TestAddComment(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/comments.php" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		for k, exp := range map[string]string{
+			"action": "take_post",
+			"auth":   "authkey",
+			"page":   "torrents",
+			"pageid": "9",
+			"body":   "[i]thanks[/i]",
+		} {
+			if v := r.PostForm.Get(k); v != exp {
+				t.Errorf("expected %s %q, got %q", k, exp, v)
+			}
+		}
+	})
+	c.session.setKeys("authkey", "")
+	if err := c.AddComment(CommentPageTorrents, 9, "[i]thanks[/i]"); err != nil {
+		t.Fatal(err)
+	}
+	c.session.setLoggedIn(false)
+	if err := c.AddComment(CommentPageTorrents, 9, "again"); err != errRequestFailedLogin {
+		t.Errorf("expected %v, got %v", errRequestFailedLogin, err)
+	}
+}
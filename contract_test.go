@@ -0,0 +1,164 @@
+package whatapi_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// contracts pairs each captured response in testdata/responses with the
+// type it decodes into. lossy lists the fields, by path with [] for any
+// array index, that the type is known not to keep, and why.
+var contracts = []struct {
+	fork, action string
+	typ          func() interface{}
+	lossy        map[string]string
+}{
+	{"gazelle", "index", func() interface{} { return &whatapi.AccountResponse{} }, nil},
+	{"red", "index", func() interface{} { return &whatapi.AccountResponse{} }, map[string]string{
+		"response.api_version": "not mapped",
+	}},
+	{"ops", "index", func() interface{} { return &whatapi.AccountResponse{} }, nil},
+	{"gazelle", "user", func() interface{} { return &whatapi.UserResponse{} }, nil},
+	{"red", "user", func() interface{} { return &whatapi.UserResponse{} }, nil},
+	{"gazelle", "torrent", func() interface{} { return &whatapi.TorrentResponse{} }, nil},
+	{"red", "torrent", func() interface{} { return &whatapi.TorrentResponse{} }, map[string]string{
+		"response.group.bbBody":                "not mapped",
+		"response.torrent.lossyWebApproved":    "not mapped",
+		"response.torrent.lossyMasterApproved": "not mapped",
+		"response.torrent.isFreeload":          "not mapped",
+	}},
+	{"ops", "torrent", func() interface{} { return &whatapi.TorrentResponse{} }, map[string]string{
+		"response.group.wikiBBcode": "not mapped",
+	}},
+	{"gazelle", "torrentgroup", func() interface{} { return &whatapi.TorrentGroupResponse{} }, nil},
+	{"red", "torrentgroup", func() interface{} { return &whatapi.TorrentGroupResponse{} }, map[string]string{
+		"response.group.bbBody":                   "not mapped",
+		"response.torrents[].lossyWebApproved":    "not mapped",
+		"response.torrents[].lossyMasterApproved": "not mapped",
+		"response.torrents[].isFreeload":          "not mapped",
+	}},
+	{"ops", "torrentgroup", func() interface{} { return &whatapi.TorrentGroupResponse{} }, map[string]string{
+		"response.group.wikiBBcode": "not mapped",
+	}},
+	{"gazelle", "browse", func() interface{} { return &whatapi.TorrentSearchResponse{} }, map[string]string{
+		"response.results[].releaseType": "sent as the release type's name, kept as its number",
+	}},
+	{"red", "browse", func() interface{} { return &whatapi.TorrentSearchResponse{} }, nil},
+	{"gazelle", "artist", func() interface{} { return &whatapi.ArtistResponse{} }, nil},
+	{"gazelle", "notifications", func() interface{} { return &whatapi.NotificationsResponse{} }, nil},
+	{"gazelle", "announcements", func() interface{} { return &whatapi.AnnouncementsResponse{} }, nil},
+	{"gazelle", "requests", func() interface{} { return &whatapi.RequestsSearchResponse{} }, nil},
+	{"gazelle", "usersearch", func() interface{} { return &whatapi.UserSearchResponse{} }, nil},
+	{"gazelle", "collage", func() interface{} { return &whatapi.CollageResponse{} }, nil},
+	{"gazelle", "inbox", func() interface{} { return &whatapi.MailboxResponse{} }, nil},
+	{"gazelle", "forum", func() interface{} { return &whatapi.ForumResponse{} }, nil},
+	{"gazelle", "bookmarks", func() interface{} { return &whatapi.TorrentBookmarksResponse{} }, nil},
+	{"gazelle", "top10", func() interface{} { return &whatapi.TopTenTorrentsResponse{} }, nil},
+}
+
+func TestResponseContracts(t *testing.T) {
+	for _, c := range contracts {
+		name := c.fork + "/" + c.action
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "responses", name+".json"))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		var orig interface{}
+		if err = json.Unmarshal(b, &orig); err != nil {
+			t.Errorf("%s: fixture is not JSON: %v", name, err)
+			continue
+		}
+		typed := c.typ()
+		if err = json.Unmarshal(b, typed); err != nil {
+			t.Errorf("%s: decoding into %T: %v", name, typed, err)
+			continue
+		}
+		if b, err = json.Marshal(typed); err != nil {
+			t.Errorf("%s: encoding %T: %v", name, typed, err)
+			continue
+		}
+		var rt interface{}
+		if err = json.Unmarshal(b, &rt); err != nil {
+			t.Fatal(err)
+		}
+		seen := map[string]bool{}
+		for _, p := range lost("", orig, rt) {
+			seen[p] = true
+			if _, ok := c.lossy[p]; !ok {
+				t.Errorf("%s: %s does not survive a round trip through %T", name, p, typed)
+			}
+		}
+		for p := range c.lossy {
+			if !seen[p] {
+				t.Errorf("%s: %s now survives a round trip, remove it from lossy", name, p)
+			}
+		}
+	}
+}
+
+// lost returns the paths of the values in orig that are missing from, or
+// different in, rt. Keys are matched case insensitively, as
+// encoding/json does when decoding.
+func lost(path string, orig, rt interface{}) []string {
+	switch o := orig.(type) {
+	case map[string]interface{}:
+		r, ok := rt.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var paths []string
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			v, ok := lookup(r, k)
+			if !ok {
+				paths = append(paths, p)
+				continue
+			}
+			paths = append(paths, lost(p, o[k], v)...)
+		}
+		return paths
+	case []interface{}:
+		r, ok := rt.([]interface{})
+		if !ok || len(r) != len(o) {
+			return []string{path}
+		}
+		var paths []string
+		for i := range o {
+			paths = append(paths, lost(path+"[]", o[i], r[i])...)
+		}
+		return paths
+	default:
+		if !reflect.DeepEqual(orig, rt) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+func lookup(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
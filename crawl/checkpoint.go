@@ -0,0 +1,62 @@
+package crawl
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// Checkpoint records the outcome of each key of a named crawl in a cache
+// database, so that running the crawl again, after a crash or a ban, skips
+// the keys already fetched and tries again only those that failed or were
+// never reached. Keys are told apart by how fmt prints them.
+type Checkpoint struct {
+	db   *sql.DB
+	name string
+}
+
+// NewCheckpoint returns the checkpoint for the crawl called name in db,
+// which must have been set up by whatapi.Cache or whatapi.MigrateCache.
+func NewCheckpoint(db *sql.DB, name string) *Checkpoint {
+	return &Checkpoint{db: db, name: name}
+}
+
+func (c *Checkpoint) ns(outcome string) string {
+	return "crawl/" + c.name + "/" + outcome
+}
+
+// Done reports whether key has been fetched.
+func (c *Checkpoint) Done(ctx context.Context, key string) (bool, error) {
+	var done bool
+	return whatapi.GetObject(ctx, c.db, c.ns("done"), key, &done)
+}
+
+// Failed returns the keys whose last try failed, with the error each
+// failed with.
+func (c *Checkpoint) Failed(ctx context.Context) (map[string]string, error) {
+	keys, err := whatapi.ObjectKeys(ctx, c.db, c.ns("failed"))
+	if err != nil {
+		return nil, err
+	}
+	failed := make(map[string]string, len(keys))
+	for _, k := range keys {
+		var msg string
+		if _, err := whatapi.GetObject(ctx, c.db, c.ns("failed"), k, &msg); err != nil {
+			return nil, err
+		}
+		failed[k] = msg
+	}
+	return failed, nil
+}
+
+// record records the outcome of fetching key.
+func (c *Checkpoint) record(ctx context.Context, key string, fetchErr error) error {
+	if fetchErr != nil {
+		return whatapi.PutObject(ctx, c.db, c.ns("failed"), key, fetchErr.Error())
+	}
+	if err := whatapi.PutObject(ctx, c.db, c.ns("done"), key, true); err != nil {
+		return err
+	}
+	return whatapi.DeleteObject(ctx, c.db, c.ns("failed"), key)
+}
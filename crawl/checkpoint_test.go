@@ -0,0 +1,71 @@
+package crawl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCheckpoint(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err = whatapi.MigrateCache(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		fetched []int
+		broken  = map[int]bool{2: true, 4: true}
+	)
+	fetch := func(ctx context.Context, id int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched = append(fetched, id)
+		if broken[id] {
+			return 0, errors.New("banned")
+		}
+		return id, nil
+	}
+	c := Config{Workers: 2, Checkpoint: NewCheckpoint(db, "discography")}
+	for range Run(ctx, Keys(1, 2, 3, 4), fetch, c) {
+	}
+	failed, err := c.Checkpoint.Failed(ctx)
+	if err != nil || len(failed) != 2 || failed["2"] != "banned" || failed["4"] != "banned" {
+		t.Errorf("expected 2 and 4 to have failed, got %v, %v", failed, err)
+	}
+
+	// run again once the ban is over
+	fetched, broken = nil, map[int]bool{}
+	var last Progress
+	c.Progress = func(p Progress) { last = p }
+	for r := range Run(ctx, Keys(1, 2, 3, 4, 5), fetch, c) {
+		if r.Err != nil {
+			t.Errorf("unexpected error %v", r.Err)
+		}
+	}
+	sort.Ints(fetched)
+	if len(fetched) != 3 || fetched[0] != 2 || fetched[1] != 4 || fetched[2] != 5 {
+		t.Errorf("expected only the failed and new keys to be fetched, got %v", fetched)
+	}
+	if last != (Progress{Done: 3, Skipped: 2}) {
+		t.Errorf("bad progress %+v", last)
+	}
+	if failed, err := c.Checkpoint.Failed(ctx); err != nil || len(failed) != 0 {
+		t.Errorf("expected no failures left, got %v, %v", failed, err)
+	}
+	if done, err := c.Checkpoint.Done(ctx, "4"); !done || err != nil {
+		t.Errorf("expected 4 to be done, got %v, %v", done, err)
+	}
+}
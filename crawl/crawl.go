@@ -0,0 +1,290 @@
+// Package crawl runs a whatapi request for each of many keys, such as
+// torrent ids or URLs, through a bounded pool of workers, retrying the
+// failures worth retrying and handing back typed results as they come.
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// Fetcher fetches and decodes the result for key. It is expected to go
+// through a whatapi client, so that requests are made under its rate limit
+// and cache.
+type Fetcher[K, T any] func(ctx context.Context, key K) (T, error)
+
+// Result is the outcome of fetching Key. Attempts counts the tries it
+// took, including the last.
+type Result[K, T any] struct {
+	Key      K
+	Value    T
+	Err      error
+	Attempts int
+}
+
+// Progress counts the keys a crawl has fetched so far, those it gave up
+// on, those a checkpoint had already fetched, and how many retries it has
+// made.
+type Progress struct {
+	Done    int
+	Failed  int
+	Skipped int
+	Retried int
+}
+
+// Config configures a crawl. The zero value runs one worker that doesn't
+// retry.
+type Config struct {
+	// Workers is how many keys are fetched at once, at least 1.
+	Workers int
+	// Governor, if set, further limits the requests in flight to what
+	// the site is coping with. See whatapi.NewGovernor.
+	Governor *whatapi.Governor
+	// Retries is how many more times a key is tried after a retryable
+	// failure.
+	Retries int
+	// Retryable reports whether a failure is worth retrying. The default
+	// retries pages the site is blocked by and network timeouts.
+	Retryable func(error) bool
+	// Backoff is how long to wait before the attempt'th retry. The
+	// default doubles from a second up to a minute, or waits as long as
+	// a blocked site asked.
+	Backoff func(attempt int, err error) time.Duration
+	// Progress, if set, is called after each key is done with. Calls are
+	// never concurrent.
+	Progress func(Progress)
+	// Report, if set, is told of each key as it is taken and done with,
+	// for a progress bar such as whatapi.ProgressChan drives. Keys
+	// skipped for the checkpoint count as done.
+	Report whatapi.Progress
+	// Checkpoint, if set, records each key's outcome, and keys it has
+	// recorded as fetched are skipped without a result. A result whose
+	// outcome couldn't be recorded has the error from recording it.
+	Checkpoint *Checkpoint
+}
+
+// Retryable is the default Config.Retryable.
+func Retryable(err error) bool {
+	var b *whatapi.BlockedError
+	if errors.As(err, &b) {
+		return true
+	}
+	var n net.Error
+	return errors.As(err, &n) && n.Timeout()
+}
+
+// Backoff is the default Config.Backoff.
+func Backoff(attempt int, err error) time.Duration {
+	var b *whatapi.BlockedError
+	if errors.As(err, &b) && b.RetryAfter > 0 {
+		return b.RetryAfter
+	}
+	d := time.Second
+	for i := 1; i < attempt && d < time.Minute; i++ {
+		d *= 2
+	}
+	if d > time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+// Keys returns a channel that yields keys in order, for Run.
+func Keys[K any](keys ...K) <-chan K {
+	c := make(chan K, len(keys))
+	for _, k := range keys {
+		c <- k
+	}
+	close(c)
+	return c
+}
+
+// Action returns a fetcher that decodes the ajax.php action, with the
+// parameters params returns for each id, into a T. T is the whole
+// response, such as whatapi.TorrentResponse.
+func Action[T any](c whatapi.Client, action string, params func(id int) url.Values) Fetcher[int, T] {
+	return func(ctx context.Context, id int) (T, error) {
+		var r T
+		err := c.DoContext(ctx, action, params(id), &r)
+		return r, err
+	}
+}
+
+// Project returns a fetcher that decodes only what T declares of the
+// response to the ajax.php action for each id, for crawls that need just
+// a few fields of many responses. See whatapi.Projected.
+func Project[T any](c whatapi.Client, action string, params func(id int) url.Values) Fetcher[int, T] {
+	return func(ctx context.Context, id int) (T, error) {
+		return whatapi.Project[T](ctx, c, action, params(id))
+	}
+}
+
+// ByID returns the parameters for an action that takes just an id, for
+// Action.
+func ByID(id int) url.Values {
+	return url.Values{"id": {strconv.Itoa(id)}}
+}
+
+// URL returns a fetcher that decodes the JSON response to each URL into a
+// T.
+func URL[T any](c whatapi.Client) Fetcher[string, T] {
+	return func(ctx context.Context, requestURL string) (T, error) {
+		var r T
+		err := c.GetJSONContext(ctx, requestURL, &r)
+		return r, err
+	}
+}
+
+// Run fetches each key read from keys with fetch, and sends the results
+// on the returned channel in the order they finish. Workers wait for each
+// result to be received before reading another key, so a slow reader
+// slows the crawl rather than piling up results. The channel is closed
+// once keys is closed and drained, or ctx is done.
+func Run[K, T any](ctx context.Context, keys <-chan K, fetch Fetcher[K, T], c Config) <-chan Result[K, T] {
+	if c.Workers < 1 {
+		c.Workers = 1
+	}
+	if c.Retryable == nil {
+		c.Retryable = Retryable
+	}
+	if c.Backoff == nil {
+		c.Backoff = Backoff
+	}
+	r := &run[K, T]{config: c, fetch: fetch, results: make(chan Result[K, T])}
+	var wg sync.WaitGroup
+	for i := 0; i < c.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx, keys)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(r.results)
+	}()
+	return r.results
+}
+
+type run[K, T any] struct {
+	config  Config
+	fetch   Fetcher[K, T]
+	results chan Result[K, T]
+
+	mu       sync.Mutex
+	progress Progress
+}
+
+func (r *run[K, T]) work(ctx context.Context, keys <-chan K) {
+	for {
+		var (
+			k  K
+			ok bool
+		)
+		select {
+		case k, ok = <-keys:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		if r.config.Report != nil {
+			r.config.Report.Add(1)
+		}
+		var key string
+		if cp := r.config.Checkpoint; cp != nil {
+			key = fmt.Sprint(k)
+			if done, err := cp.Done(ctx, key); err == nil && done {
+				r.skip()
+				continue
+			}
+		}
+		res := r.get(ctx, k)
+		if ctx.Err() != nil {
+			return
+		}
+		r.report(res.Err)
+		if cp := r.config.Checkpoint; cp != nil {
+			if err := cp.record(ctx, key, res.Err); err != nil {
+				res.Err = err
+			}
+		}
+		select {
+		case r.results <- res:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// get fetches k, retrying as configured.
+func (r *run[K, T]) get(ctx context.Context, k K) Result[K, T] {
+	res := Result[K, T]{Key: k}
+	for {
+		res.Attempts++
+		res.Value, res.Err = r.attempt(ctx, k)
+		if res.Err == nil || res.Attempts > r.config.Retries || !r.config.Retryable(res.Err) {
+			return res
+		}
+		t := time.NewTimer(r.config.Backoff(res.Attempts, res.Err))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			res.Err = ctx.Err()
+			return res
+		}
+		r.mu.Lock()
+		r.progress.Retried++
+		r.mu.Unlock()
+	}
+}
+
+func (r *run[K, T]) attempt(ctx context.Context, k K) (T, error) {
+	if g := r.config.Governor; g != nil {
+		if err := g.Acquire(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+		defer g.Release()
+	}
+	return r.fetch(ctx, k)
+}
+
+// report counts a finished key, and tells the progress callback.
+func (r *run[K, T]) report(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.progress.Failed++
+	} else {
+		r.progress.Done++
+	}
+	r.notify(err)
+}
+
+// skip counts a key the checkpoint had already fetched.
+func (r *run[K, T]) skip() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Skipped++
+	r.notify(nil)
+}
+
+func (r *run[K, T]) notify(err error) {
+	if r.config.Progress != nil {
+		r.config.Progress(r.progress)
+	}
+	if r.config.Report != nil {
+		r.config.Report.Finish(0, err)
+	}
+}
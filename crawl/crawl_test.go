@@ -0,0 +1,195 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func noBackoff(int, error) time.Duration { return 0 }
+
+func TestRun(t *testing.T) {
+	var (
+		mu             sync.Mutex
+		inFlight, most int
+		progress       []Progress
+	)
+	fetch := func(ctx context.Context, id int) (int, error) {
+		mu.Lock()
+		if inFlight++; inFlight > most {
+			most = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if id == 3 {
+			return 0, errors.New("no such torrent")
+		}
+		return id * 10, nil
+	}
+	report := whatapi.NewProgressChan()
+	c := Config{Workers: 2, Progress: func(p Progress) { progress = append(progress, p) }, Report: report}
+	got := map[int]Result[int, int]{}
+	for r := range Run(context.Background(), Keys(1, 2, 3, 4, 5), fetch, c) {
+		got[r.Key] = r
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 results, got %v", got)
+	}
+	for id, r := range got {
+		if id == 3 {
+			if r.Err == nil || r.Attempts != 1 {
+				t.Errorf("expected 3 to fail once without a retry, got %+v", r)
+			}
+		} else if r.Err != nil || r.Value != id*10 {
+			t.Errorf("bad result for %d: %+v", id, r)
+		}
+	}
+	if most > 2 {
+		t.Errorf("expected at most 2 in flight, got %d", most)
+	}
+	if len(progress) != 5 || progress[4] != (Progress{Done: 4, Failed: 1}) {
+		t.Errorf("bad progress %v", progress)
+	}
+	if r := report.Report(); r.Total != 5 || r.Done != 4 || r.Failed != 1 {
+		t.Errorf("bad report %+v", r)
+	}
+}
+
+func TestRunRetries(t *testing.T) {
+	tries := map[int]int{}
+	var mu sync.Mutex
+	fetch := func(ctx context.Context, id int) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if tries[id]++; tries[id] < 3 {
+			return "", &whatapi.BlockedError{Err: whatapi.ErrSiteMaintenance}
+		}
+		return "ok", nil
+	}
+	var last Progress
+	c := Config{Workers: 2, Retries: 2, Backoff: noBackoff, Progress: func(p Progress) { last = p }}
+	for r := range Run(context.Background(), Keys(1, 2), fetch, c) {
+		if r.Err != nil || r.Value != "ok" || r.Attempts != 3 {
+			t.Errorf("expected success on the third try, got %+v", r)
+		}
+	}
+	if last != (Progress{Done: 2, Retried: 4}) {
+		t.Errorf("bad progress %+v", last)
+	}
+
+	c.Retries = 1
+	tries = map[int]int{}
+	for r := range Run(context.Background(), Keys(1), fetch, c) {
+		if !errors.Is(r.Err, whatapi.ErrSiteMaintenance) || r.Attempts != 2 {
+			t.Errorf("expected to give up after 2 tries, got %+v", r)
+		}
+	}
+}
+
+func TestRunBackPressure(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetched []int
+	)
+	fetch := func(ctx context.Context, id int) (int, error) {
+		mu.Lock()
+		fetched = append(fetched, id)
+		mu.Unlock()
+		return id, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := Run(ctx, Keys(1, 2, 3, 4, 5, 6), fetch, Config{Workers: 2})
+	<-results
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	n := len(fetched)
+	mu.Unlock()
+	if n > 3 {
+		t.Errorf("expected unread results to hold the crawl back, fetched %d", n)
+	}
+	cancel()
+	for range results {
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for _, c := range []struct {
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{1, errors.New("timeout"), time.Second},
+		{3, errors.New("timeout"), 4 * time.Second},
+		{20, errors.New("timeout"), time.Minute},
+		{1, &whatapi.BlockedError{Err: whatapi.ErrSiteMaintenance, RetryAfter: time.Hour}, time.Hour},
+	} {
+		if got := Backoff(c.attempt, c.err); got != c.want {
+			t.Errorf("Backoff(%d, %v) = %s, expected %s", c.attempt, c.err, got, c.want)
+		}
+	}
+	if Retryable(errors.New("Request failed: bad id parameter")) {
+		t.Error("expected a failed request not to be retried")
+	}
+}
+
+// actionSite records the actions it is asked for, and answers each with
+// success.
+type actionSite struct {
+	whatapi.Client
+	mu     sync.Mutex
+	action []string
+}
+
+func (s *actionSite) DoContext(ctx context.Context, action string, params url.Values, result interface{}) error {
+	s.mu.Lock()
+	s.action = append(s.action, action+"?"+params.Encode())
+	s.mu.Unlock()
+	switch r := result.(type) {
+	case *whatapi.TorrentResponse:
+		r.Status = "success"
+	case *whatapi.Projected[hash]:
+		r.Status = "success"
+		r.Response.Torrent.InfoHash = params.Get("id")
+	}
+	return nil
+}
+
+// hash projects a torrent response down to its info hash.
+type hash struct {
+	Torrent struct {
+		InfoHash string `json:"infoHash"`
+	} `json:"torrent"`
+}
+
+func TestAction(t *testing.T) {
+	s := &actionSite{}
+	fetch := Action[whatapi.TorrentResponse](s, "torrent", ByID)
+	for r := range Run(context.Background(), Keys(7, 8), fetch, Config{}) {
+		if r.Err != nil || r.Value.Status != "success" {
+			t.Errorf("bad result %+v", r)
+		}
+	}
+	sort.Strings(s.action)
+	if len(s.action) != 2 || s.action[0] != "torrent?id=7" || s.action[1] != "torrent?id=8" {
+		t.Errorf("bad requests %v", s.action)
+	}
+}
+
+func TestProject(t *testing.T) {
+	s := &actionSite{}
+	fetch := Project[hash](s, "torrent", ByID)
+	for r := range Run(context.Background(), Keys(7), fetch, Config{}) {
+		if r.Err != nil || r.Value.Torrent.InfoHash != "7" {
+			t.Errorf("bad result %+v", r)
+		}
+	}
+}
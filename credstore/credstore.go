@@ -0,0 +1,192 @@
+// Package credstore keeps tracker credentials in an encrypted file, so
+// tools can log in again unattended without plaintext config files.
+package credstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// ErrNotFound is returned when a store has no credentials for a site.
+var ErrNotFound = errors.New("credstore: no credentials for site")
+
+// Credentials are what is needed to log in to one site.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	APIKey   string `json:"apiKey,omitempty"`
+}
+
+// Store saves and loads credentials by site name.
+type Store interface {
+	Load(site string) (Credentials, error)
+	Save(site string, c Credentials) error
+	Delete(site string) error
+}
+
+// KeySource supplies the secret used to encrypt a store.
+type KeySource interface {
+	Key() ([]byte, error)
+}
+
+// EnvKey reads the secret from the named environment variable.
+type EnvKey string
+
+func (e EnvKey) Key() ([]byte, error) {
+	k := os.Getenv(string(e))
+	if k == "" {
+		return nil, fmt.Errorf("credstore: %s is not set", string(e))
+	}
+	return []byte(k), nil
+}
+
+// KeychainKey reads the secret from the OS keychain: the login keychain
+// on macOS, or the Secret Service via secret-tool elsewhere.
+type KeychainKey struct {
+	Service string
+	Account string
+}
+
+func (k KeychainKey) Key() ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password",
+			"-s", k.Service, "-a", k.Account, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup",
+			"service", k.Service, "account", k.Account)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credstore: reading keychain: %s", err)
+	}
+	s := strings.TrimRight(string(out), "\n")
+	if s == "" {
+		return nil, fmt.Errorf("credstore: keychain has no secret for %s", k.Service)
+	}
+	return []byte(s), nil
+}
+
+// FileStore is a Store kept in a single file, encrypted by whatapi.Seal.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	key  KeySource
+}
+
+// NewFileStore returns a store kept in the file at path, encrypted with
+// the secret from key. The file is created on the first Save.
+func NewFileStore(path string, key KeySource) *FileStore {
+	return &FileStore{path: path, key: key}
+}
+
+func (f *FileStore) Load(site string) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.read()
+	if err != nil {
+		return Credentials{}, err
+	}
+	c, ok := all[site]
+	if !ok {
+		return Credentials{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *FileStore) Save(site string, c Credentials) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.read()
+	if err != nil {
+		return err
+	}
+	all[site] = c
+	return f.write(all)
+}
+
+func (f *FileStore) Delete(site string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.read()
+	if err != nil {
+		return err
+	}
+	delete(all, site)
+	return f.write(all)
+}
+
+func (f *FileStore) read() (map[string]Credentials, error) {
+	all := map[string]Credentials{}
+	sealed, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	secret, err := f.key.Key()
+	if err != nil {
+		return nil, err
+	}
+	b, err := whatapi.Unseal(secret, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("credstore: decrypting store: %w", err)
+	}
+	return all, json.Unmarshal(b, &all)
+}
+
+func (f *FileStore) write(all map[string]Credentials) error {
+	b, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	secret, err := f.key.Key()
+	if err != nil {
+		return err
+	}
+	sealed, err := whatapi.Seal(secret, b)
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, sealed, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// Login logs c in with the credentials saved for site, with their API key
+// if they have one and otherwise their password.
+func Login(c whatapi.Authenticator, s Store, site string) error {
+	creds, err := s.Load(site)
+	if err != nil {
+		return err
+	}
+	if creds.APIKey != "" {
+		return c.LoginWithAPIKey(creds.Username, creds.APIKey)
+	}
+	return c.Login(creds.Username, creds.Password)
+}
+
+// CredentialsFunc returns the credentials saved for site as needed by
+// whatapi.WithAutoRelogin, so the password is only read from the store
+// when the client has to log in again.
+func CredentialsFunc(s Store, site string) whatapi.CredentialsFunc {
+	return func() (string, string, error) {
+		creds, err := s.Load(site)
+		if err != nil {
+			return "", "", err
+		}
+		return creds.Username, creds.Password, nil
+	}
+}
@@ -0,0 +1,160 @@
+package credstore
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// fixedKey is a KeySource with a fixed secret.
+type fixedKey string
+
+func (k fixedKey) Key() ([]byte, error) {
+	return []byte(k), nil
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "creds")
+	s := NewFileStore(path, fixedKey("hunter2"))
+	if _, err := s.Load("red"); err != ErrNotFound {
+		t.Errorf("expected no credentials before saving, got %v", err)
+	}
+	creds := Credentials{Username: "user", Password: "pass", APIKey: "key"}
+	if err := s.Save("red", creds); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("ops", Credentials{Username: "other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a store opened again with the same secret reads them back
+	s = NewFileStore(path, fixedKey("hunter2"))
+	if c, err := s.Load("red"); err != nil || c != creds {
+		t.Errorf("expected %+v, got %+v, %v", creds, c, err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plain := range []string{"user", "pass"} {
+		if bytes.Contains(b, []byte(plain)) {
+			t.Errorf("expected %q encrypted", plain)
+		}
+	}
+
+	if err := s.Delete("red"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("red"); err != ErrNotFound {
+		t.Errorf("expected deleted credentials gone, got %v", err)
+	}
+	if c, err := s.Load("ops"); err != nil || c.Username != "other" {
+		t.Errorf("expected the other site's credentials kept, got %+v, %v", c, err)
+	}
+}
+
+func TestFileStoreWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "creds")
+	if err := NewFileStore(path, fixedKey("hunter2")).Save("red", Credentials{Username: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewFileStore(path, fixedKey("hunter3"))
+	if _, err := s.Load("red"); !errors.Is(err, whatapi.ErrSealed) {
+		t.Errorf("expected the wrong secret to fail, got %v", err)
+	}
+	if err := s.Save("ops", Credentials{}); !errors.Is(err, whatapi.ErrSealed) {
+		t.Errorf("expected a save with the wrong secret to fail, got %v", err)
+	}
+}
+
+func TestFileStoreTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "creds")
+	s := NewFileStore(path, fixedKey("hunter2"))
+	if err := s.Save("red", Credentials{Username: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 1
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("red"); !errors.Is(err, whatapi.ErrSealed) {
+		t.Errorf("expected a changed store to fail, got %v", err)
+	}
+	if err := ioutil.WriteFile(path, b[:4], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("red"); !errors.Is(err, whatapi.ErrSealed) {
+		t.Errorf("expected a truncated store to fail, got %v", err)
+	}
+}
+
+func TestEnvKey(t *testing.T) {
+	t.Setenv("CREDSTORE_TEST_KEY", "")
+	if _, err := EnvKey("CREDSTORE_TEST_KEY").Key(); err == nil {
+		t.Error("expected an unset key to fail")
+	}
+	t.Setenv("CREDSTORE_TEST_KEY", "hunter2")
+	if k, err := EnvKey("CREDSTORE_TEST_KEY").Key(); err != nil || string(k) != "hunter2" {
+		t.Errorf("expected the key, got %q, %v", k, err)
+	}
+}
+
+// recordingClient records how it was logged in.
+type recordingClient struct {
+	whatapi.Authenticator
+	username, password, key string
+}
+
+func (c *recordingClient) Login(username, password string) error {
+	c.username, c.password = username, password
+	return nil
+}
+
+func (c *recordingClient) LoginWithAPIKey(username, key string) error {
+	c.username, c.key = username, key
+	return nil
+}
+
+func TestLogin(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "creds"), fixedKey("hunter2"))
+	if err := s.Save("red", Credentials{Username: "user", Password: "pass"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("ops", Credentials{Username: "user", Password: "pass", APIKey: "key"}); err != nil {
+		t.Fatal(err)
+	}
+	c := &recordingClient{}
+	if err := Login(c, s, "red"); err != nil || c.username != "user" || c.password != "pass" || c.key != "" {
+		t.Errorf("expected a login with the password, got %+v, %v", c, err)
+	}
+	c = &recordingClient{}
+	if err := Login(c, s, "ops"); err != nil || c.username != "user" || c.password != "" || c.key != "key" {
+		t.Errorf("expected a login with the API key, got %+v, %v", c, err)
+	}
+	if err := Login(&recordingClient{}, s, "nowhere"); err != ErrNotFound {
+		t.Errorf("expected no credentials, got %v", err)
+	}
+}
+
+func TestCredentialsFunc(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "creds"), fixedKey("hunter2"))
+	creds := CredentialsFunc(s, "red")
+	if _, _, err := creds(); err != ErrNotFound {
+		t.Errorf("expected no credentials yet, got %v", err)
+	}
+	// read when called, so a changed password is picked up
+	if err := s.Save("red", Credentials{Username: "user", Password: "pass"}); err != nil {
+		t.Fatal(err)
+	}
+	if u, p, err := creds(); err != nil || u != "user" || p != "pass" {
+		t.Errorf("expected the saved credentials, got %q %q, %v", u, p, err)
+	}
+}
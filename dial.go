@@ -0,0 +1,97 @@
+package whatapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WithDialer makes the client open its connections with d, keeping the
+// rest of its transport, such as a proxy set with WithTransport. The
+// transport must be an *http.Transport.
+func WithDialer(d *net.Dialer) Option {
+	return withDial(d.DialContext)
+}
+
+// WithLocalAddr makes the client connect from addr, an IPv4 or IPv6
+// address or the name of a network interface, for trackers that only
+// accept requests from a whitelisted address. Connections are made over
+// the address's IP version only. An interface's first IPv4 address is
+// used, or its first address if it has no IPv4 one.
+func WithLocalAddr(addr string) Option {
+	return func(w *ClientStruct) error {
+		ip, err := localIP(addr)
+		if err != nil {
+			return err
+		}
+		network := "tcp6"
+		if ip.To4() != nil {
+			network = "tcp4"
+		}
+		d := &net.Dialer{LocalAddr: &net.TCPAddr{IP: ip}}
+		return withDial(func(ctx context.Context, _, address string) (net.Conn, error) {
+			return d.DialContext(ctx, network, address)
+		})(w)
+	}
+}
+
+// localIP returns addr, if it is an IP address, or the address of the
+// interface it names.
+func localIP(addr string) (net.IP, error) {
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip, nil
+	}
+	iface, err := net.InterfaceByName(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var ip net.IP
+	for _, a := range addrs {
+		n, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if n.IP.To4() != nil {
+			return n.IP, nil
+		}
+		if ip == nil {
+			ip = n.IP
+		}
+	}
+	if ip == nil {
+		return nil, fmt.Errorf("interface %s has no address", addr)
+	}
+	return ip, nil
+}
+
+// withDial sets the dial function of a copy of the client's transport.
+func withDial(dial func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	return editTransport(func(t *http.Transport) { t.DialContext = dial })
+}
+
+// editTransport applies edit to a copy of the client's transport, which
+// must be an *http.Transport, so clones don't change the transport of
+// their parent.
+func editTransport(edit func(t *http.Transport)) Option {
+	return func(w *ClientStruct) error {
+		var t *http.Transport
+		switch rt := w.client.Transport.(type) {
+		case nil:
+			t = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			t = rt.Clone()
+		default:
+			return fmt.Errorf("can't configure a %T transport", rt)
+		}
+		edit(t)
+		c := *w.client
+		c.Transport = t
+		w.client = &c
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package whatapi
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestWithLocalAddr(t *testing.T) {
+	var remote string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}, WithLocalAddr("127.0.0.1"))
+	if _, err := c.GetTorrent(1, url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if host, _, _ := net.SplitHostPort(remote); host != "127.0.0.1" {
+		t.Errorf("expected a connection from 127.0.0.1, got %s", remote)
+	}
+
+	// the test server only listens on IPv4
+	c, _ = newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {}, WithLocalAddr("::1"))
+	if _, err := c.GetTorrent(1, url.Values{}); err == nil {
+		t.Error("expected an IPv6 client not to reach an IPv4 server")
+	}
+
+	if _, err := NewClient("https://example.com", "agent", WithLocalAddr("no-such-interface0")); err == nil {
+		t.Error("expected an unknown interface to be refused")
+	}
+}
+
+func TestWithDialerKeepsTransport(t *testing.T) {
+	proxy := func(*http.Request) (*url.URL, error) { return nil, nil }
+	c, err := NewClient("https://example.com", "agent",
+		WithTransport(&http.Transport{Proxy: proxy}), WithDialer(&net.Dialer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, ok := c.(*ClientStruct).client.Transport.(*http.Transport)
+	if !ok || tr.Proxy == nil || tr.DialContext == nil {
+		t.Errorf("expected the dialer added to the transport, got %+v", tr)
+	}
+	if _, err := NewClient("https://example.com", "agent",
+		WithTransport(http.NewFileTransport(http.Dir("testdata"))), WithDialer(&net.Dialer{})); err == nil {
+		t.Error("expected a transport that isn't an *http.Transport to be refused")
+	}
+}
@@ -0,0 +1,136 @@
+package whatapi
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Trump is a torrent replaced by a new torrent in the same edition,
+// format and media.
+type Trump struct {
+	Old TorrentStruct
+	New TorrentStruct
+}
+
+// EditionChange is a torrent moved to another edition.
+type EditionChange struct {
+	TorrentID int
+	From      RemasterInfo
+	To        RemasterInfo
+}
+
+// GroupDiff is how a torrent group changed between two snapshots.
+type GroupDiff struct {
+	// Since is when the earlier snapshot was taken, if known.
+	Since time.Time
+	// Added and Removed leave out the torrents in Trumped.
+	Added    []TorrentStruct
+	Removed  []TorrentStruct
+	Trumped  []Trump
+	Editions []EditionChange
+}
+
+// Empty reports whether nothing changed.
+func (d GroupDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 &&
+		len(d.Trumped) == 0 && len(d.Editions) == 0
+}
+
+// DiffTorrentGroups compares two snapshots of a torrent group, such as
+// the one a watcher saw last and the current one, and reports the
+// torrents added, removed and trumped, and those moved to other editions.
+// A removed torrent is taken to be trumped by an added torrent in the
+// same edition, format, encoding and media.
+func DiffTorrentGroups(old, cur TorrentGroup) GroupDiff {
+	d := GroupDiff{}
+	before := map[int]TorrentStruct{}
+	for _, t := range old.Torrent {
+		before[t.ID()] = t
+	}
+	now := map[int]bool{}
+	added := []TorrentStruct{}
+	for _, t := range cur.Torrent {
+		now[t.ID()] = true
+		o, ok := before[t.ID()]
+		if !ok {
+			added = append(added, t)
+			continue
+		}
+		if from, to := remasterInfo(o), remasterInfo(t); from != to {
+			d.Editions = append(d.Editions, EditionChange{TorrentID: t.ID(), From: from, To: to})
+		}
+	}
+	for _, o := range old.Torrent {
+		if now[o.ID()] {
+			continue
+		}
+		trumped := false
+		for i, t := range added {
+			if sameSlot(o, t) {
+				d.Trumped = append(d.Trumped, Trump{Old: o, New: t})
+				added = append(added[:i], added[i+1:]...)
+				trumped = true
+				break
+			}
+		}
+		if !trumped {
+			d.Removed = append(d.Removed, o)
+		}
+	}
+	d.Added = added
+	return d
+}
+
+// sameSlot reports whether a and b are in the same edition, format,
+// encoding and media, so that one can trump the other.
+func sameSlot(a, b TorrentStruct) bool {
+	return remasterInfo(a) == remasterInfo(b) && a.FormatF == b.FormatF &&
+		a.EncodingF == b.EncodingF && a.MediaF == b.MediaF
+}
+
+func remasterInfo(t TorrentStruct) RemasterInfo {
+	return RemasterInfo{
+		Year:            t.RemasterYearF,
+		Title:           t.RemasterTitleF,
+		RecordLabel:     t.RemasterRecordLabelF,
+		CatalogueNumber: t.RemasterCatalogueNumberF,
+	}
+}
+
+// GetTorrentGroupChanges retrieves the torrent group with the provided id, and on a cached client how it changed since the copy in the cache, which the retrieved group then replaces. The diff is empty on a client without a cache, if there was no cached copy, or if the cached copy is still fresh and so is what is returned.
+func (w *ClientStruct) GetTorrentGroupChanges(id int) (TorrentGroup, GroupDiff, error) {
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(id))
+	requestURL, err := w.ajaxURL("torrentgroup", params)
+	if err != nil {
+		return TorrentGroup{}, GroupDiff{}, err
+	}
+	body, since, err := w.outer.Cached(w.context(), requestURL)
+	if err == errNoCache {
+		// without a cache there is no earlier snapshot to compare with
+		body, err = nil, nil
+	}
+	if err != nil {
+		return TorrentGroup{}, GroupDiff{}, err
+	}
+	cur, err := w.GetTorrentGroup(id, url.Values{})
+	if err != nil || body == nil {
+		return cur, GroupDiff{}, err
+	}
+	old := TorrentGroupResponse{}
+	err = w.fetchJSON(w.context(), requestURL, &old, func() ([]byte, error) { return body, nil })
+	if err != nil {
+		return cur, GroupDiff{}, err
+	}
+	d := DiffTorrentGroups(old.Response, cur)
+	d.Since = since
+	return cur, d, nil
+}
+
+// Cached needs a cache to look in, so on a client that isn't wrapped by
+// Cache it only reports errNoCache.
+func (w *ClientStruct) Cached(ctx context.Context, requestURL string) ([]byte, time.Time, error) {
+	return nil, time.Time{}, errNoCache
+}
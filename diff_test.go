@@ -0,0 +1,98 @@
+package whatapi_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestDiffTorrentGroups(t *testing.T) {
+	old := whatapi.TorrentGroup{Torrent: []whatapi.TorrentStruct{
+		{IDF: 1, FormatF: "FLAC", MediaF: "CD"},
+		{IDF: 2, FormatF: "MP3", MediaF: "CD"},
+		{IDF: 3, FormatF: "FLAC", MediaF: "Vinyl"},
+		{IDF: 4, FormatF: "FLAC", MediaF: "WEB"},
+	}}
+	cur := whatapi.TorrentGroup{Torrent: []whatapi.TorrentStruct{
+		{IDF: 1, FormatF: "FLAC", MediaF: "CD", RemasteredF: true, RemasterYearF: 2011},
+		{IDF: 2, FormatF: "MP3", MediaF: "CD"},
+		{IDF: 5, FormatF: "FLAC", MediaF: "Vinyl"},
+		{IDF: 6, FormatF: "FLAC", MediaF: "SACD"},
+	}}
+	d := whatapi.DiffTorrentGroups(old, cur)
+	if len(d.Added) != 1 || d.Added[0].ID() != 6 {
+		t.Errorf("expected torrent 6 added, got %v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].ID() != 4 {
+		t.Errorf("expected torrent 4 removed, got %v", d.Removed)
+	}
+	if len(d.Trumped) != 1 || d.Trumped[0].Old.ID() != 3 || d.Trumped[0].New.ID() != 5 {
+		t.Errorf("expected torrent 3 trumped by 5, got %v", d.Trumped)
+	}
+	if len(d.Editions) != 1 || d.Editions[0].TorrentID != 1 || d.Editions[0].To.Year != 2011 {
+		t.Errorf("expected torrent 1 moved to the 2011 edition, got %v", d.Editions)
+	}
+	if !whatapi.DiffTorrentGroups(cur, cur).Empty() {
+		t.Error("expected no changes between identical snapshots")
+	}
+}
+
+func TestGetTorrentGroupChanges(t *testing.T) {
+	torrents := `[{"id":1,"media":"CD","format":"FLAC","encoding":"Lossless"},{"id":2,"media":"CD","format":"MP3","encoding":"320"}]`
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") == "index" {
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+			return
+		}
+		rw.Write([]byte(`{"status":"success","response":{"group":{"id":42,"name":"OK Computer"},"torrents":` + torrents + `}}`))
+	}))
+	defer srv.Close()
+	c, err := whatapi.NewClient(srv.URL, "agent", whatapi.WithRateLimit(0, 0), whatapi.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Login("user", ""); err != nil {
+		t.Fatal(err)
+	}
+	g, d, err := c.GetTorrentGroupChanges(42)
+	if err != nil || g.Group.ID() != 42 || !d.Empty() {
+		t.Fatalf("expected the group with no changes on an uncached client, got %v, %+v, %v", g.Group.ID(), d, err)
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// responses expire at once, so each call fetches the group again
+	cc, err := whatapi.Cache(c, db, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, d, err = cc.GetTorrentGroupChanges(42); err != nil || !d.Empty() {
+		t.Fatalf("expected no changes without a snapshot, got %+v, %v", d, err)
+	}
+	// the MP3 320 is trumped, and a V0 added ahead of its trump
+	torrents = `[{"id":1,"media":"CD","format":"FLAC","encoding":"Lossless"},{"id":4,"media":"CD","format":"MP3","encoding":"V0 (VBR)"},{"id":3,"media":"CD","format":"MP3","encoding":"320"}]`
+	g, d, err = cc.GetTorrentGroupChanges(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Torrent) != 3 {
+		t.Errorf("expected the current group, got %d torrents", len(g.Torrent))
+	}
+	if len(d.Trumped) != 1 || d.Trumped[0].Old.ID() != 2 || d.Trumped[0].New.ID() != 3 {
+		t.Errorf("expected torrent 2 trumped by 3, got %v", d.Trumped)
+	}
+	if len(d.Added) != 1 || d.Added[0].ID() != 4 || len(d.Removed) != 0 {
+		t.Errorf("expected torrent 4 added, got %v, removed %v", d.Added, d.Removed)
+	}
+	if d.Since.IsZero() {
+		t.Error("expected when the snapshot was taken")
+	}
+}
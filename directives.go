@@ -0,0 +1,116 @@
+package whatapi
+
+import (
+	"context"
+	"time"
+)
+
+// CacheDirective overrides, for the requests made with a context, how a
+// cached client decides whether a cached response will do, as a
+// Cache-Control request header does for HTTP caches.
+type CacheDirective func(*cacheDirectives)
+
+type cacheDirectives struct {
+	maxAge   time.Duration
+	maxStale time.Duration
+	minFresh time.Duration
+	noCache  bool
+	only     bool
+}
+
+type cacheDirectivesKey struct{}
+
+// WithCacheDirectives returns a context whose requests on a cached client
+// follow ds. The methods that take a context, GetJSONContext, DoContext
+// and DoPost, see them directly; for a typed method, such as GetTorrent,
+// call it on the view WithContext returns for the context, as in
+// c.WithContext(WithCacheDirectives(ctx, NoCache)).GetTorrent(id, nil).
+func WithCacheDirectives(ctx context.Context, ds ...CacheDirective) context.Context {
+	d := directivesFrom(ctx)
+	for _, f := range ds {
+		f(&d)
+	}
+	return context.WithValue(ctx, cacheDirectivesKey{}, d)
+}
+
+// WithDefaultDirectives makes the cache follow ds for every request made
+// without directives of its own. A cache over the same database made
+// with NoCache, for example, refreshes whatever it is asked for.
+func WithDefaultDirectives(ds ...CacheDirective) CacheOption {
+	return func(c *cachingClient) {
+		for _, f := range ds {
+			f(&c.defaults)
+		}
+	}
+}
+
+// directives returns the directives for a request made with ctx: those
+// set with WithCacheDirectives if there are any, or else the cache's
+// defaults.
+func (c *cachingClient) directives(ctx context.Context) cacheDirectives {
+	if d, ok := ctx.Value(cacheDirectivesKey{}).(cacheDirectives); ok {
+		return d
+	}
+	return c.defaults
+}
+
+func directivesFrom(ctx context.Context) cacheDirectives {
+	d, _ := ctx.Value(cacheDirectivesKey{}).(cacheDirectives)
+	return d
+}
+
+// MaxAge accepts cached responses no older than d, even if the client
+// would cache them for longer. A d of 0 is NoCache.
+func MaxAge(d time.Duration) CacheDirective {
+	return func(c *cacheDirectives) {
+		if d <= 0 {
+			c.noCache = true
+		}
+		c.maxAge = d
+	}
+}
+
+// MaxStale accepts cached responses up to d past their expiry.
+func MaxStale(d time.Duration) CacheDirective {
+	return func(c *cacheDirectives) {
+		c.maxStale = d
+	}
+}
+
+// MinFresh only accepts cached responses that will still be fresh for d.
+func MinFresh(d time.Duration) CacheDirective {
+	return func(c *cacheDirectives) {
+		c.minFresh = d
+	}
+}
+
+// NoCache fetches the response from the site whatever is cached, and
+// caches it as usual, for example to refresh a page on request.
+var NoCache CacheDirective = func(c *cacheDirectives) {
+	c.noCache = true
+}
+
+// CacheOnly never contacts the site: it returns the cached response
+// however old, or ErrNotCached if there isn't one, for example for
+// offline analysis.
+var CacheOnly CacheDirective = func(c *cacheDirectives) {
+	c.only = true
+}
+
+// acceptable reports whether the cached response e, which the client
+// would otherwise keep for ttl, satisfies the directives.
+func (d cacheDirectives) acceptable(e cacheEntry, ttl time.Duration) bool {
+	if len(e.body) == 0 {
+		return false
+	}
+	if d.only {
+		return true
+	}
+	if d.noCache {
+		return false
+	}
+	if d.maxAge > 0 && d.maxAge < ttl {
+		ttl = d.maxAge
+	}
+	return time.Since(e.timestamp) <= ttl+d.maxStale-d.minFresh
+}
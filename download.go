@@ -0,0 +1,276 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NamingFunc chooses the file name, relative to the download directory,
+// of a saved .torrent file. Names that are absolute or that lead out of
+// the download directory are refused.
+type NamingFunc func(id int, infoHash string) string
+
+// IDNaming names .torrent files after their torrent id.
+func IDNaming(id int, infoHash string) string {
+	return strconv.Itoa(id) + ".torrent"
+}
+
+// SaveOptions controls SaveTorrents.
+type SaveOptions struct {
+	// Concurrency is the number of downloads in flight at once. Less
+	// than one means one. All downloads still go through the client's
+	// rate limiter.
+	Concurrency int
+	// Tokens is the number of freeleech tokens SaveTorrents may spend,
+	// one each on the first torrents it saves. No token is spent on a
+	// torrent already in the manifest, or on one with the infohash of
+	// another, as a torrent is downloaded once without a token to check
+	// that before it is downloaded again with one.
+	Tokens int
+	// Progress, if set, is told of each torrent as it is saved or
+	// skipped, with the size of its .torrent file.
+	Progress Progress
+}
+
+// SaveResult reports what SaveTorrents did with one torrent id.
+type SaveResult struct {
+	ID        int
+	Path      string
+	InfoHash  string
+	UsedToken bool
+	// TokenErr is why a token couldn't be spent on the torrent, which
+	// was then downloaded without one.
+	TokenErr error
+	// Skipped is set when the torrent was already in the manifest, or
+	// another id resolved to a torrent already saved.
+	Skipped bool
+	Err     error
+}
+
+// ManifestName is the name of the file SaveTorrents keeps in the download
+// directory to record what it has saved.
+const ManifestName = "manifest.json"
+
+type manifestEntry struct {
+	ID        int       `json:"id"`
+	Path      string    `json:"path"`
+	InfoHash  string    `json:"infoHash"`
+	UsedToken bool      `json:"usedToken"`
+	Saved     time.Time `json:"saved"`
+}
+
+type manifest struct {
+	mu      sync.Mutex
+	path    string
+	Entries []manifestEntry `json:"entries"`
+	ids     map[int]manifestEntry
+	hashes  map[string]bool
+}
+
+func loadManifest(dir string) (*manifest, error) {
+	m := &manifest{
+		path:   filepath.Join(dir, ManifestName),
+		ids:    map[int]manifestEntry{},
+		hashes: map[string]bool{},
+	}
+	b, err := ioutil.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	for _, e := range m.Entries {
+		m.ids[e.ID] = e
+		m.hashes[e.InfoHash] = true
+	}
+	return m, nil
+}
+
+// reserve claims hash for a torrent about to be saved, and reports whether
+// it was free.
+func (m *manifest) reserve(hash string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.hashes[hash] {
+		return false
+	}
+	m.hashes[hash] = true
+	return true
+}
+
+// release frees a hash reserved for a torrent that couldn't be saved.
+func (m *manifest) release(hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.hashes, hash)
+}
+
+// add records e, whose hash must be reserved, and rewrites the manifest.
+func (m *manifest) add(e manifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Entries = append(m.Entries, e)
+	m.ids[e.ID] = e
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.path, b)
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// SaveTorrents downloads the .torrent files for ids into dir, naming them
+// with naming. A manifest in dir records each saved torrent so that an
+// interrupted run can be resumed by calling SaveTorrents again with the
+// same arguments; ids and infohashes already in the manifest are skipped.
+// Per torrent failures are reported in the results rather than stopping
+// the run.
+func (w *ClientStruct) SaveTorrents(ids []int, dir string, naming NamingFunc, opts SaveOptions) ([]SaveResult, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	m, err := loadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if naming == nil {
+		naming = IDNaming
+	}
+	progress := progressOf(opts.Progress)
+	progress.Add(len(ids))
+	results := make([]SaveResult, len(ids))
+	todo := []int{}
+	tokens := make(chan struct{}, opts.Tokens)
+	for n := 0; n < opts.Tokens; n++ {
+		tokens <- struct{}{}
+	}
+	for i, id := range ids {
+		results[i].ID = id
+		if e, ok := m.ids[id]; ok {
+			results[i].Path = filepath.Join(dir, e.Path)
+			results[i].InfoHash = e.InfoHash
+			results[i].Skipped = true
+			progress.Finish(0, nil)
+			continue
+		}
+		todo = append(todo, i)
+	}
+
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				n := w.saveTorrent(&results[i], dir, naming, m, tokens)
+				progress.Finish(n, results[i].Err)
+			}
+		}()
+	}
+	for _, i := range todo {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// saveTorrent saves the torrent r is for, spending one of tokens on it if
+// there are any left, and returns the size of what it downloaded. The
+// torrent is downloaded without a token first, so that one is only spent
+// on a torrent that isn't already saved and can be.
+func (w *ClientStruct) saveTorrent(r *SaveResult, dir string, naming NamingFunc, m *manifest, tokens chan struct{}) int64 {
+	data, err := w.DownloadTorrent(r.ID, false)
+	if err != nil {
+		r.Err = err
+		return 0
+	}
+	n := int64(len(data))
+	if r.InfoHash, err = InfoHash(data); err != nil {
+		r.Err = err
+		return n
+	}
+	if !m.reserve(r.InfoHash) {
+		r.Skipped = true
+		return n
+	}
+	name, err := localName(naming(r.ID, r.InfoHash))
+	if err == nil {
+		r.Path = filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(r.Path), 0755)
+	}
+	if err == nil {
+		if withToken := w.spendToken(r, tokens); withToken != nil {
+			data = withToken
+			n += int64(len(data))
+		}
+		err = writeFileAtomic(r.Path, data)
+	}
+	if err != nil {
+		m.release(r.InfoHash)
+		r.Err = err
+		return n
+	}
+	r.Err = m.add(manifestEntry{
+		ID:        r.ID,
+		Path:      name,
+		InfoHash:  r.InfoHash,
+		UsedToken: r.UsedToken,
+		Saved:     time.Now(),
+	})
+	return n
+}
+
+// spendToken downloads the torrent r is for again with one of tokens, if
+// there are any left, and returns what it downloaded. If the download
+// fails the token is put back and its error kept in r, and it returns
+// nil, leaving the torrent as downloaded without one.
+func (w *ClientStruct) spendToken(r *SaveResult, tokens chan struct{}) []byte {
+	select {
+	case <-tokens:
+	default:
+		return nil
+	}
+	data, err := w.DownloadTorrent(r.ID, true)
+	if err != nil {
+		tokens <- struct{}{}
+		r.TokenErr = err
+		return nil
+	}
+	r.UsedToken = true
+	return data
+}
+
+// localName cleans name, and refuses names that are absolute, lead out of
+// the download directory, or would overwrite the manifest.
+func localName(name string) (string, error) {
+	clean := filepath.Clean(name)
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" ||
+		clean == "." || clean == ".." || clean == ManifestName ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errBadTorrentName(name)
+	}
+	return clean, nil
+}
@@ -0,0 +1,131 @@
+package whatapi
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSaveTorrents(t *testing.T) {
+	var mu sync.Mutex
+	tokens := []string{}
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		id := q.Get("id")
+		if q.Get("usetoken") == "1" {
+			mu.Lock()
+			tokens = append(tokens, id)
+			mu.Unlock()
+			if id == "4" {
+				http.Error(rw, "no tokens left", http.StatusForbidden)
+				return
+			}
+		}
+		name := id
+		if id == "2" {
+			// the same torrent as 1, by another id
+			name = "1"
+		}
+		rw.Write([]byte("d4:infod4:name" + strconv.Itoa(len(name)) + ":" + name + "ee"))
+	})
+	dir := t.TempDir()
+	naming := func(id int, infoHash string) string {
+		if id == 3 {
+			return "../escaped.torrent"
+		}
+		return filepath.Join("sub", IDNaming(id, infoHash))
+	}
+
+	progress := NewProgressChan()
+	results, err := c.SaveTorrents([]int{1, 4, 5, 3, 2}, dir, naming, SaveOptions{Tokens: 2, Progress: progress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Err != nil || r.Skipped || !r.UsedToken || r.Path != filepath.Join(dir, "sub", "1.torrent") {
+		t.Errorf("bad result for a new torrent %+v", r)
+	}
+	if r := results[1]; r.Err != nil || r.UsedToken || r.TokenErr == nil {
+		t.Errorf("expected a failed token download to be saved without a token, got %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub", "4.torrent")); err != nil {
+		t.Errorf("didn't keep a torrent whose token download failed: %v", err)
+	}
+	if r := results[2]; r.Err != nil || !r.UsedToken || r.TokenErr != nil {
+		t.Errorf("expected the token given back to be spent, got %+v", r)
+	}
+	if r := results[3]; r.Err == nil || r.UsedToken {
+		t.Errorf("expected an escaping name to fail without a token, got %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "..", "escaped.torrent")); err == nil {
+		t.Error("saved a torrent outside the directory")
+	}
+	if r := results[4]; r.Err != nil || !r.Skipped || r.UsedToken {
+		t.Errorf("expected a duplicate to be skipped, got %+v", r)
+	}
+	if len(tokens) != 3 || tokens[0] != "1" || tokens[1] != "4" || tokens[2] != "5" {
+		t.Errorf("expected tokens tried on 1, 4 and 5, got %v", tokens)
+	}
+	if p := progress.Report(); p.Total != 5 || p.Done != 4 || p.Failed != 1 || p.Bytes != 7*19 {
+		t.Errorf("bad progress %+v", p)
+	}
+
+	tokens = tokens[:0]
+	results, err = c.SaveTorrents([]int{1, 4, 5}, dir, naming, SaveOptions{Tokens: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Skipped || r.Err != nil {
+			t.Errorf("expected a saved torrent to be skipped on resume, got %+v", r)
+		}
+	}
+	if len(tokens) != 0 {
+		t.Errorf("spent tokens on resume %v", tokens)
+	}
+}
+
+func TestSaveTorrentsSkipsDuplicatesBeforeTokens(t *testing.T) {
+	tokens := []string{}
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("usetoken") == "1" {
+			tokens = append(tokens, q.Get("id"))
+		}
+		// 1 and 2 are the same torrent
+		rw.Write([]byte("d4:infod4:name1:1ee"))
+	})
+	results, err := c.SaveTorrents([]int{1, 2}, t.TempDir(), IDNaming, SaveOptions{Tokens: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[1]; !r.Skipped || r.UsedToken || r.Err != nil {
+		t.Errorf("expected the duplicate to be skipped without a token, got %+v", r)
+	}
+	if len(tokens) != 1 || tokens[0] != "1" {
+		t.Errorf("expected a token spent only on 1, got %v", tokens)
+	}
+}
+
+func TestLocalName(t *testing.T) {
+	for name, ok := range map[string]bool{
+		"1.torrent":           true,
+		"a/b/1.torrent":       true,
+		"a/../1.torrent":      true,
+		"../1.torrent":        false,
+		"a/../../1.torrent":   false,
+		"/etc/1.torrent":      false,
+		"":                    false,
+		"..":                  false,
+		ManifestName:          false,
+		"./" + ManifestName:   false,
+		"..torrent":           true,
+		"sub/" + ManifestName: true,
+	} {
+		if _, err := localName(name); (err == nil) != ok {
+			t.Errorf("%q: expected ok %v, got %v", name, ok, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// The lists of a user's torrents GetUserTorrents returns.
+const (
+	UserSeeding  = "seeding"
+	UserSnatched = "snatched"
+	UserLeeching = "leeching"
+	UserUploaded = "uploaded"
+)
+
+// userTorrentsPage is the most torrents the site returns at once.
+const userTorrentsPage = 500
+
+// UserTorrent is a torrent in one of a user's lists.
+type UserTorrent struct {
+	GroupID    int    `json:"groupId"`
+	Name       string `json:"name"`
+	TorrentID  int    `json:"torrentId"`
+	ArtistName string `json:"artistName"`
+	ArtistID   int    `json:"artistId"`
+}
+
+// GetUserTorrents retrieves up to limit torrents, from offset, of the list, such as UserSeeding, of the user with the provided id, on sites with the CapUserTorrents capability. The site only shows another user's lists as far as their paranoia allows.
+func (w *ClientStruct) GetUserTorrents(userID int, list string, limit, offset int) ([]UserTorrent, error) {
+	torrents := UserTorrentsResponse{}
+	action, err := w.profile.action(CapUserTorrents)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(userID))
+	params.Set("type", list)
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("offset", strconv.Itoa(offset))
+	if err = w.Do(action, params, &torrents); err != nil {
+		return nil, err
+	}
+	return torrents.Response[list], checkResponseStatus(torrents.Status, torrents.Error)
+}
+
+// allUserTorrents retrieves the whole of one of a user's lists, a page at
+// a time.
+func (w *ClientStruct) allUserTorrents(userID int, list string) ([]UserTorrent, error) {
+	all := []UserTorrent{}
+	for {
+		page, err := w.GetUserTorrents(userID, list, userTorrentsPage, len(all))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < userTorrentsPage {
+			return all, nil
+		}
+	}
+}
+
+// The kinds of Duplicate.
+const (
+	// DuplicateFormats is an edition held in more than one format or
+	// encoding.
+	DuplicateFormats = "formats"
+	// DuplicateEditions is a group held in more than one edition.
+	DuplicateEditions = "editions"
+)
+
+// Duplicate is some of a user's torrents that are likely redundant.
+type Duplicate struct {
+	Kind  string
+	Group GroupStruct
+	// Edition is the edition held more than once, for DuplicateFormats.
+	Edition  Edition
+	Torrents []TorrentStruct
+}
+
+// FindDuplicates scans lists of the user's torrents, UserSeeding and UserSnatched if none are given, for likely duplicates: editions held in more than one format or encoding, and groups held in more than one edition, to help the user trim redundant seeds. Only the groups the user holds more than one torrent of are fetched, and lists and groups both come through the client's cache, if it has one. Duplicates are reported in the order their groups first appear in the lists, an edition's before its group's.
+func (w *ClientStruct) FindDuplicates(userID int, lists ...string) ([]Duplicate, error) {
+	if len(lists) == 0 {
+		lists = []string{UserSeeding, UserSnatched}
+	}
+	held := map[int]map[int]bool{}
+	order := []int{}
+	for _, list := range lists {
+		torrents, err := w.allUserTorrents(userID, list)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range torrents {
+			if held[t.GroupID] == nil {
+				held[t.GroupID] = map[int]bool{}
+				order = append(order, t.GroupID)
+			}
+			held[t.GroupID][t.TorrentID] = true
+		}
+	}
+	dupes := []Duplicate{}
+	for _, id := range order {
+		if len(held[id]) < 2 {
+			continue
+		}
+		g, err := w.GetTorrentGroup(id, url.Values{})
+		if err != nil {
+			return nil, err
+		}
+		dupes = append(dupes, duplicates(g, held[id])...)
+	}
+	return dupes, nil
+}
+
+// duplicates returns the duplicates among the torrents of g that are
+// held. Held torrents no longer in the group are left out.
+func duplicates(g TorrentGroup, held map[int]bool) []Duplicate {
+	editions := map[Edition][]TorrentStruct{}
+	order := []Edition{}
+	all := []TorrentStruct{}
+	for _, t := range g.Torrent {
+		if !held[t.ID()] {
+			continue
+		}
+		e := EditionOf(t)
+		if _, ok := editions[e]; !ok {
+			order = append(order, e)
+		}
+		editions[e] = append(editions[e], t)
+		all = append(all, t)
+	}
+	dupes := []Duplicate{}
+	for _, e := range order {
+		if len(editions[e]) > 1 {
+			dupes = append(dupes, Duplicate{Kind: DuplicateFormats, Group: g.Group, Edition: e, Torrents: editions[e]})
+		}
+	}
+	if len(order) > 1 {
+		dupes = append(dupes, Duplicate{Kind: DuplicateEditions, Group: g.Group, Torrents: all})
+	}
+	return dupes
+}
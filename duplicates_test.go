@@ -0,0 +1,81 @@
+package whatapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+var userTorrentsProfile = SiteProfile{
+	Name:    "test",
+	Actions: map[Capability]string{CapUserTorrents: "user_torrents"},
+}
+
+func TestFindDuplicates(t *testing.T) {
+	groups := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "user_torrents":
+			if q.Get("id") != "7" || q.Get("limit") != "500" || q.Get("offset") != "0" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			list := `[]`
+			switch q.Get("type") {
+			case UserSeeding:
+				list = `[{"groupId":"1","name":"Album","torrentId":"11","artistName":"A","artistId":"3"},
+{"groupId":"1","name":"Album","torrentId":"12","artistName":"A","artistId":"3"},
+{"groupId":"2","name":"Other","torrentId":"21","artistName":"A","artistId":"3"}]`
+			case UserSnatched:
+				list = `[{"groupId":"1","name":"Album","torrentId":"11","artistName":"A","artistId":"3"},
+{"groupId":"1","name":"Album","torrentId":"13","artistName":"A","artistId":"3"},
+{"groupId":"2","name":"Other","torrentId":"21","artistName":"A","artistId":"3"}]`
+			}
+			fmt.Fprintf(rw, `{"status":"success","response":{%q:%s}}`, q.Get("type"), list)
+		case "torrentgroup":
+			groups++
+			if q.Get("id") != "1" {
+				t.Errorf("unexpected group %s", q.Get("id"))
+			}
+			rw.Write([]byte(`{"status":"success","response":{"group":{"id":1,"name":"Album"},"torrents":[
+{"id":11,"media":"CD","format":"FLAC","encoding":"Lossless"},
+{"id":12,"media":"CD","format":"MP3","encoding":"V0 (VBR)"},
+{"id":13,"media":"Vinyl","format":"FLAC","encoding":"24bit Lossless","remastered":true,"remasterYear":2015},
+{"id":14,"media":"CD","format":"MP3","encoding":"320"}]}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}, WithSiteProfile(userTorrentsProfile))
+	dupes, err := c.FindDuplicates(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups != 1 {
+		t.Errorf("expected only the group held twice to be fetched, got %d", groups)
+	}
+	ids := func(d Duplicate) []int {
+		r := []int{}
+		for _, t := range d.Torrents {
+			r = append(r, t.ID())
+		}
+		return r
+	}
+	if len(dupes) != 2 {
+		t.Fatalf("expected 2 duplicates, got %+v", dupes)
+	}
+	if d := dupes[0]; d.Kind != DuplicateFormats || d.Edition.Media != "CD" || !equalInts(ids(d), []int{11, 12}) {
+		t.Errorf("bad formats duplicate %+v", d)
+	}
+	if d := dupes[1]; d.Kind != DuplicateEditions || d.Group.ID() != 1 || !equalInts(ids(d), []int{11, 12, 13}) {
+		t.Errorf("bad editions duplicate %+v", d)
+	}
+}
+
+func TestGetUserTorrentsUnsupported(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}, WithSiteProfile(GazelleProfile))
+	if _, err := c.GetUserTorrents(7, UserSeeding, 10, 0); err == nil {
+		t.Error("expected user torrents to be unsupported")
+	}
+}
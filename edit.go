@@ -0,0 +1,137 @@
+package whatapi
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RemasterInfo is the edition of a torrent. The zero RemasterInfo is the
+// original release.
+type RemasterInfo struct {
+	Year            int
+	Title           string
+	RecordLabel     string
+	CatalogueNumber string
+}
+
+// TorrentEdit holds the fields of Gazelle's torrent edit form, which
+// replaces all of them, so start from TorrentEditFrom to change only some.
+type TorrentEdit struct {
+	Remaster bool
+	RemasterInfo
+
+	Scene              bool
+	Format             string
+	Bitrate            string
+	OtherBitrate       string
+	VBR                bool
+	Media              string
+	ReleaseDescription string
+}
+
+// TorrentEditFrom returns the edit form for t as it is.
+func TorrentEditFrom(t TorrentStruct) TorrentEdit {
+	return TorrentEdit{
+		Remaster:           t.RemasteredF,
+		RemasterInfo:       remasterInfo(t),
+		Scene:              t.SceneF,
+		Format:             t.FormatF,
+		Bitrate:            t.EncodingF,
+		Media:              t.MediaF,
+		ReleaseDescription: t.DescriptionF,
+	}
+}
+
+// Fields returns the form's fields under their torrents.php names, which
+// are those of the upload form.
+func (e TorrentEdit) Fields() url.Values {
+	v := UploadForm{
+		Remaster:                e.Remaster,
+		RemasterYear:            e.Year,
+		RemasterTitle:           e.Title,
+		RemasterRecordLabel:     e.RecordLabel,
+		RemasterCatalogueNumber: e.CatalogueNumber,
+		Scene:                   e.Scene,
+		Format:                  e.Format,
+		Bitrate:                 e.Bitrate,
+		OtherBitrate:            e.OtherBitrate,
+		VBR:                     e.VBR,
+		Media:                   e.Media,
+		ReleaseDescription:      e.ReleaseDescription,
+	}.Fields()
+	// the edit form doesn't have these
+	v.Del("submit")
+	v.Del("type")
+	return v
+}
+
+// errorPageMessage matches the message of Gazelle's error page.
+var errorPageMessage = regexp.MustCompile(`(?is)<div class="box pad">\s*<p>(.*?)</p>`)
+
+// checkRedirected returns an error unless the site answered a torrent
+// form by redirecting to the group page at u, as it does on success. On
+// failure it answers with its error page, whose message the error holds.
+func checkRedirected(page []byte, u *url.URL) error {
+	if u != nil && u.Query().Get("id") != "" {
+		return nil
+	}
+	reason := "form was not accepted"
+	if m := errorPageMessage.FindSubmatch(page); m != nil {
+		reason = strings.TrimSpace(html.UnescapeString(markup.ReplaceAllString(string(m[1]), "")))
+	} else if t := strings.TrimSpace(string(pageTitle(page))); t != "" {
+		reason = t
+	}
+	return errRequestFailedReason(reason)
+}
+
+// EditTorrent replaces the edit form fields of the torrent with the provided id, for users with edit permissions on sites with the CapEditTorrent capability.
+func (w *ClientStruct) EditTorrent(torrentID int, fields TorrentEdit) error {
+	action, err := w.profile.action(CapEditTorrent)
+	if err != nil {
+		return err
+	}
+	params := fields.Fields()
+	params.Set("action", action)
+	params.Set("torrentid", strconv.Itoa(torrentID))
+	page, u, err := w.postFormURL("torrents.php", params)
+	if err != nil {
+		return err
+	}
+	return checkRedirected(page, u)
+}
+
+// SetRemaster moves the torrent with the provided id to the edition r, leaving its other fields as they are, for users with edit permissions on sites with the CapEditTorrent capability. A zero r makes it the original release.
+func (w *ClientStruct) SetRemaster(torrentID int, r RemasterInfo) error {
+	if _, err := w.profile.action(CapEditTorrent); err != nil {
+		return err
+	}
+	t, err := w.GetTorrent(torrentID, url.Values{})
+	if err != nil {
+		return err
+	}
+	e := TorrentEditFrom(t.Torrent)
+	e.Remaster = r != RemasterInfo{}
+	e.RemasterInfo = r
+	return w.EditTorrent(torrentID, e)
+}
+
+// MergeGroups merges the torrent group with id fromID into the group with id toID, for users with edit permissions on sites with the CapMergeGroups capability.
+func (w *ClientStruct) MergeGroups(fromID, toID int) error {
+	action, err := w.profile.action(CapMergeGroups)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("action", action)
+	params.Set("groupid", strconv.Itoa(fromID))
+	params.Set("targetgroupid", strconv.Itoa(toID))
+	params.Set("confirm", "true")
+	page, u, err := w.postFormURL("torrents.php", params)
+	if err != nil {
+		return err
+	}
+	return checkRedirected(page, u)
+}
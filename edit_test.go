@@ -0,0 +1,109 @@
+package whatapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestTorrentEditFields(t *testing.T) {
+	e := whatapi.TorrentEditFrom(whatapi.TorrentStruct{
+		RemasteredF: true, RemasterYearF: 2011, RemasterTitleF: "Deluxe",
+		FormatF: "FLAC", EncodingF: "Lossless", MediaF: "CD",
+	})
+	v := e.Fields()
+	exp := map[string]string{
+		"remaster": "on", "remaster_year": "2011", "remaster_title": "Deluxe",
+		"format": "FLAC", "bitrate": "Lossless", "media": "CD",
+		"submit": "", "type": "", "scene": "",
+	}
+	for k, s := range exp {
+		if v.Get(k) != s {
+			t.Errorf("expected %s to be %q, got %q", k, s, v.Get(k))
+		}
+	}
+}
+
+// editSite accepts edits of torrent 5 and merges into group 2, redirecting
+// to the group page, and answers anything else with its error page.
+func editSite(t *testing.T, profile whatapi.SiteProfile) (whatapi.Client, *url.Values) {
+	t.Helper()
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") == "index" {
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+			return
+		}
+		if r.Method == "GET" {
+			rw.Write([]byte(`<html><title>Group :: Site</title></html>`))
+			return
+		}
+		r.ParseForm()
+		form = r.PostForm
+		if form.Get("torrentid") == "5" || form.Get("targetgroupid") == "2" {
+			http.Redirect(rw, r, "/torrents.php?id=2", http.StatusFound)
+			return
+		}
+		rw.Write([]byte(`<html><title>Error :: Site</title><div class="box pad"><p>You may not edit &amp; merge this.</p></div></html>`))
+	}))
+	t.Cleanup(srv.Close)
+	c, err := whatapi.NewClient(srv.URL, "agent", whatapi.WithRateLimit(0, 0), whatapi.WithAPIKey("key"), whatapi.WithSiteProfile(profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Login("user", ""); err != nil {
+		t.Fatal(err)
+	}
+	return c, &form
+}
+
+func TestEditTorrent(t *testing.T) {
+	c, form := editSite(t, whatapi.RedactedProfile)
+	e := whatapi.TorrentEdit{Format: "FLAC", Bitrate: "Lossless", Media: "CD"}
+	if err := c.EditTorrent(5, e); err != nil {
+		t.Fatal(err)
+	}
+	for k, exp := range map[string]string{"action": "takeedit", "torrentid": "5", "auth": "a", "format": "FLAC"} {
+		if got := form.Get(k); got != exp {
+			t.Errorf("expected %s %q, got %q", k, exp, got)
+		}
+	}
+	err := c.EditTorrent(6, e)
+	if err == nil || err.Error() != "Request failed: You may not edit & merge this." {
+		t.Errorf("expected the error page's message, got %v", err)
+	}
+}
+
+func TestMergeGroups(t *testing.T) {
+	c, form := editSite(t, whatapi.RedactedProfile)
+	if err := c.MergeGroups(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	for k, exp := range map[string]string{"action": "merge", "groupid": "1", "targetgroupid": "2", "confirm": "true"} {
+		if got := form.Get(k); got != exp {
+			t.Errorf("expected %s %q, got %q", k, exp, got)
+		}
+	}
+	if err := c.MergeGroups(1, 3); err == nil {
+		t.Error("expected the error page to fail the merge")
+	}
+}
+
+func TestEditUnsupported(t *testing.T) {
+	c, form := editSite(t, whatapi.GazelleProfile)
+	if err := c.EditTorrent(5, whatapi.TorrentEdit{}); err == nil {
+		t.Error("expected editing to be unsupported")
+	}
+	if err := c.MergeGroups(1, 2); err == nil {
+		t.Error("expected merging to be unsupported")
+	}
+	if err := c.SetRemaster(5, whatapi.RemasterInfo{}); err == nil {
+		t.Error("expected setting the edition to be unsupported")
+	}
+	if *form != nil {
+		t.Errorf("expected nothing to be posted, got %v", *form)
+	}
+}
@@ -0,0 +1,210 @@
+// Package enrich cross-references whatapi similar artist data with the
+// Last.fm and ListenBrainz APIs to build richer recommendations.
+package enrich
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+const (
+	lastFMURL       = "https://ws.audioscrobbler.com/2.0/"
+	listenBrainzURL = "https://api.listenbrainz.org/1/popularity/artist"
+)
+
+// Config holds the credentials for the external services. Either key may
+// be empty, in which case that service is skipped.
+type Config struct {
+	LastFMKey         string
+	ListenBrainzToken string
+	// SiteTags also fetches each similar artist from the tracker to
+	// fill in Recommendation.SiteTags. It costs one request per artist.
+	SiteTags   bool
+	HTTPClient *http.Client
+}
+
+// Recommendation is a similar artist with data gathered from the tracker
+// and the external services.
+type Recommendation struct {
+	ArtistID        int
+	Name            string
+	Score           int
+	SiteTags        []string
+	MBID            string
+	Listeners       int64
+	PlayCount       int64
+	GlobalTags      []string
+	ListenCount     int64
+	ListenUserCount int64
+}
+
+// Enricher looks up recommendation data for artists.
+type Enricher struct {
+	config Config
+	client *http.Client
+}
+
+// New returns an Enricher using the provided configuration.
+func New(c Config) *Enricher {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Enricher{config: c, client: client}
+}
+
+// Recommend returns up to limit artists similar to artistID, enriched with
+// whichever external services are configured.
+func (e *Enricher) Recommend(w whatapi.ArtistAPI, artistID, limit int) ([]Recommendation, error) {
+	similar, err := w.GetSimilarArtists(artistID, limit)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]Recommendation, 0, len(similar))
+	for _, s := range similar {
+		r := Recommendation{ArtistID: s.ID, Name: s.Name, Score: s.Score}
+		if e.config.SiteTags {
+			a, err := w.GetArtist(s.ID, url.Values{})
+			if err != nil {
+				return recs, err
+			}
+			for _, t := range a.Tags {
+				r.SiteTags = append(r.SiteTags, t.Name)
+			}
+		}
+		if e.config.LastFMKey != "" {
+			if err := e.lastFM(&r); err != nil {
+				return recs, err
+			}
+		}
+		recs = append(recs, r)
+	}
+	if e.config.ListenBrainzToken != "" {
+		if err := e.listenBrainz(recs); err != nil {
+			return recs, err
+		}
+	}
+	return recs, nil
+}
+
+type lastFMArtist struct {
+	Artist struct {
+		Name  string `json:"name"`
+		MBID  string `json:"mbid"`
+		Stats struct {
+			Listeners string `json:"listeners"`
+			PlayCount string `json:"playcount"`
+		} `json:"stats"`
+		Tags struct {
+			Tag []struct {
+				Name string `json:"name"`
+			} `json:"tag"`
+		} `json:"tags"`
+	} `json:"artist"`
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+}
+
+// lastFM fills in the Last.fm fields of r. Artists Last.fm doesn't know
+// about are left empty rather than treated as an error; other errors, and
+// responses that aren't 2xx, are.
+func (e *Enricher) lastFM(r *Recommendation) error {
+	params := url.Values{}
+	params.Set("method", "artist.getinfo")
+	params.Set("artist", r.Name)
+	params.Set("api_key", e.config.LastFMKey)
+	params.Set("format", "json")
+	resp, err := e.client.Get(lastFMURL + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var a lastFMArtist
+	err = json.Unmarshal(body, &a)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// errors usually come with a code, but pages from a proxy in
+		// front of the API don't
+		if err != nil || a.Error == 0 {
+			return fmt.Errorf("last.fm: %s", resp.Status)
+		}
+	} else if err != nil {
+		return err
+	}
+	switch a.Error {
+	case 0:
+	case 6: // artist not found
+		return nil
+	default:
+		return fmt.Errorf("last.fm error %d: %s", a.Error, a.Message)
+	}
+	r.MBID = a.Artist.MBID
+	r.Listeners, _ = strconv.ParseInt(a.Artist.Stats.Listeners, 10, 64)
+	r.PlayCount, _ = strconv.ParseInt(a.Artist.Stats.PlayCount, 10, 64)
+	for _, t := range a.Artist.Tags.Tag {
+		r.GlobalTags = append(r.GlobalTags, t.Name)
+	}
+	return nil
+}
+
+type listenBrainzPopularity struct {
+	ArtistMBID       string `json:"artist_mbid"`
+	TotalListenCount int64  `json:"total_listen_count"`
+	TotalUserCount   int64  `json:"total_user_count"`
+}
+
+// listenBrainz fills in listen counts for every recommendation with a
+// MusicBrainz id, which only Last.fm provides.
+func (e *Enricher) listenBrainz(recs []Recommendation) error {
+	byMBID := map[string]*Recommendation{}
+	mbids := []string{}
+	for i := range recs {
+		if recs[i].MBID == "" {
+			continue
+		}
+		byMBID[recs[i].MBID] = &recs[i]
+		mbids = append(mbids, recs[i].MBID)
+	}
+	if len(mbids) == 0 {
+		return nil
+	}
+	reqBody, err := json.Marshal(map[string][]string{"artist_mbids": mbids})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", listenBrainzURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+e.config.ListenBrainzToken)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("listenbrainz: %s", resp.Status)
+	}
+	var pop []listenBrainzPopularity
+	if err := json.NewDecoder(resp.Body).Decode(&pop); err != nil {
+		return err
+	}
+	for _, p := range pop {
+		if r, ok := byMBID[p.ArtistMBID]; ok {
+			r.ListenCount = p.TotalListenCount
+			r.ListenUserCount = p.TotalUserCount
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// fakeSite has artists similar to any artist, tagged on the site.
+type fakeSite struct {
+	whatapi.ArtistAPI
+	t       *testing.T
+	similar string
+}
+
+func (s fakeSite) GetSimilarArtists(id, limit int) (whatapi.SimilarArtists, error) {
+	var sa whatapi.SimilarArtists
+	if err := json.Unmarshal([]byte(s.similar), &sa); err != nil {
+		s.t.Fatal(err)
+	}
+	return sa, nil
+}
+
+func (s fakeSite) GetArtist(id int, params url.Values) (whatapi.Artist, error) {
+	a := whatapi.Artist{}
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{"id":%d,"tags":[{"name":"rock","count":3}]}`, id)), &a)
+	return a, err
+}
+
+// redirect sends every request to the test server instead of the host
+// asked for.
+type redirect struct {
+	to *url.URL
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme, req.URL.Host = r.to.Scheme, r.to.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newEnricher(t *testing.T, h http.HandlerFunc, c Config) *Enricher {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	c.HTTPClient = &http.Client{Transport: redirect{u}}
+	return New(c)
+}
+
+func TestRecommend(t *testing.T) {
+	e := newEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2.0/":
+			if r.URL.Query().Get("api_key") != "key" {
+				t.Errorf("bad last.fm query %s", r.URL.RawQuery)
+			}
+			switch r.URL.Query().Get("artist") {
+			case "Known":
+				fmt.Fprint(w, `{"artist":{"name":"Known","mbid":"mb-1","stats":{"listeners":"10","playcount":"200"},
+"tags":{"tag":[{"name":"indie"},{"name":"pop"}]}}}`)
+			default:
+				fmt.Fprint(w, `{"error":6,"message":"The artist you supplied could not be found"}`)
+			}
+		case "/1/popularity/artist":
+			if r.Header.Get("Authorization") != "Token token" {
+				t.Errorf("bad listenbrainz authorization %q", r.Header.Get("Authorization"))
+			}
+			var req struct {
+				MBIDs []string `json:"artist_mbids"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.MBIDs) != 1 || req.MBIDs[0] != "mb-1" {
+				t.Errorf("bad listenbrainz request %+v, %v", req, err)
+			}
+			fmt.Fprint(w, `[{"artist_mbid":"mb-1","total_listen_count":500,"total_user_count":7}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}, Config{LastFMKey: "key", ListenBrainzToken: "token", SiteTags: true})
+
+	site := fakeSite{t: t, similar: `[{"id":2,"name":"Known","score":90},{"id":3,"name":"Unknown","score":40}]`}
+	recs, err := e.Recommend(site, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 recommendations, got %+v", recs)
+	}
+	k := recs[0]
+	if k.ArtistID != 2 || k.Score != 90 || k.MBID != "mb-1" || k.Listeners != 10 || k.PlayCount != 200 ||
+		strings.Join(k.GlobalTags, ",") != "indie,pop" || strings.Join(k.SiteTags, ",") != "rock" ||
+		k.ListenCount != 500 || k.ListenUserCount != 7 {
+		t.Errorf("bad recommendation %+v", k)
+	}
+	if u := recs[1]; u.MBID != "" || u.Listeners != 0 || u.ListenCount != 0 || len(u.SiteTags) != 1 {
+		t.Errorf("expected an artist unknown to last.fm left empty, got %+v", u)
+	}
+}
+
+func TestRecommendErrors(t *testing.T) {
+	site := fakeSite{t: t, similar: `[{"id":2,"name":"Known","score":90}]`}
+	for _, c := range []struct {
+		name   string
+		status int
+		body   string
+		exp    string
+	}{
+		{"error page", http.StatusBadGateway, `<html>Bad Gateway</html>`, "last.fm: 502 Bad Gateway"},
+		{"error status", http.StatusForbidden, `{"error":10,"message":"Invalid API key"}`, "last.fm error 10: Invalid API key"},
+		{"error code", http.StatusOK, `{"error":29,"message":"Rate limit exceeded"}`, "last.fm error 29: Rate limit exceeded"},
+		{"garbled", http.StatusOK, `{"artist":`, "unexpected end of JSON input"},
+	} {
+		e := newEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			fmt.Fprint(w, c.body)
+		}, Config{LastFMKey: "key"})
+		if _, err := e.Recommend(site, 1, 1); err == nil || err.Error() != c.exp {
+			t.Errorf("%s: expected %q, got %v", c.name, c.exp, err)
+		}
+	}
+
+	e := newEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/2.0/" {
+			fmt.Fprint(w, `{"artist":{"mbid":"mb-1"}}`)
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}, Config{LastFMKey: "key", ListenBrainzToken: "token"})
+	if _, err := e.Recommend(site, 1, 1); err == nil || !strings.Contains(err.Error(), "listenbrainz: 503") {
+		t.Errorf("expected the listenbrainz error, got %v", err)
+	}
+}
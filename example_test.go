@@ -0,0 +1,194 @@
+package whatapi_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// fixtures is a transport that answers ajax requests with the sample
+// responses in a testdata directory, and downloads with a tiny .torrent
+// file, so that the examples run without a tracker.
+type fixtures string
+
+func (dir fixtures) RoundTrip(r *http.Request) (*http.Response, error) {
+	body := []byte("d4:infod6:lengthi1e4:name5:a.txt12:piece lengthi16384e6:pieces0:ee")
+	if !strings.HasSuffix(r.URL.Path, "torrents.php") {
+		var err error
+		action := r.URL.Query().Get("action")
+		if body, err = ioutil.ReadFile(filepath.Join(string(dir), action+".json")); err != nil {
+			return nil, err
+		}
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+// site stands in for a real tracker. Leave out WithTransport to talk to
+// the one at the client's URL.
+var site = fixtures("testdata/responses/gazelle")
+
+func Example_loginWithAPIKey() {
+	c, err := whatapi.NewClient("https://example.com/", "example/1.0",
+		whatapi.WithAPIKey("0123456789abcdef"), whatapi.WithTransport(site))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Login("listener", ""); err != nil {
+		log.Fatal(err)
+	}
+	a, err := c.GetAccountInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s (%s) ratio %.2f\n", a.Username, a.UserStats.Class, a.UserStats.Ratio)
+	// Output: listener (Power User) ratio 5.00
+}
+
+func Example_cachedSearch() {
+	// any database/sql driver for sqlite or mysql will do, imported by
+	// the program for its side effects
+	db, err := sql.Open("sqlite3", "whatapi-cache.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := whatapi.NewClient("https://example.com/", "example/1.0",
+		whatapi.WithAPIKey("0123456789abcdef"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// wrap the client before logging in, so the session is kept in db
+	c, err = whatapi.Cache(c, db, 24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Login("listener", ""); err != nil {
+		log.Fatal(err)
+	}
+	// a second search for the same thing within a day is answered from
+	// db without a request
+	for i := 0; i < 2; i++ {
+		s, err := c.SearchTorrents("radiohead", url.Values{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range s.Results {
+			fmt.Println(r.Artist(), "-", r.Name())
+		}
+	}
+}
+
+func Example_artistDiscography() {
+	c, err := whatapi.NewClient("https://example.com/", "example/1.0",
+		whatapi.WithAPIKey("0123456789abcdef"), whatapi.WithTransport(site))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Login("listener", ""); err != nil {
+		log.Fatal(err)
+	}
+	a, err := c.GetArtist(5, url.Values{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(a.Name())
+	for _, g := range a.TorrentGroup {
+		fmt.Printf("%d %s (%s)\n", g.Year(), g.Name(), whatapi.ReleaseTypeString(g.ReleaseType()))
+		for _, t := range g.Torrent {
+			fmt.Printf("\t%d %s %s %s\n", t.ID(), t.Media(), t.Format(), t.Encoding())
+		}
+	}
+	// Output:
+	// Radiohead
+	// 1997 OK Computer (Album)
+	// 	1337 CD FLAC Lossless
+}
+
+func Example_downloadTorrent() {
+	c, err := whatapi.NewClient("https://example.com/", "example/1.0",
+		whatapi.WithAPIKey("0123456789abcdef"), whatapi.WithTransport(site))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Login("listener", ""); err != nil {
+		log.Fatal(err)
+	}
+	data, err := c.DownloadTorrent(1337, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hash, err := whatapi.InfoHash(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(hash)
+	// Output: D31759CE9CCFA3CBE8B3732998A7628D5790512E
+}
+
+// printing is a decorator that prints the action of each request the
+// client it wraps makes.
+type printing struct {
+	whatapi.Decorator
+}
+
+func (p *printing) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("GET", u.Query().Get("action"))
+	return p.Decorator.FetchContext(ctx, requestURL)
+}
+
+// Clone wraps a clone of the client p wraps, so that clones are printed
+// too.
+func (p *printing) Clone(opts ...whatapi.Option) (whatapi.Decorator, error) {
+	inner, err := p.Decorator.Clone(opts...)
+	if err != nil {
+		return nil, err
+	}
+	clone := &printing{inner}
+	inner.SetOuter(clone)
+	return clone, nil
+}
+
+func Example_decorator() {
+	c, err := whatapi.NewClient("https://example.com/", "example/1.0",
+		whatapi.WithAPIKey("0123456789abcdef"), whatapi.WithTransport(site))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// wrap a clone, as Cache does, so that c itself is left as it was
+	inner, err := whatapi.Clone(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p := &printing{inner.(whatapi.Decorator)}
+	p.SetOuter(p)
+	if err := p.Login("listener", ""); err != nil {
+		log.Fatal(err)
+	}
+	a, err := p.GetArtist(5, url.Values{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(a.Name())
+	// Output:
+	// GET artist
+	// Radiohead
+}
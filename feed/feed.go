@@ -0,0 +1,244 @@
+// Package feed turns whatapi artist pages and notifications into RSS and
+// Atom feeds, so new uploads can be followed in a feed reader.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// Item is an entry in a feed, a torrent. Its Link is also its id.
+type Item struct {
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+}
+
+// Feed is a list of items, newest first.
+type Feed struct {
+	Title   string
+	Link    string
+	Updated time.Time
+	Items   []Item
+}
+
+// torrentLink returns the link to the torrent with torrentID in the group
+// with groupID, which is also the item's id, as an item is a torrent.
+func torrentLink(c whatapi.Client, groupID, torrentID int) (string, error) {
+	return c.PageURL("torrents.php", url.Values{
+		"id":        {strconv.Itoa(groupID)},
+		"torrentid": {strconv.Itoa(torrentID)},
+	})
+}
+
+func (f *Feed) sort() {
+	sort.SliceStable(f.Items, func(i, j int) bool {
+		return f.Items[i].Published.After(f.Items[j].Published)
+	})
+	if len(f.Items) > 0 {
+		f.Updated = f.Items[0].Published
+	}
+}
+
+// Artist returns a feed of the artist's torrents, each dated by its
+// upload.
+func Artist(c whatapi.Client, artistID int) (Feed, error) {
+	a, err := c.GetArtist(artistID, url.Values{})
+	if err != nil {
+		return Feed{}, err
+	}
+	l, err := c.PageURL("artist.php", url.Values{"id": {strconv.Itoa(artistID)}})
+	if err != nil {
+		return Feed{}, err
+	}
+	f := Feed{Title: a.Name(), Link: l}
+	for _, g := range a.TorrentGroup {
+		for _, t := range g.Torrent {
+			l, err := torrentLink(c, g.ID(), t.ID())
+			if err != nil {
+				return Feed{}, err
+			}
+			published, _ := t.UploadTime()
+			f.Items = append(f.Items, Item{
+				Title:       g.String() + " " + t.String(),
+				Link:        l,
+				Description: whatapi.FormatSize(t.Size),
+				Published:   published,
+			})
+		}
+	}
+	f.sort()
+	return f, nil
+}
+
+// Notifications returns a feed of the first page of the user's torrent
+// notifications.
+func Notifications(c whatapi.Client) (Feed, error) {
+	n, err := c.GetNotificationsPage(whatapi.NotificationsOptions{})
+	if err != nil {
+		return Feed{}, err
+	}
+	l, err := c.PageURL("torrents.php", url.Values{"action": {"notify"}})
+	if err != nil {
+		return Feed{}, err
+	}
+	f := Feed{Title: "Notifications", Link: l}
+	for _, t := range n.Results {
+		l, err := torrentLink(c, t.GroupID, t.TorrentID)
+		if err != nil {
+			return Feed{}, err
+		}
+		published, _ := t.Time()
+		f.Items = append(f.Items, Item{
+			Title:       t.GroupName + " [" + t.FormatEncoding() + " " + t.Media + "]",
+			Link:        l,
+			Description: whatapi.FormatSize(t.Size),
+			Published:   published,
+		})
+	}
+	f.sort()
+	return f, nil
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description,omitempty"`
+	PubDate     string `xml:"pubDate,omitempty"`
+}
+
+func rssDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// WriteRSS writes the feed as RSS 2.0.
+func (f Feed) WriteRSS(w io.Writer) error {
+	r := rss{Version: "2.0"}
+	r.Channel.Title = f.Title
+	r.Channel.Link = f.Link
+	r.Channel.Description = f.Title
+	r.Channel.LastBuildDate = rssDate(f.Updated)
+	for _, i := range f.Items {
+		r.Channel.Items = append(r.Channel.Items, rssItem{
+			Title:       i.Title,
+			Link:        i.Link,
+			GUID:        i.Link,
+			Description: i.Description,
+			PubDate:     rssDate(i.Published),
+		})
+	}
+	return write(w, r)
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+// WriteAtom writes the feed as Atom.
+func (f Feed) WriteAtom(w io.Writer) error {
+	a := atom{
+		Title:   f.Title,
+		ID:      f.Link,
+		Link:    atomLink{f.Link},
+		Updated: f.Updated.Format(time.RFC3339),
+	}
+	for _, i := range f.Items {
+		a.Entries = append(a.Entries, atomEntry{
+			Title:   i.Title,
+			ID:      i.Link,
+			Link:    atomLink{i.Link},
+			Updated: i.Published.Format(time.RFC3339),
+			Summary: i.Description,
+		})
+	}
+	return write(w, a)
+}
+
+func write(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	return e.Encode(v)
+}
+
+// Handler serves feeds from a client. A request with an artist parameter
+// gets that artist's feed, any other the user's notifications. Feeds are
+// RSS unless the format parameter is "atom". Requests go through the
+// client, so a cached client serves repeated polls from its cache.
+type Handler struct {
+	Client whatapi.Client
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		f   Feed
+		err error
+	)
+	if a := r.URL.Query().Get("artist"); a != "" {
+		id, convErr := strconv.Atoi(a)
+		if convErr != nil {
+			http.Error(w, "bad artist id", http.StatusBadRequest)
+			return
+		}
+		f, err = Artist(h.Client, id)
+	} else {
+		f, err = Notifications(h.Client)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if r.URL.Query().Get("format") == "atom" {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		err = f.WriteAtom(w)
+	} else {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		err = f.WriteRSS(w)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
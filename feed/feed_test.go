@@ -0,0 +1,146 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// fakeClient serves an artist and notifications, for a site under a
+// subpath.
+type fakeClient struct {
+	whatapi.Client
+	t *testing.T
+}
+
+func (c fakeClient) PageURL(endpoint string, params url.Values) (string, error) {
+	b, err := whatapi.NewURLBuilder("https://site.example/sub/")
+	if err != nil {
+		return "", err
+	}
+	return b.Build(endpoint, "", params)
+}
+
+func (c fakeClient) GetArtist(id int, params url.Values) (whatapi.Artist, error) {
+	a := whatapi.Artist{}
+	err := json.Unmarshal([]byte(`{"id":1,"name":"Artist","torrentgroup":[
+		{"groupId":10,"groupName":"Old","groupYear":2001,"torrent":[
+			{"id":100,"media":"CD","format":"FLAC","encoding":"Lossless","size":1048576,"time":"2020-01-01 00:00:00"},
+			{"id":101,"media":"WEB","format":"MP3","encoding":"320","size":2048,"time":"2022-01-01 00:00:00"}]},
+		{"groupId":11,"groupName":"New","groupYear":2021,"torrent":[
+			{"id":110,"media":"CD","format":"FLAC","encoding":"Lossless","size":1024,"time":"2021-01-01 00:00:00"}]}]}`), &a)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return a, nil
+}
+
+func (c fakeClient) GetNotificationsPage(opts whatapi.NotificationsOptions) (whatapi.Notifications, error) {
+	return whatapi.Notifications{Results: []whatapi.NotificationTorrent{
+		{TorrentID: 200, GroupID: 20, GroupName: "Album", Format: "FLAC", Encoding: "Lossless", Media: "CD", Size: 1024, NotificationTime: "2022-02-01 00:00:00"},
+		{TorrentID: 201, GroupID: 20, GroupName: "Album", Format: "MP3", Encoding: "V0", Media: "CD", Size: 1024, NotificationTime: "2022-02-02 00:00:00"},
+	}}, nil
+}
+
+func TestArtist(t *testing.T) {
+	f, err := Artist(fakeClient{t: t}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Artist" || f.Link != "https://site.example/sub/artist.php?id=1" {
+		t.Errorf("bad feed %q %q", f.Title, f.Link)
+	}
+	exp := []string{
+		"https://site.example/sub/torrents.php?id=10&torrentid=101",
+		"https://site.example/sub/torrents.php?id=11&torrentid=110",
+		"https://site.example/sub/torrents.php?id=10&torrentid=100",
+	}
+	if len(f.Items) != len(exp) {
+		t.Fatalf("expected an item for each torrent, got %+v", f.Items)
+	}
+	for i, l := range exp {
+		if f.Items[i].Link != l {
+			t.Errorf("item %d: expected %s, got %s", i, l, f.Items[i].Link)
+		}
+	}
+	if f.Items[0].Published.Year() != 2022 || !f.Updated.Equal(f.Items[0].Published) {
+		t.Errorf("expected items dated by their torrents, got %+v", f.Items[0])
+	}
+	if !strings.Contains(f.Items[0].Title, "Old") || !strings.Contains(f.Items[0].Title, "MP3") {
+		t.Errorf("bad item title %q", f.Items[0].Title)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	f, err := Notifications(fakeClient{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Link != "https://site.example/sub/torrents.php?action=notify" {
+		t.Errorf("bad feed link %q", f.Link)
+	}
+	if len(f.Items) != 2 || f.Items[0].Link != "https://site.example/sub/torrents.php?id=20&torrentid=201" ||
+		f.Items[0].Title != "Album [MP3 V0 CD]" || f.Items[0].Description != whatapi.FormatSize(1024) {
+		t.Errorf("bad items %+v", f.Items)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	f, err := Artist(fakeClient{t: t}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := f.WriteRSS(&b); err != nil {
+		t.Fatal(err)
+	}
+	var r rss
+	if err := xml.Unmarshal(b.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	guids := map[string]bool{}
+	for _, i := range r.Channel.Items {
+		guids[i.GUID] = true
+	}
+	if len(r.Channel.Items) != 3 || len(guids) != 3 || r.Channel.Items[0].PubDate != "Sat, 01 Jan 2022 00:00:00 +0000" {
+		t.Errorf("bad rss %s", b.String())
+	}
+
+	b.Reset()
+	if err := f.WriteAtom(&b); err != nil {
+		t.Fatal(err)
+	}
+	var a atom
+	if err := xml.Unmarshal(b.Bytes(), &a); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Entries) != 3 || a.Entries[0].ID != r.Channel.Items[0].GUID || a.ID != f.Link {
+		t.Errorf("bad atom %s", b.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler{Client: fakeClient{t: t}}
+	for _, c := range []struct{ query, contentType, contains string }{
+		{"artist=1", "application/rss+xml; charset=utf-8", "<rss"},
+		{"artist=1&format=atom", "application/atom+xml; charset=utf-8", "<feed"},
+		{"", "application/rss+xml; charset=utf-8", "action=notify"},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/feed?"+c.query, nil))
+		if ct := rec.Header().Get("Content-Type"); ct != c.contentType || !strings.Contains(rec.Body.String(), c.contains) {
+			t.Errorf("%s: bad response %s %s", c.query, ct, rec.Body.String())
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/feed?artist=x", nil))
+	if rec.Code != 400 {
+		t.Errorf("expected a bad artist id to be refused, got %d", rec.Code)
+	}
+}
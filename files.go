@@ -0,0 +1,39 @@
+package whatapi
+
+import (
+	"path"
+	"strings"
+)
+
+// FileList is the files in a torrent, as returned by Files.
+type FileList []FileStruct
+
+var artworkExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+	".bmp": true, ".tif": true, ".tiff": true, ".webp": true,
+}
+
+func (l FileList) withExtension(match func(ext string) bool) FileList {
+	r := FileList{}
+	for _, f := range l {
+		if match(strings.ToLower(path.Ext(f.Name()))) {
+			r = append(r, f)
+		}
+	}
+	return r
+}
+
+// HasCue reports whether any of the files is a cue sheet.
+func (l FileList) HasCue() bool {
+	return len(l.withExtension(func(ext string) bool { return ext == ".cue" })) > 0
+}
+
+// LogFiles returns the rip logs.
+func (l FileList) LogFiles() FileList {
+	return l.withExtension(func(ext string) bool { return ext == ".log" })
+}
+
+// ArtworkFiles returns the images.
+func (l FileList) ArtworkFiles() FileList {
+	return l.withExtension(func(ext string) bool { return artworkExtensions[ext] })
+}
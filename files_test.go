@@ -0,0 +1,47 @@
+package whatapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestFileList(t *testing.T) {
+	to := whatapi.TorrentStruct{FileList: "CD1/01.flac{{{100}}}|||CD1/Rip.LOG{{{5}}}|||CD1/album.cue{{{2}}}|||Cover.JPG{{{30}}}|||scans/back.png{{{40}}}|||notes.txt{{{1}}}"}
+	l, err := to.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.HasCue() {
+		t.Error("expected a cue sheet")
+	}
+	if logs := l.LogFiles(); len(logs) != 1 || logs[0].Name() != "CD1/Rip.LOG" {
+		t.Errorf("expected the log, got %v", logs)
+	}
+	if art := l.ArtworkFiles(); len(art) != 2 || art[0].Name() != "Cover.JPG" || art[1].Name() != "scans/back.png" {
+		t.Errorf("expected the images, got %v", art)
+	}
+	none := whatapi.FileList{}
+	if none.HasCue() || len(none.LogFiles()) != 0 || len(none.ArtworkFiles()) != 0 {
+		t.Error("expected an empty list to have nothing")
+	}
+}
+
+func TestRipDetails(t *testing.T) {
+	var to whatapi.TorrentStruct
+	if err := json.Unmarshal([]byte(`{"hasLog":true,"hasCue":true,"logScore":95,"logChecksum":true}`), &to); err != nil {
+		t.Fatal(err)
+	}
+	if !to.HasCueSheet() || to.RipLogScore() != 95 || !to.LogChecksum() {
+		t.Errorf("bad rip details %v %v %v", to.HasCueSheet(), to.RipLogScore(), to.LogChecksum())
+	}
+	for _, r := range []whatapi.TorrentLog{
+		whatapi.ArtistTorrentStruct{HasLogF: true, HasCue: true, LogScore: 80},
+		whatapi.SearchTorrentStruct{HasLogF: true, HasCue: true, LogScore: 80},
+	} {
+		if !r.HasCueSheet() || r.RipLogScore() != 80 {
+			t.Errorf("%T: bad rip details %v %v", r, r.HasCueSheet(), r.RipLogScore())
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package whatapi
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultFillScore is the least score FillableRequests reports unless
+// told otherwise.
+const DefaultFillScore = 0.8
+
+// LibraryItem is a release in a local library.
+type LibraryItem struct {
+	Artist string
+	Title  string
+	Year   int
+	// Format, Encoding and Media are as the site names them, such as
+	// "FLAC", "Lossless" and "CD". Empty fields match any request.
+	Format   string
+	Encoding string
+	Media    string
+	// Path is where the release is, for the application's use.
+	Path string
+}
+
+// Library is an inventory of local releases, such as a music library
+// scanned from disk or exported from a player.
+type Library interface {
+	Items() ([]LibraryItem, error)
+}
+
+// LibraryItems is a Library held in memory.
+type LibraryItems []LibraryItem
+
+// Items returns the items.
+func (l LibraryItems) Items() ([]LibraryItem, error) {
+	return l, nil
+}
+
+// Fill is a request that a library item could fill.
+type Fill struct {
+	Request RequestsSearchResult
+	Item    LibraryItem
+	// Score is how alike the request and the item are, from 0 to 1.
+	Score float64
+}
+
+// FillableRequests returns the unfilled requests in requests that an
+// item in lib could fill, with the best matching item for each, best
+// first. Items must be in one of a request's formats, encodings and media
+// to fill it, and then score by how alike their artists, titles and years
+// are, ignoring case and punctuation. A minScore of 0 means
+// DefaultFillScore.
+func FillableRequests(lib Library, requests RequestsSearch, minScore float64) ([]Fill, error) {
+	if minScore == 0 {
+		minScore = DefaultFillScore
+	}
+	items, err := lib.Items()
+	if err != nil {
+		return nil, err
+	}
+	fills := []Fill{}
+	for _, r := range requests.Results {
+		if r.IsFilled {
+			continue
+		}
+		best := Fill{Request: r}
+		for _, it := range items {
+			if !canFill(r, it) {
+				continue
+			}
+			if score := fillScore(r, it); score > best.Score {
+				best.Item, best.Score = it, score
+			}
+		}
+		if best.Score >= minScore {
+			fills = append(fills, best)
+		}
+	}
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Score > fills[j].Score })
+	return fills, nil
+}
+
+// canFill reports whether it is in one of the formats, encodings and
+// media r asks for.
+func canFill(r RequestsSearchResult, it LibraryItem) bool {
+	return accepts(r.FormatList, it.Format) &&
+		accepts(r.BitrateList, it.Encoding) &&
+		accepts(r.MediaList, it.Media)
+}
+
+// accepts reports whether the request list, separated by pipes or commas,
+// includes v. An empty list or one with "Any" accepts anything.
+func accepts(list, v string) bool {
+	if v == "" {
+		return true
+	}
+	options := strings.FieldsFunc(list, func(r rune) bool { return r == '|' || r == ',' })
+	if len(options) == 0 {
+		return true
+	}
+	for _, o := range options {
+		o = strings.TrimSpace(o)
+		if strings.EqualFold(o, "Any") || strings.EqualFold(o, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// fillScore weighs title over artist, as requests often credit artists
+// differently, and year least.
+func fillScore(r RequestsSearchResult, it LibraryItem) float64 {
+	artist := 0.0
+	for _, group := range r.Artists {
+		for _, a := range group {
+			if s := similarity(a.Name, it.Artist); s > artist {
+				artist = s
+			}
+		}
+	}
+	if len(r.Artists) == 0 || len(r.Artists[0]) == 0 {
+		// not a music request, or the artist isn't known
+		artist = 1
+	}
+	return 0.35*artist + 0.5*similarity(r.Title, it.Title) + 0.15*yearScore(r.Year, it.Year)
+}
+
+// yearScore is 1 for the same year, less for a year apart, nothing for
+// further apart, and a half when either year isn't known.
+func yearScore(a, b int) float64 {
+	switch d := a - b; {
+	case a == 0 || b == 0:
+		return 0.5
+	case d == 0:
+		return 1
+	case d == 1 || d == -1:
+		return 0.75
+	}
+	return 0
+}
+
+// similarity is the Dice coefficient of the letter pairs of the
+// normalised strings, from 0 to 1.
+func similarity(a, b string) float64 {
+	a, b = normalise(a), normalise(b)
+	if a == b {
+		return 1
+	}
+	pa, pb := pairs(a), pairs(b)
+	if len(pa)+len(pb) == 0 {
+		return 0
+	}
+	counts := map[string]int{}
+	for _, p := range pb {
+		counts[p]++
+	}
+	shared := 0
+	for _, p := range pa {
+		if counts[p] > 0 {
+			counts[p]--
+			shared++
+		}
+	}
+	return float64(2*shared) / float64(len(pa)+len(pb))
+}
+
+// normalise lower cases s, unescapes HTML entities, drops bracketed
+// asides such as "(Deluxe Edition)", spells out ampersands, drops a
+// leading "the" and reduces punctuation to single spaces.
+func normalise(s string) string {
+	s = strings.ToLower(html.UnescapeString(s))
+	s = stripBrackets(s)
+	s = strings.Replace(s, "&", " and ", -1)
+	s = strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+	return strings.TrimPrefix(s, "the ")
+}
+
+func stripBrackets(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case (r == ')' || r == ']') && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func pairs(s string) []string {
+	r := []rune(s)
+	p := make([]string, 0, len(r))
+	for i := 0; i+1 < len(r); i++ {
+		p = append(p, string(r[i:i+2]))
+	}
+	return p
+}
@@ -0,0 +1,43 @@
+package whatapi_test
+
+import (
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestFillableRequests(t *testing.T) {
+	requests := whatapi.RequestsSearch{Results: []whatapi.RequestsSearchResult{
+		{RequestID: 1, Title: "OK Computer", Year: 1997,
+			Artists:    [][]whatapi.ArtistID{{{Name: "Radiohead"}}},
+			FormatList: "FLAC", BitrateList: "Lossless|24bit Lossless", MediaList: "Any"},
+		{RequestID: 2, Title: "Kid A", Year: 2000,
+			Artists:    [][]whatapi.ArtistID{{{Name: "Radiohead"}}},
+			FormatList: "MP3", BitrateList: "V0 (VBR)", MediaList: "Any"},
+		{RequestID: 3, Title: "Blue Lines", Year: 1991,
+			Artists:    [][]whatapi.ArtistID{{{Name: "Massive Attack"}}},
+			FormatList: "Any", BitrateList: "Any", MediaList: "Any", IsFilled: true},
+		{RequestID: 4, Title: "The Bends (Collector's Edition)", Year: 2009,
+			Artists:    [][]whatapi.ArtistID{{{Name: "Radiohead"}}},
+			FormatList: "Any", BitrateList: "Any", MediaList: "CD"},
+	}}
+	lib := whatapi.LibraryItems{
+		{Artist: "Radiohead", Title: "OK Computer", Year: 1997, Format: "FLAC", Encoding: "Lossless", Media: "CD"},
+		{Artist: "Radiohead", Title: "Kid A", Year: 2000, Format: "FLAC", Encoding: "Lossless"},
+		{Artist: "Massive Attack", Title: "Blue Lines", Year: 1991},
+		{Artist: "Radiohead", Title: "The Bends", Year: 1995},
+	}
+	fills, err := whatapi.FillableRequests(lib, requests, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 2 {
+		t.Fatalf("expected 2 fills, got %+v", fills)
+	}
+	if fills[0].Request.RequestID != 1 || fills[0].Score != 1 {
+		t.Errorf("expected an exact fill of request 1 first, got %+v", fills[0])
+	}
+	if fills[1].Request.RequestID != 4 || fills[1].Score >= 1 {
+		t.Errorf("expected a partial fill of request 4, got %+v", fills[1])
+	}
+}
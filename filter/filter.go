@@ -0,0 +1,202 @@
+// Package filter builds predicates over whatapi torrents and groups that
+// combine into selections such as
+//
+//	filter.Format("FLAC").And(filter.Media("CD")).And(filter.SizeLess(2 << 30))
+//
+// and picks the search results or group torrents they match.
+package filter
+
+import (
+	"strings"
+
+	"github.com/charles-haynes/whatapi"
+	"github.com/charles-haynes/whatapi/tags"
+)
+
+// Predicate reports whether a value is wanted.
+type Predicate[T any] func(T) bool
+
+// And matches what both p and q match. q is not tried if p fails.
+func (p Predicate[T]) And(q Predicate[T]) Predicate[T] {
+	return func(v T) bool { return p(v) && q(v) }
+}
+
+// Or matches what either p or q matches. q is not tried if p matches.
+func (p Predicate[T]) Or(q Predicate[T]) Predicate[T] {
+	return func(v T) bool { return p(v) || q(v) }
+}
+
+// Not matches what p doesn't.
+func (p Predicate[T]) Not() Predicate[T] {
+	return func(v T) bool { return !p(v) }
+}
+
+// All matches what every one of ps matches, and so everything if ps is
+// empty.
+func All[T any](ps ...Predicate[T]) Predicate[T] {
+	return func(v T) bool {
+		for _, p := range ps {
+			if !p(v) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches what any of ps matches, and so nothing if ps is empty.
+func Any[T any](ps ...Predicate[T]) Predicate[T] {
+	return func(v T) bool {
+		for _, p := range ps {
+			if p(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Torrent is a predicate over torrents.
+type Torrent = Predicate[whatapi.Torrent]
+
+// Group is a predicate over torrent groups.
+type Group = Predicate[whatapi.Group]
+
+// Torrents returns the torrents of ts that p matches, in order.
+func Torrents[T whatapi.Torrent](ts []T, p Torrent) []T {
+	r := []T{}
+	for _, t := range ts {
+		if p(t) {
+			r = append(r, t)
+		}
+	}
+	return r
+}
+
+// Groups returns the groups of gs that p matches, in order.
+func Groups[T whatapi.Group](gs []T, p Group) []T {
+	r := []T{}
+	for _, g := range gs {
+		if p(g) {
+			r = append(r, g)
+		}
+	}
+	return r
+}
+
+// oneOf reports whether s is one of values, ignoring case.
+func oneOf(s string, values []string) bool {
+	for _, v := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Format matches torrents in any of formats, such as "FLAC" or "MP3".
+func Format(formats ...string) Torrent {
+	return func(t whatapi.Torrent) bool { return oneOf(t.Format(), formats) }
+}
+
+// Encoding matches torrents with any of encodings, such as "Lossless",
+// "24bit Lossless" or "V0 (VBR)".
+func Encoding(encodings ...string) Torrent {
+	return func(t whatapi.Torrent) bool { return oneOf(t.Encoding(), encodings) }
+}
+
+// Media matches torrents ripped from any of media, such as "CD", "Vinyl"
+// or "WEB".
+func Media(media ...string) Torrent {
+	return func(t whatapi.Torrent) bool { return oneOf(t.Media(), media) }
+}
+
+// SizeLess matches torrents smaller than n bytes.
+func SizeLess(n int64) Torrent {
+	return func(t whatapi.Torrent) bool { return t.FileSize() < n }
+}
+
+// SizeAtLeast matches torrents of n bytes or more.
+func SizeAtLeast(n int64) Torrent {
+	return func(t whatapi.Torrent) bool { return t.FileSize() >= n }
+}
+
+// Scene matches scene releases.
+func Scene() Torrent {
+	return func(t whatapi.Torrent) bool { return t.Scene() }
+}
+
+// Remastered matches torrents of a remaster rather than the original
+// release.
+func Remastered() Torrent {
+	return func(t whatapi.Torrent) bool { return t.Remastered() }
+}
+
+// Free matches torrents that don't count against the user's ratio, being
+// freeleech or neutral leech.
+func Free() Torrent {
+	return func(t whatapi.Torrent) bool { return t.LeechStatus().Free() }
+}
+
+// LogScoreAtLeast matches torrents with a rip log scoring score or more.
+// Torrents that don't report their log score don't match.
+func LogScoreAtLeast(score int) Torrent {
+	return func(t whatapi.Torrent) bool {
+		l, ok := t.(whatapi.TorrentLog)
+		return ok && l.HasLog() && l.RipLogScore() >= score
+	}
+}
+
+// CueSheet matches torrents with a cue sheet. Torrents that don't report
+// whether they have one don't match.
+func CueSheet() Torrent {
+	return func(t whatapi.Torrent) bool {
+		l, ok := t.(whatapi.TorrentLog)
+		return ok && l.HasCueSheet()
+	}
+}
+
+// SeedersAtLeast matches torrents with n seeders or more. Torrents that
+// don't report their peers don't match.
+func SeedersAtLeast(n int) Torrent {
+	return func(t whatapi.Torrent) bool {
+		s, ok := t.(whatapi.TorrentStats)
+		return ok && s.SeederCount() >= n
+	}
+}
+
+// Artist matches groups by artist, ignoring case.
+func Artist(artist string) Group {
+	return func(g whatapi.Group) bool { return strings.EqualFold(g.Artist(), artist) }
+}
+
+// Years matches groups released from one year to another, inclusive.
+func Years(from, to int) Group {
+	return func(g whatapi.Group) bool { return g.Year() >= from && g.Year() <= to }
+}
+
+// ReleaseType matches groups of any of types, such as 1 for albums. See
+// whatapi.ReleaseTypeString.
+func ReleaseType(types ...int) Group {
+	return func(g whatapi.Group) bool {
+		for _, r := range types {
+			if g.ReleaseType() == r {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Tag matches groups with a tag that is genre or, by the tags package's
+// hierarchy, a kind of it, so that Tag("rock") matches "post.rock".
+func Tag(genre string) Group {
+	return func(g whatapi.Group) bool {
+		for _, t := range g.Tags() {
+			if tags.IsA(t, genre) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func ids[T interface{ ID() int }](vs []T) []int {
+	r := []int{}
+	for _, v := range vs {
+		r = append(r, v.ID())
+	}
+	return r
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTorrents(t *testing.T) {
+	ts := []whatapi.SearchTorrentStruct{
+		{TorrentID: 1, FormatF: "FLAC", MediaF: "CD", Size: 400 << 20, HasLogF: true, LogScore: 100, Seeders: 5},
+		{TorrentID: 2, FormatF: "FLAC", MediaF: "Vinyl", Size: 1 << 30, Seeders: 20},
+		{TorrentID: 3, FormatF: "FLAC", MediaF: "CD", Size: 3 << 30, HasLogF: true, LogScore: 100},
+		{TorrentID: 4, FormatF: "MP3", MediaF: "CD", Size: 100 << 20, Seeders: 50},
+	}
+	for _, c := range []struct {
+		name string
+		p    Torrent
+		want []int
+	}{
+		{"flac cd under 2GiB", Format("FLAC").And(Media("CD")).And(SizeLess(2 << 30)), []int{1}},
+		{"case", Format("flac").And(Media("cd", "vinyl")), []int{1, 2, 3}},
+		{"or", Media("Vinyl").Or(Format("MP3")), []int{2, 4}},
+		{"not", Format("FLAC").Not(), []int{4}},
+		{"log", LogScoreAtLeast(100), []int{1, 3}},
+		{"seeders", SeedersAtLeast(10), []int{2, 4}},
+		{"all", All(Format("FLAC"), SizeAtLeast(1<<30)), []int{2, 3}},
+		{"all of none", All[whatapi.Torrent](), []int{1, 2, 3, 4}},
+		{"any of none", Any[whatapi.Torrent](), []int{}},
+	} {
+		if got := ids(Torrents(ts, c.p)); !equal(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	gs := []whatapi.TorrentSearchResultStruct{
+		{GroupID: 1, ArtistF: "Slint", GroupYear: 1991, ReleaseTypeF: 1, TagsF: []string{"post.rock"}},
+		{GroupID: 2, ArtistF: "Burial", GroupYear: 2007, ReleaseTypeF: 1, TagsF: []string{"dubstep"}},
+		{GroupID: 3, ArtistF: "slint", GroupYear: 1994, ReleaseTypeF: 5, TagsF: []string{"indie.rock"}},
+	}
+	for _, c := range []struct {
+		name string
+		p    Group
+		want []int
+	}{
+		{"artist", Artist("SLINT"), []int{1, 3}},
+		{"years", Years(1990, 2000).And(ReleaseType(1)), []int{1}},
+		{"genre", Tag("rock"), []int{1, 3}},
+	} {
+		if got := ids(Groups(gs, c.p)); !equal(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package whatapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// JSONType is the type of a JSON value, as a Fixup matches it.
+type JSONType string
+
+// The JSON types.
+const (
+	JSONNull   JSONType = "null"
+	JSONBool   JSONType = "bool"
+	JSONNumber JSONType = "number"
+	JSONString JSONType = "string"
+	JSONArray  JSONType = "array"
+	JSONObject JSONType = "object"
+)
+
+// jsonTypeOf returns the type of v, as decoded with UseNumber.
+func jsonTypeOf(v interface{}) JSONType {
+	switch v.(type) {
+	case bool:
+		return JSONBool
+	case json.Number, float64:
+		return JSONNumber
+	case string:
+		return JSONString
+	case []interface{}:
+		return JSONArray
+	case map[string]interface{}:
+		return JSONObject
+	}
+	return JSONNull
+}
+
+// Fixup patches a value that a site is known to send with the wrong JSON
+// type, e.g. false where an object is expected. Fixups are only applied
+// when a response fails to decode as is, and only change values of the
+// Wrong type found at Path, so the same text elsewhere in the response,
+// such as in a description, is left alone.
+type Fixup struct {
+	// Action limits the fixup to responses for that ajax.php action.
+	// An empty Action applies to every response.
+	Action string
+	// Path is where the value is in the response, as object keys and
+	// array indexes separated by dots, e.g. "response.torrentgroup.*.extendedArtists".
+	// A * stands for every key or index at its level.
+	Path string
+	// Wrong is the type the site sends the value as.
+	Wrong JSONType
+	// Replacement is the literal JSON to decode instead, e.g. {}.
+	Replacement string
+}
+
+// apply replaces the values of the wrong type at path in v, and returns v
+// and whether it changed.
+func (f Fixup) apply(v interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		if jsonTypeOf(v) != f.Wrong {
+			return v, false
+		}
+		var r interface{}
+		if err := decodeJSON([]byte(f.Replacement), &r); err != nil {
+			return v, false
+		}
+		return r, true
+	}
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			if e, ok := f.apply(e, path[1:]); ok {
+				v[k], changed = e, true
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			if path[0] != "*" && path[0] != strconv.Itoa(i) {
+				continue
+			}
+			if e, ok := f.apply(e, path[1:]); ok {
+				v[i], changed = e, true
+			}
+		}
+	}
+	return v, changed
+}
+
+// decodeJSON decodes b into v keeping numbers as written, so that
+// encoding v again doesn't change them.
+func decodeJSON(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// DefaultFixups work around bugs seen in the wild. They apply to every
+// profile whose Fixups are nil.
+var DefaultFixups = []Fixup{
+	// orpheus bug in get artist
+	{Action: "artist", Path: "response.torrentgroup.*.extendedArtists", Wrong: JSONBool, Replacement: "{}"},
+	// orpheus bug in top 10
+	{Action: "top10", Path: "response.*.results.*.artist", Wrong: JSONBool, Replacement: `""`},
+}
+
+// AddFixup registers an extra fixup with the profile, in place of any
+// registered for the same action, path and wrong type. A profile with nil
+// Fixups gets the fixup on top of DefaultFixups.
+func (p *SiteProfile) AddFixup(f Fixup) {
+	current := p.fixups()
+	fixups := make([]Fixup, 0, len(current)+1)
+	for _, g := range current {
+		if g.Action != f.Action || g.Path != f.Path || g.Wrong != f.Wrong {
+			fixups = append(fixups, g)
+		}
+	}
+	// copied, as profiles such as GazelleProfile share their fixups
+	p.Fixups = append(fixups, f)
+}
+
+// fixups returns the profile's fixups, or DefaultFixups if it has none.
+func (p SiteProfile) fixups() []Fixup {
+	if p.Fixups == nil {
+		return DefaultFixups
+	}
+	return p.Fixups
+}
+
+func (p SiteProfile) fixup(action string, body []byte) []byte {
+	var v interface{}
+	decoded, changed := false, false
+	for _, f := range p.fixups() {
+		if f.Action != "" && f.Action != action {
+			continue
+		}
+		if !decoded {
+			if err := decodeJSON(body, &v); err != nil {
+				return body
+			}
+			decoded = true
+		}
+		var ok bool
+		if v, ok = f.apply(v, strings.Split(f.Path, ".")); ok {
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return body
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// actionOf returns the ajax.php action of requestURL, if any.
+func actionOf(requestURL string) string {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("action")
+}
@@ -0,0 +1,76 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFixup(t *testing.T) {
+	p := SiteProfile{Fixups: []Fixup{}}
+	p.AddFixup(Fixup{Action: "artist", Path: "response.a", Wrong: JSONBool, Replacement: "{}"})
+	p.AddFixup(Fixup{Path: "response.list.*.b", Wrong: JSONString, Replacement: "1"})
+	p.AddFixup(Fixup{Path: "response.list.*.b", Wrong: JSONString, Replacement: "2"})
+	if len(p.Fixups) != 2 {
+		t.Errorf("expected a fixup for the same path and type to replace the other, got %+v", p.Fixups)
+	}
+	for _, tc := range []struct{ action, in, exp string }{
+		{"artist", `{"response":{"a":false,"list":[{"b":"1"},{"b":3}]}}`, `{"response":{"a":{},"list":[{"b":2},{"b":3}]}}`},
+		{"top10", `{"response":{"a":false,"list":[{"b":"1"}]}}`, `{"response":{"a":false,"list":[{"b":2}]}}`},
+		{"artist", `{"response":{"a":{"k":1},"c":"1"}}`, `{"response":{"a":{"k":1},"c":"1"}}`},
+		// the same text as a string, or at another path, is left alone
+		{"artist", `{"response":{"a":"\"a\":false <b>","description":"\"a\":false","x":{"a":false}}}`,
+			`{"response":{"a":"\"a\":false <b>","description":"\"a\":false","x":{"a":false}}}`},
+		// numbers are kept as written
+		{"artist", `{"response":{"a":false,"n":12345678901234567890}}`, `{"response":{"a":{},"n":12345678901234567890}}`},
+	} {
+		if got := string(p.fixup(tc.action, []byte(tc.in))); got != tc.exp {
+			t.Errorf("fixup(%q, %s) = %s, expected %s", tc.action, tc.in, got, tc.exp)
+		}
+	}
+}
+
+func TestDefaultFixups(t *testing.T) {
+	r := ArtistResponse{}
+	body := GazelleProfile.fixup("artist", []byte(`{"status":"success","response":{"torrentgroup":[{"groupId":1,"extendedArtists":false,"wikiImage":"\"extendedArtists\":false"}]}}`))
+	if err := json.Unmarshal(body, &r); err != nil {
+		t.Fatal(err)
+	}
+	if g := r.Response.TorrentGroup[0]; g.ExtendedArtists == nil || g.WikiImage != `"extendedArtists":false` {
+		t.Errorf("bad fixed up group %+v", g)
+	}
+	top := TopTenTorrentsResponse{}
+	body = GazelleProfile.fixup("top10", []byte(`{"status":"success","response":[{"results":[{"torrentId":1,"artist":false}]}]}`))
+	if err := json.Unmarshal(body, &top); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFixupsDefault(t *testing.T) {
+	body := `{"status":"success","response":[{"results":[{"torrentId":1,"artist":false}]}]}`
+	p := SiteProfile{Name: "custom"}
+	if got := string(p.fixup("top10", []byte(body))); got == body {
+		t.Error("expected a profile without fixups to get the default ones")
+	}
+	p.AddFixup(Fixup{Action: "user", Path: "response.a", Wrong: JSONBool, Replacement: "{}"})
+	if len(p.Fixups) != len(DefaultFixups)+1 {
+		t.Errorf("expected the fixup to be added to the default ones, got %+v", p.Fixups)
+	}
+	if got := string(SiteProfile{Fixups: []Fixup{}}.fixup("top10", []byte(body))); got != body {
+		t.Errorf("expected an empty list of fixups to turn them off, got %s", got)
+	}
+
+	RegisterProfile("fixups.example.com", SiteProfile{Name: "registered"})
+	defer unregisterProfile("fixups.example.com")
+	if got := string(ProfileFor("fixups.example.com").fixup("top10", []byte(body))); got == body {
+		t.Error("expected a registered profile without fixups to get the default ones")
+	}
+}
+
+func TestActionOf(t *testing.T) {
+	if a := actionOf("https://example.com/ajax.php?action=artist&id=1"); a != "artist" {
+		t.Errorf(`expected action "artist", got %q`, a)
+	}
+	if a := actionOf("https://example.com/torrents.php?id=1"); a != "" {
+		t.Errorf(`expected no action, got %q`, a)
+	}
+}
@@ -0,0 +1,46 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Friend is a user on the current user's friends list.
+type Friend struct {
+	ID         int    `json:"id"`
+	Username   string `json:"username"`
+	Comment    string `json:"comment"`
+	LastAccess string `json:"lastAccess"`
+	Avatar     string `json:"avatar"`
+}
+
+// GetFriends retrieves the current user's friends list, on sites with the CapFriends capability.
+func (w *ClientStruct) GetFriends() ([]Friend, error) {
+	friends := FriendsResponse{}
+	action, err := w.profile.action(CapFriends)
+	if err != nil {
+		return friends.Response, err
+	}
+	if err = w.Do(action, url.Values{}, &friends); err != nil {
+		return friends.Response, err
+	}
+	return friends.Response, checkResponseStatus(friends.Status, friends.Error)
+}
+
+// AddFriend adds the user with the provided id to the current user's friends list.
+func (w *ClientStruct) AddFriend(userID int) error {
+	params := url.Values{}
+	params.Set("action", "add")
+	params.Set("friendid", strconv.Itoa(userID))
+	_, err := w.postForm("friends.php", params)
+	return err
+}
+
+// RemoveFriend removes the user with the provided id from the current user's friends list.
+func (w *ClientStruct) RemoveFriend(userID int) error {
+	params := url.Values{}
+	params.Set("action", "Remove friend")
+	params.Set("friendid", strconv.Itoa(userID))
+	_, err := w.postForm("friends.php", params)
+	return err
+}
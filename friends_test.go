@@ -0,0 +1,74 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+var friendsProfile = SiteProfile{
+	Name:    "test",
+	Actions: map[Capability]string{CapFriends: "friends"},
+}
+
+func TestGetFriends(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":[{"id":5,"username":"alice","comment":"uploader","lastAccess":"2020-01-02 03:04:05"}]}`))
+	}, WithSiteProfile(friendsProfile))
+	friends, err := c.GetFriends()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=friends"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if len(friends) != 1 || friends[0].ID != 5 || friends[0].Username != "alice" || friends[0].Comment != "uploader" {
+		t.Errorf("bad friends %+v", friends)
+	}
+}
+
+func TestGetFriendsUnsupported(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}, WithSiteProfile(GazelleProfile))
+	if _, err := c.GetFriends(); err == nil {
+		t.Error("expected friends to be unsupported")
+	}
+}
+
+func TestAddRemoveFriend(t *testing.T) {
+	var posts []map[string]string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/friends.php" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		r.ParseForm()
+		post := map[string]string{}
+		for k := range r.PostForm {
+			post[k] = r.PostForm.Get(k)
+		}
+		posts = append(posts, post)
+	})
+	c.session.setKeys("authkey", "")
+	if err := c.AddFriend(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RemoveFriend(5); err != nil {
+		t.Fatal(err)
+	}
+	exp := []map[string]string{
+		{"action": "add", "auth": "authkey", "friendid": "5"},
+		{"action": "Remove friend", "auth": "authkey", "friendid": "5"},
+	}
+	if len(posts) != len(exp) {
+		t.Fatalf("expected posts %v, got %v", exp, posts)
+	}
+	for i := range exp {
+		for k, v := range exp[i] {
+			if posts[i][k] != v {
+				t.Errorf("post %d: expected %s %q, got %q", i, k, v, posts[i][k])
+			}
+		}
+	}
+}
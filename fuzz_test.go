@@ -0,0 +1,136 @@
+//go:build go1.18
+// +build go1.18
+
+package whatapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// responseTypes are the responses fuzzed by FuzzDecode, by action.
+var responseTypes = map[string]func() interface{}{
+	"index":         func() interface{} { return &AccountResponse{} },
+	"user":          func() interface{} { return &UserResponse{} },
+	"torrent":       func() interface{} { return &TorrentResponse{} },
+	"torrentgroup":  func() interface{} { return &TorrentGroupResponse{} },
+	"browse":        func() interface{} { return &TorrentSearchResponse{} },
+	"artist":        func() interface{} { return &ArtistResponse{} },
+	"notifications": func() interface{} { return &NotificationsResponse{} },
+	"announcements": func() interface{} { return &AnnouncementsResponse{} },
+	"requests":      func() interface{} { return &RequestsSearchResponse{} },
+	"usersearch":    func() interface{} { return &UserSearchResponse{} },
+}
+
+// FuzzDecode runs responses through the same fixups and decoding as
+// getJSON, then through the accessors applications call on the result,
+// which must not panic however malformed the response.
+func FuzzDecode(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "responses", "*", "*.json"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, name := range files {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		action := filepath.Base(name)
+		f.Add(action[:len(action)-len(".json")], b)
+	}
+	f.Fuzz(func(t *testing.T, action string, body []byte) {
+		typ, ok := responseTypes[action]
+		if !ok {
+			return
+		}
+		body = GazelleProfile.fixup(action, body)
+		r := typ()
+		if json.Unmarshal(body, r) != nil {
+			return
+		}
+		use(r)
+	})
+}
+
+// use calls the accessors of the decoded response r.
+func use(r interface{}) {
+	switch r := r.(type) {
+	case *TorrentResponse:
+		_ = r.Response.Group.String()
+		_ = r.Response.Torrent.String()
+		_, _ = r.Response.Torrent.Files()
+		_ = EditionOf(r.Response.Torrent)
+	case *TorrentGroupResponse:
+		_ = r.Response.Group.String()
+		for _, t := range r.Response.Torrent {
+			_ = t.String()
+			_, _ = t.Files()
+		}
+		_ = TranscodeCandidates(r.Response)
+	case *TorrentSearchResponse:
+		for _, p := range r.Response.Flatten() {
+			_ = p.Group.String()
+			_ = p.Torrent.String()
+		}
+	case *ArtistResponse:
+		_ = r.Response.Name()
+		for _, g := range r.Response.TorrentGroup {
+			_ = g.String()
+			for _, t := range g.Torrent {
+				_ = t.String()
+			}
+		}
+	case *NotificationsResponse:
+		for _, n := range r.Response.Results {
+			_, _ = n.Time()
+			_ = n.Tags()
+			_ = n.FormatEncoding()
+		}
+	}
+}
+
+// FuzzParseFileList checks that the file list parser never panics, and
+// that whatever it parses can be scored and searched.
+func FuzzParseFileList(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"aaa{{{123}}}|||bbb{{{456}}}",
+		"|||a{{{1}}}",
+		"{{{}}}",
+		"a{{{1}}}{{{2}}}",
+		"cover.jpg{{{-1}}}|||CD1/rip.log{{{99999999999999999999}}}",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, list string) {
+		files, err := TorrentStruct{FileList: list}.ParseFileList()
+		if err != nil {
+			return
+		}
+		for _, fs := range files {
+			_ = fs.Name()
+			if fs.Size < 0 {
+				t.Errorf("parsed negative size %d from %q", fs.Size, list)
+			}
+		}
+		l := FileList(files)
+		_ = l.HasCue()
+		_ = l.LogFiles()
+		_ = l.ArtworkFiles()
+		if s := FileListSimilarity(files, files); s < 0 || s > 1 {
+			t.Errorf("similarity of %q with itself is %f", list, s)
+		}
+	})
+}
+
+// FuzzTime checks that malformed times are errors, not panics.
+func FuzzTime(f *testing.F) {
+	f.Add("2016-11-22 19:20:21")
+	f.Add("0000-00-00 00:00:00")
+	f.Add("1479842421")
+	f.Fuzz(func(t *testing.T, s string) {
+		_, _ = NotificationTorrent{NotificationTime: s}.Time()
+	})
+}
@@ -1,8 +1,11 @@
 module github.com/charles-haynes/whatapi
 
-go 1.12
+go 1.18
 
 require (
 	github.com/jmoiron/sqlx v1.2.0
-	golang.org/x/net v0.0.0-20191109021931-daa7c04131f5
+	github.com/mattn/go-sqlite3 v1.14.16
+	golang.org/x/crypto v0.17.0
+	golang.org/x/net v0.10.0
+	golang.org/x/text v0.14.0
 )
@@ -0,0 +1,37 @@
+package whatapi
+
+import "net/url"
+
+// TorrentGroupOptions are the options of GetTorrentGroupWith.
+type TorrentGroupOptions struct {
+	// Hydrate replaces the summary of each torrent in the group with the
+	// full torrent, fetched with GetTorrent, for sites whose group
+	// responses leave out details such as file lists.
+	Hydrate bool
+	// Concurrency is the number of torrents fetched at once, at least 1.
+	// Requests still share the client's rate limit and cache.
+	Concurrency int
+}
+
+// GetTorrentGroupWith retrieves torrent group information using the provided group id, fetching each torrent in full if opts.Hydrate is set.
+func (w *ClientStruct) GetTorrentGroupWith(id int, opts TorrentGroupOptions) (TorrentGroup, error) {
+	g, err := w.GetTorrentGroup(id, url.Values{})
+	if err != nil || !opts.Hydrate {
+		return g, err
+	}
+	errs := make([]error, len(g.Torrent))
+	parallel(len(g.Torrent), opts.Concurrency, func(i int) {
+		t, err := w.GetTorrent(g.Torrent[i].ID(), url.Values{})
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		g.Torrent[i] = t.Torrent
+	})
+	for _, err := range errs {
+		if err != nil {
+			return g, err
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,82 @@
+package whatapi
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// groupServer serves a group with torrents 1, 2 and 3 in summary, and
+// each of them in full with a file path naming it, but fails torrent
+// bad.
+func groupServer(t *testing.T, bad string) (*ClientStruct, *int) {
+	var (
+		mu                 sync.Mutex
+		inFlight, maxCalls int
+	)
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "torrentgroup":
+			rw.Write([]byte(`{"status":"success","response":{"group":{"id":9},"torrents":[{"id":1},{"id":2},{"id":3}]}}`))
+		case "torrent":
+			mu.Lock()
+			inFlight++
+			if inFlight > maxCalls {
+				maxCalls = inFlight
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+			id := q.Get("id")
+			if id == bad {
+				rw.Write([]byte(`{"status":"failure","error":"bad id parameter"}`))
+				return
+			}
+			rw.Write([]byte(`{"status":"success","response":{"group":{"id":9},"torrent":{"id":` + id + `,"filePath":"album ` + id + `"}}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	})
+	return c, &maxCalls
+}
+
+func TestGetTorrentGroupWithHydrate(t *testing.T) {
+	c, maxCalls := groupServer(t, "")
+	g, err := c.GetTorrentGroupWith(9, TorrentGroupOptions{Hydrate: true, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Torrent) != 3 {
+		t.Fatalf("expected 3 torrents, got %d", len(g.Torrent))
+	}
+	for i, exp := range []string{"album 1", "album 2", "album 3"} {
+		if p := g.Torrent[i].FilePath(); p != exp {
+			t.Errorf("expected torrent %d to have path %q, got %q", i, exp, p)
+		}
+	}
+	if *maxCalls > 2 {
+		t.Errorf("expected at most 2 torrents fetched at once, got %d", *maxCalls)
+	}
+}
+
+func TestGetTorrentGroupWithoutHydrate(t *testing.T) {
+	c, _ := groupServer(t, "")
+	g, err := c.GetTorrentGroupWith(9, TorrentGroupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Torrent) != 3 || g.Torrent[0].FilePath() != "" {
+		t.Errorf("expected summary torrents, got %+v", g.Torrent)
+	}
+}
+
+func TestGetTorrentGroupWithHydrateFailure(t *testing.T) {
+	c, _ := groupServer(t, "2")
+	if _, err := c.GetTorrentGroupWith(9, TorrentGroupOptions{Hydrate: true}); err == nil {
+		t.Error("expected hydrating a group with a bad torrent to fail")
+	}
+}
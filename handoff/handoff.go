@@ -0,0 +1,316 @@
+// Package handoff passes downloaded .torrent files to a local torrent
+// client: qBittorrent through its WebUI API, Transmission through its RPC
+// interface, or any client that watches a folder, such as Deluge with its
+// AutoAdd plugin or rTorrent.
+package handoff
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// ErrRejected is returned when a client refuses a torrent, usually
+// because it is not a valid .torrent file.
+var ErrRejected = errors.New("handoff: torrent rejected")
+
+// Torrent is a .torrent file and where and how a client should download it.
+type Torrent struct {
+	Data []byte
+	// Name names the .torrent file in a watch folder.
+	Name string
+	// Category is qBittorrent's category, the first of Transmission's
+	// labels and the subfolder of a watch folder.
+	Category string
+	// Labels are qBittorrent's tags and Transmission's labels.
+	Labels []string
+	// SavePath is where the client puts the torrent's contents. Empty
+	// means the client's default.
+	SavePath string
+	Paused   bool
+}
+
+// Client adds torrents to a torrent client.
+type Client interface {
+	Add(ctx context.Context, t Torrent) error
+}
+
+// Options are the options of Send.
+type Options struct {
+	Category string
+	Labels   []string
+	// DownloadDir, if set, is where each torrent is saved, in a folder
+	// named by the torrent's group, as "Artist - Album (Year)".
+	DownloadDir string
+	UseToken    bool
+	Paused      bool
+}
+
+// Send downloads torrent id from the site c and adds it to the client to.
+func Send(ctx context.Context, c whatapi.TorrentAPI, to Client, id int, opts Options) error {
+	t, err := c.GetTorrent(id, url.Values{})
+	if err != nil {
+		return err
+	}
+	data, err := c.DownloadTorrent(id, opts.UseToken)
+	if err != nil {
+		return err
+	}
+	name := FolderName(whatapi.GroupString(t.Group))
+	h := Torrent{
+		Data:     data,
+		Name:     fmt.Sprintf("%s [%d]", name, id),
+		Category: opts.Category,
+		Labels:   opts.Labels,
+		Paused:   opts.Paused,
+	}
+	if opts.DownloadDir != "" {
+		h.SavePath = filepath.Join(opts.DownloadDir, name)
+	}
+	return to.Add(ctx, h)
+}
+
+// FolderName makes s safe to use as a file or folder name on any system.
+func FolderName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+	return strings.TrimRight(strings.TrimSpace(s), ".")
+}
+
+// WatchDir is a folder a torrent client watches for new .torrent files.
+// It can't set a torrent's save path or labels.
+type WatchDir string
+
+// Add writes t into the folder, or into its Category subfolder.
+func (d WatchDir) Add(ctx context.Context, t Torrent) error {
+	dir := string(d)
+	if t.Category != "" {
+		dir = filepath.Join(dir, FolderName(t.Category))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := FolderName(t.Name)
+	if name == "" {
+		name = "torrent"
+	}
+	// write elsewhere first, so the client never sees half a file
+	p := filepath.Join(dir, name+".torrent")
+	tmp := p + ".part"
+	if err := ioutil.WriteFile(tmp, t.Data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Qbittorrent adds torrents through qBittorrent's WebUI API.
+type Qbittorrent struct {
+	// URL is the WebUI's address, such as "http://localhost:8080".
+	URL                string
+	Username, Password string
+	HTTPClient         *http.Client
+
+	mu     sync.Mutex
+	client *http.Client
+}
+
+// Add adds t to qBittorrent, logging in first if need be.
+func (q *Qbittorrent) Add(ctx context.Context, t Torrent) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("torrents", FolderName(t.Name)+".torrent")
+	if err != nil {
+		return err
+	}
+	if _, err = fw.Write(t.Data); err != nil {
+		return err
+	}
+	for k, v := range map[string]string{
+		"category": t.Category,
+		"tags":     strings.Join(t.Labels, ","),
+		"savepath": t.SavePath,
+	} {
+		if v != "" {
+			mw.WriteField(k, v)
+		}
+	}
+	if t.Paused {
+		mw.WriteField("paused", "true")
+	}
+	if err = mw.Close(); err != nil {
+		return err
+	}
+	for tries := 0; ; tries++ {
+		resp, err := q.post(ctx, "/api/v2/torrents/add", mw.FormDataContentType(), body.Bytes())
+		if err != nil {
+			return err
+		}
+		if resp.code == http.StatusForbidden && tries == 0 {
+			if err := q.login(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		if resp.code != http.StatusOK {
+			return fmt.Errorf("handoff: qBittorrent: %d %s", resp.code, resp.body)
+		}
+		if strings.TrimSpace(resp.body) == "Fails." {
+			return ErrRejected
+		}
+		return nil
+	}
+}
+
+func (q *Qbittorrent) login(ctx context.Context) error {
+	form := url.Values{"username": {q.Username}, "password": {q.Password}}
+	resp, err := q.post(ctx, "/api/v2/auth/login", "application/x-www-form-urlencoded", []byte(form.Encode()))
+	if err != nil {
+		return err
+	}
+	if resp.code != http.StatusOK || strings.TrimSpace(resp.body) != "Ok." {
+		return fmt.Errorf("handoff: qBittorrent login failed: %d %s", resp.code, resp.body)
+	}
+	return nil
+}
+
+type response struct {
+	code int
+	body string
+}
+
+func (q *Qbittorrent) post(ctx context.Context, endpoint, contentType string, body []byte) (response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(q.URL, "/")+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return response{}, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	// the WebUI refuses requests from other origins
+	req.Header.Set("Referer", q.URL)
+	resp, err := q.httpClient().Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	return response{resp.StatusCode, string(b)}, err
+}
+
+// httpClient returns a client keeping the session cookie, sharing
+// HTTPClient's transport.
+func (q *Qbittorrent) httpClient() *http.Client {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.client == nil {
+		c := http.Client{}
+		if q.HTTPClient != nil {
+			c = *q.HTTPClient
+		}
+		if c.Jar == nil {
+			c.Jar, _ = cookiejar.New(nil)
+		}
+		q.client = &c
+	}
+	return q.client
+}
+
+// Transmission adds torrents through Transmission's RPC interface.
+type Transmission struct {
+	// URL is the RPC endpoint, such as
+	// "http://localhost:9091/transmission/rpc".
+	URL                string
+	Username, Password string
+	HTTPClient         *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// transmissionSessionHeader carries the token Transmission requires to
+// guard against cross site requests.
+const transmissionSessionHeader = "X-Transmission-Session-Id"
+
+// Add adds t to Transmission. A torrent Transmission already has is not
+// an error.
+func (tr *Transmission) Add(ctx context.Context, t Torrent) error {
+	args := map[string]interface{}{
+		"metainfo": base64.StdEncoding.EncodeToString(t.Data),
+		"paused":   t.Paused,
+	}
+	if t.SavePath != "" {
+		args["download-dir"] = t.SavePath
+	}
+	labels := t.Labels
+	if t.Category != "" {
+		labels = append([]string{t.Category}, labels...)
+	}
+	if len(labels) > 0 {
+		args["labels"] = labels
+	}
+	body, err := json.Marshal(map[string]interface{}{"method": "torrent-add", "arguments": args})
+	if err != nil {
+		return err
+	}
+	client := tr.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for tries := 0; ; tries++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", tr.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if tr.Username != "" {
+			req.SetBasicAuth(tr.Username, tr.Password)
+		}
+		tr.mu.Lock()
+		req.Header.Set(transmissionSessionHeader, tr.sessionID)
+		tr.mu.Unlock()
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusConflict && tries == 0 {
+			tr.mu.Lock()
+			tr.sessionID = resp.Header.Get(transmissionSessionHeader)
+			tr.mu.Unlock()
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("handoff: Transmission: %s", resp.Status)
+		}
+		var r struct {
+			Result string `json:"result"`
+		}
+		if err := json.Unmarshal(b, &r); err != nil {
+			return err
+		}
+		if r.Result != "success" {
+			return fmt.Errorf("%w: %s", ErrRejected, r.Result)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,194 @@
+package handoff
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+var torrent = Torrent{
+	Data:     []byte("d4:infod4:name1:xee"),
+	Name:     "Artist - Album (2000) [7]",
+	Category: "music",
+	Labels:   []string{"flac", "red"},
+	SavePath: "/data/Artist - Album (2000)",
+}
+
+func TestFolderName(t *testing.T) {
+	for in, want := range map[string]string{
+		"AC/DC - Back in Black (1980)": "AC_DC - Back in Black (1980)",
+		`What? "Yes": <No>|*`:          "What_ _Yes__ _No___",
+		" trailing dots... ":           "trailing dots",
+	} {
+		if got := FolderName(in); got != want {
+			t.Errorf("FolderName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWatchDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := WatchDir(dir).Add(context.Background(), torrent); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "music", torrent.Name+".torrent"))
+	if err != nil || string(b) != string(torrent.Data) {
+		t.Errorf("expected the torrent in the category folder, got %q, %v", b, err)
+	}
+}
+
+func TestQbittorrent(t *testing.T) {
+	var logins int
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/auth/login":
+			logins++
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "secret" {
+				rw.Write([]byte("Fails."))
+				return
+			}
+			http.SetCookie(rw, &http.Cookie{Name: "SID", Value: "s1", Path: "/"})
+			rw.Write([]byte("Ok."))
+		case "/api/v2/torrents/add":
+			if c, err := r.Cookie("SID"); err != nil || c.Value != "s1" {
+				rw.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatal(err)
+			}
+			if r.FormValue("category") != "music" || r.FormValue("tags") != "flac,red" ||
+				r.FormValue("savepath") != torrent.SavePath || r.FormValue("paused") != "" {
+				t.Errorf("bad form %v", r.MultipartForm.Value)
+			}
+			f, _, err := r.FormFile("torrents")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b, _ := ioutil.ReadAll(f); string(b) != string(torrent.Data) {
+				rw.Write([]byte("Fails."))
+				return
+			}
+			rw.Write([]byte("Ok."))
+		}
+	}))
+	defer srv.Close()
+	q := &Qbittorrent{URL: srv.URL, Username: "admin", Password: "secret"}
+	for i := 0; i < 2; i++ {
+		if err := q.Add(context.Background(), torrent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("expected to log in once, logged in %d times", logins)
+	}
+	bad := torrent
+	bad.Data = []byte("nope")
+	if err := q.Add(context.Background(), bad); !errors.Is(err, ErrRejected) {
+		t.Errorf("expected ErrRejected, got %v", err)
+	}
+	q = &Qbittorrent{URL: srv.URL, Username: "admin", Password: "wrong"}
+	if err := q.Add(context.Background(), torrent); err == nil {
+		t.Error("expected a failed login")
+	}
+}
+
+func TestTransmission(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(transmissionSessionHeader) != "abc" {
+			rw.Header().Set(transmissionSessionHeader, "abc")
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+		if u, p, _ := r.BasicAuth(); u != "user" || p != "pass" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Method    string `json:"method"`
+			Arguments struct {
+				Metainfo    string   `json:"metainfo"`
+				DownloadDir string   `json:"download-dir"`
+				Labels      []string `json:"labels"`
+				Paused      bool     `json:"paused"`
+			} `json:"arguments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		a := req.Arguments
+		if req.Method != "torrent-add" || a.DownloadDir != torrent.SavePath || !a.Paused ||
+			len(a.Labels) != 3 || a.Labels[0] != "music" {
+			t.Errorf("bad request %+v", req)
+		}
+		if b, _ := base64.StdEncoding.DecodeString(a.Metainfo); string(b) != string(torrent.Data) {
+			rw.Write([]byte(`{"result":"invalid or corrupt torrent file"}`))
+			return
+		}
+		rw.Write([]byte(`{"result":"success","arguments":{"torrent-added":{"id":1}}}`))
+	}))
+	defer srv.Close()
+	tr := &Transmission{URL: srv.URL, Username: "user", Password: "pass"}
+	paused := torrent
+	paused.Paused = true
+	if err := tr.Add(context.Background(), paused); err != nil {
+		t.Fatal(err)
+	}
+	paused.Data = []byte("nope")
+	if err := tr.Add(context.Background(), paused); !errors.Is(err, ErrRejected) {
+		t.Errorf("expected ErrRejected, got %v", err)
+	}
+}
+
+// site serves one torrent.
+type site struct {
+	whatapi.TorrentAPI
+	tokens int
+}
+
+func (s *site) GetTorrent(id int, params url.Values) (whatapi.GetTorrentStruct, error) {
+	return whatapi.GetTorrentStruct{Group: whatapi.GroupStruct{
+		NameF:     "Back in Black",
+		YearF:     1980,
+		MusicInfo: whatapi.MusicInfo{Artists: []whatapi.MusicInfoStruct{{Name: "AC/DC"}}},
+	}}, nil
+}
+
+func (s *site) DownloadTorrent(id int, useToken bool) ([]byte, error) {
+	if useToken {
+		s.tokens++
+	}
+	return torrent.Data, nil
+}
+
+type recorder []Torrent
+
+func (r *recorder) Add(ctx context.Context, t Torrent) error {
+	*r = append(*r, t)
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	s, r := &site{}, &recorder{}
+	opts := Options{Category: "music", Labels: []string{"red"}, DownloadDir: "/data", UseToken: true}
+	if err := Send(context.Background(), s, r, 7, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(*r) != 1 || s.tokens != 1 {
+		t.Fatalf("expected one torrent sent with a token, got %+v", *r)
+	}
+	got := (*r)[0]
+	if got.Name != "AC_DC - Back in Black (1980) [7]" || got.Category != "music" ||
+		got.SavePath != filepath.Join("/data", "AC_DC - Back in Black (1980)") {
+		t.Errorf("bad torrent %+v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package whatapi
+
+import (
+	"math"
+	"time"
+)
+
+// HealthWeights weighs what Health makes of a torrent's stats. Seeders,
+// Age and Retention weigh the parts of the score against each other, and
+// Reported and Trumpable are the fractions of the way to 1 a torrent's
+// score is raised for being reported or trumpable.
+type HealthWeights struct {
+	Seeders   float64
+	Age       float64
+	Retention float64
+	Reported  float64
+	Trumpable float64
+	// SeedersHalf is how many seeders make the seeders part half of
+	// its best.
+	SeedersHalf int
+	// AgeHalf is the age at which the age part is half of its best.
+	AgeHalf time.Duration
+}
+
+// DefaultHealthWeights are the weights Health uses.
+var DefaultHealthWeights = HealthWeights{
+	Seeders:     0.6,
+	Age:         0.1,
+	Retention:   0.3,
+	Reported:    0.5,
+	Trumpable:   0.25,
+	SeedersHalf: 5,
+	AgeHalf:     2 * 365 * 24 * time.Hour,
+}
+
+// Health scores how well t is seeded, from 0 for a torrent at risk of
+// being lost to 1 for one in no danger, with DefaultHealthWeights. Sort
+// by it to find the torrents most worth seeding or downloading to
+// preserve. See HealthWeights.Health.
+func Health(t Torrent) (score float64, complete bool) {
+	return DefaultHealthWeights.Health(t)
+}
+
+// Health scores how well t is seeded, from 0 for a torrent at risk to 1
+// for one in no danger. It is made of the number of seeders; the age, as
+// older torrents lose their seeders; and the retention, the share of
+// snatchers still seeding. Reported and trumpable torrents, which may be
+// removed, aren't worth preserving, so their scores are raised towards
+// 1, sorting them after unflagged torrents as well seeded. Torrents that
+// don't report their stats score 0.
+//
+// complete reports whether the score accounts for whether t is reported
+// and trumpable. Torrents from searches and artist pages don't say, so
+// their scores are incomplete: get them with GetTorrent to score them
+// fully.
+func (w HealthWeights) Health(t Torrent) (score float64, complete bool) {
+	s, ok := t.(TorrentStats)
+	if !ok {
+		return 0, false
+	}
+	seeders := float64(s.SeederCount())
+	parts := []struct{ weight, score float64 }{
+		{w.Seeders, seeders / (seeders + math.Max(float64(w.SeedersHalf), 1))},
+		{w.Age, 1},
+		{w.Retention, 1},
+	}
+	if up, err := s.UploadTime(); err == nil && w.AgeHalf > 0 {
+		age := math.Max(time.Since(up).Hours(), 0)
+		parts[1].score = math.Pow(0.5, age/w.AgeHalf.Hours())
+	}
+	if n := s.SnatchCount(); n > 0 {
+		parts[2].score = math.Min(seeders/float64(n), 1)
+	}
+	total, weights := 0.0, 0.0
+	for _, p := range parts {
+		total += p.weight * p.score
+		weights += p.weight
+	}
+	reported, reportedKnown := s.IsReported()
+	trumpable, trumpableKnown := s.IsTrumpable()
+	complete = reportedKnown && trumpableKnown
+	if weights == 0 {
+		return 0, complete
+	}
+	h := total / weights
+	if reported {
+		h += (1 - h) * w.Reported
+	}
+	if trumpable {
+		h += (1 - h) * w.Trumpable
+	}
+	return math.Max(0, math.Min(h, 1)), complete
+}
@@ -0,0 +1,64 @@
+package whatapi
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(-d).Format(timeLayout) }
+	year := 365 * 24 * time.Hour
+	no := false
+	health := func(tr Torrent) float64 {
+		h, _ := Health(tr)
+		return h
+	}
+	fresh := TorrentStruct{Seeders: 50, Snatched: 60, Time: at(time.Hour), Trumpable: &no}
+	old := TorrentStruct{Seeders: 50, Snatched: 60, Time: at(10 * year)}
+	lonely := TorrentStruct{Seeders: 1, Snatched: 200, Time: at(year)}
+	dead := TorrentStruct{Seeders: 0, Snatched: 0, Time: at(20 * year)}
+	if h := health(fresh); h < 0.85 || h > 1 {
+		t.Errorf("expected a well seeded new torrent to be healthy, got %f", h)
+	}
+	if health(old) >= health(fresh) {
+		t.Errorf("expected age to count against a torrent, got %f >= %f", health(old), health(fresh))
+	}
+	if h := health(lonely); h > 0.3 {
+		t.Errorf("expected a torrent most snatchers stopped seeding to be unhealthy, got %f", h)
+	}
+	if h := health(dead); h > 0.31 {
+		t.Errorf("expected an unseeded torrent to be at risk, got %f", h)
+	}
+	reported := lonely
+	reported.Reported = true
+	if h, exp := health(reported), health(lonely)+(1-health(lonely))*0.5; math.Abs(h-exp) > 1e-9 {
+		t.Errorf("expected a reported torrent to be raised half way to 1, got %f, want %f", h, exp)
+	}
+	yes := true
+	trumpable := lonely
+	trumpable.Trumpable = &yes
+	if health(trumpable) <= health(lonely) || health(trumpable) >= health(reported) {
+		t.Errorf("expected a trumpable torrent to be raised less than a reported one, got %f", health(trumpable))
+	}
+	seedersOnly := HealthWeights{Seeders: 1, SeedersHalf: 50}
+	if h, _ := seedersOnly.Health(fresh); math.Abs(h-0.5) > 1e-9 {
+		t.Errorf("expected half the score at SeedersHalf seeders, got %f", h)
+	}
+	if h := health(ArtistTorrentStruct{}); h < 0 || h > 1 {
+		t.Errorf("expected a score from 0 to 1, got %f", h)
+	}
+}
+
+func TestHealthComplete(t *testing.T) {
+	no := false
+	if _, complete := Health(TorrentStruct{Trumpable: &no}); !complete {
+		t.Error("expected a torrent with both flags known to be scored completely")
+	}
+	for _, tr := range []Torrent{TorrentStruct{}, ArtistTorrentStruct{}, SearchTorrentStruct{}} {
+		if _, complete := Health(tr); complete {
+			t.Errorf("%T: expected a torrent with unknown flags to be scored incompletely", tr)
+		}
+	}
+}
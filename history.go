@@ -0,0 +1,240 @@
+package whatapi
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// UserPost is a forum post in a user's post history.
+type UserPost struct {
+	PostID      int    `json:"postId"`
+	ThreadID    int    `json:"topicId"`
+	ThreadTitle string `json:"threadTitle"`
+	// LastPostID is the last post in the thread.
+	LastPostID int    `json:"lastPostId"`
+	Locked     bool   `json:"locked"`
+	Sticky     bool   `json:"sticky"`
+	AddedTime  string `json:"addedTime"`
+	// Body is the post as HTML, and BbBody as BBCode. Posts read from
+	// the post history page have no BbBody.
+	Body   string `json:"body"`
+	BbBody string `json:"bbbody"`
+}
+
+// Link returns the site relative link to the post in its thread.
+func (p UserPost) Link() string {
+	return fmt.Sprintf("forums.php?action=viewthread&threadid=%d&postid=%d#post%d",
+		p.ThreadID, p.PostID, p.PostID)
+}
+
+// UserPosts is a page of a user's post history.
+type UserPosts struct {
+	CurrentPage int        `json:"currentPage"`
+	Pages       int        `json:"pages"`
+	Posts       []UserPost `json:"threads"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (p UserPosts) PageNumber() int {
+	return p.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (p UserPosts) PageCount() int {
+	return p.Pages
+}
+
+// UserComment is a comment in a user's comment history.
+type UserComment struct {
+	PostID int `json:"postId"`
+	// Page is the kind of page the comment is on, and PageID the id
+	// of the torrent group, artist, collage or request.
+	Page      CommentPage `json:"page"`
+	PageID    int         `json:"pageId"`
+	PageName  string      `json:"pageName"`
+	AddedTime string      `json:"addedTime"`
+	BbBody    string      `json:"bbBody"`
+	Body      string      `json:"body"`
+}
+
+// Link returns the site relative link to the comment on its page.
+func (c UserComment) Link() string {
+	var page string
+	switch c.Page {
+	case CommentPageCollages:
+		page = fmt.Sprintf("collages.php?action=comments&collageid=%d", c.PageID)
+	case CommentPageRequests:
+		page = fmt.Sprintf("requests.php?action=view&id=%d", c.PageID)
+	default:
+		page = fmt.Sprintf("%s.php?id=%d", c.Page, c.PageID)
+	}
+	return fmt.Sprintf("%s&postid=%d#post%d", page, c.PostID, c.PostID)
+}
+
+// UserComments is a page of a user's comment history.
+type UserComments struct {
+	CurrentPage int           `json:"currentPage"`
+	Pages       int           `json:"pages"`
+	Comments    []UserComment `json:"comments"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (c UserComments) PageNumber() int {
+	return c.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (c UserComments) PageCount() int {
+	return c.Pages
+}
+
+func (w *ClientStruct) getUserHistory(c Capability, userID, page int, params url.Values, result interface{}) error {
+	action, err := w.profile.action(c)
+	if err != nil {
+		return err
+	}
+	params.Set("id", strconv.Itoa(userID))
+	params.Set("page", strconv.Itoa(page))
+	requestURL, err := w.ajaxURL(action, params)
+	if err != nil {
+		return err
+	}
+	return w.GetJSON(requestURL, result)
+}
+
+// GetUserPosts retrieves a page of the forum posts made by the user with the provided id. Sites with the CapUserPosts capability return them through the API; on others they are read from the user's post history page.
+func (w *ClientStruct) GetUserPosts(userID, page int) (UserPosts, error) {
+	if !w.profile.Supports(CapUserPosts) {
+		return w.scrapeUserPosts(userID, page)
+	}
+	posts := UserPostsResponse{}
+	if err := w.getUserHistory(CapUserPosts, userID, page, url.Values{"type": {"posts"}}, &posts); err != nil {
+		return posts.Response, err
+	}
+	return posts.Response, checkResponseStatus(posts.Status, posts.Error)
+}
+
+// GetUserComments retrieves a page of the comments made by the user with the provided id. Sites with the CapUserComments capability return them through the API; on others they are read from the user's comment history page, which only lists comments on torrents.
+func (w *ClientStruct) GetUserComments(userID, page int) (UserComments, error) {
+	if !w.profile.Supports(CapUserComments) {
+		return w.scrapeUserComments(userID, page)
+	}
+	comments := UserCommentsResponse{}
+	if err := w.getUserHistory(CapUserComments, userID, page, url.Values{}, &comments); err != nil {
+		return comments.Response, err
+	}
+	return comments.Response, checkResponseStatus(comments.Status, comments.Error)
+}
+
+var (
+	// historyPost matches a post on the post and comment history pages,
+	// which show each in a table with the post's id.
+	historyPost = regexp.MustCompile(`(?is)<table[^>]*id="post(\d+)"[^>]*>(.*?)</table>`)
+	// historyThread matches the link to the thread a post is in.
+	historyThread = regexp.MustCompile(`(?is)href="forums\.php\?action=viewthread&(?:amp;)?threadid=(\d+)[^"]*"[^>]*>(.*?)</a>`)
+	// historyComment matches the link to the torrent group a comment is
+	// on.
+	historyComment = regexp.MustCompile(`(?is)href="torrents\.php\?id=(\d+)[^"]*"[^>]*>(.*?)</a>`)
+	// historyTime matches a post's time, which the pages show in the
+	// title of its relative time.
+	historyTime = regexp.MustCompile(`(?is)<span class="time[^"]*" title="([^"]*)"`)
+	// historyBody matches a post's body.
+	historyBody = regexp.MustCompile(`(?is)<div id="content\d+"[^>]*>(.*?)</div>\s*</td>`)
+	// historyPage matches the links to the other pages of the history.
+	historyPage = regexp.MustCompile(`[?&](?:amp;)?page=(\d+)`)
+)
+
+// historyTimeLayout is the format of the times on history pages.
+const historyTimeLayout = "Jan 02 2006, 15:04"
+
+// historyPages returns the number of pages of a history, as the highest
+// page linked to or current.
+func historyPages(page []byte, current int) int {
+	pages := current
+	for _, m := range historyPage.FindAllSubmatch(page, -1) {
+		if n, _ := strconv.Atoi(string(m[1])); n > pages {
+			pages = n
+		}
+	}
+	return pages
+}
+
+// historyPostTime returns when a post on a history page was made, in the
+// format of AddedTime where it can be read.
+func historyPostTime(post []byte) string {
+	m := historyTime.FindSubmatch(post)
+	if m == nil {
+		return ""
+	}
+	s := html.UnescapeString(string(m[1]))
+	if t, err := time.Parse(historyTimeLayout, s); err == nil {
+		return t.Format(timeLayout)
+	}
+	return s
+}
+
+// historyPostBody returns the HTML of a post on a history page.
+func historyPostBody(post []byte) string {
+	if m := historyBody.FindSubmatch(post); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// linkText returns the text of a link, without its markup.
+func linkText(b []byte) string {
+	return html.UnescapeString(markup.ReplaceAllString(string(b), ""))
+}
+
+// scrapeUserPosts reads a page of the user's posts off their post history
+// page.
+func (w *ClientStruct) scrapeUserPosts(userID, page int) (UserPosts, error) {
+	posts := UserPosts{CurrentPage: page, Posts: []UserPost{}}
+	body, err := w.getPage("userhistory.php", url.Values{
+		"action": {"posts"},
+		"userid": {strconv.Itoa(userID)},
+		"page":   {strconv.Itoa(page)},
+	})
+	if err != nil {
+		return posts, err
+	}
+	for _, m := range historyPost.FindAllSubmatch(body, -1) {
+		p := UserPost{AddedTime: historyPostTime(m[2]), Body: historyPostBody(m[2])}
+		p.PostID, _ = strconv.Atoi(string(m[1]))
+		if t := historyThread.FindSubmatch(m[2]); t != nil {
+			p.ThreadID, _ = strconv.Atoi(string(t[1]))
+			p.ThreadTitle = linkText(t[2])
+		}
+		posts.Posts = append(posts.Posts, p)
+	}
+	posts.Pages = historyPages(body, page)
+	return posts, nil
+}
+
+// scrapeUserComments reads a page of the user's torrent comments off
+// their comment history page.
+func (w *ClientStruct) scrapeUserComments(userID, page int) (UserComments, error) {
+	comments := UserComments{CurrentPage: page, Comments: []UserComment{}}
+	body, err := w.getPage("comments.php", url.Values{
+		"id":   {strconv.Itoa(userID)},
+		"page": {strconv.Itoa(page)},
+	})
+	if err != nil {
+		return comments, err
+	}
+	for _, m := range historyPost.FindAllSubmatch(body, -1) {
+		c := UserComment{Page: CommentPageTorrents, AddedTime: historyPostTime(m[2]), Body: historyPostBody(m[2])}
+		c.PostID, _ = strconv.Atoi(string(m[1]))
+		if g := historyComment.FindSubmatch(m[2]); g != nil {
+			c.PageID, _ = strconv.Atoi(string(g[1]))
+			c.PageName = linkText(g[2])
+		}
+		comments.Comments = append(comments.Comments, c)
+	}
+	comments.Pages = historyPages(body, page)
+	return comments, nil
+}
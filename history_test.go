@@ -0,0 +1,152 @@
+package whatapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestHistoryLinks(t *testing.T) {
+	tests := []struct {
+		link, exp string
+	}{
+		{whatapi.UserPost{PostID: 7, ThreadID: 3}.Link(),
+			"forums.php?action=viewthread&threadid=3&postid=7#post7"},
+		{whatapi.UserComment{PostID: 7, Page: whatapi.CommentPageTorrents, PageID: 3}.Link(),
+			"torrents.php?id=3&postid=7#post7"},
+		{whatapi.UserComment{PostID: 7, Page: whatapi.CommentPageArtist, PageID: 3}.Link(),
+			"artist.php?id=3&postid=7#post7"},
+		{whatapi.UserComment{PostID: 7, Page: whatapi.CommentPageCollages, PageID: 3}.Link(),
+			"collages.php?action=comments&collageid=3&postid=7#post7"},
+		{whatapi.UserComment{PostID: 7, Page: whatapi.CommentPageRequests, PageID: 3}.Link(),
+			"requests.php?action=view&id=3&postid=7#post7"},
+	}
+	for _, tt := range tests {
+		if tt.link != tt.exp {
+			t.Errorf("expected %s, got %s", tt.exp, tt.link)
+		}
+	}
+}
+
+// historyClient returns a client with profile of a site whose pages and
+// API are served by h.
+func historyClient(t *testing.T, profile whatapi.SiteProfile, h http.HandlerFunc) whatapi.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") == "index" {
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+			return
+		}
+		h(rw, r)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := whatapi.NewClient(srv.URL, "agent", whatapi.WithRateLimit(0, 0), whatapi.WithAPIKey("key"), whatapi.WithSiteProfile(profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Login("user", ""); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestGetUserHistory(t *testing.T) {
+	var queries []string
+	profile := whatapi.SiteProfile{Name: "history", Actions: map[whatapi.Capability]string{
+		whatapi.CapUserPosts:    "userhistory",
+		whatapi.CapUserComments: "user_comments",
+	}}
+	c := historyClient(t, profile, func(rw http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("action") == "userhistory" {
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":2,"pages":3,"threads":[` +
+				`{"postId":7,"topicId":3,"threadTitle":"Hi &amp; bye","lastPostId":9,"locked":true,"sticky":false,` +
+				`"addedTime":"2020-01-02 03:04:05","body":"<b>hi</b>","bbbody":"[b]hi[/b]"}]}}`))
+			return
+		}
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"comments":[` +
+			`{"postId":8,"page":"artist","pageId":4,"pageName":"Radiohead","addedTime":"2020-01-02 03:04:05","bbBody":"nice","body":"nice"}]}}`))
+	})
+	posts, err := c.GetUserPosts(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=userhistory&id=1&page=2&type=posts"; queries[0] != exp {
+		t.Errorf("expected query %s, got %s", exp, queries[0])
+	}
+	if posts.PageCount() != 3 || len(posts.Posts) != 1 {
+		t.Fatalf("bad posts %+v", posts)
+	}
+	if p := posts.Posts[0]; p.PostID != 7 || p.ThreadID != 3 || p.LastPostID != 9 || !p.Locked || p.BbBody != "[b]hi[/b]" {
+		t.Errorf("bad post %+v", p)
+	}
+	comments, err := c.GetUserComments(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=user_comments&id=1&page=1"; queries[1] != exp {
+		t.Errorf("expected query %s, got %s", exp, queries[1])
+	}
+	if len(comments.Comments) != 1 || comments.Comments[0].Page != whatapi.CommentPageArtist || comments.Comments[0].PageID != 4 {
+		t.Errorf("bad comments %+v", comments)
+	}
+}
+
+// historyPage is a history page with the posts of a post or comment
+// history, and links to its other pages.
+func historyPage(link string) string {
+	post := func(id, title string) string {
+		return `<table class="forum_post box vertical_margin" id="post` + id + `"><tr class="colhead_dark"><td colspan="2">` +
+			`<span><a href="` + link + `postid=` + id + `#post` + id + `">` + title + `</a></span>` +
+			`<span class="time tooltip" title="Jan 02 2020, 03:04">2 years ago</span></td></tr>` +
+			`<tr><td class="avatar"></td><td class="body"><div id="content` + id + `"><b>post ` + id + `</b></div></td></tr></table>`
+	}
+	return `<html><div class="linkbox"><strong>1</strong> <a href="?page=2&amp;userid=1">2</a> <a href="?page=5&amp;userid=1">Last</a></div>` +
+		post("11", "Hi &amp; bye") + post("12", "<strong>Other</strong>") + `</html>`
+}
+
+func TestGetUserHistoryPages(t *testing.T) {
+	var paths []string
+	c := historyClient(t, whatapi.GazelleProfile, func(rw http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		if r.URL.Path == "/userhistory.php" {
+			rw.Write([]byte(historyPage(`forums.php?action=viewthread&amp;threadid=3&amp;`)))
+			return
+		}
+		rw.Write([]byte(historyPage(`torrents.php?id=42&amp;`)))
+	})
+	posts, err := c.GetUserPosts(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "/userhistory.php?action=posts&page=1&userid=1"; paths[0] != exp {
+		t.Errorf("expected %s, got %s", exp, paths[0])
+	}
+	if posts.PageNumber() != 1 || posts.PageCount() != 5 || len(posts.Posts) != 2 {
+		t.Fatalf("bad posts %+v", posts)
+	}
+	p := posts.Posts[0]
+	if p.PostID != 11 || p.ThreadID != 3 || p.ThreadTitle != "Hi & bye" || p.AddedTime != "2020-01-02 03:04:00" || p.Body != "<b>post 11</b>" {
+		t.Errorf("bad post %+v", p)
+	}
+	if posts.Posts[1].ThreadTitle != "Other" {
+		t.Errorf("expected the title without markup, got %q", posts.Posts[1].ThreadTitle)
+	}
+
+	comments, err := c.GetUserComments(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "/comments.php?id=1&page=2"; paths[1] != exp {
+		t.Errorf("expected %s, got %s", exp, paths[1])
+	}
+	if len(comments.Comments) != 2 || comments.PageCount() != 5 {
+		t.Fatalf("bad comments %+v", comments)
+	}
+	cm := comments.Comments[0]
+	if cm.PostID != 11 || cm.Page != whatapi.CommentPageTorrents || cm.PageID != 42 || cm.PageName != "Hi & bye" {
+		t.Errorf("bad comment %+v", cm)
+	}
+}
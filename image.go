@@ -0,0 +1,95 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxImageSize is the largest image FetchImage downloads.
+const maxImageSize = 20 << 20
+
+// errNotImage is returned when an image URL serves something else, such
+// as a host's error page.
+var errNotImage = errors.New("not an image")
+
+// Image is a picture, such as an artist's image or a group's wiki image.
+type Image struct {
+	ContentType string
+	Data        []byte
+}
+
+// FetchImage downloads the image at imageURL, as a group's WikiImage or an
+// artist's Image, and keeps it in the cache database, if the client has
+// one, so each image is only fetched once. The request carries the
+// client's user agent and the site as its referer, which image hosts
+// serving the site expect, but none of the client's cookies or its API
+// key.
+func (w *ClientStruct) FetchImage(ctx context.Context, imageURL string) (Image, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return Image{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Image{}, errRequestFailedReason("unsupported image URL " + imageURL)
+	}
+	if w.db != nil {
+		img, ok, err := cachedImage(ctx, w.db, imageURL)
+		if err != nil || ok {
+			return img, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return Image{}, err
+	}
+	req.Header.Set("User-Agent", w.agent())
+	req.Header.Set("Referer", w.baseURL.String()+"/")
+	// the client's transport, for proxies, but not its cookie jar
+	resp, err := (&http.Client{Transport: w.client.Transport}).Do(req)
+	if err != nil {
+		return Image{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Image{}, errRequestFailedReason("Status Code " + resp.Status)
+	}
+	body, err := readLimited(resp.Body, maxImageSize)
+	if err != nil {
+		return Image{}, err
+	}
+	img := Image{ContentType: resp.Header.Get("Content-Type"), Data: body}
+	if !strings.HasPrefix(img.ContentType, "image/") {
+		// some hosts don't say, or say application/octet-stream
+		img.ContentType = http.DetectContentType(body)
+	}
+	if !strings.HasPrefix(img.ContentType, "image/") {
+		return Image{}, errNotImage
+	}
+	if w.db != nil {
+		err = writeCache(ctx, w.db, func() error {
+			_, err := w.db.ExecContext(ctx, `REPLACE INTO blobs VALUES(?,?,?,?)`,
+				imageURL, img.ContentType, img.Data, time.Now())
+			return err
+		})
+	}
+	return img, err
+}
+
+// cachedImage returns the image at imageURL from db, and reports whether
+// there was one.
+func cachedImage(ctx context.Context, db *sql.DB, imageURL string) (Image, bool, error) {
+	var img Image
+	err := retryBusy(ctx, func() error {
+		return db.QueryRowContext(ctx,
+			`SELECT contenttype, body FROM blobs WHERE url=?`, imageURL).Scan(&img.ContentType, &img.Data)
+	})
+	if err == sql.ErrNoRows {
+		return Image{}, false, nil
+	}
+	return img, err == nil, err
+}
@@ -0,0 +1,54 @@
+package whatapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFetchImage(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {}, WithAPIKey("secret"))
+	fetches := 0
+	host := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fetches++
+		if r.Header.Get("User-Agent") != "agent" || r.Header.Get("Referer") != c.baseURL.String()+"/" {
+			t.Errorf("bad headers %v", r.Header)
+		}
+		if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+			t.Errorf("expected no credentials sent to an image host, got %v", r.Header)
+		}
+		switch r.URL.Path {
+		case "/a.png":
+			rw.Header().Set("Content-Type", "application/octet-stream")
+			rw.Write(png)
+		default:
+			rw.Write([]byte("<html>gone</html>"))
+		}
+	}))
+	defer host.Close()
+	if err := c.PersistSession(openCache(t)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		img, err := c.FetchImage(ctx, host.URL+"/a.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.ContentType != "image/png" || string(img.Data) != string(png) {
+			t.Errorf("bad image %+v", img)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected the image to be fetched once, fetched %d times", fetches)
+	}
+	if _, err := c.FetchImage(ctx, host.URL+"/missing.png"); err != errNotImage {
+		t.Errorf("expected errNotImage, got %v", err)
+	}
+	if _, err := c.FetchImage(ctx, "file:///etc/passwd"); err == nil {
+		t.Error("expected a file URL to be refused")
+	}
+}
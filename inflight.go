@@ -0,0 +1,97 @@
+package whatapi
+
+import (
+	"context"
+	"time"
+)
+
+// WithInflightLocks keeps a table in the cache database of the requests
+// each client sharing it is fetching, so that when a process misses the
+// cache for a request another process is already fetching, it waits up
+// to wait for that response to be cached rather than fetching it too. A
+// claim on a request lapses after ttl, in case the process holding it
+// died; with a ttl or wait of 0 nothing is claimed. It suits separate
+// processes, such as a crawler and a web UI, sharing one cache.
+func WithInflightLocks(ttl, wait time.Duration) CacheOption {
+	return func(c *cachingClient) {
+		c.inflightTTL, c.inflightWait = ttl, wait
+	}
+}
+
+// claim records that this client is fetching key, and reports whether it
+// was free to. It waits no longer for the database than writeCache does,
+// so that a busy cache delays the fetch rather than stalling it.
+func (c *cachingClient) claim(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := withBusyTimeout(ctx)
+	defer cancel()
+	now := time.Now()
+	var claimed bool
+	err := writeCache(ctx, c.db, func() error {
+		_, err := c.db.ExecContext(ctx,
+			`DELETE FROM inflight WHERE namespace=? AND requesturl=? AND expires<?`,
+			c.Namespace(), key, now.UnixNano())
+		if err != nil {
+			return err
+		}
+		res, err := c.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO inflight (namespace, requesturl, expires) VALUES(?,?,?)`,
+			c.Namespace(), key, now.Add(c.inflightTTL).UnixNano())
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		claimed = n == 1
+		return err
+	})
+	return claimed, err
+}
+
+// release gives up the claim on key. It doesn't use the request's
+// context, which may be done by now, so that the claim doesn't linger
+// until it lapses.
+func (c *cachingClient) release(key string) error {
+	ctx, cancel := withBusyTimeout(context.Background())
+	defer cancel()
+	return writeCache(ctx, c.db, func() error {
+		_, err := c.db.ExecContext(ctx,
+			`DELETE FROM inflight WHERE namespace=? AND requesturl=?`, c.Namespace(), key)
+		return err
+	})
+}
+
+// held reports whether a client has a claim on key that hasn't lapsed. It
+// says yes if it can't tell, so that the caller keeps waiting.
+func (c *cachingClient) held(ctx context.Context, key string) bool {
+	var n int
+	err := c.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM inflight WHERE namespace=? AND requesturl=? AND expires>=?`,
+		c.Namespace(), key, time.Now().UnixNano()).Scan(&n)
+	return err != nil || n > 0
+}
+
+// awaitOther polls the cache, for up to the wait, for another process to
+// cache an acceptable response for key, and returns it if one does. It
+// stops waiting early if the other process gives up its claim without
+// caching a response, as it does when its fetch fails.
+func (c *cachingClient) awaitOther(ctx context.Context, key string, d cacheDirectives) (cacheEntry, bool) {
+	poll := c.inflightWait / 20
+	if poll < 50*time.Millisecond {
+		poll = 50 * time.Millisecond
+	}
+	deadline := time.Now().Add(c.inflightWait)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return cacheEntry{}, false
+		case <-time.After(poll):
+		}
+		held := c.held(ctx, key)
+		if e, err := c.lookup(ctx, key); err == nil && d.acceptable(e, c.ttl(e)) {
+			return e, true
+		}
+		if !held {
+			return cacheEntry{}, false
+		}
+	}
+	return cacheEntry{}, false
+}
@@ -0,0 +1,162 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// inflightClients returns two clients caching in one database through
+// separate connections, as two processes sharing it would, with in flight
+// locks of ttl and wait, and the URL of a torrent request on srv.
+func inflightClients(t *testing.T, srv *httptest.Server, ttl, wait time.Duration) (Client, Client, string) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	var cs [2]Client
+	for i := range cs {
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		cs[i], err = Cache(loggedInClient(t, srv.URL), db, time.Hour, WithInflightLocks(ttl, wait))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, _ := url.Parse(srv.URL)
+	requestURL, _ := buildURL(*u, "ajax.php", "torrent", url.Values{"id": {"1"}})
+	return cs[0], cs[1], requestURL
+}
+
+// blockingServer counts its requests and holds the first one until release
+// is closed, answering it with status.
+func blockingServer(t *testing.T, status int) (srv *httptest.Server, hits *int32, started, release chan struct{}) {
+	hits = new(int32)
+	started, release = make(chan struct{}), make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(hits, 1) == 1 {
+			close(started)
+			<-release
+			rw.WriteHeader(status)
+		}
+		rw.Write([]byte(`{"status":"success","response":{"group":{"name":"Album"}}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits, started, release
+}
+
+type inflightResponse struct {
+	Response struct{ Group struct{ Name string } }
+}
+
+func TestInflightSharesResponse(t *testing.T) {
+	srv, hits, started, release := blockingServer(t, http.StatusOK)
+	a, b, requestURL := inflightClients(t, srv, time.Minute, 5*time.Second)
+	errs := make(chan error, 2)
+	var ra, rb inflightResponse
+	go func() { errs <- a.GetJSON(requestURL, &ra) }()
+	<-started
+	go func() { errs <- b.GetJSON(requestURL, &rb) }()
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("expected one request to the server, got %d", n)
+	}
+	if ra.Response.Group.Name != "Album" || rb.Response.Group.Name != "Album" {
+		t.Errorf("expected both clients to get the response, got %+v and %+v", ra, rb)
+	}
+}
+
+func TestInflightFetchFails(t *testing.T) {
+	srv, hits, started, release := blockingServer(t, http.StatusInternalServerError)
+	a, b, requestURL := inflightClients(t, srv, time.Minute, 10*time.Second)
+	errs := make(chan error, 1)
+	go func() {
+		var r inflightResponse
+		errs <- a.GetJSON(requestURL, &r)
+	}()
+	<-started
+	done := make(chan error, 1)
+	var r inflightResponse
+	go func() { done <- b.GetJSON(requestURL, &r) }()
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	if err := <-errs; err == nil {
+		t.Error("expected the first fetch to fail")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected the waiting client to fetch for itself, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting client kept waiting after the other fetch failed")
+	}
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("expected two requests to the server, got %d", n)
+	}
+	if r.Response.Group.Name != "Album" {
+		t.Errorf("expected the waiting client's own response, got %+v", r)
+	}
+}
+
+func TestInflightWaitCancelled(t *testing.T) {
+	srv, hits, started, release := blockingServer(t, http.StatusOK)
+	defer close(release)
+	a, b, requestURL := inflightClients(t, srv, time.Minute, 10*time.Second)
+	go func() {
+		var r inflightResponse
+		a.GetJSON(requestURL, &r)
+	}()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		var r inflightResponse
+		done <- b.GetJSONContext(ctx, requestURL, &r)
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the deadline error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting client ignored its cancelled context")
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("expected the cancelled client not to fetch, got %d requests", n)
+	}
+}
+
+func TestInflightWithoutTTL(t *testing.T) {
+	srv, hits, started, release := blockingServer(t, http.StatusOK)
+	defer close(release)
+	a, b, requestURL := inflightClients(t, srv, 0, 10*time.Second)
+	go func() {
+		var r inflightResponse
+		a.GetJSON(requestURL, &r)
+	}()
+	<-started
+	var r inflightResponse
+	if err := b.GetJSON(requestURL, &r); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("expected claims to be off without a ttl, and both clients to fetch, got %d requests", n)
+	}
+}
@@ -0,0 +1,72 @@
+package whatapi
+
+import (
+	"net/url"
+	"strings"
+)
+
+// KeyChange is a change to the user's keys, seen by RefreshKeys. A
+// changed passkey breaks download URLs made with the old one.
+type KeyChange struct {
+	OldPassKey string
+	NewPassKey string
+	// AuthKeyChanged is set when the authkey changed too.
+	AuthKeyChanged bool
+}
+
+// PassKeyChanged reports whether the passkey changed.
+func (k KeyChange) PassKeyChanged() bool {
+	return k.OldPassKey != k.NewPassKey
+}
+
+// RewriteURL returns the download URL u with the old passkey replaced by
+// the new one, or u unchanged if it doesn't have the old passkey.
+func (k KeyChange) RewriteURL(u string) string {
+	if !k.PassKeyChanged() || k.OldPassKey == "" {
+		return u
+	}
+	p, err := url.Parse(u)
+	if err != nil || p.Query().Get("torrent_pass") != k.OldPassKey {
+		return u
+	}
+	// replace it in place, so the rest of the URL is as it was
+	p.RawQuery = strings.Replace(p.RawQuery,
+		"torrent_pass="+url.QueryEscape(k.OldPassKey),
+		"torrent_pass="+url.QueryEscape(k.NewPassKey), 1)
+	return p.String()
+}
+
+// WithKeyChangeHook calls hook whenever RefreshKeys sees the user's keys
+// change, so that the application can rewrite the download URLs it has
+// stored, for instance with KeyChange.RewriteURL.
+func WithKeyChangeHook(hook func(KeyChange)) Option {
+	return func(w *ClientStruct) error {
+		w.keyChangeHook = hook
+		return nil
+	}
+}
+
+// RefreshKeys fetches the user's keys again, for instance after the passkey has been reset, and replaces the client's own and any it persisted. It returns how they changed, and calls the hook set with WithKeyChangeHook if they did.
+func (w *ClientStruct) RefreshKeys() (KeyChange, error) {
+	if !w.session.isLoggedIn() {
+		return KeyChange{}, errRequestFailedLogin
+	}
+	oldAuth, oldPass := w.session.keys()
+	k := KeyChange{OldPassKey: oldPass}
+	if err := w.GetAccount(); err != nil {
+		return k, err
+	}
+	var newAuth string
+	newAuth, k.NewPassKey = w.session.keys()
+	k.AuthKeyChanged = newAuth != oldAuth
+	if !k.PassKeyChanged() && !k.AuthKeyChanged {
+		return k, nil
+	}
+	if err := w.saveKeys(); err != nil {
+		return k, err
+	}
+	if w.keyChangeHook != nil {
+		w.keyChangeHook(k)
+	}
+	return k, nil
+}
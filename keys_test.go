@@ -0,0 +1,30 @@
+package whatapi_test
+
+import (
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestKeyChangeRewriteURL(t *testing.T) {
+	k := whatapi.KeyChange{OldPassKey: "old", NewPassKey: "new"}
+	tests := []struct {
+		u, exp string
+	}{
+		{"https://example.com/torrents.php?action=download&id=1&torrent_pass=old",
+			"https://example.com/torrents.php?action=download&id=1&torrent_pass=new"},
+		{"https://example.com/torrents.php?id=1&torrent_pass=old&action=download",
+			"https://example.com/torrents.php?id=1&torrent_pass=new&action=download"},
+		{"https://example.com/torrents.php?action=download&id=1&torrent_pass=other",
+			"https://example.com/torrents.php?action=download&id=1&torrent_pass=other"},
+	}
+	for _, tt := range tests {
+		if got := k.RewriteURL(tt.u); got != tt.exp {
+			t.Errorf("expected %s, got %s", tt.exp, got)
+		}
+	}
+	same := whatapi.KeyChange{OldPassKey: "old", NewPassKey: "old"}
+	if same.PassKeyChanged() || same.RewriteURL(tests[0].u) != tests[0].u {
+		t.Error("expected an unchanged passkey to leave URLs alone")
+	}
+}
@@ -0,0 +1,71 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LeechStatus is how downloading a torrent counts against the user's
+// ratio.
+type LeechStatus int
+
+const (
+	// LeechNormal torrents count in full.
+	LeechNormal LeechStatus = iota
+	// LeechFree torrents don't count downloads.
+	LeechFree
+	// LeechNeutral torrents count neither downloads nor uploads.
+	LeechNeutral
+	// LeechPersonalFree torrents are freeleech for this user only,
+	// usually because they spent a token on them.
+	LeechPersonalFree
+)
+
+var leechStatusNames = []string{"Normal", "Freeleech", "Neutral Leech", "Personal Freeleech"}
+
+func (s LeechStatus) String() string {
+	if s < 0 || int(s) >= len(leechStatusNames) {
+		return "LeechStatus(" + strconv.Itoa(int(s)) + ")"
+	}
+	return leechStatusNames[s]
+}
+
+// Free reports whether downloads of the torrent don't count against the
+// user's ratio.
+func (s LeechStatus) Free() bool {
+	return s != LeechNormal
+}
+
+// UnmarshalJSON decodes the freeTorrent value of any fork: a bool, or a
+// number or string where 0 is normal, 1 freeleech and 2 neutral leech.
+func (s *LeechStatus) UnmarshalJSON(b []byte) error {
+	v := strings.Trim(string(b), `"`)
+	switch v {
+	case "", "null", "false":
+		*s = LeechNormal
+		return nil
+	case "true":
+		*s = LeechFree
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n >= len(leechStatusNames) {
+		return fmt.Errorf("bad leech status %s", b)
+	}
+	*s = LeechStatus(n)
+	return nil
+}
+
+// MarshalJSON encodes normal and freeleech as bools, as most sites send
+// them, and other statuses as numbers.
+func (s LeechStatus) MarshalJSON() ([]byte, error) {
+	switch s {
+	case LeechNormal:
+		return []byte("false"), nil
+	case LeechFree:
+		return []byte("true"), nil
+	}
+	return json.Marshal(int(s))
+}
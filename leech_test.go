@@ -0,0 +1,52 @@
+package whatapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestLeechStatus(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		exp whatapi.LeechStatus
+	}{
+		{`{"freeTorrent":false}`, whatapi.LeechNormal},
+		{`{"freeTorrent":true}`, whatapi.LeechFree},
+		{`{"freeTorrent":"0"}`, whatapi.LeechNormal},
+		{`{"freeTorrent":"1"}`, whatapi.LeechFree},
+		{`{"freeTorrent":"2"}`, whatapi.LeechNeutral},
+		{`{"freeTorrent":2}`, whatapi.LeechNeutral},
+		{`{"freeTorrent":null}`, whatapi.LeechNormal},
+		{`{"freeTorrent":false,"isNeutralLeech":true}`, whatapi.LeechNeutral},
+	} {
+		var to whatapi.TorrentStruct
+		if err := json.Unmarshal([]byte(tc.in), &to); err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+			continue
+		}
+		if s := to.LeechStatus(); s != tc.exp {
+			t.Errorf("%s: expected %s, got %s", tc.in, tc.exp, s)
+		}
+		if to.FreeTorrent != tc.exp.Free() {
+			t.Errorf("%s: expected FreeTorrent %v", tc.in, tc.exp.Free())
+		}
+		var a whatapi.ArtistTorrentStruct
+		var n whatapi.NotificationTorrent
+		if err := json.Unmarshal([]byte(tc.in), &a); err != nil || a.FreeTorrent != a.LeechStatus().Free() {
+			t.Errorf("%s: bad artist torrent %v, %v", tc.in, a.LeechStatus(), err)
+		}
+		if err := json.Unmarshal([]byte(tc.in), &n); err != nil || n.FreeTorrent != n.LeechStatus.Free() {
+			t.Errorf("%s: bad notification %v, %v", tc.in, n.LeechStatus, err)
+		}
+	}
+	var to whatapi.TorrentStruct
+	if err := json.Unmarshal([]byte(`{"freeTorrent":"7"}`), &to); err == nil {
+		t.Error("expected an unknown status to fail")
+	}
+	s := whatapi.SearchTorrentStruct{IsPersonalFreeleech: true}
+	if s.LeechStatus() != whatapi.LeechPersonalFree || !s.LeechStatus().Free() {
+		t.Errorf("expected a personal freeleech, got %s", s.LeechStatus())
+	}
+}
@@ -0,0 +1,153 @@
+package whatapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// coerce rewrites the JSON body so that its values have the types the
+// fields of v expect, where they can be converted: numbers sent as
+// strings, bools sent as numbers or strings, and strings sent as numbers.
+// Nulls already decode as zero values. Values that can't be converted are
+// left for json.Unmarshal to reject.
+func coerce(body []byte, v interface{}) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var raw interface{}
+	if err := d.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return json.Marshal(coerceValue(raw, reflect.TypeOf(v)))
+}
+
+func coerceValue(v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for k, e := range m {
+			f, ok := fieldFor(t, k)
+			// fields with the string option expect their value quoted
+			if ok && !strings.Contains(f.Tag.Get("json"), ",string") {
+				m[k] = coerceValue(e, f.Type)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if l, ok := v.([]interface{}); ok {
+			for i := range l {
+				l[i] = coerceValue(l[i], t.Elem())
+			}
+		}
+	case reflect.Map:
+		if m, ok := v.(map[string]interface{}); ok {
+			for k := range m {
+				m[k] = coerceValue(m[k], t.Elem())
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch x := v.(type) {
+		case string:
+			s := strings.TrimSpace(x)
+			if s == "" {
+				return json.Number("0")
+			}
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return json.Number(s)
+			}
+			if f, err := strconv.ParseFloat(s, 64); err == nil && f == float64(int64(f)) {
+				return json.Number(strconv.FormatInt(int64(f), 10))
+			}
+		case json.Number:
+			if f, err := x.Float64(); err == nil && f == float64(int64(f)) {
+				return json.Number(strconv.FormatInt(int64(f), 10))
+			}
+		case bool:
+			if x {
+				return json.Number("1")
+			}
+			return json.Number("0")
+		}
+	case reflect.Float32, reflect.Float64:
+		if x, ok := v.(string); ok {
+			s := strings.TrimSpace(x)
+			if s == "" {
+				return json.Number("0")
+			}
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+	case reflect.Bool:
+		switch x := v.(type) {
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(x)); err == nil {
+				return b
+			}
+			if x == "" {
+				return false
+			}
+		case json.Number:
+			if f, err := x.Float64(); err == nil {
+				return f != 0
+			}
+		}
+	case reflect.String:
+		if x, ok := v.(json.Number); ok {
+			return x.String()
+		}
+	}
+	return v
+}
+
+// fieldFor returns the field of struct type t that the JSON key decodes
+// into, matching names as encoding/json does, including the fields of
+// embedded structs.
+func fieldFor(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			et := f.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				if ef, ok := fieldFor(et, key); ok {
+					return ef, true
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == key {
+			return f, true
+		}
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = &f
+		}
+	}
+	if folded != nil {
+		return *folded, true
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,50 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCoerce(t *testing.T) {
+	in := `{"group":{"id":"42","categoryId":"1","year":1997.0,"vanityHouse":"0","tags":["rock"]},
+"torrents":[{"id":"1337","size":"412345678","hasLog":1,"hasCue":"true","logScore":"100","seeders":null,"format":"FLAC"}]}`
+	var g TorrentGroup
+	if err := json.Unmarshal([]byte(in), &g); err == nil {
+		t.Fatal("expected the response to need coercing")
+	}
+	body, err := coerce([]byte(in), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(body, &g); err != nil {
+		t.Fatalf("coerced response did not decode: %s\n%s", err, body)
+	}
+	if g.Group.ID() != 42 || g.Group.CategoryID != 1 || g.Group.Year() != 1997 || g.Group.VanityHouse {
+		t.Errorf("group decoded wrong: %+v", g.Group)
+	}
+	tr := g.Torrent[0]
+	if tr.ID() != 1337 || tr.Size != 412345678 || !tr.HasLog() || !tr.HasCue ||
+		tr.LogScore != 100 || tr.Seeders != 0 || tr.Format() != "FLAC" {
+		t.Errorf("torrent decoded wrong: %+v", tr)
+	}
+
+	var r RequestsSearch
+	body, err = coerce([]byte(`{"results":[{"requestId":"5","bounty":"1024","artists":[[{"id":7,"name":"x"}]]}]}`), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(body, &r); err != nil {
+		t.Fatalf("coerced response did not decode: %s\n%s", err, body)
+	}
+	if r.Results[0].RequestID != 5 || r.Results[0].Bounty != 1024 || r.Results[0].Artists[0][0].ID != "7" {
+		t.Errorf("request decoded wrong: %+v", r.Results[0])
+	}
+
+	body, err = coerce([]byte(`{"group":{"id":"abc"}}`), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(body, &g); err == nil {
+		t.Error("expected an id that isn't a number to still fail")
+	}
+}
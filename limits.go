@@ -0,0 +1,53 @@
+package whatapi
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultMaxResponseSize is the largest response body a client reads
+// unless told otherwise by WithMaxResponseSize.
+const DefaultMaxResponseSize = 32 << 20
+
+// WithMaxResponseSize limits the responses to the ajax.php action to n
+// bytes, or all other responses if action is "". Larger responses fail
+// with ErrResponseTooLarge rather than being read into memory.
+func WithMaxResponseSize(action string, n int64) Option {
+	return func(w *ClientStruct) error {
+		if w.maxResponseSize == nil {
+			w.maxResponseSize = map[string]int64{}
+		}
+		w.maxResponseSize[action] = n
+		return nil
+	}
+}
+
+func (w *ClientStruct) maxSize(requestURL string) int64 {
+	if n, ok := w.maxResponseSize[actionOf(requestURL)]; ok {
+		return n
+	}
+	if n, ok := w.maxResponseSize[""]; ok {
+		return n
+	}
+	return DefaultMaxResponseSize
+}
+
+// readLimited reads all of r, failing if there is more than n bytes.
+func readLimited(r io.Reader, n int64) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r, n+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > n {
+		return nil, ErrResponseTooLarge
+	}
+	return body, nil
+}
+
+// looksLikeHTML reports whether body is an HTML page, such as the login
+// page the site serves once a session has expired.
+func looksLikeHTML(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	return len(body) > 0 && body[0] == '<'
+}
@@ -0,0 +1,38 @@
+package whatapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		// a valid response of 60 bytes, padded out to the size asked for
+		rw.Write([]byte(`{"status":"success","response":{}}` + strings.Repeat(" ", 26)))
+	}, WithMaxResponseSize("", 50), WithMaxResponseSize("index", 100))
+	var r struct{ Status string }
+	if err := c.GetJSON(srv.URL+"/ajax.php?action=torrent&id=1", &r); err != ErrResponseTooLarge {
+		t.Errorf("expected %v, got %v", ErrResponseTooLarge, err)
+	}
+	if err := c.GetJSON(srv.URL+"/ajax.php?action=index", &r); err != nil || r.Status != "success" {
+		t.Errorf("expected the action's larger limit to apply, got %v", err)
+	}
+}
+
+func TestDefaultMaxResponseSize(t *testing.T) {
+	c := loggedInClient(t, "http://example.com")
+	if n := c.maxSize("http://example.com/ajax.php?action=index"); n != DefaultMaxResponseSize {
+		t.Errorf("expected the default limit, got %d", n)
+	}
+}
+
+func TestUnexpectedHTML(t *testing.T) {
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("\n  <!DOCTYPE html><html><body>Login</body></html>"))
+	})
+	var r struct{ Status string }
+	if err := c.GetJSON(srv.URL+"/ajax.php?action=index", &r); err != ErrUnexpectedHTML {
+		t.Errorf("expected %v, got %v", ErrUnexpectedHTML, err)
+	}
+}
@@ -0,0 +1,339 @@
+// Package logchecker reads EAC and XLD rip logs and estimates the score a
+// Gazelle site's log checker would give them, with the reason for each
+// point deducted, so that uploads can be checked before they are made.
+// The estimate follows the deductions the site makes for settings and
+// errors; it doesn't verify the logs' checksums, so a log the site finds
+// edited scores lower there.
+package logchecker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+// MaxScore is the score of a log with nothing to deduct.
+const MaxScore = 100
+
+// Ripper is the program that wrote a log.
+type Ripper string
+
+// The rippers whose logs are checked. A log of any other scores 0.
+const (
+	EAC     Ripper = "EAC"
+	XLD     Ripper = "XLD"
+	Unknown Ripper = ""
+)
+
+// Deduction is points taken off a log's score, and why.
+type Deduction struct {
+	Reason string
+	Points int
+	// Track is the track the deduction is for, or 0 if it is for the
+	// whole rip.
+	Track int
+}
+
+// Result is the estimated score of a log.
+type Result struct {
+	Ripper  Ripper
+	Version string
+	// Score is MaxScore less the deductions, and never less than 0.
+	Score      int
+	Deductions []Deduction
+	// Checksum reports whether the log has its ripper's checksum. The
+	// checksum itself isn't verified.
+	Checksum bool
+	Tracks   int
+}
+
+// Check estimates the score of a log, as its ripper wrote it, in UTF-16
+// with a byte order mark as EAC writes them or in UTF-8.
+func Check(log []byte) Result {
+	text := decode(log)
+	l := parse(text)
+	switch {
+	case strings.Contains(text, "Exact Audio Copy"):
+		return l.score(EAC, eacVersion, eacRules, eacTrackRules)
+	case strings.Contains(text, "X Lossless Decoder"):
+		return l.score(XLD, xldVersion, xldRules, xldTrackRules)
+	}
+	return Result{Deductions: []Deduction{{Reason: "Unknown log file", Points: MaxScore}}}
+}
+
+// CheckFile estimates the score of the log in the file at path.
+func CheckFile(path string) (Result, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	return Check(b), nil
+}
+
+// CheckRipLog estimates the score of a log retrieved from the site, to
+// compare with the site's own.
+func CheckRipLog(l whatapi.RipLog) Result {
+	return Check([]byte(l.Log))
+}
+
+// decode returns log as UTF-8 text with Unix line endings.
+func decode(log []byte) string {
+	var text string
+	switch {
+	case bytes.HasPrefix(log, []byte{0xff, 0xfe}):
+		text = decodeUTF16(log[2:], func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+	case bytes.HasPrefix(log, []byte{0xfe, 0xff}):
+		text = decodeUTF16(log[2:], func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+	default:
+		text = string(bytes.TrimPrefix(log, []byte("\xef\xbb\xbf")))
+	}
+	return strings.Replace(text, "\r\n", "\n", -1)
+}
+
+func decodeUTF16(b []byte, unit func([]byte) uint16) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, unit(b[i:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+var (
+	// setting matches a "Key : Value" line.
+	setting = regexp.MustCompile(`^\s*([^:]+?)\s*:\s*(.*?)\s*$`)
+	// trackHeader starts the section of a track, "Track  1" in EAC's
+	// logs and "Track 01" in XLD's.
+	trackHeader = regexp.MustCompile(`^\s*Track\s+(\d+)\s*$`)
+	// eacCRC matches EAC's "Test CRC" and "Copy CRC" lines.
+	eacCRC = regexp.MustCompile(`^\s*(Test|Copy) CRC\s+([0-9A-Fa-f]{8})`)
+	// the first lines of each ripper's logs, with its version
+	eacVersion = regexp.MustCompile(`Exact Audio Copy (V[^\n]*?)(?: from [^\n]*)?\n`)
+	xldVersion = regexp.MustCompile(`X Lossless Decoder version ([^\n]*)\n`)
+)
+
+// parsedLog is a log's settings before the first track, and each
+// track's settings and lines.
+type parsedLog struct {
+	text     string
+	settings map[string]string
+	tracks   []track
+}
+
+type track struct {
+	number   int
+	settings map[string]string
+	lines    []string
+	testCRC  string
+	copyCRC  string
+}
+
+func parse(text string) parsedLog {
+	l := parsedLog{text: text, settings: map[string]string{}}
+	settings := l.settings
+	var t *track
+	for _, line := range strings.Split(text, "\n") {
+		if m := trackHeader.FindStringSubmatch(line); m != nil {
+			l.tracks = append(l.tracks, track{settings: map[string]string{}})
+			t = &l.tracks[len(l.tracks)-1]
+			t.number, _ = strconv.Atoi(m[1])
+			settings = t.settings
+			continue
+		}
+		if t != nil {
+			t.lines = append(t.lines, line)
+			if m := eacCRC.FindStringSubmatch(line); m != nil {
+				if m[1] == "Test" {
+					t.testCRC = strings.ToUpper(m[2])
+				} else {
+					t.copyCRC = strings.ToUpper(m[2])
+				}
+				continue
+			}
+		}
+		if m := setting.FindStringSubmatch(line); m != nil {
+			if _, ok := settings[m[1]]; !ok {
+				settings[m[1]] = m[2]
+			}
+		}
+	}
+	for i := range l.tracks {
+		t := &l.tracks[i]
+		// XLD writes its CRCs as settings
+		if crc, ok := t.settings["CRC32 hash (test run)"]; ok {
+			t.testCRC = strings.ToUpper(crc)
+		}
+		if crc, ok := t.settings["CRC32 hash"]; ok {
+			t.copyCRC = strings.ToUpper(crc)
+		}
+	}
+	return l
+}
+
+// rule deducts points from a log when fails reports that it breaks it.
+type rule struct {
+	reason string
+	points int
+	fails  func(l parsedLog) bool
+}
+
+// trackRule deducts points for each track it fails.
+type trackRule struct {
+	reason string
+	points int
+	fails  func(t track) bool
+}
+
+// is returns a rule failing logs whose setting key doesn't have a value
+// that ok accepts, or that lack it.
+func is(key string, ok func(v string) bool) func(l parsedLog) bool {
+	return func(l parsedLog) bool {
+		v, found := l.settings[key]
+		return !found || !ok(v)
+	}
+}
+
+func equals(want string) func(v string) bool {
+	return func(v string) bool { return strings.EqualFold(v, want) }
+}
+
+func contains(want ...string) func(v string) bool {
+	return func(v string) bool {
+		for _, w := range want {
+			if strings.Contains(strings.ToLower(v), strings.ToLower(w)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// has returns a rule failing logs with the setting key.
+func has(key string) func(l parsedLog) bool {
+	return func(l parsedLog) bool {
+		_, found := l.settings[key]
+		return found
+	}
+}
+
+// noTestCRCs fails rips without test and copy.
+func noTestCRCs(l parsedLog) bool {
+	for _, t := range l.tracks {
+		if t.testCRC != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// crcMismatch fails tracks whose test and copy differ.
+func crcMismatch(t track) bool {
+	return t.testCRC != "" && t.copyCRC != "" && t.testCRC != t.copyCRC
+}
+
+// mentions returns a track rule failing tracks with a line containing
+// text.
+func mentions(text string) func(t track) bool {
+	return func(t track) bool {
+		for _, line := range t.lines {
+			if strings.Contains(line, text) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// nonZero returns a track rule failing tracks whose count key is more
+// than 0.
+func nonZero(key string) func(t track) bool {
+	return func(t track) bool {
+		f := strings.Fields(t.settings[key])
+		if len(f) == 0 {
+			return false
+		}
+		n, err := strconv.Atoi(f[0])
+		return err == nil && n > 0
+	}
+}
+
+var eacRules = []rule{
+	{"Rip was not done in Secure mode", 20, is("Read mode", contains("Secure"))},
+	{"Accurate stream was not used", 20, func(l parsedLog) bool {
+		return is("Utilize accurate stream", equals("Yes"))(l) && is("Read mode", contains("accurate stream"))(l)
+	}},
+	{"Audio cache was not defeated", 10, func(l parsedLog) bool {
+		return is("Defeat audio cache", equals("Yes"))(l) && is("Read mode", contains("disable cache"))(l)
+	}},
+	{"C2 pointers were used", 10, func(l parsedLog) bool {
+		return is("Make use of C2 pointers", equals("No"))(l) && is("Read mode", contains("NO C2"))(l)
+	}},
+	{"Missing offset samples were not filled with silence", 5, is("Fill up missing offset samples with silence", equals("Yes"))},
+	{"Leading and trailing silent blocks were deleted", 5, is("Delete leading and trailing silent blocks", equals("No"))},
+	{"Null samples were not used in CRC calculations", 5, is("Null samples used in CRC calculations", equals("Yes"))},
+	{"Gaps were not appended", 10, is("Gap handling", contains("Appended"))},
+	{"Audio was normalized", 100, has("Normalize to")},
+	{"Range rip", 30, func(l parsedLog) bool { return strings.Contains(l.text, "Range status and errors") }},
+	{"Test and copy was not used", 10, noTestCRCs},
+}
+
+var eacTrackRules = []trackRule{
+	{"Test and copy CRCs mismatch", 30, crcMismatch},
+	{"Suspicious positions", 20, mentions("Suspicious position")},
+	{"Missing samples", 20, mentions("Missing samples")},
+	{"Timing problems", 20, mentions("Timing problem")},
+}
+
+var xldRules = []rule{
+	{"Rip was not done with a secure ripper", 20, is("Ripper mode", contains("XLD Secure Ripper", "CDParanoia"))},
+	{"Audio cache was not disabled", 10, is("Disable audio cache", equals("OK"))},
+	{"C2 error pointers were used", 10, is("Make use of C2 Error Pointers", equals("NO"))},
+	{"Gaps were not analyzed and appended", 10, is("Gap status", func(v string) bool {
+		return contains("Analyzed")(v) && contains("Appended")(v)
+	})},
+	{"Test and copy was not used", 10, noTestCRCs},
+}
+
+var xldTrackRules = []trackRule{
+	{"Test and copy CRCs mismatch", 30, crcMismatch},
+	{"Read errors", 20, nonZero("Read error")},
+	{"Skipped errors", 20, nonZero("Skipped (treated as error)")},
+	{"Inconsistent error sectors", 20, nonZero("Inconsistency in error sectors")},
+	{"Damaged sectors", 20, nonZero("Damaged sector count")},
+}
+
+func (l parsedLog) score(r Ripper, version *regexp.Regexp, rules []rule, trackRules []trackRule) Result {
+	res := Result{
+		Ripper:     r,
+		Score:      MaxScore,
+		Deductions: []Deduction{},
+		Checksum:   strings.Contains(l.text, "==== Log checksum") || strings.Contains(l.text, "BEGIN XLD SIGNATURE"),
+		Tracks:     len(l.tracks),
+	}
+	if m := version.FindStringSubmatch(l.text); m != nil {
+		res.Version = strings.TrimSpace(m[1])
+	}
+	for _, rule := range rules {
+		if rule.fails(l) {
+			res.Deductions = append(res.Deductions, Deduction{Reason: rule.reason, Points: rule.points})
+		}
+	}
+	for _, t := range l.tracks {
+		for _, rule := range trackRules {
+			if rule.fails(t) {
+				res.Deductions = append(res.Deductions, Deduction{Reason: rule.reason, Points: rule.points, Track: t.number})
+			}
+		}
+	}
+	for _, d := range res.Deductions {
+		res.Score -= d.Points
+	}
+	if res.Score < 0 {
+		res.Score = 0
+	}
+	return res
+}
@@ -0,0 +1,184 @@
+package logchecker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+const eacLog = `Exact Audio Copy V1.0 beta 3 from 29. August 2011
+
+EAC extraction logfile from 2. March 2020, 10:00
+
+Artist / Album
+
+Used drive  : PLEXTOR DVDR   PX-716A   Adapter: 0  ID: 0
+
+Read mode               : Secure
+Utilize accurate stream : Yes
+Defeat audio cache      : Yes
+Make use of C2 pointers : No
+
+Read offset correction                      : 30
+Overread into Lead-In and Lead-Out          : No
+Fill up missing offset samples with silence : Yes
+Delete leading and trailing silent blocks   : No
+Null samples used in CRC calculations       : Yes
+Used interface                              : Native Win32 interface for Win NT & 2000
+Gap handling                                : Appended to previous track
+
+Track  1
+
+     Filename C:\Music\01 - One.wav
+
+     Peak level 98.0 %
+     Test CRC 1A2B3C4D
+     Copy CRC 1A2B3C4D
+     Copy OK
+
+Track  2
+
+     Filename C:\Music\02 - Two.wav
+
+     Peak level 97.0 %
+     Test CRC 11111111
+     Copy CRC 22222222
+     Suspicious position 0:02:20
+     Copy finished
+
+==== Log checksum 0123456789ABCDEF ====
+`
+
+const xldLog = `X Lossless Decoder version 20191004 (152.2)
+
+XLD extraction logfile from 2020-03-02 10:00:00 +0000
+
+Used drive : HL-DT-ST DVDRW GX40N (revision RQ00)
+Ripper mode             : XLD Secure Ripper
+Disable audio cache     : OK
+Make use of C2 Error Pointers : NO
+Read offset correction  : 6
+Gap status              : Analyzed, Appended
+
+Track 01
+    Filename : /Music/01 - One.flac
+    CRC32 hash (test run)  : 1A2B3C4D
+    CRC32 hash             : 1A2B3C4D
+    CRC32 hash (skip zero) : 99999999
+    Statistics
+        Read error                           : 0
+        Skipped (treated as error)           : 0
+        Damaged sector count                 : 0
+
+Track 02
+    Filename : /Music/02 - Two.flac
+    CRC32 hash (test run)  : 5E6F7A8B
+    CRC32 hash             : 5E6F7A8B
+    Statistics
+        Read error                           : 3
+        Damaged sector count                 : 0
+
+-----BEGIN XLD SIGNATURE-----
+abc
+-----END XLD SIGNATURE-----
+`
+
+func reasons(r Result) []string {
+	s := []string{}
+	for _, d := range r.Deductions {
+		s = append(s, d.Reason)
+	}
+	return s
+}
+
+func TestCheckEAC(t *testing.T) {
+	r := Check([]byte(eacLog))
+	if r.Ripper != EAC || r.Version != "V1.0 beta 3" || r.Tracks != 2 || !r.Checksum {
+		t.Errorf("bad result %+v", r)
+	}
+	if len(r.Deductions) != 2 ||
+		r.Deductions[0] != (Deduction{"Test and copy CRCs mismatch", 30, 2}) ||
+		r.Deductions[1] != (Deduction{"Suspicious positions", 20, 2}) {
+		t.Errorf("bad deductions %+v", r.Deductions)
+	}
+	if r.Score != 50 {
+		t.Errorf("expected a score of 50, got %d", r.Score)
+	}
+}
+
+func TestCheckEACSettings(t *testing.T) {
+	log := strings.NewReplacer(
+		"Read mode               : Secure", "Read mode               : Burst",
+		"Defeat audio cache      : Yes", "Defeat audio cache      : No",
+		"     Test CRC 1A2B3C4D\n", "",
+		"     Test CRC 11111111\n", "",
+		"     Suspicious position 0:02:20\n", "",
+		"Gap handling                                : Appended to previous track\n", "",
+	).Replace(eacLog)
+	r := Check([]byte(log))
+	want := []string{"Rip was not done in Secure mode", "Audio cache was not defeated", "Gaps were not appended", "Test and copy was not used"}
+	if got := reasons(r); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected deductions %v, got %v", want, got)
+	}
+	if r.Score != 50 {
+		t.Errorf("expected a score of 50, got %d", r.Score)
+	}
+}
+
+func TestCheckEACOldReadMode(t *testing.T) {
+	log := strings.NewReplacer(
+		"Read mode               : Secure\n", "Read mode               : Secure with NO C2, accurate stream, disable cache\n",
+		"Utilize accurate stream : Yes\n", "",
+		"Defeat audio cache      : Yes\n", "",
+		"Make use of C2 pointers : No\n", "",
+	).Replace(eacLog)
+	if r := Check([]byte(log)); r.Score != 50 {
+		t.Errorf("expected the settings in the read mode to count, got %v", reasons(r))
+	}
+}
+
+func TestCheckXLD(t *testing.T) {
+	r := Check([]byte(xldLog))
+	if r.Ripper != XLD || r.Version != "20191004 (152.2)" || r.Tracks != 2 || !r.Checksum {
+		t.Errorf("bad result %+v", r)
+	}
+	if len(r.Deductions) != 1 || r.Deductions[0] != (Deduction{"Read errors", 20, 2}) || r.Score != 80 {
+		t.Errorf("bad deductions %+v, score %d", r.Deductions, r.Score)
+	}
+}
+
+func TestCheckUnknown(t *testing.T) {
+	if r := Check([]byte("cdparanoia III release 10.2")); r.Ripper != Unknown || r.Score != 0 {
+		t.Errorf("expected an unknown log to score 0, got %+v", r)
+	}
+}
+
+func TestCheckFileUTF16(t *testing.T) {
+	// EAC writes its logs in UTF-16 with Windows line endings
+	units := utf16.Encode([]rune(strings.Replace(eacLog, "\n", "\r\n", -1)))
+	b := []byte{0xff, 0xfe}
+	for _, u := range units {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	path := filepath.Join(t.TempDir(), "rip.log")
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := CheckFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ripper != EAC || r.Score != 50 || r.Tracks != 2 {
+		t.Errorf("bad result %+v", r)
+	}
+}
+
+func TestCheckRipLog(t *testing.T) {
+	if r := CheckRipLog(whatapi.RipLog{Log: xldLog, Score: 80}); r.Score != 80 {
+		t.Errorf("expected the site's score, got %d", r.Score)
+	}
+}
@@ -0,0 +1,90 @@
+package whatapi
+
+import (
+	"context"
+	"time"
+)
+
+// MaintenanceReport describes what CacheMaintenance did.
+type MaintenanceReport struct {
+	// Expired is the number of expired responses deleted.
+	Expired int64
+	// Reclaimed is the number of bytes the database file shrank by.
+	Reclaimed int64
+}
+
+// CacheMaintenance returns errNoCache, as the client has no cache.
+func (w *ClientStruct) CacheMaintenance(ctx context.Context) (MaintenanceReport, error) {
+	return MaintenanceReport{}, errNoCache
+}
+
+// CacheMaintenance deletes expired responses from the cache and compacts
+// the database file. Compacting rewrites the whole database, so it blocks
+// other use of the cache for as long as that takes.
+func (c *cachingClient) CacheMaintenance(ctx context.Context) (MaintenanceReport, error) {
+	var r MaintenanceReport
+	before, err := c.dbSize(ctx)
+	if err != nil {
+		return r, err
+	}
+	err = writeCache(ctx, c.db, func() error {
+		res, err := c.db.ExecContext(ctx,
+			`DELETE FROM urlcache WHERE timestamp < datetime('now', '-' || COALESCE(ttl, ?) || ' seconds')`,
+			int64(c.cacheFor/time.Second))
+		if err != nil {
+			return err
+		}
+		r.Expired, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return r, err
+	}
+	for _, stmt := range []string{`PRAGMA wal_checkpoint(TRUNCATE)`, `VACUUM`} {
+		if err = writeCache(ctx, c.db, func() error {
+			_, err := c.db.ExecContext(ctx, stmt)
+			return err
+		}); err != nil {
+			return r, err
+		}
+	}
+	after, err := c.dbSize(ctx)
+	if err != nil {
+		return r, err
+	}
+	r.Reclaimed = before - after
+	return r, nil
+}
+
+// dbSize returns the size of the database in bytes.
+func (c *cachingClient) dbSize(ctx context.Context) (int64, error) {
+	var pages, size int64
+	if err := c.db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := c.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&size); err != nil {
+		return 0, err
+	}
+	return pages * size, nil
+}
+
+// WithAutoMaintenance runs CacheMaintenance every interval until ctx is
+// done. Errors are passed to onErr, if it isn't nil.
+func WithAutoMaintenance(ctx context.Context, interval time.Duration, onErr func(error)) CacheOption {
+	return func(c *cachingClient) {
+		go func() {
+			t := time.NewTicker(interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+				}
+				if _, err := c.CacheMaintenance(ctx); err != nil && onErr != nil {
+					onErr(err)
+				}
+			}
+		}()
+	}
+}
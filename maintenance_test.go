@@ -0,0 +1,66 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheMaintenance(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Query().Get("id")]++
+		mu.Unlock()
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cc, err := Cache(c, db, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL)
+	get := func(id string) {
+		requestURL, _ := buildURL(*u, "ajax.php", "torrent", url.Values{"id": {id}})
+		var r struct{ Status string }
+		if err := cc.GetJSON(requestURL, &r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get("1")
+	get("2")
+	if _, err = db.Exec(`UPDATE urlcache SET timestamp = datetime('now', '-2 hours') WHERE requesturl LIKE '%id=1%'`); err != nil {
+		t.Fatal(err)
+	}
+	r, err := cc.CacheMaintenance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Expired != 1 {
+		t.Errorf("expected 1 expired response, got %d", r.Expired)
+	}
+	get("1")
+	get("2")
+	if hits["1"] != 2 || hits["2"] != 1 {
+		t.Errorf("expected only the expired response to be fetched again, got %v", hits)
+	}
+}
+
+func TestCacheMaintenanceNoCache(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	})
+	if _, err := c.CacheMaintenance(context.Background()); err != errNoCache {
+		t.Errorf("expected %v, got %v", errNoCache, err)
+	}
+}
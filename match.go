@@ -0,0 +1,169 @@
+package whatapi
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultMatchScore is the least file list similarity a Matcher reports
+// unless told otherwise.
+const DefaultMatchScore = 0.9
+
+// Match is a torrent on another tracker that may be the same as the
+// torrent being matched.
+type Match struct {
+	Tracker string
+	GetTorrentStruct
+	// Score is the similarity of the file lists, from 0 to 1.
+	Score float64
+	// ExactHash is set when the torrents have the same info hash.
+	ExactHash bool
+}
+
+// MatchError is returned with the matches found when some of the
+// candidates couldn't be compared.
+type MatchError struct {
+	Tracker string
+	// Candidates are the errors getting each candidate, by torrent id.
+	Candidates map[int]error
+}
+
+func (e *MatchError) Error() string {
+	ids := make([]int, 0, len(e.Candidates))
+	for id := range e.Candidates {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("torrent %d: %v", id, e.Candidates[id])
+	}
+	return fmt.Sprintf("couldn't compare %d candidates on %s: %s", len(ids), e.Tracker, strings.Join(msgs, "; "))
+}
+
+// Matcher finds the equivalents of a torrent on other trackers.
+type Matcher struct {
+	Trackers *Trackers
+	// MinScore is the least file list similarity to report. Zero means
+	// DefaultMatchScore.
+	MinScore float64
+}
+
+// Match finds the torrents on the tracker called target that may be the
+// same as source, best first. A torrent with the same info hash is the
+// only match. Otherwise the target is searched for the source's group, and
+// each torrent of the same total size is compared file by file. Candidates
+// that can't be got or whose file lists can't be read are skipped, and
+// reported in a MatchError returned with the other matches.
+func (m Matcher) Match(source GetTorrentStruct, target string) ([]Match, error) {
+	c, ok := m.Trackers.Get(target)
+	if !ok {
+		return nil, fmt.Errorf("unknown tracker %q", target)
+	}
+	if source.Torrent.InfoHash != "" {
+		r, err := c.GetTorrentByHash(source.Torrent.InfoHash)
+		if err == nil {
+			return []Match{{Tracker: target, GetTorrentStruct: r, Score: 1, ExactHash: true}}, nil
+		}
+		// a failure status means the target doesn't have the hash
+		if !failedStatus(err) {
+			return nil, err
+		}
+	}
+	files, err := source.Torrent.Files()
+	if err != nil {
+		return nil, err
+	}
+	opts := TorrentSearchOptions{GroupName: source.Group.Name(), Year: source.Group.Year()}
+	if a := source.Group.MusicInfo.Artists; len(a) == 1 {
+		opts.ArtistName = html.UnescapeString(a[0].Name)
+	}
+	search, err := c.SearchTorrentsWith("", opts)
+	if err != nil {
+		return nil, err
+	}
+	min := m.MinScore
+	if min == 0 {
+		min = DefaultMatchScore
+	}
+	matches := []Match{}
+	failed := map[int]error{}
+	for _, p := range search.Flatten() {
+		if p.Torrent.FileSize() != source.Torrent.Size {
+			continue
+		}
+		id := p.Torrent.ID()
+		r, err := c.GetTorrent(id, url.Values{})
+		if err != nil {
+			failed[id] = err
+			continue
+		}
+		f, err := r.Torrent.Files()
+		if err != nil {
+			failed[id] = err
+			continue
+		}
+		if score := FileListSimilarity(files, f); score >= min {
+			matches = append(matches, Match{Tracker: target, GetTorrentStruct: r, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(failed) > 0 {
+		return matches, &MatchError{Tracker: target, Candidates: failed}
+	}
+	return matches, nil
+}
+
+// FileListSimilarity scores how alike two file lists are, from 0 for
+// nothing in common to 1 for the same files. Files are compared by size
+// and base name, ignoring case; files that only match on size, as renamed
+// files do, count half.
+func FileListSimilarity(a, b []FileStruct) float64 {
+	var total int64
+	for _, f := range a {
+		total += f.Size
+	}
+	for _, f := range b {
+		total += f.Size
+	}
+	if total == 0 {
+		if len(a) == 0 && len(b) == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	type key struct {
+		name string
+		size int64
+	}
+	byName := map[key]int{}
+	bySize := map[int64]int{}
+	for _, f := range b {
+		byName[key{strings.ToLower(path.Base(f.Name())), f.Size}]++
+		bySize[f.Size]++
+	}
+	var exact, sized int64
+	unmatched := []FileStruct{}
+	for _, f := range a {
+		k := key{strings.ToLower(path.Base(f.Name())), f.Size}
+		if byName[k] > 0 {
+			byName[k]--
+			bySize[f.Size]--
+			exact += f.Size
+			continue
+		}
+		unmatched = append(unmatched, f)
+	}
+	for _, f := range unmatched {
+		if bySize[f.Size] > 0 {
+			bySize[f.Size]--
+			sized += f.Size
+		}
+	}
+	return float64(2*exact+sized) / float64(total)
+}
@@ -0,0 +1,106 @@
+package whatapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestFileListSimilarity(t *testing.T) {
+	f := func(name string, size int64) whatapi.FileStruct {
+		return whatapi.FileStruct{NameF: name, Size: size}
+	}
+	a := []whatapi.FileStruct{f("01 - One.flac", 100), f("02 - Two.flac", 200), f("folder.jpg", 10)}
+	for _, c := range []struct {
+		name string
+		b    []whatapi.FileStruct
+		exp  float64
+	}{
+		{"same", a, 1},
+		{"other folder and case", []whatapi.FileStruct{f("CD1/01 - ONE.flac", 100), f("CD1/02 - two.flac", 200), f("CD1/folder.jpg", 10)}, 1},
+		{"renamed", []whatapi.FileStruct{f("1.flac", 100), f("2.flac", 200), f("folder.jpg", 10)}, 320.0 / 620},
+		{"missing artwork", a[:2], 600.0 / 610},
+		{"different", []whatapi.FileStruct{f("01 - One.flac", 101)}, 0},
+		{"empty", nil, 0},
+	} {
+		if s := whatapi.FileListSimilarity(a, c.b); s != c.exp {
+			t.Errorf("%s: expected similarity %v, got %v", c.name, c.exp, s)
+		}
+	}
+	if s := whatapi.FileListSimilarity(nil, nil); s != 1 {
+		t.Errorf("expected empty lists to be the same, got %v", s)
+	}
+}
+
+// matchSite has the torrent with hash EXACT, and searches find torrents 1
+// to 4: 1 has the source's files, 2 can't be got, 3 is another size and 4
+// has other files.
+func matchSite(t *testing.T) whatapi.Client {
+	t.Helper()
+	files := map[string]string{
+		"1": `01 - One.flac{{{100}}}|||02 - Two.flac{{{200}}}|||folder.jpg{{{10}}}`,
+		"4": `track.flac{{{310}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "index":
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+		case "browse":
+			rw.Write([]byte(`{"status":"success","response":{"results":[{"groupId":7,"groupName":"Album","torrents":[` +
+				`{"torrentId":1,"size":310},{"torrentId":2,"size":310},{"torrentId":3,"size":999},{"torrentId":4,"size":310}]}]}}`))
+		case "torrent":
+			if q.Get("hash") == "EXACT" {
+				rw.Write([]byte(`{"status":"success","response":{"group":{"id":7},"torrent":{"id":9}}}`))
+			} else if f, ok := files[q.Get("id")]; ok && q.Get("hash") == "" {
+				rw.Write([]byte(`{"status":"success","response":{"group":{"id":7},"torrent":{"id":` + q.Get("id") + `,"fileList":"` + f + `"}}}`))
+			} else {
+				rw.Write([]byte(`{"status":"failure","error":"bad id parameter"}`))
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := whatapi.NewClient(srv.URL, "agent", whatapi.WithRateLimit(0, 0), whatapi.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Login("user", ""); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestMatch(t *testing.T) {
+	trackers := whatapi.NewTrackers()
+	trackers.Add("red", matchSite(t))
+	m := whatapi.Matcher{Trackers: trackers}
+	source := whatapi.GetTorrentStruct{
+		Group: whatapi.GroupStruct{NameF: "Album"},
+		Torrent: whatapi.TorrentStruct{
+			InfoHash: "EXACT",
+			Size:     310,
+			FileList: `01 - One.flac{{{100}}}|||02 - Two.flac{{{200}}}|||folder.jpg{{{10}}}`,
+		},
+	}
+	matches, err := m.Match(source, "red")
+	if err != nil || len(matches) != 1 || !matches[0].ExactHash || matches[0].Torrent.ID() != 9 {
+		t.Fatalf("expected the torrent with the same hash, got %v, %v", matches, err)
+	}
+
+	source.Torrent.InfoHash = "OTHER"
+	matches, err = m.Match(source, "red")
+	if len(matches) != 1 || matches[0].Torrent.ID() != 1 || matches[0].Score != 1 || matches[0].ExactHash {
+		t.Errorf("expected torrent 1 to match by its files, got %v", matches)
+	}
+	var me *whatapi.MatchError
+	if !errors.As(err, &me) || len(me.Candidates) != 1 || me.Candidates[2] == nil || me.Tracker != "red" {
+		t.Errorf("expected torrent 2 to be reported, got %v", err)
+	}
+
+	if _, err = m.Match(source, "ops"); err == nil {
+		t.Error("expected an unknown tracker to fail")
+	}
+}
@@ -0,0 +1,136 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// NotificationsOptions are the typed parameters of GetNotificationsPage.
+type NotificationsOptions struct {
+	// Page is the page of results to return, from 1. Zero means the
+	// first page.
+	Page int
+	// FilterID limits the results to those raised by one of the user's
+	// notification filters. Zero means all filters.
+	FilterID int
+}
+
+func (o NotificationsOptions) params() url.Values {
+	params := url.Values{}
+	if o.Page > 0 {
+		params.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.FilterID > 0 {
+		params.Set("filterid", strconv.Itoa(o.FilterID))
+	}
+	return params
+}
+
+// GetNotificationsPage retrieves a page of torrent notifications for the current user.
+func (w *ClientStruct) GetNotificationsPage(opts NotificationsOptions) (Notifications, error) {
+	return w.GetNotifications(opts.params())
+}
+
+// UnreadCounts are the unread items of each kind the site shows the user.
+type UnreadCounts struct {
+	Messages         int
+	Notifications    int
+	NewAnnouncement  bool
+	NewBlog          bool
+	NewSubscriptions bool
+}
+
+// GetUnreadCounts retrieves the current user's unread counts. It always goes to the site, since a cached count is of little use.
+func (w *ClientStruct) GetUnreadCounts() (UnreadCounts, error) {
+	account, err := w.GetAccountInfo()
+	if err != nil {
+		return UnreadCounts{}, err
+	}
+	n := account.Notifications
+	return UnreadCounts{
+		Messages:         n.Messages,
+		Notifications:    n.Notifications,
+		NewAnnouncement:  n.NewAnnouncment,
+		NewBlog:          n.NewBlog,
+		NewSubscriptions: n.NewSubscriptions,
+	}, nil
+}
+
+// MarkNotificationsRead marks the notifications for the provided torrent ids as read, or every notification if there are none.
+func (w *ClientStruct) MarkNotificationsRead(torrentIDs ...int) error {
+	if len(torrentIDs) == 0 {
+		params := url.Values{}
+		params.Set("action", "notify_clear")
+		_, err := w.postForm("torrents.php", params)
+		return err
+	}
+	for _, id := range torrentIDs {
+		params := url.Values{}
+		params.Set("action", "notify_clear_item")
+		params.Set("torrentid", strconv.Itoa(id))
+		if _, err := w.postForm("torrents.php", params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotificationSettings are the events that raise site notifications for
+// the user.
+type NotificationSettings struct {
+	News          bool `json:"news"`
+	Blog          bool `json:"blog"`
+	Quotes        bool `json:"quotes"`
+	Subscriptions bool `json:"subscriptions"`
+	Inbox         bool `json:"inbox"`
+	StaffPM       bool `json:"staffPM"`
+	Torrents      bool `json:"torrents"`
+	Collages      bool `json:"collages"`
+}
+
+func (s NotificationSettings) params() url.Values {
+	params := url.Values{}
+	for name, on := range map[string]bool{
+		"news":          s.News,
+		"blog":          s.Blog,
+		"quotes":        s.Quotes,
+		"subscriptions": s.Subscriptions,
+		"inbox":         s.Inbox,
+		"staffPM":       s.StaffPM,
+		"torrents":      s.Torrents,
+		"collages":      s.Collages,
+	} {
+		params.Set(name, "0")
+		if on {
+			params.Set(name, "1")
+		}
+	}
+	return params
+}
+
+// GetNotificationSettings retrieves which events raise site notifications for the current user, on sites with the CapNotificationSettings capability. It always goes to the site, so that it sees any update just made.
+func (w *ClientStruct) GetNotificationSettings() (NotificationSettings, error) {
+	settings := NotificationSettingsResponse{}
+	action, err := w.profile.action(CapNotificationSettings)
+	if err != nil {
+		return settings.Response, err
+	}
+	ctx := WithCacheDirectives(w.context(), NoCache)
+	if err = w.DoContext(ctx, action, url.Values{}, &settings); err != nil {
+		return settings.Response, err
+	}
+	return settings.Response, checkResponseStatus(settings.Status, settings.Error)
+}
+
+// UpdateNotificationSettings sets which events raise site notifications for the current user, on sites with the CapNotificationSettings capability. Every setting is sent, so change those returned by GetNotificationSettings rather than starting from scratch.
+func (w *ClientStruct) UpdateNotificationSettings(s NotificationSettings) error {
+	action, err := w.profile.action(CapNotificationSettings)
+	if err != nil {
+		return err
+	}
+	var st GenericResponse
+	if err = w.DoPost(w.context(), action, s.params(), &st); err != nil {
+		return err
+	}
+	return checkResponseStatus(st.Status, st.Error)
+}
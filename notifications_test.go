@@ -0,0 +1,129 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetNotificationsPage(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"currentPages":2,"pages":3,"numNew":1,"results":[
+			{"torrentId":1,"groupCategoryId":1,"torrentTags":"rock  indie","format":"FLAC","encoding":"Lossless","notificationTime":"2020-01-02 03:04:05","unread":true},
+			{"torrentId":2,"format":"MP3","unread":false}]}}`))
+	})
+	n, err := c.GetNotificationsPage(NotificationsOptions{Page: 2, FilterID: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=notifications&filterid=4&page=2"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	u := n.Unread()
+	if len(u) != 1 || u[0].TorrentID != 1 {
+		t.Fatalf("bad unread notifications %+v", u)
+	}
+	if tags := u[0].Tags(); len(tags) != 2 || tags[0] != "rock" || tags[1] != "indie" {
+		t.Errorf("bad tags %q", tags)
+	}
+	if u[0].Category() != CategoryMusic || u[0].FormatEncoding() != "FLAC Lossless" || n.Results[1].FormatEncoding() != "MP3" {
+		t.Errorf("bad notification %+v", u[0])
+	}
+	if tm, err := u[0].Time(); err != nil || !tm.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("bad time %v, %v", tm, err)
+	}
+
+	if _, err = c.GetNotificationsPage(NotificationsOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=notifications"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+}
+
+func TestGetUnreadCounts(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if a := r.URL.Query().Get("action"); a != "index" {
+			t.Errorf("unexpected action %s", a)
+		}
+		rw.Write([]byte(`{"status":"success","response":{"notifications":{"messages":2,"notifications":5,"newAnnouncement":true,"newBlog":false,"newSubscriptions":true}}}`))
+	})
+	n, err := c.GetUnreadCounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (UnreadCounts{Messages: 2, Notifications: 5, NewAnnouncement: true, NewSubscriptions: true}); n != exp {
+		t.Errorf("expected %+v, got %+v", exp, n)
+	}
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	var posts []string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/torrents.php" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		r.ParseForm()
+		posts = append(posts, r.PostForm.Get("action")+" "+r.PostForm.Get("torrentid"))
+	})
+	if err := c.MarkNotificationsRead(3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkNotificationsRead(); err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"notify_clear_item 3", "notify_clear_item 4", "notify_clear "}
+	if len(posts) != len(exp) {
+		t.Fatalf("expected posts %q, got %q", exp, posts)
+	}
+	for i := range exp {
+		if posts[i] != exp[i] {
+			t.Errorf("expected post %q, got %q", exp[i], posts[i])
+		}
+	}
+}
+
+func TestNotificationSettings(t *testing.T) {
+	profile := SiteProfile{Name: "test", Actions: map[Capability]string{CapNotificationSettings: "notifysettings"}}
+	var post map[string]string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") != "notifysettings" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Method == "GET" {
+			rw.Write([]byte(`{"status":"success","response":{"news":true,"blog":false,"quotes":true,"subscriptions":false}}`))
+			return
+		}
+		r.ParseForm()
+		post = map[string]string{}
+		for k := range r.PostForm {
+			post[k] = r.PostForm.Get(k)
+		}
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}, WithSiteProfile(profile))
+	c.session.setKeys("authkey", "")
+	s, err := c.GetNotificationSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.News || s.Blog || !s.Quotes || s.Subscriptions {
+		t.Errorf("bad settings %+v", s)
+	}
+	s.Subscriptions = true
+	if err := c.UpdateNotificationSettings(s); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{"auth": "authkey", "news": "1", "blog": "0", "quotes": "1", "subscriptions": "1", "inbox": "0"}
+	for k, v := range exp {
+		if post[k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, post[k])
+		}
+	}
+
+	c.profile = GazelleProfile
+	if _, err := c.GetNotificationSettings(); err == nil {
+		t.Error("expected notification settings to be unsupported")
+	}
+}
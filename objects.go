@@ -0,0 +1,78 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// PutObject stores v, encoded as JSON, under key in the namespace ns of
+// the cache database db, replacing whatever was stored there before. It is
+// for data derived from responses, such as name to id resolutions or
+// match results, that should live alongside the cache. Objects are kept
+// apart from the cache, so cache maintenance never removes them. Use a
+// client's Namespace in ns to keep one user's objects from another's.
+// db must have been set up by Cache or MigrateCache.
+func PutObject(ctx context.Context, db *sql.DB, ns, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeCache(ctx, db, func() error {
+		_, err := db.ExecContext(ctx,
+			`REPLACE INTO objects VALUES(?,?,?,?)`, ns, key, string(value), time.Now())
+		return err
+	})
+}
+
+// GetObject decodes the object stored under key in the namespace ns of db
+// into v, and reports whether there was one.
+func GetObject(ctx context.Context, db *sql.DB, ns, key string, v interface{}) (bool, error) {
+	var value string
+	err := retryBusy(ctx, func() error {
+		return db.QueryRowContext(ctx,
+			`SELECT value FROM objects WHERE namespace=? AND key=?`, ns, key).Scan(&value)
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal([]byte(value), v)
+}
+
+// DeleteObject removes the object stored under key in the namespace ns of
+// db, if there is one.
+func DeleteObject(ctx context.Context, db *sql.DB, ns, key string) error {
+	return writeCache(ctx, db, func() error {
+		_, err := db.ExecContext(ctx,
+			`DELETE FROM objects WHERE namespace=? AND key=?`, ns, key)
+		return err
+	})
+}
+
+// ObjectKeys returns the keys of the objects stored in the namespace ns of
+// db, in order.
+func ObjectKeys(ctx context.Context, db *sql.DB, ns string) ([]string, error) {
+	var keys []string
+	err := retryBusy(ctx, func() error {
+		keys = nil
+		rows, err := db.QueryContext(ctx,
+			`SELECT key FROM objects WHERE namespace=? ORDER BY key`, ns)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var k string
+			if err := rows.Scan(&k); err != nil {
+				return err
+			}
+			keys = append(keys, k)
+		}
+		return rows.Err()
+	})
+	return keys, err
+}
@@ -0,0 +1,43 @@
+package whatapi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestObjects(t *testing.T) {
+	ctx := context.Background()
+	db := openCache(t)
+	type resolution struct {
+		Name string
+		ID   int
+	}
+	var r resolution
+	if ok, err := GetObject(ctx, db, "artists", "Radiohead", &r); ok || err != nil {
+		t.Fatalf("expected no object, got %v, %v", ok, err)
+	}
+	if err := PutObject(ctx, db, "artists", "Radiohead", resolution{"Radiohead", 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutObject(ctx, db, "artists", "Radiohead", resolution{"Radiohead", 6}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := GetObject(ctx, db, "artists", "Radiohead", &r); !ok || err != nil || r.ID != 6 {
+		t.Errorf("expected the replaced object, got %+v, %v, %v", r, ok, err)
+	}
+	if ok, _ := GetObject(ctx, db, "other", "Radiohead", &r); ok {
+		t.Error("expected namespaces to be kept apart")
+	}
+	if err := PutObject(ctx, db, "artists", "Portishead", resolution{"Portishead", 7}); err != nil {
+		t.Fatal(err)
+	}
+	if keys, err := ObjectKeys(ctx, db, "artists"); err != nil || len(keys) != 2 || keys[0] != "Portishead" || keys[1] != "Radiohead" {
+		t.Errorf("expected both keys in order, got %v, %v", keys, err)
+	}
+	if err := DeleteObject(ctx, db, "artists", "Radiohead"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := GetObject(ctx, db, "artists", "Radiohead", &r); ok || err != nil {
+		t.Errorf("expected the object to be deleted, got %v, %v", ok, err)
+	}
+}
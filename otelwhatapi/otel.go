@@ -0,0 +1,139 @@
+// Package otelwhatapi traces the requests a whatapi client makes with
+// OpenTelemetry, so that services embedding the client see its API calls
+// in their distributed traces. It is a module of its own, so that only
+// programs that use it depend on OpenTelemetry.
+package otelwhatapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	"github.com/charles-haynes/whatapi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/charles-haynes/whatapi/otelwhatapi"
+
+// Attribute keys set on each span.
+const (
+	ActionKey     = attribute.Key("whatapi.action")
+	CacheHitKey   = attribute.Key("whatapi.cache_hit")
+	StatusKey     = attribute.Key("whatapi.status")
+	RetryCountKey = attribute.Key("whatapi.retry_count")
+)
+
+// Option configures the tracing decorator.
+type Option func(*tracingClient)
+
+// WithTracerProvider makes the decorator start its spans with tracers
+// from tp rather than the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(t *tracingClient) {
+		t.tp = tp
+	}
+}
+
+type tracingClient struct {
+	whatapi.Decorator
+	tp     trace.TracerProvider
+	tracer trace.Tracer
+}
+
+// Trace wraps a clone of c in a decorator that starts a span for each
+// request it sends, recording the ajax action, whether the response came
+// from a cache, the status the site answered with and how often the
+// request was retried. c must be a whatapi.Decorator, such as a client
+// made by whatapi.NewClient or whatapi.Cache, and is itself left
+// untraced. Wrap a cached client to see cache hits.
+func Trace(c whatapi.Client, opts ...Option) (whatapi.Client, error) {
+	d, ok := c.(whatapi.Decorator)
+	if !ok {
+		return nil, errors.New("otelwhatapi: client can't be decorated")
+	}
+	inner, err := d.Clone()
+	if err != nil {
+		return nil, err
+	}
+	t := &tracingClient{Decorator: inner, tp: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.tp.Tracer(instrumentationName)
+	inner.SetOuter(t)
+	return t, nil
+}
+
+// Clone clones the client it wraps, and traces the clone too.
+func (t *tracingClient) Clone(opts ...whatapi.Option) (whatapi.Decorator, error) {
+	inner, err := t.Decorator.Clone(opts...)
+	if err != nil {
+		return nil, err
+	}
+	clone := *t
+	clone.Decorator = inner
+	inner.SetOuter(&clone)
+	return &clone, nil
+}
+
+// FetchContext traces a GET of requestURL.
+func (t *tracingClient) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	return t.traced(ctx, "GET", requestURL, func(ctx context.Context) ([]byte, error) {
+		return t.Decorator.FetchContext(ctx, requestURL)
+	})
+}
+
+// PostContext traces a POST to requestURL.
+func (t *tracingClient) PostContext(ctx context.Context, requestURL string, form url.Values) ([]byte, error) {
+	return t.traced(ctx, "POST", requestURL, func(ctx context.Context) ([]byte, error) {
+		return t.Decorator.PostContext(ctx, requestURL, form)
+	})
+}
+
+func (t *tracingClient) traced(ctx context.Context, method, requestURL string, send func(context.Context) ([]byte, error)) ([]byte, error) {
+	action := actionOf(requestURL)
+	name := "whatapi " + method
+	if action != "" {
+		name += " " + action
+	}
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(ActionKey.String(action)))
+	defer span.End()
+
+	info := whatapi.ResponseInfoFrom(ctx)
+	if info == nil {
+		ctx, info = whatapi.WithResponseInfo(ctx)
+	}
+	retries := info.Retries
+	body, err := send(ctx)
+	span.SetAttributes(
+		CacheHitKey.Bool(err == nil && info.FromCache),
+		RetryCountKey.Int(info.Retries-retries))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return body, err
+	}
+	var st whatapi.GenericResponse
+	if json.Unmarshal(body, &st) == nil && st.Status != "" {
+		span.SetAttributes(StatusKey.String(st.Status))
+		if st.Status != "success" {
+			span.SetStatus(codes.Error, st.Error)
+		}
+	}
+	return body, nil
+}
+
+// actionOf returns the ajax action requestURL asks for, if any.
+func actionOf(requestURL string) string {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("action")
+}
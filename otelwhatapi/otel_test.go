@@ -0,0 +1,90 @@
+package otelwhatapi
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeClient answers every request with body, from a cache if cached.
+type fakeClient struct {
+	whatapi.Decorator
+	body   string
+	cached bool
+}
+
+func (f *fakeClient) SetOuter(c whatapi.Decorator) {}
+
+func (f *fakeClient) Clone(opts ...whatapi.Option) (whatapi.Decorator, error) {
+	clone := *f
+	return &clone, nil
+}
+
+func (f *fakeClient) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	if info := whatapi.ResponseInfoFrom(ctx); info != nil {
+		info.FromCache = f.cached
+	}
+	return []byte(f.body), nil
+}
+
+func (f *fakeClient) PostContext(ctx context.Context, requestURL string, form url.Values) ([]byte, error) {
+	return f.FetchContext(ctx, requestURL)
+}
+
+func TestTrace(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		client *fakeClient
+		post   bool
+		span   string
+		status string
+		code   codes.Code
+	}{
+		{"fetch", &fakeClient{body: `{"status":"success","response":{}}`}, false, "whatapi GET torrent", "success", codes.Unset},
+		{"cache hit", &fakeClient{body: `{"status":"success","response":{}}`, cached: true}, false, "whatapi GET torrent", "success", codes.Unset},
+		{"failure", &fakeClient{body: `{"status":"failure","error":"bad id"}`}, true, "whatapi POST torrent", "failure", codes.Error},
+	} {
+		sr := tracetest.NewSpanRecorder()
+		traced, err := Trace(c.client, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc := traced.(whatapi.Decorator)
+		u := "https://example.com/ajax.php?action=torrent&id=1"
+		if c.post {
+			_, err = tc.PostContext(context.Background(), u, url.Values{})
+		} else {
+			_, err = tc.FetchContext(context.Background(), u)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		spans := sr.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("%s: expected 1 span, got %d", c.name, len(spans))
+		}
+		s := spans[0]
+		if s.Name() != c.span || s.Status().Code != c.code {
+			t.Errorf("%s: bad span %q, status %v", c.name, s.Name(), s.Status())
+		}
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, a := range s.Attributes() {
+			attrs[a.Key] = a.Value
+		}
+		if v := attrs[ActionKey]; v.AsString() != "torrent" {
+			t.Errorf("%s: bad action %q", c.name, v.AsString())
+		}
+		if v, ok := attrs[CacheHitKey]; !ok || v.AsBool() != c.client.cached {
+			t.Errorf("%s: bad cache hit %v", c.name, v.AsBool())
+		}
+		if v := attrs[StatusKey]; v.AsString() != c.status {
+			t.Errorf("%s: bad status %q", c.name, v.AsString())
+		}
+	}
+}
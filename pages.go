@@ -0,0 +1,82 @@
+package whatapi
+
+// DefaultMaxPages is the most pages FetchAll will fetch.
+const DefaultMaxPages = 100
+
+// FetchAll fetches every page of a paginated endpoint, starting from page 1,
+// and folds them together with merge. fetch is expected to go through a
+// client, so that the pages are fetched under its rate limit. If there are
+// more than DefaultMaxPages pages it returns what it has merged so far with
+// ErrTooManyPages.
+func FetchAll[T Paginated](fetch func(page int) (T, error), merge func(a, b T) T) (T, error) {
+	return FetchAllN(DefaultMaxPages, fetch, merge)
+}
+
+// FetchAllN is FetchAll with a limit of maxPages pages.
+func FetchAllN[T Paginated](maxPages int, fetch func(page int) (T, error), merge func(a, b T) T) (T, error) {
+	all, err := fetch(1)
+	if err != nil {
+		return all, err
+	}
+	for page := 2; page <= all.PageCount(); page++ {
+		if page > maxPages {
+			return all, ErrTooManyPages
+		}
+		next, err := fetch(page)
+		if err != nil {
+			return all, err
+		}
+		all = merge(all, next)
+	}
+	return all, nil
+}
+
+// resultsIter steps through the results of a paginated search, fetching
+// pages as needed. It counts the pages itself, as some forks leave
+// currentPage out of their results, and stops at an empty page or, for
+// sites that ignore the page asked for, at one that starts with the same
+// result as the last.
+type resultsIter[T any] struct {
+	// fetch fetches a page, from 1, returning its results and the number
+	// of pages, or 0 if the site didn't say.
+	fetch func(page int) ([]T, int, error)
+	// id identifies a result, to tell repeated pages apart.
+	id      func(T) int
+	results []T
+	pages   int
+	pageNo  int
+	i       int
+	err     error
+}
+
+// Next advances to the next result, and reports whether there is one.
+func (it *resultsIter[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.i++
+	if it.i < len(it.results) {
+		return true
+	}
+	if it.pageNo > 0 && it.pages != 0 && it.pageNo >= it.pages {
+		return false
+	}
+	prev := it.results
+	it.pageNo++
+	it.results, it.pages, it.err = it.fetch(it.pageNo)
+	it.i = 0
+	if it.err != nil || len(it.results) == 0 {
+		return false
+	}
+	return len(prev) == 0 || it.id(prev[0]) != it.id(it.results[0])
+}
+
+// Result returns the current result.
+func (it *resultsIter[T]) Result() T {
+	return it.results[it.i]
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *resultsIter[T]) Err() error {
+	return it.err
+}
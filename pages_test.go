@@ -0,0 +1,52 @@
+package whatapi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func fakePages(pages int, fetched *[]int) func(int) (whatapi.TorrentSnatchers, error) {
+	return func(page int) (whatapi.TorrentSnatchers, error) {
+		*fetched = append(*fetched, page)
+		return whatapi.TorrentSnatchers{CurrentPage: page, Pages: pages}, nil
+	}
+}
+
+func mergeSnatchers(a, b whatapi.TorrentSnatchers) whatapi.TorrentSnatchers {
+	a.Snatchers = append(a.Snatchers, b.Snatchers...)
+	a.CurrentPage = b.CurrentPage
+	return a
+}
+
+func TestFetchAll(t *testing.T) {
+	var fetched []int
+	all, err := whatapi.FetchAll(fakePages(3, &fetched), mergeSnatchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 3 || all.PageNumber() != 3 {
+		t.Errorf("expected 3 pages, fetched %v ending at %d", fetched, all.PageNumber())
+	}
+
+	fetched = nil
+	all, err = whatapi.FetchAllN(2, fakePages(5, &fetched), mergeSnatchers)
+	if err != whatapi.ErrTooManyPages {
+		t.Errorf("expected ErrTooManyPages, got %v", err)
+	}
+	if len(fetched) != 2 || all.PageNumber() != 2 {
+		t.Errorf("expected 2 pages, fetched %v ending at %d", fetched, all.PageNumber())
+	}
+
+	fail := errors.New("fail")
+	_, err = whatapi.FetchAll(func(page int) (whatapi.TorrentSnatchers, error) {
+		if page == 2 {
+			return whatapi.TorrentSnatchers{}, fail
+		}
+		return whatapi.TorrentSnatchers{CurrentPage: page, Pages: 3}, nil
+	}, mergeSnatchers)
+	if err != fail {
+		t.Errorf("expected %v, got %v", fail, err)
+	}
+}
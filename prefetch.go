@@ -0,0 +1,100 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+)
+
+type backgroundKey struct{}
+
+// withBackground marks requests made with ctx as background requests,
+// which yield to other requests under the rate limit.
+func withBackground(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+func isBackground(ctx context.Context) bool {
+	b, _ := ctx.Value(backgroundKey{}).(bool)
+	return b
+}
+
+func closedErrs(err error) <-chan error {
+	errs := make(chan error, 1)
+	errs <- err
+	close(errs)
+	return errs
+}
+
+// Prefetch needs a cache to fill, so on a client that isn't wrapped by
+// Cache it only reports errNoCache.
+func (w *ClientStruct) Prefetch(urls []string) <-chan error {
+	return w.PrefetchProgress(urls, nil)
+}
+
+// PrefetchProgress only reports errNoCache, as Prefetch does.
+func (w *ClientStruct) PrefetchProgress(urls []string, p Progress) <-chan error {
+	if !w.session.isLoggedIn() {
+		return closedErrs(errRequestFailedLogin)
+	}
+	return closedErrs(errNoCache)
+}
+
+// PrefetchAction prefetches the ajax.php action once for each set of
+// parameters. See Prefetch.
+func (w *ClientStruct) PrefetchAction(action string, paramSets []url.Values) <-chan error {
+	urls := make([]string, 0, len(paramSets))
+	for _, params := range paramSets {
+		requestURL, err := w.ajaxURL(action, params)
+		if err != nil {
+			return closedErrs(err)
+		}
+		urls = append(urls, requestURL)
+	}
+	return w.outer.Prefetch(urls)
+}
+
+// Prefetch fills the cache with the responses for urls in the background,
+// at a lower priority than other requests made through the client. URLs
+// that are already cached are skipped. Any errors are sent on the returned
+// channel, which is closed once every url has been tried.
+func (c *cachingClient) Prefetch(urls []string) <-chan error {
+	return c.PrefetchProgress(urls, nil)
+}
+
+// PrefetchProgress is Prefetch telling p of each url as it is fetched or
+// found already cached, with the size of the response fetched.
+func (c *cachingClient) PrefetchProgress(urls []string, p Progress) <-chan error {
+	progress := progressOf(p)
+	progress.Add(len(urls))
+	errs := make(chan error, len(urls))
+	go func() {
+		defer close(errs)
+		for _, u := range urls {
+			n, err := c.prefetch(u)
+			progress.Finish(n, err)
+			if err != nil {
+				errs <- err
+			}
+		}
+	}()
+	return errs
+}
+
+// prefetch caches the response for requestURL unless it is already
+// cached, and returns its size if it fetched it.
+func (c *cachingClient) prefetch(requestURL string) (int64, error) {
+	ctx := context.Background()
+	e, err := c.lookup(ctx, requestURL)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil && c.fresh(e) {
+		return 0, nil
+	}
+	body, err := c.Decorator.FetchContext(withBackground(ctx), requestURL)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(body)), c.updateCache(ctx, requestURL, body, e)
+}
@@ -0,0 +1,109 @@
+package whatapi
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestPrefetch(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	c, srv := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Query().Get("id")]++
+		mu.Unlock()
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	})
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cc, err := Cache(c, db, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL)
+	paramSets := []url.Values{}
+	for _, id := range []string{"1", "2", "3"} {
+		paramSets = append(paramSets, url.Values{"id": {id}})
+	}
+	requestURL, _ := buildURL(*u, "ajax.php", "torrent", paramSets[0])
+	var r struct{ Status string }
+	if err = cc.GetJSON(requestURL, &r); err != nil {
+		t.Fatal(err)
+	}
+	for err := range cc.PrefetchAction("torrent", paramSets) {
+		t.Error(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if hits[id] != 1 {
+			t.Errorf("expected one request for %s, got %d", id, hits[id])
+		}
+	}
+	requestURL, _ = buildURL(*u, "ajax.php", "torrent", paramSets[2])
+	if err = cc.GetJSON(requestURL, &r); err != nil {
+		t.Fatal(err)
+	}
+	if hits["3"] != 1 {
+		t.Errorf("expected prefetched response to be served from the cache, got %d requests", hits["3"])
+	}
+
+	progress := NewProgressChan()
+	urls := []string{requestURL}
+	requestURL, _ = buildURL(*u, "ajax.php", "torrent", url.Values{"id": {"4"}})
+	for err := range cc.PrefetchProgress(append(urls, requestURL), progress) {
+		t.Error(err)
+	}
+	if p := progress.Report(); p.Total != 2 || p.Done != 2 || p.Bytes != 34 {
+		t.Errorf("expected a cached and a fetched response, got %+v", p)
+	}
+}
+
+func TestPrefetchNoCache(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	})
+	errs := c.Prefetch([]string{"http://example.com/ajax.php?action=index"})
+	if err := <-errs; err != errNoCache {
+		t.Errorf("expected %v, got %v", errNoCache, err)
+	}
+	if _, ok := <-errs; ok {
+		t.Error("expected channel to be closed")
+	}
+}
+
+func TestRateLimiterBackgroundYields(t *testing.T) {
+	r := newRateLimiter(1, 50*time.Millisecond)
+	r.wait(false)
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		r.wait(true)
+		mu.Lock()
+		order = append(order, "background")
+		mu.Unlock()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		r.wait(false)
+		mu.Lock()
+		order = append(order, "foreground")
+		mu.Unlock()
+	}()
+	wg.Wait()
+	if len(order) != 2 || order[0] != "foreground" {
+		t.Errorf("expected the foreground request to go first, got %v", order)
+	}
+}
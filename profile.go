@@ -0,0 +1,192 @@
+package whatapi
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capability names an optional feature that only some Gazelle forks expose.
+type Capability string
+
+const (
+	CapSnatchers Capability = "snatchers"
+	CapPeers     Capability = "peers"
+	// CapArtistSections maps to the artist action parameter that
+	// selects which sections of the page to return.
+	CapArtistSections Capability = "artist_sections"
+	CapArtistComments Capability = "artist_comments"
+	// CapBonusStore maps to the action that lists, and with an item
+	// parameter buys, bonus point store items.
+	CapBonusStore Capability = "bonus_store"
+	// CapSeedingReport maps to the action that reports the bonus
+	// points earned by each torrent the user seeds.
+	CapSeedingReport Capability = "seeding_report"
+	// CapUserPosts and CapUserComments map to the actions that list
+	// the forum posts and the comments a user has made. The posts are
+	// asked for with type=posts, as the userhistory action wants.
+	CapUserPosts    Capability = "user_posts"
+	CapUserComments Capability = "user_comments"
+	// CapFriends maps to the action that lists the user's friends.
+	CapFriends Capability = "friends"
+	// CapReports and CapResolveReport map to the actions that list
+	// the moderation queue and resolve a report in it.
+	CapReports       Capability = "reports"
+	CapResolveReport Capability = "resolve_report"
+	// CapDownloadToken maps to the action that issues a one-time
+	// download URL for a torrent, on forks that don't accept passkey
+	// download URLs.
+	CapDownloadToken Capability = "download_token"
+	// CapNotificationSettings maps to the action that returns, and when
+	// posted to updates, which events raise site notifications.
+	CapNotificationSettings Capability = "notification_settings"
+	// CapUserTorrents maps to the action that lists the torrents a user
+	// is seeding, has snatched, is leeching or has uploaded.
+	CapUserTorrents Capability = "user_torrents"
+	// CapRipLog maps to the action that returns one of a torrent's rip
+	// logs. Other sites' logs are read from their log pages.
+	CapRipLog Capability = "riplog"
+	// CapAPIKey maps to the scheme API keys are sent with in the
+	// Authorization header, empty for a bare key, on sites that issue
+	// the keys WithAPIKey sets.
+	CapAPIKey Capability = "api_key"
+	// CapEditTorrent and CapMergeGroups map to the torrents.php actions
+	// that take a torrent's edit form and merge one group into another.
+	CapEditTorrent Capability = "edit_torrent"
+	CapMergeGroups Capability = "merge_groups"
+)
+
+// SiteProfile describes the optional features and quirks of a particular
+// Gazelle deployment.
+type SiteProfile struct {
+	Name string
+	// Actions maps each supported capability to the ajax.php action,
+	// or for some capabilities the parameter, that provides it.
+	// Capabilities not in the map are unsupported.
+	Actions map[Capability]string
+	// Fixups patch bad values in the site's responses. Nil means
+	// DefaultFixups; an empty slice turns them off.
+	Fixups []Fixup
+	// Strict fails responses with values of the wrong type, such as
+	// ids sent as strings, rather than converting them.
+	Strict bool
+	// Paths maps stock endpoints such as "ajax.php", "login.php" and
+	// "torrents.php" to the paths the site serves them at, for
+	// deployments with renamed endpoints. Relative paths are resolved
+	// against the client's base URL, so a site under a subpath only
+	// needs that in its base URL. Endpoints not in the map are at their
+	// stock paths.
+	Paths map[string]string
+	// DownloadURLField names the field of the torrent, in responses to
+	// the torrent action, that holds a signed download URL, on forks
+	// that send one in place of accepting passkey download URLs.
+	DownloadURLField string
+	// LoginRedirect is the endpoint a successful login redirects to. A
+	// login that isn't redirected there has failed. Empty means
+	// "index.php".
+	LoginRedirect string
+}
+
+// Supports reports whether the site exposes the capability c.
+func (p SiteProfile) Supports(c Capability) bool {
+	_, ok := p.Actions[c]
+	return ok
+}
+
+// Capabilities returns the optional capabilities the site exposes, in
+// name order.
+func (p SiteProfile) Capabilities() []Capability {
+	caps := make([]Capability, 0, len(p.Actions))
+	for c := range p.Actions {
+		caps = append(caps, c)
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+	return caps
+}
+
+func (p SiteProfile) action(c Capability) (string, error) {
+	a, ok := p.Actions[c]
+	if !ok {
+		return "", errUnsupported(c)
+	}
+	return a, nil
+}
+
+// path returns the path the site serves endpoint at.
+func (p SiteProfile) path(endpoint string) string {
+	if path, ok := p.Paths[endpoint]; ok {
+		return path
+	}
+	return endpoint
+}
+
+// GazelleProfile is the profile for a stock Gazelle install, which has
+// none of the optional capabilities.
+var GazelleProfile = SiteProfile{Name: "gazelle", Fixups: DefaultFixups}
+
+// RedactedProfile is the profile for redacted.sh. It only lists the
+// actions in the site's API documentation and the stock torrents.php and
+// userhistory actions it kept; the rest of the optional capabilities are
+// left out rather than guessed. It has no CapDownloadToken, as the site
+// accepts passkey download URLs.
+var RedactedProfile = SiteProfile{
+	Name: "redacted",
+	Actions: map[Capability]string{
+		CapAPIKey:       "",
+		CapEditTorrent:  "takeedit",
+		CapMergeGroups:  "merge",
+		CapUserTorrents: "user_torrents",
+		CapRipLog:       "riplog",
+		CapUserPosts:    "userhistory",
+	},
+	Fixups: DefaultFixups,
+}
+
+// OrpheusProfile is the profile for orpheus.network, which wants its API
+// keys sent as tokens. Like RedactedProfile it only lists the actions
+// the site's Gazelle source serves.
+var OrpheusProfile = SiteProfile{
+	Name: "orpheus",
+	Actions: map[Capability]string{
+		CapAPIKey:      "token",
+		CapEditTorrent: "takeedit",
+		CapMergeGroups: "merge",
+		CapRipLog:      "riplog",
+		CapUserPosts:   "userhistory",
+	},
+	Fixups: DefaultFixups,
+}
+
+// knownProfiles maps tracker host names to their site profiles. It is
+// guarded by profilesMu, as profiles may be registered while clients are
+// being made.
+var profilesMu sync.RWMutex
+var knownProfiles = map[string]SiteProfile{
+	"redacted.sh":     RedactedProfile,
+	"redacted.ch":     RedactedProfile,
+	"orpheus.network": OrpheusProfile,
+}
+
+// ProfileFor returns the profile registered for host, or GazelleProfile
+// if there isn't one.
+func ProfileFor(host string) SiteProfile {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	if p, ok := knownProfiles[strings.ToLower(host)]; ok {
+		return p
+	}
+	return GazelleProfile
+}
+
+// RegisterProfile makes p the profile used by new clients for host. It is
+// safe to call while clients are being made.
+func RegisterProfile(host string, p SiteProfile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	knownProfiles[strings.ToLower(host)] = p
+}
+
+// Capabilities returns the optional capabilities the client's site exposes, so that applications can check for a feature before using it.
+func (w *ClientStruct) Capabilities() []Capability {
+	return w.profile.Capabilities()
+}
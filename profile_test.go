@@ -0,0 +1,143 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestProfileFor(t *testing.T) {
+	for host, exp := range map[string]string{
+		"redacted.sh":     "redacted",
+		"REDACTED.ch":     "redacted",
+		"orpheus.network": "orpheus",
+		"example.com":     "gazelle",
+	} {
+		if p := ProfileFor(host); p.Name != exp {
+			t.Errorf("%s: expected profile %s, got %s", host, exp, p.Name)
+		}
+	}
+}
+
+// unregisterProfile undoes RegisterProfile for host.
+func unregisterProfile(host string) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	delete(knownProfiles, host)
+}
+
+func TestRegisterProfileConcurrently(t *testing.T) {
+	defer unregisterProfile("race.example.com")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterProfile("race.example.com", SiteProfile{Name: "race"})
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := NewClient("https://race.example.com/", "agent"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if p := ProfileFor("race.example.com"); p.Name != "race" {
+		t.Errorf("expected the registered profile, got %s", p.Name)
+	}
+}
+
+func TestRegisteredProfileGatesEndpoint(t *testing.T) {
+	RegisterProfile("127.0.0.1", snatchersProfile)
+	t.Cleanup(func() { unregisterProfile("127.0.0.1") })
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"snatchers":[]}}`))
+	})
+	exp := []Capability{CapPeers, CapSnatchers}
+	if caps := c.Capabilities(); !reflect.DeepEqual(caps, exp) {
+		t.Errorf("expected capabilities %v, got %v", exp, caps)
+	}
+	if _, err := c.GetTorrentSnatchers(12, 1); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=torrentsnatchers&id=12&page=1"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if _, err := c.GetArtistComments(3, 1); err == nil {
+		t.Error("expected artist comments to be unsupported")
+	}
+}
+
+func TestUnsupportedRejectedLocally(t *testing.T) {
+	for _, profile := range []SiteProfile{GazelleProfile, RedactedProfile, OrpheusProfile} {
+		requests := 0
+		c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+			requests++
+			rw.Write([]byte(`{"status":"success","response":{}}`))
+		}, WithSiteProfile(profile))
+		for _, tc := range []struct {
+			capability Capability
+			call       func() error
+		}{
+			{CapSnatchers, func() error { _, err := c.GetTorrentSnatchers(1, 1); return err }},
+			{CapPeers, func() error { _, err := c.GetTorrentPeers(1, 1); return err }},
+			{CapBonusStore, func() error { _, err := c.BonusStore(); return err }},
+			{CapSeedingReport, func() error { _, err := c.GetSeedingReport(); return err }},
+			{CapFriends, func() error { _, err := c.GetFriends(); return err }},
+			{CapReports, func() error { _, err := c.GetReports("", 1); return err }},
+			{CapResolveReport, func() error { return c.ResolveReport(1, "") }},
+			{CapNotificationSettings, func() error { _, err := c.GetNotificationSettings(); return err }},
+		} {
+			if profile.Supports(tc.capability) {
+				t.Errorf("%s: expected %s to be unsupported", profile.Name, tc.capability)
+				continue
+			}
+			if err := tc.call(); err == nil || err.Error() != errUnsupported(tc.capability).Error() {
+				t.Errorf("%s: expected %s to be unsupported, got %v", profile.Name, tc.capability, err)
+			}
+		}
+		if requests != 0 {
+			t.Errorf("%s: expected unsupported calls not to reach the site, got %d requests", profile.Name, requests)
+		}
+	}
+}
+
+func TestAPIKeyScheme(t *testing.T) {
+	for _, c := range []struct {
+		profile SiteProfile
+		auth    string
+	}{
+		{RedactedProfile, "key"},
+		{OrpheusProfile, "token key"},
+	} {
+		var auth string
+		cl, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			rw.Write([]byte(`{"status":"success","response":{}}`))
+		}, WithSiteProfile(c.profile), WithAPIKey("key"))
+		if _, err := cl.GetTorrent(1, url.Values{}); err != nil {
+			t.Fatal(err)
+		}
+		if auth != c.auth {
+			t.Errorf("%s: expected Authorization %q, got %q", c.profile.Name, c.auth, auth)
+		}
+	}
+}
+
+func TestAjaxURL(t *testing.T) {
+	w := loggedInClient(t, "https://example.com/gazelle/")
+	u, err := w.ajaxURL("torrent", url.Values{"id": {"1"}})
+	if err != nil || u != "https://example.com/gazelle/ajax.php?action=torrent&id=1" {
+		t.Errorf("bad URL %s, %v", u, err)
+	}
+	w = loggedInClient(t, "https://example.com/gazelle/", WithSiteProfile(SiteProfile{Paths: map[string]string{"ajax.php": "/api/ajax.php"}}))
+	u, err = w.ajaxURL("index", nil)
+	if err != nil || u != "https://example.com/api/ajax.php?action=index" {
+		t.Errorf("expected the profile's path, got %s, %v", u, err)
+	}
+}
@@ -0,0 +1,131 @@
+package whatapi
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress is told how a bulk operation, such as SaveTorrents,
+// PrefetchProgress or a crawl, is going, for a frontend to show.
+// Operations call it from several goroutines at once.
+type Progress interface {
+	// Add is called with the number of items the operation has been
+	// given, as it learns of them.
+	Add(items int)
+	// Finish is called as each item is done with, with the bytes it
+	// transferred and its error, if it failed.
+	Finish(bytes int64, err error)
+}
+
+// noProgress is the Progress of an operation nobody is watching.
+type noProgress struct{}
+
+func (noProgress) Add(int)             {}
+func (noProgress) Finish(int64, error) {}
+
+// progressOf returns p, or a Progress that does nothing if p is nil.
+func progressOf(p Progress) Progress {
+	if p == nil {
+		return noProgress{}
+	}
+	return p
+}
+
+// ProgressReport is how far a bulk operation has got.
+type ProgressReport struct {
+	// Total is the number of items the operation has been given so far.
+	Total int
+	// Done and Failed are the items that have succeeded and failed.
+	Done   int
+	Failed int
+	Bytes  int64
+	// Elapsed is the time since the first item was added.
+	Elapsed time.Duration
+	// ETA is how much longer the items left should take, at the rate
+	// items have finished so far, or 0 before any have.
+	ETA time.Duration
+}
+
+// ProgressChan is a Progress that sends a ProgressReport on C each time
+// an item is added or finished. Reports aren't queued: one that hasn't
+// been received yet is replaced by the next, so a slow frontend never
+// holds the operation up.
+type ProgressChan struct {
+	C <-chan ProgressReport
+
+	c      chan ProgressReport
+	mu     sync.Mutex
+	start  time.Time
+	report ProgressReport
+	closed bool
+}
+
+// NewProgressChan returns a ProgressChan with no items.
+func NewProgressChan() *ProgressChan {
+	c := make(chan ProgressReport, 1)
+	return &ProgressChan{C: c, c: c}
+}
+
+func (p *ProgressChan) Add(items int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	p.report.Total += items
+	p.send()
+}
+
+func (p *ProgressChan) Finish(bytes int64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.report.Failed++
+	} else {
+		p.report.Done++
+	}
+	p.report.Bytes += bytes
+	p.send()
+}
+
+// Report returns the latest report.
+func (p *ProgressChan) Report() ProgressReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current()
+}
+
+// Close closes C. Call it once the operation has returned; items reported
+// after are ignored.
+func (p *ProgressChan) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.c)
+	}
+}
+
+func (p *ProgressChan) current() ProgressReport {
+	r := p.report
+	if p.start.IsZero() {
+		return r
+	}
+	r.Elapsed = time.Since(p.start)
+	if finished := r.Done + r.Failed; finished > 0 && r.Total > finished {
+		r.ETA = r.Elapsed / time.Duration(finished) * time.Duration(r.Total-finished)
+	}
+	return r
+}
+
+// send replaces any report not yet received with the current one.
+func (p *ProgressChan) send() {
+	if p.closed {
+		return
+	}
+	select {
+	case <-p.c:
+	default:
+	}
+	p.c <- p.current()
+}
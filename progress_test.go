@@ -0,0 +1,35 @@
+package whatapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProgressChan(t *testing.T) {
+	p := NewProgressChan()
+	p.Add(4)
+	p.Finish(10, nil)
+	p.Finish(0, errors.New("failed"))
+	// reports not yet received are replaced, not queued
+	r := <-p.C
+	if r.Total != 4 || r.Done != 1 || r.Failed != 1 || r.Bytes != 10 {
+		t.Errorf("bad report %+v", r)
+	}
+	select {
+	case r := <-p.C:
+		t.Errorf("expected only the latest report, got another %+v", r)
+	default:
+	}
+	if r.ETA < 0 || r.ETA > 2*r.Elapsed+1 {
+		t.Errorf("expected an ETA of about the time taken so far, got %s after %s", r.ETA, r.Elapsed)
+	}
+	p.Add(1)
+	p.Close()
+	p.Finish(0, nil)
+	if r, ok := <-p.C; !ok || r.Total != 5 {
+		t.Errorf("expected the last report before closing, got %+v", r)
+	}
+	if _, ok := <-p.C; ok {
+		t.Error("expected C closed")
+	}
+}
@@ -0,0 +1,27 @@
+package whatapi
+
+import (
+	"context"
+	"net/url"
+)
+
+// Projected is an ajax.php response with its payload decoded into T. T is
+// a projection: a struct with just the fields a caller needs, tagged as
+// in the full response type, so that the rest of the payload is skipped
+// rather than decoded. Over a crawl of many thousands of responses that
+// saves most of the allocations, and the garbage collection they cause.
+type Projected[T any] struct {
+	Status   string `json:"status"`
+	Error    string `json:"error"`
+	Response T      `json:"response"`
+}
+
+// Project sends the ajax.php action with params through c and decodes
+// only what T declares of the response. See Projected.
+func Project[T any](ctx context.Context, c Client, action string, params url.Values) (T, error) {
+	var r Projected[T]
+	if err := c.DoContext(ctx, action, params, &r); err != nil {
+		return r.Response, err
+	}
+	return r.Response, checkResponseStatus(r.Status, r.Error)
+}
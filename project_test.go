@@ -0,0 +1,53 @@
+package whatapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// torrentSizes projects a browse response down to each torrent's id and
+// size.
+type torrentSizes struct {
+	Pages   int `json:"pages"`
+	Results []struct {
+		Torrents []struct {
+			TorrentID int   `json:"torrentId"`
+			Size      int64 `json:"size"`
+		} `json:"torrents"`
+	} `json:"results"`
+}
+
+func TestProject(t *testing.T) {
+	browse, err := ioutil.ReadFile("testdata/responses/gazelle/browse.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write(browse)
+	})
+	s, err := Project[torrentSizes](context.Background(), c, "browse", url.Values{"searchstr": {"ok"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=browse&searchstr=ok"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if s.Pages != 3 || len(s.Results) == 0 || len(s.Results[0].Torrents) == 0 ||
+		s.Results[0].Torrents[0].TorrentID != 1337 || s.Results[0].Torrents[0].Size != 412345678 {
+		t.Errorf("bad projection %+v", s)
+	}
+}
+
+func TestProjectFailure(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"failure","error":"bad id parameter"}`))
+	})
+	if _, err := Project[torrentSizes](context.Background(), c, "torrent", url.Values{"id": {"0"}}); err == nil {
+		t.Error("expected the failure to be returned")
+	}
+}
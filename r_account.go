@@ -8,8 +8,10 @@ type Account struct {
 	Notifications struct {
 		Messages       int  `json:"messages"`
 		Notifications  int  `json:"notifications"`
-		NewAnnouncment bool `json:"newAnnouncment"`
+		NewAnnouncment bool `json:"newAnnouncement"`
 		NewBlog        bool `json:"newBlog"`
+		// NewSubscriptions is only sent by some sites.
+		NewSubscriptions bool `json:"newSubscriptions"`
 	} `json:"notifications"`
 	UserStats struct {
 		Uploaded      int64   `json:"uploaded"`
@@ -17,5 +19,12 @@ type Account struct {
 		Ratio         float64 `json:"ratio"`
 		RequiredRatio float64 `json:"requiredRatio"`
 		Class         string  `json:"class"`
+		// BonusPoints and BonusPointsPerHour are only sent by sites
+		// with a bonus point system.
+		BonusPoints        int64   `json:"bonusPoints"`
+		BonusPointsPerHour float64 `json:"bonusPointsPerHour"`
+		// FreeleechTokens is only sent by sites that give out
+		// personal freeleech tokens.
+		FreeleechTokens int `json:"freeleechTokens"`
 	} `json:"userstats"`
-}
\ No newline at end of file
+}
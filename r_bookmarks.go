@@ -12,7 +12,7 @@ type TorrentBookmarks struct {
 		RecordLabel     string          `json:"recordLabel"`
 		CatalogueNumber string          `json:"catalogueNumber"`
 		TagList         string          `json:"tagList"`
-		ReleaseType     string          `json:"releastType"`
+		ReleaseType     string          `json:"releaseType"`
 		VanityHouse     bool            `json:"vanityHouse"`
 		Image           string          `json:"image"`
 		Torrents        []TorrentStruct `json:"torrents"`
@@ -0,0 +1,42 @@
+package whatapi
+
+type CollageGroup struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	Year            int       `json:"year"`
+	CategoryID      int       `json:"categoryId"`
+	RecordLabel     string    `json:"recordLabel"`
+	CatalogueNumber string    `json:"catalogueNumber"`
+	VanityHouse     bool      `json:"vanityHouse"`
+	TagList         string    `json:"tagList"`
+	ReleaseType     int       `json:"releaseType"`
+	WikiImage       string    `json:"wikiImage"`
+	MusicInfo       MusicInfo `json:"musicInfo"`
+}
+
+// CollageArtist is an artist in an artists collage.
+type CollageArtist struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type Collage struct {
+	ID                  int            `json:"id"`
+	Name                string         `json:"name"`
+	Description         string         `json:"description"`
+	CreatorID           int            `json:"creatorID"`
+	Deleted             bool           `json:"deleted"`
+	CollageCategoryID   int            `json:"collageCategoryID"`
+	CollageCategoryName string         `json:"collageCategoryName"`
+	Locked              bool           `json:"locked"`
+	MaxGroups           int            `json:"maxGroups"`
+	MaxGroupsPerUser    int            `json:"maxGroupsPerUser"`
+	HasBookmarked       bool           `json:"hasBookmarked"`
+	SubscriberCount     int            `json:"subscriberCount"`
+	TorrentGroupIDList  []int          `json:"torrentGroupIDList"`
+	TorrentGroups       []CollageGroup `json:"torrentgroups"`
+	// Artists are the entries of an artists collage, which has them
+	// instead of torrent groups.
+	Artists []CollageArtist `json:"artists"`
+}
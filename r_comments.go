@@ -0,0 +1,47 @@
+package whatapi
+
+type Comment struct {
+	PostID         int    `json:"postId"`
+	AddedTime      string `json:"addedTime"`
+	BbBody         string `json:"bbBody"`
+	Body           string `json:"body"`
+	EditedUserID   int    `json:"editedUserId"`
+	EditedTime     string `json:"editedTime"`
+	EditedUsername string `json:"editedUsername"`
+	UserInfo       struct {
+		AuthorID   int    `json:"authorId"`
+		AuthorName string `json:"authorName"`
+		Artist     bool   `json:"artist"`
+		Donor      bool   `json:"donor"`
+		Warned     bool   `json:"warned"`
+		Avatar     string `json:"avatar"`
+		Enabled    bool   `json:"enabled"`
+		UserTitle  string `json:"userTitle"`
+	} `json:"userinfo"`
+}
+
+type Comments struct {
+	Page     int       `json:"page"`
+	Pages    int       `json:"pages"`
+	Comments []Comment `json:"comments"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (c Comments) PageNumber() int {
+	return c.Page
+}
+
+// PageCount returns the number of pages.
+func (c Comments) PageCount() int {
+	return c.Pages
+}
+
+// CommentPage is the kind of page a comment is posted on.
+type CommentPage string
+
+const (
+	CommentPageTorrents CommentPage = "torrents"
+	CommentPageArtist   CommentPage = "artist"
+	CommentPageCollages CommentPage = "collages"
+	CommentPageRequests CommentPage = "requests"
+)
@@ -43,13 +43,23 @@ type Forum struct {
 		LastID         int    `json:"lastID"`
 		LastTime       string `json:"lastTime"`
 		LastAuthorId   int    `json:"lastAuthorId"`
-		LastAuthorName string `json:"lastAuthorNam"`
+		LastAuthorName string `json:"lastAuthorName"`
 		LastReadPage   int    `json:"lastReadPage"`
 		LastReadPostID int    `json:"lastReadPostId"`
 		Read           bool   `json:"read"`
 	} `json:"threads"`
 }
 
+// PageNumber returns the number of this page, from 1.
+func (f Forum) PageNumber() int {
+	return f.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (f Forum) PageCount() int {
+	return f.Pages
+}
+
 type Thread struct {
 	ForumID     int `json:"forumId"`
 	ForumName   string `json:"forumName"`
@@ -95,6 +105,16 @@ type Thread struct {
 	} `json:"posts"`
 }
 
+// PageNumber returns the number of this page, from 1.
+func (t Thread) PageNumber() int {
+	return t.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (t Thread) PageCount() int {
+	return t.Pages
+}
+
 type Subscriptions struct {
 	Threads []struct {
 		ForumID     int    `json:"forumId"`
@@ -32,3 +32,13 @@ type Mailbox struct {
 		Date          string `json:"date"`
 	} `json:"messages"`
 }
+
+// PageNumber returns the number of this page, from 1.
+func (m Mailbox) PageNumber() int {
+	return m.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (m Mailbox) PageCount() int {
+	return m.Pages
+}
@@ -1,33 +1,99 @@
 package whatapi
 
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// timeLayout is the format Gazelle uses for times in its responses.
+const timeLayout = "2006-01-02 15:04:05"
+
+type NotificationTorrent struct {
+	TorrentID        int         `json:"torrentId"`
+	GroupID          int         `json:"groupId"`
+	GroupName        string      `json:"groupName"`
+	GroupCategoryID  int         `json:"groupCategoryId"`
+	WikiImage        string      `json:"wikiImage"`
+	TorrentTags      string      `json:"torrentTags"`
+	Size             int64       `json:"size"`
+	FileCount        int         `json:"filecount"`
+	Format           string      `json:"format"`
+	Encoding         string      `json:"encoding"`
+	Media            string      `json:"media"`
+	Scene            bool        `json:"scene"`
+	GroupYear        int         `json:"groupYear"`
+	RemasterYear     int         `json:"remasterYear"`
+	RemasterTitle    string      `json:"remasterTitle"`
+	Snatched         int         `json:"snatched"`
+	Seeders          int         `json:"seeders"`
+	Leechers         int         `json:"leechers"`
+	NotificationTime string      `json:"notificationTime"`
+	HasLog           bool        `json:"hasLog"`
+	HasCue           bool        `json:"hasCue"`
+	LogScore         int         `json:"logScore"`
+	LeechStatus      LeechStatus `json:"freeTorrent"`
+	FreeTorrent      bool        `json:"-"`
+	LogInDB          bool        `json:"logInDb"`
+	Unread           bool        `json:"unread"`
+}
+
+// UnmarshalJSON decodes the notification. freeTorrent is decoded as the
+// LeechStatus, the kind of freeleech the torrent is, and FreeTorrent set
+// to whether it is any kind.
+func (n *NotificationTorrent) UnmarshalJSON(b []byte) error {
+	type plain NotificationTorrent
+	if err := json.Unmarshal(b, (*plain)(n)); err != nil {
+		return err
+	}
+	n.FreeTorrent = n.LeechStatus.Free()
+	return nil
+}
+
+// Category returns the category of the torrent's group.
+func (n NotificationTorrent) Category() Category {
+	return Category(n.GroupCategoryID)
+}
+
+// Tags returns the torrent's tags, which the site sends space separated.
+func (n NotificationTorrent) Tags() []string {
+	return strings.Fields(n.TorrentTags)
+}
+
+// Time returns when the notification was raised.
+func (n NotificationTorrent) Time() (time.Time, error) {
+	return time.Parse(timeLayout, n.NotificationTime)
+}
+
+// FormatEncoding returns the format and encoding, as in "FLAC Lossless".
+func (n NotificationTorrent) FormatEncoding() string {
+	return strings.TrimSpace(n.Format + " " + n.Encoding)
+}
+
 type Notifications struct {
-	CurrentPages int `json:"currentPages"`
-	Pages        int `json:"pages"`
-	NumNew       int `json:"numNew"`
-	Results      []struct {
-		TorrentID        int    `json:"torrentId"`
-		GroupID          int    `json:"groupId"`
-		GroupName        string `json:"groupName"`
-		GroupCategoryID  int    `json:"groupCategoryId"`
-		TorrentTags      string `json:"torrentTags"`
-		Size             int64  `json:"size"`
-		FileCount        int    `json:"filecount"`
-		Format           string `json:"format"`
-		Encoding         string `json:"encoding"`
-		Media            string `json:"mdia"`
-		Scene            bool   `json:"scene"`
-		GroupYear        int    `json:"groupYear"`
-		RemasterYear     int    `json:"remasterYear"`
-		RemasterTitle    string `json:"remasterTitle"`
-		Snatched         int    `json:"snatched"`
-		Seeders          int    `json:"seeders"`
-		Leechers         int    `json:"leechers"`
-		NotificationTime string `json:"notificationTime"`
-		HasLog           bool   `json:"hasLog"`
-		HasCue           bool   `json:"hasCue"`
-		LogScore         int    `json:"logScore"`
-		FreeTorrent      bool   `json:"freeTorrent"`
-		LogInDB          bool   `json:"logInDb"`
-		Unread           bool   `json:"unread"`
-	} `json:"results"`
-}
\ No newline at end of file
+	CurrentPages int                   `json:"currentPages"`
+	Pages        int                   `json:"pages"`
+	NumNew       int                   `json:"numNew"`
+	Results      []NotificationTorrent `json:"results"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (n Notifications) PageNumber() int {
+	return n.CurrentPages
+}
+
+// PageCount returns the number of pages.
+func (n Notifications) PageCount() int {
+	return n.Pages
+}
+
+// Unread returns the results that haven't been read.
+func (n Notifications) Unread() []NotificationTorrent {
+	r := []NotificationTorrent{}
+	for _, t := range n.Results {
+		if t.Unread {
+			r = append(r, t)
+		}
+	}
+	return r
+}
@@ -1,7 +1,11 @@
 package whatapi
 
 import (
+	"encoding/json"
 	"html"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ArtistID struct {
@@ -9,36 +13,48 @@ type ArtistID struct {
 	Name string `json:"name"`
 }
 
+type RequestsSearchResult struct {
+	RequestID       int          `json:"requestId"`
+	RequestorID     int          `json:"requestorId"`
+	ReqyestorName   string       `json:"requestorName"`
+	TimeAdded       string       `json:"timeAdded"`
+	LastVote        string       `json:"lastVote"`
+	VoteCount       int          `json:"voteCount"`
+	Bounty          int64        `json:"bounty"`
+	CategoryID      int          `json:"categoryId"`
+	CategoryName    string       `json:"categoryName"`
+	Artists         [][]ArtistID `json:"artists"`
+	Title           string       `json:"title"`
+	Year            int          `json:"year"`
+	Image           string       `json:"image"`
+	Description     string       `json:"description"`
+	CatalogueNumber string       `json:"catalogueNumber"`
+	ReleaseType     string       `json:"releaseType"`
+	BitrateList     string       `json:"bitrateList"`
+	FormatList      string       `json:"formatList"`
+	MediaList       string       `json:"mediaList"`
+	LogCue          string       `json:"logCue"`
+	IsFilled        bool         `json:"isFilled"`
+	FillerID        int          `json:"fillerId"`
+	FillerName      string       `json:"fillerName"`
+	TorrentID       int          `json:"torrentId"`
+	TimeFilled      string       `json:"timeFilled"`
+}
+
 type RequestsSearch struct {
-	CurrentPage int `json:"currentPage"`
-	Pages       int `json:"pages"`
-	Results     []struct {
-		RequestID       int          `json:"requestId"`
-		RequestorID     int          `json:"requestorId"`
-		ReqyestorName   string       `json:"requestorName"`
-		TimeAdded       string       `json:"timeAdded"`
-		LastVote        string       `json:"lastVote"`
-		VoteCount       int          `json:"voteCount"`
-		Bounty          int64        `json:"bounty"`
-		CategoryID      int          `json:"categoryId"`
-		CategoryName    string       `json:"categoryName"`
-		Artists         [][]ArtistID `json:"artists"`
-		Title           string       `json:"title"`
-		Year            int          `json:"year"`
-		Image           string       `json:"image"`
-		Description     string       `json:"description"`
-		CatalogueNumber string       `json:"catalogueNumber"`
-		ReleaseType     string       `json:"releaseType"`
-		BitrateList     string       `json:"bitrateList"`
-		FormatList      string       `json:"formatList"`
-		MediaList       string       `json:"mediaList"`
-		LogCue          string       `json:"logCue"`
-		IsFilled        bool         `json:"isFilled"`
-		FillerID        int          `json:"fillerId"`
-		FillerName      string       `json:"fillerName"`
-		TorrentID       int          `json:"torrentId"`
-		TimeFilled      string       `json:"timeFilled"`
-	} `json:"results"`
+	CurrentPage int                    `json:"currentPage"`
+	Pages       int                    `json:"pages"`
+	Results     []RequestsSearchResult `json:"results"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (s RequestsSearch) PageNumber() int {
+	return s.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (s RequestsSearch) PageCount() int {
+	return s.Pages
 }
 
 type SearchTorrentStruct struct {
@@ -109,6 +125,14 @@ func (ts SearchTorrentStruct) HasLog() bool {
 	return ts.HasLogF
 }
 
+func (ts SearchTorrentStruct) HasCueSheet() bool {
+	return ts.HasCue
+}
+
+func (ts SearchTorrentStruct) RipLogScore() int {
+	return ts.LogScore
+}
+
 func (ts SearchTorrentStruct) String() string {
 	return TorrentString(ts)
 }
@@ -121,6 +145,47 @@ func (ts SearchTorrentStruct) FileSize() int64 {
 	return ts.Size
 }
 
+func (ts SearchTorrentStruct) LeechStatus() LeechStatus {
+	switch {
+	case ts.IsFreeleech:
+		return LeechFree
+	case ts.IsNeutralLeech:
+		return LeechNeutral
+	case ts.IsPersonalFreeleech:
+		return LeechPersonalFree
+	}
+	return LeechNormal
+}
+
+func (ts SearchTorrentStruct) SeederCount() int {
+	return ts.Seeders
+}
+
+func (ts SearchTorrentStruct) LeecherCount() int {
+	return ts.Leechers
+}
+
+func (ts SearchTorrentStruct) SnatchCount() int {
+	return ts.Snatches
+}
+
+// IsReported is never known, searches don't say which torrents are
+// reported.
+func (ts SearchTorrentStruct) IsReported() (reported, known bool) {
+	return false, false
+}
+
+// IsTrumpable is never known, searches don't say which torrents are
+// trumpable.
+func (ts SearchTorrentStruct) IsTrumpable() (trumpable, known bool) {
+	return false, false
+}
+
+// UploadTime returns when the torrent was uploaded.
+func (ts SearchTorrentStruct) UploadTime() (time.Time, error) {
+	return time.Parse(timeLayout, ts.Time)
+}
+
 type TorrentSearchResultStruct struct {
 	GroupID       int                   `json:"groupId"`
 	GroupName     string                `json:"groupName"`
@@ -129,12 +194,53 @@ type TorrentSearchResultStruct struct {
 	Bookmarked    bool                  `json:"bookmarked"`
 	VanityHouse   bool                  `json:"vanityHouse"`
 	GroupYear     int                   `json:"groupYear"`
-	ReleaseTypeF  int                   `json:"releasetType,string"`
+	ReleaseTypeF  int                   `json:"releaseType"`
 	GroupTime     string                `json:"groupTime"`
 	TotalSnatched int                   `json:"totalSnatched"`
 	TotalSeeders  int                   `json:"totalSeeders"`
 	TotalLeechers int                   `json:"totalLeechers"`
+	MaxSize       int64                 `json:"maxSize"`
 	Torrents      []SearchTorrentStruct `json:"torrents"`
+	// Results outside the music category are not grouped, and carry
+	// their single torrent's fields on the result itself.
+	TorrentID           int    `json:"torrentId"`
+	Category            string `json:"category"`
+	FileCountF          int    `json:"fileCount"`
+	Size                int64  `json:"size"`
+	Snatches            int    `json:"snatches"`
+	Seeders             int    `json:"seeders"`
+	Leechers            int    `json:"leechers"`
+	IsFreeleech         bool   `json:"isFreeleech"`
+	IsNeutralLeech      bool   `json:"isNeutralLeech"`
+	IsPersonalFreeleech bool   `json:"isPersonalFreeleech"`
+	CanUseToken         bool   `json:"canUseToken"`
+}
+
+// Grouped reports whether the result is a group with a torrents list, as
+// music results are, rather than a single ungrouped torrent.
+func (ts TorrentSearchResultStruct) Grouped() bool {
+	return ts.TorrentID == 0 || len(ts.Torrents) > 0
+}
+
+// TorrentList returns the result's torrents. For an ungrouped result that
+// is its single torrent, built from the fields on the result.
+func (ts TorrentSearchResultStruct) TorrentList() []SearchTorrentStruct {
+	if ts.Grouped() {
+		return ts.Torrents
+	}
+	return []SearchTorrentStruct{{
+		TorrentID:           ts.TorrentID,
+		FileCountF:          ts.FileCountF,
+		Time:                ts.GroupTime,
+		Size:                ts.Size,
+		Snatches:            ts.Snatches,
+		Seeders:             ts.Seeders,
+		Leechers:            ts.Leechers,
+		IsFreeleech:         ts.IsFreeleech,
+		IsNeutralLeech:      ts.IsNeutralLeech,
+		IsPersonalFreeleech: ts.IsPersonalFreeleech,
+		CanUseToken:         ts.CanUseToken,
+	}}
 }
 
 func (ts TorrentSearchResultStruct) ID() int {
@@ -157,11 +263,64 @@ func (ts TorrentSearchResultStruct) ReleaseType() int {
 	return ts.ReleaseTypeF
 }
 
+// UnmarshalJSON decodes the result, taking its release type as a number,
+// as some sites send it, or as the release type's name, as others do. A
+// name ReleaseTypeString doesn't know decodes as 0.
+func (ts *TorrentSearchResultStruct) UnmarshalJSON(b []byte) error {
+	type plain TorrentSearchResultStruct
+	v := struct {
+		*plain
+		ReleaseType json.RawMessage `json:"releaseType"`
+	}{plain: (*plain)(ts)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	ts.ReleaseTypeF = 0
+	if len(v.ReleaseType) == 0 || string(v.ReleaseType) == "null" {
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(v.ReleaseType, &name); err != nil {
+		return json.Unmarshal(v.ReleaseType, &ts.ReleaseTypeF)
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(name)); err == nil {
+		ts.ReleaseTypeF = n
+		return nil
+	}
+	for r := 1; r <= maxReleaseType; r++ {
+		if strings.EqualFold(ReleaseTypeString(r), name) {
+			ts.ReleaseTypeF = r
+			break
+		}
+	}
+	return nil
+}
+
 func (ts TorrentSearchResultStruct) Tags() []string {
 	return ts.TagsF
 }
 
+// ResultCategory returns the result's category. Only ungrouped results
+// name their category, in the Category field; grouped results are all
+// music.
+func (ts TorrentSearchResultStruct) ResultCategory() Category {
+	if ts.Grouped() {
+		return CategoryMusic
+	}
+	c, _ := ParseCategory(ts.Category)
+	return c
+}
+
+// IsMusic reports whether the result is in the music category, and so
+// has an artist, year and release type.
+func (ts TorrentSearchResultStruct) IsMusic() bool {
+	return ts.ResultCategory() == CategoryMusic
+}
+
 func (ts TorrentSearchResultStruct) String() string {
+	if !ts.IsMusic() {
+		return ts.Name()
+	}
 	return GroupString(ts)
 }
 
@@ -171,15 +330,56 @@ type TorrentSearch struct {
 	Results     []TorrentSearchResultStruct `json:"results"`
 }
 
+// PageNumber returns the number of this page, from 1.
+func (s TorrentSearch) PageNumber() int {
+	return s.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (s TorrentSearch) PageCount() int {
+	return s.Pages
+}
+
+type UserSearchResult struct {
+	UserID   int    `json:"userId"`
+	Username string `json:"username"`
+	Donor    bool   `json:"donor"`
+	Warned   bool   `json:"warned"`
+	Enabled  bool   `json:"enabled"`
+	Class    string `json:"class"`
+}
+
 type UserSearch struct {
-	CurrentPage int `json:"currentPage"`
-	Pages       int `json:"pages"`
-	Results     []struct {
-		UserID   int    `json:"userId"`
-		Username string `json:"username"`
-		Donor    bool   `json:"donor"`
-		Warned   bool   `json:"warned"`
-		Enabled  bool   `json:"enabled"`
-		Class    string `json:"class"`
-	} `json:"results"`
+	CurrentPage int                `json:"currentPage"`
+	Pages       int                `json:"pages"`
+	Results     []UserSearchResult `json:"results"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (s UserSearch) PageNumber() int {
+	return s.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (s UserSearch) PageCount() int {
+	return s.Pages
+}
+
+// SearchPair is a torrent from a torrent search with the result it came
+// from.
+type SearchPair struct {
+	Group   TorrentSearchResultStruct
+	Torrent SearchTorrentStruct
+}
+
+// Flatten returns every torrent in the search, grouped or not, paired with
+// its result, in the order the site returned them.
+func (s TorrentSearch) Flatten() []SearchPair {
+	pairs := []SearchPair{}
+	for _, g := range s.Results {
+		for _, t := range g.TorrentList() {
+			pairs = append(pairs, SearchPair{Group: g, Torrent: t})
+		}
+	}
+	return pairs
 }
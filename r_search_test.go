@@ -0,0 +1,67 @@
+package whatapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestFlatten(t *testing.T) {
+	var s whatapi.TorrentSearch
+	err := json.Unmarshal([]byte(`{"results":[
+{"groupId":1,"groupName":"Album","torrents":[{"torrentId":10},{"torrentId":11}]},
+{"groupId":2,"groupName":"Book","torrentId":20,"category":"E-Books","size":1234}
+]}`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := s.Flatten()
+	exp := []struct{ group, torrent int }{{1, 10}, {1, 11}, {2, 20}}
+	if len(pairs) != len(exp) {
+		t.Fatalf("expected %d pairs, got %d", len(exp), len(pairs))
+	}
+	for i, e := range exp {
+		if pairs[i].Group.ID() != e.group || pairs[i].Torrent.ID() != e.torrent {
+			t.Errorf("expected pair %d to be %v, got group %d torrent %d",
+				i, e, pairs[i].Group.ID(), pairs[i].Torrent.ID())
+		}
+	}
+	if !s.Results[0].Grouped() || s.Results[1].Grouped() {
+		t.Errorf("expected only the first result to be grouped")
+	}
+	if pairs[2].Torrent.FileSize() != 1234 {
+		t.Errorf("expected ungrouped torrent size 1234, got %d", pairs[2].Torrent.FileSize())
+	}
+}
+
+func TestPaginated(t *testing.T) {
+	for _, p := range []whatapi.Paginated{
+		&whatapi.TorrentSearch{}, &whatapi.RequestsSearch{},
+		&whatapi.UserSearch{}, &whatapi.Forum{}, &whatapi.Thread{},
+		&whatapi.Mailbox{}, &whatapi.Notifications{},
+		&whatapi.TorrentSnatchers{}, &whatapi.TorrentPeers{},
+		&whatapi.Comments{}, &whatapi.UserPosts{}, &whatapi.UserComments{},
+		&whatapi.Reports{},
+	} {
+		if err := json.Unmarshal([]byte(`{"currentPage":2,"pages":5,"currentPages":2,"page":2}`), p); err != nil {
+			t.Fatal(err)
+		}
+		if p.PageNumber() != 2 || p.PageCount() != 5 {
+			t.Errorf("%T: expected page 2 of 5, got %d of %d", p, p.PageNumber(), p.PageCount())
+		}
+	}
+}
+
+func TestSearchResultReleaseType(t *testing.T) {
+	for in, exp := range map[string]int{`1`: 1, `"5"`: 5, `"Album"`: 1, `"Compilation"`: 7, `"Nonsense"`: 0, `null`: 0} {
+		var r whatapi.TorrentSearchResultStruct
+		if err := json.Unmarshal([]byte(`{"groupId":1,"releaseType":`+in+`}`), &r); err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if r.ReleaseType() != exp || r.ID() != 1 {
+			t.Errorf("%s: expected release type %d, got %d", in, exp, r.ReleaseType())
+		}
+	}
+}
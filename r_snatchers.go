@@ -0,0 +1,47 @@
+package whatapi
+
+type TorrentSnatchers struct {
+	CurrentPage int `json:"currentPage"`
+	Pages       int `json:"pages"`
+	Snatchers   []struct {
+		UserID   int    `json:"userId"`
+		Username string `json:"username"`
+		Time     string `json:"time"`
+		Seeding  bool   `json:"seeding"`
+	} `json:"snatchers"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (s TorrentSnatchers) PageNumber() int {
+	return s.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (s TorrentSnatchers) PageCount() int {
+	return s.Pages
+}
+
+type TorrentPeers struct {
+	CurrentPage int `json:"currentPage"`
+	Pages       int `json:"pages"`
+	Peers       []struct {
+		UserID      int     `json:"userId"`
+		Username    string  `json:"username"`
+		Seeding     bool    `json:"seeding"`
+		Connectable bool    `json:"connectable"`
+		Uploaded    int64   `json:"uploaded"`
+		Downloaded  int64   `json:"downloaded"`
+		Percent     float64 `json:"percent"`
+		Client      string  `json:"client"`
+	} `json:"peers"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (p TorrentPeers) PageNumber() int {
+	return p.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (p TorrentPeers) PageCount() int {
+	return p.Pages
+}
@@ -1,6 +1,9 @@
 package whatapi
 
-import "html"
+import (
+	"html"
+	"strings"
+)
 
 type TopTenTags []struct {
 	Caption string `json:"caption"`
@@ -72,3 +75,8 @@ type TopTenUsers []struct {
 		JoinDate   string  `json:"joinDate"`
 	} `json:"results"`
 }
+
+// FormatEncoding returns the format and encoding, as in "FLAC Lossless".
+func (r TopTenResult) FormatEncoding() string {
+	return strings.TrimSpace(r.Format + " " + r.Encoding)
+}
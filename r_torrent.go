@@ -1,11 +1,13 @@
 package whatapi
 
 import (
+	"encoding/json"
 	"fmt"
 	"html"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type GetTorrentStruct struct {
@@ -28,6 +30,45 @@ type MusicInfo struct {
 	Producer  []MusicInfoStruct `json:"producer"`
 }
 
+// Role is the part an artist has on a release, numbered as the site
+// numbers them, and as GroupExt.Importance does.
+type Role int
+
+// The roles an artist can have on a release.
+const (
+	RoleMain Role = iota + 1
+	RoleGuest
+	RoleRemixer
+	RoleComposer
+	RoleConductor
+	RoleDJ
+	RoleProducer
+)
+
+// Roles are all the roles, in the site's order.
+var Roles = []Role{RoleMain, RoleGuest, RoleRemixer, RoleComposer, RoleConductor, RoleDJ, RoleProducer}
+
+// Credited returns the artists credited in role r.
+func (m MusicInfo) Credited(r Role) []MusicInfoStruct {
+	switch r {
+	case RoleMain:
+		return m.Artists
+	case RoleGuest:
+		return m.With
+	case RoleRemixer:
+		return m.RemixedBy
+	case RoleComposer:
+		return m.Composers
+	case RoleConductor:
+		return m.Conductor
+	case RoleDJ:
+		return m.DJ
+	case RoleProducer:
+		return m.Producer
+	}
+	return nil
+}
+
 type GroupStruct struct {
 	WikiBodyF        string    `json:"wikiBody"`
 	WikiImageF       string    `json:"wikiImage"`
@@ -158,6 +199,48 @@ func (g GroupStruct) Importance() []int {
 	return g.importance
 }
 
+func (g GroupStruct) MainArtists() []MusicInfoStruct {
+	return g.MusicInfo.Artists
+}
+
+func (g GroupStruct) With() []MusicInfoStruct {
+	return g.MusicInfo.With
+}
+
+func (g GroupStruct) RemixedBy() []MusicInfoStruct {
+	return g.MusicInfo.RemixedBy
+}
+
+func (g GroupStruct) Composers() []MusicInfoStruct {
+	return g.MusicInfo.Composers
+}
+
+func (g GroupStruct) Conductors() []MusicInfoStruct {
+	return g.MusicInfo.Conductor
+}
+
+func (g GroupStruct) DJs() []MusicInfoStruct {
+	return g.MusicInfo.DJ
+}
+
+func (g GroupStruct) Producers() []MusicInfoStruct {
+	return g.MusicInfo.Producer
+}
+
+// CreditedArtists returns the artists credited in roles, role by role in
+// the order given, or in every role, in the site's order, if none are
+// given.
+func (g GroupStruct) CreditedArtists(roles ...Role) []MusicInfoStruct {
+	if len(roles) == 0 {
+		roles = Roles
+	}
+	r := []MusicInfoStruct{}
+	for _, role := range roles {
+		r = append(r, g.MusicInfo.Credited(role)...)
+	}
+	return r
+}
+
 func (g GroupStruct) RecordLabel() string {
 	return g.RecordLabelF
 }
@@ -343,13 +426,58 @@ type ArtistTorrentStruct struct {
 	HasCue               bool   `json:"hasCue"`
 	LogScore             int    `json:"logScore"`
 	FileCountF           int    `json:"fileCount"`
-	FreeTorrent          bool   `json:"freeTorrent"`
-	Size                 int64  `json:"size"`
-	Leechers             int    `json:"leechers"`
-	Seeders              int    `json:"seeders"`
-	Snatched             int    `json:"snatched"`
-	Time                 string `json:"time"`
-	HasFile              int    `json:"hasFile"`
+	// FreeTorrent is whether LeechStatusF is any kind of freeleech.
+	LeechStatusF LeechStatus `json:"freeTorrent"`
+	FreeTorrent  bool        `json:"-"`
+	Size         int64       `json:"size"`
+	Leechers     int         `json:"leechers"`
+	Seeders      int         `json:"seeders"`
+	Snatched     int         `json:"snatched"`
+	Time         string      `json:"time"`
+	HasFile      int         `json:"hasFile"`
+}
+
+// UnmarshalJSON decodes the torrent and sets FreeTorrent.
+func (t *ArtistTorrentStruct) UnmarshalJSON(b []byte) error {
+	type plain ArtistTorrentStruct
+	if err := json.Unmarshal(b, (*plain)(t)); err != nil {
+		return err
+	}
+	t.FreeTorrent = t.LeechStatus().Free()
+	return nil
+}
+
+func (t ArtistTorrentStruct) LeechStatus() LeechStatus {
+	return t.LeechStatusF
+}
+
+func (t ArtistTorrentStruct) SeederCount() int {
+	return t.Seeders
+}
+
+func (t ArtistTorrentStruct) LeecherCount() int {
+	return t.Leechers
+}
+
+func (t ArtistTorrentStruct) SnatchCount() int {
+	return t.Snatched
+}
+
+// IsReported is never known, artist pages don't say which torrents are
+// reported.
+func (t ArtistTorrentStruct) IsReported() (reported, known bool) {
+	return false, false
+}
+
+// IsTrumpable is never known, artist pages don't say which torrents are
+// trumpable.
+func (t ArtistTorrentStruct) IsTrumpable() (trumpable, known bool) {
+	return false, false
+}
+
+// UploadTime returns when the torrent was uploaded.
+func (t ArtistTorrentStruct) UploadTime() (time.Time, error) {
+	return time.Parse(timeLayout, t.Time)
 }
 
 func (t ArtistTorrentStruct) ID() int {
@@ -396,6 +524,12 @@ func (t ArtistTorrentStruct) HasLog() bool {
 func (t ArtistTorrentStruct) String() string {
 	return TorrentString(t)
 }
+func (t ArtistTorrentStruct) HasCueSheet() bool {
+	return t.HasCue
+}
+func (t ArtistTorrentStruct) RipLogScore() int {
+	return t.LogScore
+}
 func (t ArtistTorrentStruct) FileCount() int {
 	return t.FileCountF
 }
@@ -418,20 +552,95 @@ type TorrentStruct struct {
 	HasLogF                  bool   `json:"hasLog"`
 	HasCue                   bool   `json:"hasCue"`
 	LogScore                 int    `json:"logScore"`
+	LogChecksumF             bool   `json:"logChecksum"`
 	FileCountF               int    `json:"fileCount"`
 	Size                     int64  `json:"size"`
 	Seeders                  int    `json:"seeders"`
 	Leechers                 int    `json:"leechers"`
 	Snatched                 int    `json:"snatched"`
-	FreeTorrent              bool   `json:"freeTorrent"`
-	Reported                 bool   `json:"reported"`
-	Time                     string `json:"time"`
-	DescriptionF             string `json:"description"`
-	FileList                 string `json:"fileList"`
-	FilePathF                string `json:"filePath"`
-	UserID                   int    `json:"userID"`
-	Username                 string `json:"username"`
-	files                    []FileStruct
+	// LeechStatusF is freeTorrent as the site sent it, which on some
+	// sites leaves neutral leech to IsNeutralLeech; LeechStatus accounts
+	// for both, and FreeTorrent is whether it is any kind of freeleech.
+	LeechStatusF LeechStatus `json:"freeTorrent"`
+	FreeTorrent  bool        `json:"-"`
+	// IsNeutralLeech is sent by sites whose freeTorrent is a bool.
+	IsNeutralLeech bool `json:"isNeutralLeech"`
+	Reported       bool `json:"reported"`
+	// Trumpable is only sent by some sites, and is nil on others.
+	Trumpable    *bool  `json:"trumpable"`
+	Time         string `json:"time"`
+	DescriptionF string `json:"description"`
+	FileList     string `json:"fileList"`
+	FilePathF    string `json:"filePath"`
+	UserID       int    `json:"userID"`
+	Username     string `json:"username"`
+	// RipLogIDs are the ids of the torrent's rip logs, sent by sites
+	// with the CapRipLog capability.
+	RipLogIDs []int `json:"ripLogIds"`
+	files     FileList
+}
+
+func (t TorrentStruct) HasCueSheet() bool {
+	return t.HasCue
+}
+
+func (t TorrentStruct) RipLogScore() int {
+	return t.LogScore
+}
+
+// LogChecksum reports whether the rip logs' checksums are intact.
+func (t TorrentStruct) LogChecksum() bool {
+	return t.LogChecksumF
+}
+
+// UnmarshalJSON decodes the torrent and sets FreeTorrent from
+// LeechStatus.
+func (t *TorrentStruct) UnmarshalJSON(b []byte) error {
+	type plain TorrentStruct
+	if err := json.Unmarshal(b, (*plain)(t)); err != nil {
+		return err
+	}
+	t.FreeTorrent = t.LeechStatus().Free()
+	return nil
+}
+
+func (t TorrentStruct) LeechStatus() LeechStatus {
+	if t.LeechStatusF == LeechNormal && t.IsNeutralLeech {
+		return LeechNeutral
+	}
+	return t.LeechStatusF
+}
+
+func (t TorrentStruct) SeederCount() int {
+	return t.Seeders
+}
+
+func (t TorrentStruct) LeecherCount() int {
+	return t.Leechers
+}
+
+func (t TorrentStruct) SnatchCount() int {
+	return t.Snatched
+}
+
+// IsReported reports whether the torrent has been reported. It is always
+// known.
+func (t TorrentStruct) IsReported() (reported, known bool) {
+	return t.Reported, true
+}
+
+// IsTrumpable reports whether the site has marked the torrent as one a
+// better rip may replace. It is only known on sites that mark torrents.
+func (t TorrentStruct) IsTrumpable() (trumpable, known bool) {
+	if t.Trumpable == nil {
+		return false, false
+	}
+	return *t.Trumpable, true
+}
+
+// UploadTime returns when the torrent was uploaded.
+func (t TorrentStruct) UploadTime() (time.Time, error) {
+	return time.Parse(timeLayout, t.Time)
 }
 
 func (t TorrentStruct) ID() int {
@@ -490,7 +699,7 @@ func (t TorrentStruct) FileCount() int {
 func (t TorrentStruct) FileSize() int64 {
 	return t.Size
 }
-func (t *TorrentStruct) Files() ([]FileStruct, error) {
+func (t *TorrentStruct) Files() (FileList, error) {
 	if t.files != nil {
 		return t.files, nil
 	}
@@ -528,6 +737,9 @@ func (t TorrentStruct) ParseFileList() ([]FileStruct, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not parse %s: %s", s, err)
 		}
+		if i < 0 {
+			return nil, fmt.Errorf("could not parse %s: negative size", s)
+		}
 		f = append(f, FileStruct{m[1], i})
 	}
 	return f, nil
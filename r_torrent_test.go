@@ -1,6 +1,7 @@
 package whatapi_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/charles-haynes/whatapi"
@@ -64,3 +65,81 @@ func TestFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestTorrentStats(t *testing.T) {
+	yes := true
+	for _, s := range []whatapi.TorrentStats{
+		whatapi.TorrentStruct{Seeders: 3, Trumpable: &yes, Time: "2016-11-22 19:20:21"},
+		whatapi.ArtistTorrentStruct{Seeders: 3, Time: "2016-11-22 19:20:21"},
+		whatapi.SearchTorrentStruct{Seeders: 3, Time: "2016-11-22 19:20:21"},
+	} {
+		if s.SeederCount() != 3 {
+			t.Errorf("%T: expected 3 seeders, got %d", s, s.SeederCount())
+		}
+		if tm, err := s.UploadTime(); err != nil || tm.Year() != 2016 {
+			t.Errorf("%T: expected a 2016 upload time, got %v, %v", s, tm, err)
+		}
+	}
+}
+
+func TestTorrentFlagsKnown(t *testing.T) {
+	var ts whatapi.TorrentStruct
+	if err := json.Unmarshal([]byte(`{"reported":true}`), &ts); err != nil {
+		t.Fatal(err)
+	}
+	if r, known := ts.IsReported(); !r || !known {
+		t.Errorf("expected a known report, got %v %v", r, known)
+	}
+	if _, known := ts.IsTrumpable(); known {
+		t.Error("expected trumpable to be unknown when the site doesn't send it")
+	}
+	if err := json.Unmarshal([]byte(`{"trumpable":false}`), &ts); err != nil {
+		t.Fatal(err)
+	}
+	if tr, known := ts.IsTrumpable(); tr || !known {
+		t.Errorf("expected a known untrumpable torrent, got %v %v", tr, known)
+	}
+	for _, s := range []whatapi.TorrentStats{whatapi.ArtistTorrentStruct{}, whatapi.SearchTorrentStruct{}} {
+		_, reportedKnown := s.IsReported()
+		_, trumpableKnown := s.IsTrumpable()
+		if reportedKnown || trumpableKnown {
+			t.Errorf("%T: expected the flags to be unknown", s)
+		}
+	}
+}
+
+func TestCreditedArtists(t *testing.T) {
+	var g whatapi.GroupExt = whatapi.GroupStruct{
+		NameF: "Symphony No. 5",
+		YearF: 1975,
+		TagsF: []string{"classical"},
+		MusicInfo: whatapi.MusicInfo{
+			Composers: []whatapi.MusicInfoStruct{{ID: 1, Name: "Beethoven"}},
+			Artists:   []whatapi.MusicInfoStruct{{ID: 2, Name: "Wiener Philharmoniker"}},
+			Conductor: []whatapi.MusicInfoStruct{{ID: 3, Name: "Carlos Kleiber"}},
+			Producer:  []whatapi.MusicInfoStruct{{ID: 4, Name: "Werner Mayer"}},
+		},
+	}
+	if c := g.Conductors(); len(c) != 1 || c[0].ID != 3 {
+		t.Errorf("expected the conductor, got %v", c)
+	}
+	ids := func(a []whatapi.MusicInfoStruct) (r []int) {
+		for _, m := range a {
+			r = append(r, m.ID)
+		}
+		return r
+	}
+	if a := ids(g.CreditedArtists(whatapi.RoleConductor, whatapi.RoleComposer)); len(a) != 2 || a[0] != 3 || a[1] != 1 {
+		t.Errorf("expected the conductor then the composer, got %v", a)
+	}
+	if a := ids(g.CreditedArtists()); len(a) != 4 || a[0] != 2 || a[1] != 1 || a[2] != 3 || a[3] != 4 {
+		t.Errorf("expected every artist in role order, got %v", a)
+	}
+	if a := g.CreditedArtists(whatapi.RoleDJ); a == nil || len(a) != 0 {
+		t.Errorf("expected no DJs, got %v", a)
+	}
+	exp := "Beethoven - Symphony No. 5 - Wiener Philharmoniker Carlos Kleiber (1975)"
+	if s := whatapi.GroupString(g); s != exp {
+		t.Errorf("expected %q, got %q", exp, s)
+	}
+}
@@ -12,6 +12,8 @@ type User struct {
 		Downloaded    int64   `json:"downloaded"`
 		Ratio         string  `json:"ratio"`
 		RequiredRatio float64 `json:"requiredRatio"`
+		// BonusPoints is only sent by sites with a bonus point system.
+		BonusPoints int64 `json:"bonusPoints"`
 	} `json:"stats"`
 	Ranks struct {
 		Uploaded   int `json:"uploaded"`
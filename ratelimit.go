@@ -0,0 +1,69 @@
+package whatapi
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces requests out so that no more than n start in any
+// period. Background requests only go when no foreground request is
+// waiting for a slot.
+type rateLimiter struct {
+	mu      sync.Mutex
+	n       int
+	period  time.Duration
+	sent    []time.Time // start times of the last n requests
+	waiting int         // foreground callers blocked in wait
+	// origin is the limiter this one was cloned from, if any
+	origin *rateLimiter
+}
+
+func newRateLimiter(n int, period time.Duration) *rateLimiter {
+	return &rateLimiter{n: n, period: period}
+}
+
+// clone returns a limiter with the same rate that hasn't sent anything.
+func (r *rateLimiter) clone() *rateLimiter {
+	if r == nil {
+		return nil
+	}
+	c := newRateLimiter(r.n, r.period)
+	c.origin = r
+	return c
+}
+
+func (r *rateLimiter) wait(background bool) {
+	if r == nil {
+		return
+	}
+	if !background {
+		r.mu.Lock()
+		r.waiting++
+		r.mu.Unlock()
+		defer func() {
+			r.mu.Lock()
+			r.waiting--
+			r.mu.Unlock()
+		}()
+	}
+	for {
+		r.mu.Lock()
+		if background && r.waiting > 0 {
+			r.mu.Unlock()
+			time.Sleep(r.period / time.Duration(r.n))
+			continue
+		}
+		now := time.Now()
+		if len(r.sent) < r.n || now.Sub(r.sent[0]) >= r.period {
+			if len(r.sent) == r.n {
+				r.sent = r.sent[1:]
+			}
+			r.sent = append(r.sent, now)
+			r.mu.Unlock()
+			return
+		}
+		d := r.period - now.Sub(r.sent[0])
+		r.mu.Unlock()
+		time.Sleep(d)
+	}
+}
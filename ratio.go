@@ -0,0 +1,109 @@
+package whatapi
+
+import "math"
+
+// RequiredRatioTier is the required ratio for accounts that have
+// downloaded at least MinDownloaded bytes.
+type RequiredRatioTier struct {
+	MinDownloaded int64
+	Ratio         float64
+}
+
+// RequiredRatioTable lists required ratio tiers in increasing order of
+// MinDownloaded.
+type RequiredRatioTable []RequiredRatioTier
+
+const gib = 1 << 30
+
+// GazelleRequiredRatios is stock Gazelle's required ratio table for a user
+// seeding none of their snatches, the worst case.
+var GazelleRequiredRatios = RequiredRatioTable{
+	{0, 0},
+	{5 * gib, 0.15},
+	{10 * gib, 0.20},
+	{20 * gib, 0.30},
+	{30 * gib, 0.40},
+	{40 * gib, 0.50},
+	{50 * gib, 0.60},
+}
+
+// For returns the required ratio once downloaded bytes have been
+// downloaded.
+func (t RequiredRatioTable) For(downloaded int64) float64 {
+	r := 0.0
+	for _, tier := range t {
+		if downloaded < tier.MinDownloaded {
+			break
+		}
+		r = tier.Ratio
+	}
+	return r
+}
+
+// ClassRatioTables maps user class names to the required ratio table for
+// that class, for sites where some classes are held to different rules.
+type ClassRatioTables map[string]RequiredRatioTable
+
+// For returns the table for class, falling back to def.
+func (c ClassRatioTables) For(class string, def RequiredRatioTable) RequiredRatioTable {
+	if t, ok := c[class]; ok {
+		return t
+	}
+	return def
+}
+
+// DownloadPlan is the effect a download would have on an account.
+type DownloadPlan struct {
+	Size          int64
+	Ratio         float64 // ratio after the download
+	RequiredRatio float64 // required ratio after the download
+	// Buffer is how many more bytes could be downloaded after this one
+	// before the ratio falls below the required ratio. It is negative
+	// if the download would leave the account below it.
+	Buffer int64
+	// NeedsToken is set when the download would leave the account below
+	// its required ratio, unless a freeleech token is used on it.
+	NeedsToken bool
+}
+
+// Buffer returns how many bytes the account can download before its
+// ratio falls below its current required ratio.
+func (a Account) Buffer() int64 {
+	return buffer(a.UserStats.Uploaded, a.UserStats.Downloaded, a.UserStats.RequiredRatio)
+}
+
+// PlanDownload works out the effect of downloading size bytes. If table
+// is nil the required ratio is the account's current one, otherwise it is
+// looked up from the table using the new download total.
+func (a Account) PlanDownload(size int64, table RequiredRatioTable) DownloadPlan {
+	up := a.UserStats.Uploaded
+	down := a.UserStats.Downloaded + size
+	required := a.UserStats.RequiredRatio
+	if table != nil {
+		required = table.For(down)
+	}
+	p := DownloadPlan{
+		Size:          size,
+		Ratio:         ratio(up, down),
+		RequiredRatio: required,
+		Buffer:        buffer(up, down, required),
+	}
+	p.NeedsToken = p.Buffer < 0
+	return p
+}
+
+func ratio(up, down int64) float64 {
+	if down == 0 {
+		return math.Inf(1)
+	}
+	return float64(up) / float64(down)
+}
+
+// buffer returns how far down is below the most that could be downloaded
+// with up uploaded while keeping the required ratio.
+func buffer(up, down int64, required float64) int64 {
+	if required <= 0 {
+		return math.MaxInt64
+	}
+	return int64(float64(up)/required) - down
+}
@@ -0,0 +1,118 @@
+package whatapi
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRequiredRatioTableFor(t *testing.T) {
+	for _, c := range []struct {
+		downloaded int64
+		exp        float64
+	}{
+		{0, 0},
+		{5*gib - 1, 0},
+		{5 * gib, 0.15},
+		{10*gib - 1, 0.15},
+		{10 * gib, 0.20},
+		{20 * gib, 0.30},
+		{30 * gib, 0.40},
+		{40 * gib, 0.50},
+		{50*gib - 1, 0.50},
+		{50 * gib, 0.60},
+		{1 << 50, 0.60},
+	} {
+		if got := GazelleRequiredRatios.For(c.downloaded); got != c.exp {
+			t.Errorf("For(%d) = %v, expected %v", c.downloaded, got, c.exp)
+		}
+	}
+	if got := (RequiredRatioTable{}).For(10 * gib); got != 0 {
+		t.Errorf("expected an empty table to require nothing, got %v", got)
+	}
+	if got := (RequiredRatioTable{{gib, 0.5}}).For(0); got != 0 {
+		t.Errorf("expected nothing required below the first tier, got %v", got)
+	}
+}
+
+func TestClassRatioTables(t *testing.T) {
+	elite := RequiredRatioTable{{0, 1}}
+	tables := ClassRatioTables{"Elite": elite}
+	if got := tables.For("Elite", GazelleRequiredRatios); len(got) != 1 || got[0].Ratio != 1 {
+		t.Errorf("expected the class's table, got %v", got)
+	}
+	if got := tables.For("User", GazelleRequiredRatios); len(got) != len(GazelleRequiredRatios) {
+		t.Errorf("expected the default table, got %v", got)
+	}
+}
+
+func account(up, down int64, required float64) Account {
+	a := Account{}
+	a.UserStats.Uploaded, a.UserStats.Downloaded, a.UserStats.RequiredRatio = up, down, required
+	return a
+}
+
+func TestBuffer(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		up, down int64
+		required float64
+		exp      int64
+	}{
+		{"nothing required", 0, 10 * gib, 0, math.MaxInt64},
+		{"no upload", 0, 10 * gib, 0.5, -10 * gib},
+		{"no download", 10 * gib, 0, 0.5, 20 * gib},
+		{"nothing at all", 0, 0, 0.5, 0},
+		{"exactly at the ratio", 5 * gib, 10 * gib, 0.5, 0},
+		{"above the ratio", 10 * gib, 10 * gib, 0.5, 10 * gib},
+		{"below the ratio", 4 * gib, 10 * gib, 0.5, -2 * gib},
+	} {
+		if got := account(c.up, c.down, c.required).Buffer(); got != c.exp {
+			t.Errorf("%s: expected a buffer of %d, got %d", c.name, c.exp, got)
+		}
+	}
+}
+
+func TestPlanDownload(t *testing.T) {
+	// computed at run time, as buffer is
+	perTier := func(up int64, required float64) int64 { return int64(float64(up) / required) }
+	for _, c := range []struct {
+		name          string
+		a             Account
+		size          int64
+		table         RequiredRatioTable
+		ratio         float64
+		required      float64
+		buffer        int64
+		needsToken    bool
+		infiniteRatio bool
+	}{
+		{name: "account's ratio", a: account(3*gib, 2*gib, 0.5), size: 2 * gib,
+			ratio: 0.75, required: 0.5, buffer: 2 * gib},
+		{name: "just enough", a: account(2*gib, 2*gib, 0.5), size: 2 * gib,
+			ratio: 0.5, required: 0.5, buffer: 0},
+		{name: "one byte too many", a: account(2*gib, 2*gib, 0.5), size: 2*gib + 1,
+			ratio: float64(2*gib) / (4*gib + 1), required: 0.5, buffer: -1, needsToken: true},
+		{name: "into the next tier", a: account(gib, 4*gib, 0), size: gib, table: GazelleRequiredRatios,
+			ratio: 0.2, required: 0.15, buffer: perTier(gib, 0.15) - 5*gib},
+		{name: "below the next tier", a: account(gib, 4*gib, 0), size: gib - 1, table: GazelleRequiredRatios,
+			ratio: float64(gib) / (5*gib - 1), required: 0, buffer: math.MaxInt64},
+		{name: "the next tier needs a token", a: account(gib/2, 9*gib, 0.15), size: gib, table: GazelleRequiredRatios,
+			ratio: 0.05, required: 0.20, buffer: perTier(gib/2, 0.2) - 10*gib, needsToken: true},
+		{name: "no upload", a: account(0, 0, 0.5), size: gib,
+			ratio: 0, required: 0.5, buffer: -gib, needsToken: true},
+		{name: "nothing", a: account(gib, 0, 0.5), size: 0,
+			required: 0.5, buffer: 2 * gib, infiniteRatio: true},
+	} {
+		p := c.a.PlanDownload(c.size, c.table)
+		if p.Size != c.size || p.RequiredRatio != c.required || p.Buffer != c.buffer || p.NeedsToken != c.needsToken {
+			t.Errorf("%s: bad plan %+v", c.name, p)
+		}
+		if c.infiniteRatio {
+			if !math.IsInf(p.Ratio, 1) {
+				t.Errorf("%s: expected an infinite ratio, got %v", c.name, p.Ratio)
+			}
+		} else if math.Abs(p.Ratio-c.ratio) > 1e-9 {
+			t.Errorf("%s: expected ratio %v, got %v", c.name, c.ratio, p.Ratio)
+		}
+	}
+}
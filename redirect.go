@@ -0,0 +1,103 @@
+package whatapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxRedirects is the most redirects a request follows, unless the
+// client is made WithMaxRedirects.
+const DefaultMaxRedirects = 10
+
+// RedirectError is returned when a request is redirected in a loop, or
+// more times than the client allows. It wraps ErrRedirectLoop or
+// ErrTooManyRedirects.
+type RedirectError struct {
+	// Chain is the URLs the request went to, in order, ending with the
+	// redirect that wasn't followed.
+	Chain []string
+	Loop  bool
+}
+
+func (e *RedirectError) Error() string {
+	return e.Unwrap().Error() + ": " + strings.Join(e.Chain, " -> ")
+}
+
+func (e *RedirectError) Unwrap() error {
+	if e.Loop {
+		return ErrRedirectLoop
+	}
+	return ErrTooManyRedirects
+}
+
+// WithMaxRedirects makes the client's requests follow at most n
+// redirects. With n of 0, any redirected request fails.
+func WithMaxRedirects(n int) Option {
+	return func(w *ClientStruct) error {
+		// copy, so clones don't change the policy of their parent
+		c := *w.client
+		c.CheckRedirect = checkRedirect(n)
+		w.client = &c
+		return nil
+	}
+}
+
+type redirectsKey struct{}
+
+// recordRedirects returns a context that makes the requests made with it
+// append each URL they are redirected to to chain.
+func recordRedirects(ctx context.Context, chain *[]*url.URL) context.Context {
+	return context.WithValue(ctx, redirectsKey{}, chain)
+}
+
+// checkRedirect returns the redirect policy of a client following at most
+// max redirects. A request sent to the same URL a third time is in a
+// loop; a second time can be a site setting a cookie and sending the
+// browser back.
+func checkRedirect(max int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if chain, ok := req.Context().Value(redirectsKey{}).(*[]*url.URL); ok {
+			*chain = append(*chain, req.URL)
+		}
+		visits := 0
+		for _, v := range via {
+			if v.Method == req.Method && v.URL.String() == req.URL.String() {
+				visits++
+			}
+		}
+		if visits < 2 && len(via) <= max {
+			return nil
+		}
+		e := &RedirectError{Loop: visits >= 2}
+		for _, v := range via {
+			e.Chain = append(e.Chain, v.URL.String())
+		}
+		e.Chain = append(e.Chain, req.URL.String())
+		return e
+	}
+}
+
+// loginSucceeded reports whether a login redirected through chain reached
+// the page the site sends logged in users to.
+func (w *ClientStruct) loginSucceeded(chain []*url.URL) bool {
+	landing := w.profile.LoginRedirect
+	if landing == "" {
+		landing = "index.php"
+	}
+	page, err := w.PageURL(landing, nil)
+	if err != nil {
+		return false
+	}
+	p, err := url.Parse(page)
+	if err != nil {
+		return false
+	}
+	for _, u := range chain {
+		if u.Path == p.Path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package whatapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRedirectLoop(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ajax.php":
+			http.Redirect(rw, r, "/a", http.StatusFound)
+		case "/a":
+			http.Redirect(rw, r, "/b", http.StatusFound)
+		default:
+			http.Redirect(rw, r, "/a", http.StatusFound)
+		}
+	})
+	_, err := c.GetTorrent(1, url.Values{})
+	var redirect *RedirectError
+	if !errors.As(err, &redirect) || !errors.Is(err, ErrRedirectLoop) {
+		t.Fatalf("expected a redirect loop, got %v", err)
+	}
+	if len(redirect.Chain) != 6 {
+		t.Errorf("bad chain %v", redirect.Chain)
+	}
+}
+
+func TestMaxRedirects(t *testing.T) {
+	n := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		n++
+		if n < 4 {
+			http.Redirect(rw, r, "/"+string(rune('a'+n)), http.StatusFound)
+			return
+		}
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}, WithMaxRedirects(2))
+	if _, err := c.GetTorrent(1, url.Values{}); !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("expected too many redirects, got %v", err)
+	}
+	n = 1
+	if _, err := c.GetTorrent(1, url.Values{}); err != nil {
+		t.Errorf("expected two redirects to be followed, got %v", err)
+	}
+}
+
+func TestLoginRedirect(t *testing.T) {
+	password := "secret"
+	handler := func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login.php":
+			if r.Method == "GET" {
+				rw.Write([]byte(`<html>login</html>`))
+				return
+			}
+			if r.FormValue("password") != password {
+				http.Redirect(rw, r, "/login.php?error=1", http.StatusFound)
+				return
+			}
+			http.Redirect(rw, r, "/home.php", http.StatusFound)
+		case "/home.php":
+			// a page whose URL doesn't say index
+			http.Redirect(rw, r, "/news.php", http.StatusFound)
+		case "/ajax.php":
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+		}
+	}
+	c, _ := newTestClient(t, handler, WithSiteProfile(SiteProfile{LoginRedirect: "home.php"}))
+	c.session.setLoggedIn(false)
+	if err := c.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	c, _ = newTestClient(t, handler, WithSiteProfile(SiteProfile{LoginRedirect: "home.php"}))
+	c.session.setLoggedIn(false)
+	if err := c.Login("user", "wrong"); err != errLoginFailed {
+		t.Errorf("expected the login to fail, got %v", err)
+	}
+	c, _ = newTestClient(t, handler)
+	c.session.setLoggedIn(false)
+	if err := c.Login("user", "secret"); err != errLoginFailed {
+		t.Errorf("expected a login not reaching index.php to fail, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package whatapi
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"sync"
+)
+
+// CredentialsFunc returns the username and password to log in with.
+type CredentialsFunc func() (username, password string, err error)
+
+// WithAutoRelogin logs the client in again with the credentials from creds
+// when a request fails with ErrSessionExpired, then retries the request
+// once. creds is only called when a login is needed, so the password need
+// not be kept in memory. Once a login has failed it is not tried again
+// automatically, until Login is called and succeeds.
+func WithAutoRelogin(creds CredentialsFunc) Option {
+	return func(w *ClientStruct) error {
+		w.relogin = &relogin{creds: creds}
+		return nil
+	}
+}
+
+type relogin struct {
+	// loginMu is held for the whole of a login, so that requests that
+	// fail at once wait for one login between them. The requests Login
+	// makes itself are made withoutRelogin, as they would wait on it.
+	loginMu sync.Mutex
+	creds   CredentialsFunc
+	// mu guards generation, which counts the logins, so that requests
+	// that failed during the same expired session only log in once.
+	mu         sync.Mutex
+	generation int
+}
+
+func (r *relogin) current() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.generation
+}
+
+// login starts a fresh session, unless another request already did so
+// since generation. The client stays logged in meanwhile, so that other
+// requests whose session expires too wait here for the new one rather
+// than failing; if the login fails, it is logged out.
+func (r *relogin) login(w *ClientStruct, generation int) error {
+	r.loginMu.Lock()
+	defer r.loginMu.Unlock()
+	if r.current() != generation {
+		return nil
+	}
+	if w.throttle.failed() {
+		// don't keep trying a password that was refused
+		return errLoginFailed
+	}
+	username, password, err := r.creds()
+	if err != nil {
+		return err
+	}
+	if err = w.clearKeys(); err == nil {
+		if err = w.clearCookies(); err == nil {
+			// the credentials may be for another user now, whose
+			// session is stored under their own namespace
+			w.session.setUser(username)
+			err = w.login(username, password)
+		}
+	}
+	if err != nil {
+		w.session.setLoggedIn(false)
+		return err
+	}
+	r.mu.Lock()
+	r.generation++
+	r.mu.Unlock()
+	return nil
+}
+
+type noReloginKey struct{}
+
+// withoutRelogin marks ctx as that of a request made while logging in,
+// which may be made before the client is logged in, and fails if the
+// session has expired rather than logging in again.
+func withoutRelogin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noReloginKey{}, true)
+}
+
+func reloginAllowed(ctx context.Context) bool {
+	return ctx.Value(noReloginKey{}) == nil
+}
+
+// isLoginPage reports whether the HTML page body is the site's login form,
+// which posts to loginPath.
+func isLoginPage(body []byte, loginPath string) bool {
+	return bytes.Contains(body, []byte(path.Base(loginPath))) &&
+		bytes.Contains(body, []byte(`name="password"`))
+}
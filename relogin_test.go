@@ -0,0 +1,139 @@
+package whatapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// expiringSite is a site whose session, kept in a cookie, has expired
+// until the client logs in again.
+type expiringSite struct {
+	mu      sync.Mutex
+	session string
+	logins  int
+	expired int
+	// if set, logins are held up until gate is closed, after telling
+	// loggingIn
+	gate, loggingIn chan struct{}
+}
+
+func (s *expiringSite) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if s.gate != nil && r.URL.Path == "/login.php" {
+		s.loggingIn <- struct{}{}
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := r.Cookie("session")
+	valid := err == nil && c.Value == s.session
+	switch {
+	case r.URL.Path == "/login.php":
+		s.logins++
+		s.session = fmt.Sprint(s.logins)
+		http.SetCookie(rw, &http.Cookie{Name: "session", Value: s.session})
+		http.Redirect(rw, r, "/index.php", http.StatusFound)
+	case r.URL.Path == "/index.php":
+		rw.Write([]byte(`<html>home</html>`))
+	case !valid:
+		s.expired++
+		rw.Write([]byte(`<html><form action="login.php"><input type="password" name="password"></form></html>`))
+	case r.URL.Query().Get("action") == "index":
+		rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+	default:
+		rw.Write([]byte(`{"status":"success","response":{"group":{"id":2},"torrent":{"id":5}}}`))
+	}
+}
+
+func (s *expiringSite) counts() (logins, expired int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins, s.expired
+}
+
+func TestAutoRelogin(t *testing.T) {
+	site := &expiringSite{}
+	c, _ := newTestClient(t, site.ServeHTTP,
+		WithAutoRelogin(func() (string, string, error) { return "agent", "secret", nil }))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetTorrent(5, url.Values{})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("relogin deadlocked")
+	}
+	if n, _ := site.counts(); n != 1 {
+		t.Errorf("expected 1 login, got %d", n)
+	}
+	if authkey, _ := c.session.keys(); authkey != "a" {
+		t.Errorf("expected the new session's authkey, got %q", authkey)
+	}
+}
+
+func TestAutoReloginConcurrent(t *testing.T) {
+	site := &expiringSite{gate: make(chan struct{}), loggingIn: make(chan struct{}, 1)}
+	c, _ := newTestClient(t, site.ServeHTTP,
+		WithAutoRelogin(func() (string, string, error) { return "agent", "secret", nil }))
+
+	const n = 20
+	errs := make(chan error, n)
+	get := func() {
+		_, err := c.GetTorrent(5, url.Values{})
+		errs <- err
+	}
+	for i := 0; i < n/2; i++ {
+		go get()
+	}
+	// the rest start while the client is logging in again
+	select {
+	case <-site.loggingIn:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a relogin")
+	}
+	for i := n / 2; i < n; i++ {
+		go get()
+	}
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if _, expired := site.counts(); expired == n {
+			break
+		}
+	}
+	close(site.gate)
+
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("expected every request to wait for the relogin, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("relogin deadlocked")
+		}
+	}
+	if logins, _ := site.counts(); logins != 1 {
+		t.Errorf("expected the requests to share 1 login, got %d", logins)
+	}
+}
+
+func TestAutoReloginAsAnotherUser(t *testing.T) {
+	site := &expiringSite{}
+	c, _ := newTestClient(t, site.ServeHTTP,
+		WithAutoRelogin(func() (string, string, error) { return "new", "secret", nil }))
+	c.session.setUser("old")
+	if _, err := c.GetTorrent(5, url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if ns := c.Namespace(); ns != "new@"+c.baseURL.String() {
+		t.Errorf("expected the client named for the user it logged in as, got %q", ns)
+	}
+}
@@ -0,0 +1,95 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// ReportCategory is the kind of thing a report is about.
+type ReportCategory string
+
+const (
+	ReportTorrent       ReportCategory = "torrent"
+	ReportUser          ReportCategory = "user"
+	ReportRequest       ReportCategory = "request"
+	ReportRequestUpdate ReportCategory = "request_update"
+	ReportCollage       ReportCategory = "collage"
+	ReportThread        ReportCategory = "thread"
+	ReportPost          ReportCategory = "post"
+	ReportComment       ReportCategory = "comment"
+)
+
+// Report is a report in the moderation queue.
+type Report struct {
+	ID       int            `json:"reportId"`
+	Category ReportCategory `json:"category"`
+	// Type is the reason chosen from the site's list for the
+	// category, such as "dupe" or "trump" for torrents.
+	Type string `json:"type"`
+	// ThingID is the id of the torrent, user, request, collage,
+	// thread, post or comment reported.
+	ThingID      int    `json:"thingId"`
+	ReporterID   int    `json:"reporterId"`
+	ReporterName string `json:"reporterName"`
+	Reason       string `json:"reason"`
+	ReportedTime string `json:"reportedTime"`
+	Status       string `json:"status"`
+	ResolverID   int    `json:"resolverId"`
+	ResolvedTime string `json:"resolvedTime"`
+}
+
+// Reports is a page of the moderation queue.
+type Reports struct {
+	CurrentPage int      `json:"currentPage"`
+	Pages       int      `json:"pages"`
+	Reports     []Report `json:"reports"`
+}
+
+// PageNumber returns the number of this page, from 1.
+func (r Reports) PageNumber() int {
+	return r.CurrentPage
+}
+
+// PageCount returns the number of pages.
+func (r Reports) PageCount() int {
+	return r.Pages
+}
+
+// GetReports retrieves a page of the open reports in the provided category, for users with moderation privileges on sites with the CapReports capability.
+func (w *ClientStruct) GetReports(category ReportCategory, page int) (Reports, error) {
+	reports := ReportsResponse{}
+	action, err := w.profile.action(CapReports)
+	if err != nil {
+		return reports.Response, err
+	}
+	params := url.Values{}
+	params.Set("category", string(category))
+	params.Set("page", strconv.Itoa(page))
+	if err = w.Do(action, params, &reports); err != nil {
+		return reports.Response, err
+	}
+	return reports.Response, checkResponseStatus(reports.Status, reports.Error)
+}
+
+// GetTorrentReports retrieves a page of the open torrent reports. See GetReports.
+func (w *ClientStruct) GetTorrentReports(page int) (Reports, error) {
+	return w.GetReports(ReportTorrent, page)
+}
+
+// ResolveReport marks the report with the provided id resolved, with an optional comment, for users with moderation privileges on sites with the CapResolveReport capability.
+func (w *ClientStruct) ResolveReport(reportID int, comment string) error {
+	action, err := w.profile.action(CapResolveReport)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(reportID))
+	if comment != "" {
+		params.Set("comment", comment)
+	}
+	var st GenericResponse
+	if err = w.DoPost(w.context(), action, params, &st); err != nil {
+		return err
+	}
+	return checkResponseStatus(st.Status, st.Error)
+}
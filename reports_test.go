@@ -0,0 +1,77 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+var reportsProfile = SiteProfile{
+	Name: "test",
+	Actions: map[Capability]string{
+		CapReports:       "reports",
+		CapResolveReport: "resolve_report",
+	},
+}
+
+func TestGetTorrentReports(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":2,"pages":3,"reports":[{"reportId":8,"category":"torrent","type":"dupe","thingId":12,"reporterName":"alice","reason":"same as 11"}]}}`))
+	}, WithSiteProfile(reportsProfile))
+	reports, err := c.GetTorrentReports(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=reports&category=torrent&page=2"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if reports.PageNumber() != 2 || reports.PageCount() != 3 || len(reports.Reports) != 1 {
+		t.Fatalf("bad reports %+v", reports)
+	}
+	if r := reports.Reports[0]; r.ID != 8 || r.Category != ReportTorrent || r.Type != "dupe" || r.ThingID != 12 || r.ReporterName != "alice" {
+		t.Errorf("bad report %+v", r)
+	}
+}
+
+func TestResolveReport(t *testing.T) {
+	var query string
+	var post map[string]string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		query = r.URL.RawQuery
+		r.ParseForm()
+		post = map[string]string{}
+		for k := range r.PostForm {
+			post[k] = r.PostForm.Get(k)
+		}
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}, WithSiteProfile(reportsProfile))
+	c.session.setKeys("authkey", "")
+	if err := c.ResolveReport(8, "removed the dupe"); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=resolve_report"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	exp := map[string]string{"auth": "authkey", "id": "8", "comment": "removed the dupe"}
+	for k, v := range exp {
+		if post[k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, post[k])
+		}
+	}
+}
+
+func TestReportsUnsupported(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}, WithSiteProfile(GazelleProfile))
+	if _, err := c.GetTorrentReports(1); err == nil {
+		t.Error("expected reports to be unsupported")
+	}
+	if err := c.ResolveReport(8, ""); err == nil {
+		t.Error("expected resolving reports to be unsupported")
+	}
+}
@@ -0,0 +1,105 @@
+package whatapi
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RequestsOptions are the typed parameters of a request search.
+type RequestsOptions struct {
+	Tags []string
+	// TagsAll matches requests with all of Tags rather than any.
+	TagsAll    bool
+	ShowFilled bool
+	Categories []Category
+	// Order is the column to sort by: "votes", "bounty", "created",
+	// "lastvote", "filled" or "year". The site default is "created".
+	Order      string
+	Descending bool
+	// ArtistID and GroupID, if set, only find requests for that artist
+	// or torrent group.
+	ArtistID int
+	GroupID  int
+}
+
+func (o RequestsOptions) params() url.Values {
+	params := url.Values{}
+	if tags := searchTexts(o.Tags); len(tags) > 0 {
+		params.Set("tags", strings.Join(tags, ","))
+		if o.TagsAll {
+			params.Set("tags_type", "1")
+		} else {
+			params.Set("tags_type", "0")
+		}
+	}
+	if o.ShowFilled {
+		params.Set("show_filled", "true")
+	}
+	for _, c := range o.Categories {
+		params.Set("filter_cat["+strconv.Itoa(int(c))+"]", "1")
+	}
+	if o.Order != "" {
+		params.Set("order", o.Order)
+	}
+	if o.Descending {
+		params.Set("sort", "desc")
+	}
+	if o.ArtistID != 0 {
+		params.Set("artistid", strconv.Itoa(o.ArtistID))
+	}
+	if o.GroupID != 0 {
+		params.Set("groupid", strconv.Itoa(o.GroupID))
+	}
+	return params
+}
+
+// RequestsIter steps through every result of a request search, fetching
+// pages as needed.
+type RequestsIter struct {
+	resultsIter[RequestsSearchResult]
+}
+
+// SearchRequestsIter returns an iterator over all of the request search results for the provided search string and options. The search string is normalised as SearchTorrentsWith's is.
+func (w *ClientStruct) SearchRequestsIter(searchStr string, opts RequestsOptions) *RequestsIter {
+	searchStr, params := searchText(searchStr), opts.params()
+	return &RequestsIter{resultsIter[RequestsSearchResult]{
+		fetch: func(page int) ([]RequestsSearchResult, int, error) {
+			params.Set("page", strconv.Itoa(page))
+			s, err := w.SearchRequests(searchStr, params)
+			return s.Results, s.Pages, err
+		},
+		id: func(r RequestsSearchResult) int { return r.RequestID },
+	}}
+}
+
+// TopBounties retrieves the limit unfilled requests with the largest total bounties.
+func (w *ClientStruct) TopBounties(limit int) ([]RequestsSearchResult, error) {
+	it := w.SearchRequestsIter("", RequestsOptions{Order: "bounty", Descending: true})
+	r := []RequestsSearchResult{}
+	for len(r) < limit && it.Next() {
+		r = append(r, it.Result())
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].Bounty > r[j].Bounty })
+	return r, it.Err()
+}
+
+// GetRequestsForArtist retrieves every request for the artist with the provided id, filled or not.
+func (w *ClientStruct) GetRequestsForArtist(artistID int) ([]RequestsSearchResult, error) {
+	return w.allRequests(RequestsOptions{ArtistID: artistID, ShowFilled: true})
+}
+
+// GetRequestsForGroup retrieves every request for the torrent group with the provided id, filled or not.
+func (w *ClientStruct) GetRequestsForGroup(groupID int) ([]RequestsSearchResult, error) {
+	return w.allRequests(RequestsOptions{GroupID: groupID, ShowFilled: true})
+}
+
+func (w *ClientStruct) allRequests(opts RequestsOptions) ([]RequestsSearchResult, error) {
+	it := w.SearchRequestsIter("", opts)
+	r := []RequestsSearchResult{}
+	for it.Next() {
+		r = append(r, it.Result())
+	}
+	return r, it.Err()
+}
@@ -0,0 +1,62 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRequestsIterWithoutCurrentPage(t *testing.T) {
+	requests := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		if requests++; requests > 10 {
+			t.Error("didn't stop paging")
+			rw.Write([]byte(`{"status":"success","response":{"results":[]}}`))
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			rw.Write([]byte(`{"status":"success","response":{"results":[{"requestId":1,"bounty":10},{"requestId":2,"bounty":30}]}}`))
+		case "2":
+			rw.Write([]byte(`{"status":"success","response":{"results":[{"requestId":3,"bounty":20}]}}`))
+		default:
+			rw.Write([]byte(`{"status":"success","response":{"results":[]}}`))
+		}
+	})
+	r, err := c.TopBounties(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 3 || r[0].RequestID != 2 || r[2].RequestID != 1 {
+		t.Errorf("bad requests %+v", r)
+	}
+}
+
+func TestGetRequestsFor(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("show_filled") != "true" {
+			t.Errorf("expected filled requests too, got %v", q)
+		}
+		if q.Get("page") != "1" {
+			rw.Write([]byte(`{"status":"success","response":{"results":[]}}`))
+			return
+		}
+		switch {
+		case q.Get("artistid") == "7" && q.Get("groupid") == "":
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[{"requestId":1},{"requestId":2}]}}`))
+		case q.Get("groupid") == "9" && q.Get("artistid") == "":
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[{"requestId":3}]}}`))
+		default:
+			t.Errorf("bad query %v", q)
+			rw.Write([]byte(`{"status":"failure"}`))
+		}
+	})
+	r, err := c.GetRequestsForArtist(7)
+	if err != nil || len(r) != 2 || r[1].RequestID != 2 {
+		t.Errorf("bad requests for the artist %+v, %v", r, err)
+	}
+	r, err = c.GetRequestsForGroup(9)
+	if err != nil || len(r) != 1 || r[0].RequestID != 3 {
+		t.Errorf("bad requests for the group %+v, %v", r, err)
+	}
+}
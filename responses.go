@@ -30,6 +30,12 @@ type CategoriesResponse struct {
 	Response Categories `json:"response"`
 }
 
+type CommentsResponse struct {
+	Status   string   `json:"status"`
+	Error    string   `json:"error"`
+	Response Comments `json:"response"`
+}
+
 type ConversationResponse struct {
 	Status   string       `json:"status"`
 	Error    string       `json:"error"`
@@ -102,6 +108,18 @@ type TorrentResponse struct {
 	Response GetTorrentStruct `json:"response"`
 }
 
+type TorrentPeersResponse struct {
+	Status   string       `json:"status"`
+	Error    string       `json:"error"`
+	Response TorrentPeers `json:"response"`
+}
+
+type TorrentSnatchersResponse struct {
+	Status   string           `json:"status"`
+	Error    string           `json:"error"`
+	Response TorrentSnatchers `json:"response"`
+}
+
 type TorrentBookmarksResponse struct {
 	Status   string           `json:"status"`
 	Error    string           `json:"error"`
@@ -131,3 +149,63 @@ type UserSearchResponse struct {
 	Error    string     `json:"error"`
 	Response UserSearch `json:"response"`
 }
+
+type CollageResponse struct {
+	Status   string  `json:"status"`
+	Error    string  `json:"error"`
+	Response Collage `json:"response"`
+}
+
+type BonusItemsResponse struct {
+	Status   string      `json:"status"`
+	Error    string      `json:"error"`
+	Response []BonusItem `json:"response"`
+}
+
+type SeedingReportResponse struct {
+	Status   string        `json:"status"`
+	Error    string        `json:"error"`
+	Response SeedingReport `json:"response"`
+}
+
+type UserPostsResponse struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error"`
+	Response UserPosts `json:"response"`
+}
+
+type UserCommentsResponse struct {
+	Status   string       `json:"status"`
+	Error    string       `json:"error"`
+	Response UserComments `json:"response"`
+}
+
+type FriendsResponse struct {
+	Status   string   `json:"status"`
+	Error    string   `json:"error"`
+	Response []Friend `json:"response"`
+}
+
+type ReportsResponse struct {
+	Status   string  `json:"status"`
+	Error    string  `json:"error"`
+	Response Reports `json:"response"`
+}
+
+type NotificationSettingsResponse struct {
+	Status   string               `json:"status"`
+	Error    string               `json:"error"`
+	Response NotificationSettings `json:"response"`
+}
+
+type UserTorrentsResponse struct {
+	Status   string                   `json:"status"`
+	Error    string                   `json:"error"`
+	Response map[string][]UserTorrent `json:"response"`
+}
+
+type RipLogResponse struct {
+	Status   string `json:"status"`
+	Error    string `json:"error"`
+	Response RipLog `json:"response"`
+}
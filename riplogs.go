@@ -0,0 +1,103 @@
+package whatapi
+
+import (
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// RipLog is a rip log attached to a torrent, as its ripping program wrote
+// it, for log checkers to verify.
+type RipLog struct {
+	ID  int    `json:"logid"`
+	Log string `json:"log"`
+	// Score and Checksum are the site's verdict on the log. They are
+	// only known on sites with the CapRipLog capability.
+	Score    int  `json:"score"`
+	Checksum bool `json:"checksum"`
+}
+
+// RipLogs are a torrent's description and rip logs.
+type RipLogs struct {
+	TorrentID int
+	// Description is the uploader's description of the torrent, in
+	// BBCode.
+	Description string
+	Logs        []RipLog
+}
+
+var (
+	// preBlock matches the blocks a log page shows each log in.
+	preBlock = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	// markup matches the tags a log page highlights a log's lines with.
+	markup = regexp.MustCompile(`<[^>]*>`)
+)
+
+// GetRipLogs retrieves the description and rip logs of the torrent with the provided id. Sites with the CapRipLog capability return each log through the API; on others the logs are read from the torrent's log page. Torrents without a log make only the one request for the torrent.
+func (w *ClientStruct) GetRipLogs(id int) (RipLogs, error) {
+	t, err := w.GetTorrent(id, url.Values{})
+	if err != nil {
+		return RipLogs{}, err
+	}
+	logs := RipLogs{
+		TorrentID:   id,
+		Description: html.UnescapeString(t.Torrent.Description()),
+		Logs:        []RipLog{},
+	}
+	if !t.Torrent.HasLog() {
+		return logs, nil
+	}
+	if action, err := w.profile.action(CapRipLog); err == nil {
+		for _, logID := range t.Torrent.RipLogIDs {
+			l, err := w.getRipLog(action, id, logID)
+			if err != nil {
+				return logs, err
+			}
+			logs.Logs = append(logs.Logs, l)
+		}
+		return logs, nil
+	}
+	logs.Logs, err = w.scrapeRipLogs(id, t.Group.ID())
+	return logs, err
+}
+
+func (w *ClientStruct) getRipLog(action string, id, logID int) (RipLog, error) {
+	l := RipLogResponse{}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(id))
+	params.Set("logid", strconv.Itoa(logID))
+	if err := w.Do(action, params, &l); err != nil {
+		return l.Response, err
+	}
+	l.Response.ID = logID
+	return l.Response, checkResponseStatus(l.Status, l.Error)
+}
+
+// scrapeRipLogs reads the logs off the torrent's log page, which shows
+// each in a pre block.
+func (w *ClientStruct) scrapeRipLogs(id, groupID int) ([]RipLog, error) {
+	pageURL, err := w.PageURL("torrents.php", url.Values{
+		"action":    {"viewlog"},
+		"torrentid": {strconv.Itoa(id)},
+		"groupid":   {strconv.Itoa(groupID)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	page, err := w.doRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+	logs := []RipLog{}
+	for _, m := range preBlock.FindAllSubmatch(page, -1) {
+		text := html.UnescapeString(markup.ReplaceAllString(string(m[1]), ""))
+		logs = append(logs, RipLog{Log: text})
+	}
+	return logs, nil
+}
@@ -0,0 +1,78 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetRipLogs(t *testing.T) {
+	var logIDs []string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "torrent":
+			rw.Write([]byte(`{"status":"success","response":{"group":{"id":2},"torrent":{"id":1,"hasLog":true,"description":"Ripped with EAC &amp; checked","ripLogIds":[5,6]}}}`))
+		case "riplog":
+			if q.Get("id") != "1" {
+				t.Errorf("unexpected torrent %s", q.Get("id"))
+			}
+			logIDs = append(logIDs, q.Get("logid"))
+			rw.Write([]byte(`{"status":"success","response":{"log":"Exact Audio Copy V1.0","score":100,"checksum":true}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}, WithSiteProfile(SiteProfile{Name: "test", Actions: map[Capability]string{CapRipLog: "riplog"}}))
+	logs, err := c.GetRipLogs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs.Description != "Ripped with EAC & checked" {
+		t.Errorf("bad description %q", logs.Description)
+	}
+	if len(logIDs) != 2 || logIDs[0] != "5" || logIDs[1] != "6" {
+		t.Errorf("expected both logs to be fetched, got %v", logIDs)
+	}
+	if len(logs.Logs) != 2 || logs.Logs[1].ID != 6 || logs.Logs[1].Log != "Exact Audio Copy V1.0" ||
+		logs.Logs[1].Score != 100 || !logs.Logs[1].Checksum {
+		t.Errorf("bad logs %+v", logs.Logs)
+	}
+}
+
+func TestGetRipLogsFromPage(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/ajax.php" && q.Get("action") == "torrent":
+			rw.Write([]byte(`{"status":"success","response":{"group":{"id":2},"torrent":{"id":1,"hasLog":true}}}`))
+		case r.URL.Path == "/torrents.php" && q.Get("action") == "viewlog":
+			if q.Get("torrentid") != "1" || q.Get("groupid") != "2" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			rw.Write([]byte(`<html><body><div class="log">
+<pre>EAC extraction logfile
+<span class="good">Log checksum &lt;ok&gt;</span></pre>
+</div><div class="log"><pre class="log">Second disc</pre></div></body></html>`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}, WithSiteProfile(GazelleProfile))
+	logs, err := c.GetRipLogs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs.Logs) != 2 || logs.Logs[0].Log != "EAC extraction logfile\nLog checksum <ok>" || logs.Logs[1].Log != "Second disc" {
+		t.Errorf("bad logs %+v", logs.Logs)
+	}
+}
+
+func TestGetRipLogsNoLog(t *testing.T) {
+	requests := 0
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		rw.Write([]byte(`{"status":"success","response":{"group":{"id":2},"torrent":{"id":1,"description":"WEB"}}}`))
+	})
+	logs, err := c.GetRipLogs(1)
+	if err != nil || requests != 1 || len(logs.Logs) != 0 || logs.Description != "WEB" {
+		t.Errorf("expected just the description from one request, got %+v, %d requests, %v", logs, requests, err)
+	}
+}
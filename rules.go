@@ -0,0 +1,207 @@
+package whatapi
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// EventRuleMatch is the kind of Event a RuleSink raises for a torrent
+// that matches a rule.
+const EventRuleMatch = "rule"
+
+// Candidate is a torrent a rule is tested against, from a notification or
+// a search result.
+type Candidate struct {
+	TorrentID int
+	GroupID   int
+	// Artist is empty for notifications, which don't say, unless a
+	// RuleSink has looked it up.
+	Artist      string
+	GroupName   string
+	Year        int
+	Format      string
+	Encoding    string
+	Media       string
+	Size        int64
+	Tags        []string
+	LeechStatus LeechStatus
+	// Payload is the NotificationTorrent or SearchPair the candidate
+	// came from.
+	Payload interface{}
+}
+
+// NotificationCandidate returns the candidate for a notified torrent.
+func NotificationCandidate(n NotificationTorrent) Candidate {
+	return Candidate{
+		TorrentID:   n.TorrentID,
+		GroupID:     n.GroupID,
+		GroupName:   n.GroupName,
+		Year:        n.GroupYear,
+		Format:      n.Format,
+		Encoding:    n.Encoding,
+		Media:       n.Media,
+		Size:        n.Size,
+		Tags:        n.Tags(),
+		LeechStatus: n.LeechStatus,
+		Payload:     n,
+	}
+}
+
+// SearchCandidates returns a candidate for each torrent in s.
+func SearchCandidates(s TorrentSearch) []Candidate {
+	cs := []Candidate{}
+	for _, p := range s.Flatten() {
+		cs = append(cs, Candidate{
+			TorrentID:   p.Torrent.ID(),
+			GroupID:     p.Group.ID(),
+			Artist:      p.Group.Artist(),
+			GroupName:   p.Group.Name(),
+			Year:        p.Group.Year(),
+			Format:      p.Torrent.Format(),
+			Encoding:    p.Torrent.Encoding(),
+			Media:       p.Torrent.Media(),
+			Size:        p.Torrent.FileSize(),
+			Tags:        p.Group.Tags(),
+			LeechStatus: p.Torrent.LeechStatus(),
+			Payload:     p,
+		})
+	}
+	return cs
+}
+
+// Rule declares the torrents a user wants to hear about. Every field set
+// must match; lists match if any of their entries does, ignoring case.
+// Rules decode from JSON, for example
+//
+//	{"name": "vinyl", "artist": "Radiohead", "media": ["Vinyl"],
+//	 "format": ["FLAC"], "encoding": ["24bit Lossless"],
+//	 "freeleechOnly": true, "maxSize": "2 GB"}
+type Rule struct {
+	Name     string   `json:"name"`
+	Artist   string   `json:"artist,omitempty"`
+	Media    []string `json:"media,omitempty"`
+	Format   []string `json:"format,omitempty"`
+	Encoding []string `json:"encoding,omitempty"`
+	// Tags must all be on the torrent's group.
+	Tags          []string `json:"tags,omitempty"`
+	FreeleechOnly bool     `json:"freeleechOnly,omitempty"`
+	MinSize       ByteSize `json:"minSize,omitempty"`
+	MaxSize       ByteSize `json:"maxSize,omitempty"`
+	MinYear       int      `json:"minYear,omitempty"`
+	MaxYear       int      `json:"maxYear,omitempty"`
+}
+
+// Matches reports whether c satisfies the rule.
+func (r Rule) Matches(c Candidate) bool {
+	if r.Artist != "" && !strings.EqualFold(r.Artist, c.Artist) {
+		return false
+	}
+	if !anyFold(r.Media, c.Media) || !anyFold(r.Format, c.Format) || !anyFold(r.Encoding, c.Encoding) {
+		return false
+	}
+	for _, t := range r.Tags {
+		if !anyFold(c.Tags, t) {
+			return false
+		}
+	}
+	switch {
+	case r.FreeleechOnly && !c.LeechStatus.Free(),
+		r.MinSize > 0 && c.Size < int64(r.MinSize),
+		r.MaxSize > 0 && c.Size > int64(r.MaxSize),
+		r.MinYear > 0 && c.Year < r.MinYear,
+		r.MaxYear > 0 && c.Year > r.MaxYear:
+		return false
+	}
+	return true
+}
+
+// anyFold reports whether list is empty or has v in it, ignoring case.
+func anyFold(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleMatch is a candidate and the rule it matched.
+type RuleMatch struct {
+	Rule      Rule      `json:"rule"`
+	Candidate Candidate `json:"candidate"`
+}
+
+// Rules is a set of rules.
+type Rules []Rule
+
+// needArtist reports whether any of the rules match on the artist.
+func (rs Rules) needArtist() bool {
+	for _, r := range rs {
+		if r.Artist != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns a match for each rule each candidate satisfies.
+func (rs Rules) Match(cs ...Candidate) []RuleMatch {
+	ms := []RuleMatch{}
+	for _, c := range cs {
+		for _, r := range rs {
+			if r.Matches(c) {
+				ms = append(ms, RuleMatch{Rule: r, Candidate: c})
+			}
+		}
+	}
+	return ms
+}
+
+// RuleSink is a Sink for a Watcher that tests each notification against
+// Rules, and delivers an EventRuleMatch event, with a RuleMatch payload,
+// to Next for each rule it matches. Other events are dropped.
+type RuleSink struct {
+	Rules Rules
+	Next  Sink
+	// Client looks up the artist of each notified torrent's group,
+	// which notifications don't say, for rules with an Artist. A
+	// RuleSink without one fails to deliver to such rules rather than
+	// never matching them.
+	Client Client
+}
+
+// Deliver passes on the matches for e.
+func (s RuleSink) Deliver(ctx context.Context, e Event) error {
+	n, ok := e.Payload.(NotificationTorrent)
+	if e.Kind != EventNotification || !ok {
+		return nil
+	}
+	c := NotificationCandidate(n)
+	if s.Rules.needArtist() {
+		if s.Client == nil {
+			return errRuleNeedsClient
+		}
+		g, err := s.Client.GetTorrentGroup(n.GroupID, url.Values{})
+		if err != nil {
+			return err
+		}
+		c.Artist = g.Group.Artist()
+	}
+	for _, m := range s.Rules.Match(c) {
+		err := s.Next.Deliver(ctx, Event{
+			Kind:    EventRuleMatch,
+			ID:      e.ID,
+			Title:   m.Rule.Name + ": " + e.Title,
+			Time:    e.Time,
+			Payload: m,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
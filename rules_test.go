@@ -0,0 +1,110 @@
+package whatapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestRules(t *testing.T) {
+	var rules whatapi.Rules
+	err := json.Unmarshal([]byte(`[
+{"name": "vinyl", "media": ["vinyl"], "format": ["FLAC"], "encoding": ["24bit Lossless"], "maxSize": "2 GB"},
+{"name": "free", "artist": "Radiohead", "freeleechOnly": true, "minSize": 1000}
+]`), &rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		c   whatapi.Candidate
+		exp []string
+	}{
+		{whatapi.Candidate{Media: "Vinyl", Format: "FLAC", Encoding: "24bit Lossless", Size: 1 << 30}, []string{"vinyl"}},
+		{whatapi.Candidate{Media: "Vinyl", Format: "FLAC", Encoding: "24bit Lossless", Size: 3 << 30}, nil},
+		{whatapi.Candidate{Media: "CD", Format: "FLAC", Encoding: "Lossless", Size: 1 << 20}, nil},
+		{whatapi.Candidate{Artist: "radiohead", LeechStatus: whatapi.LeechFree, Size: 1 << 20}, []string{"free"}},
+		{whatapi.Candidate{Artist: "Radiohead", Media: "Vinyl", Format: "FLAC", Encoding: "24bit Lossless",
+			LeechStatus: whatapi.LeechNeutral, Size: 1 << 20}, []string{"vinyl", "free"}},
+		{whatapi.Candidate{Artist: "Radiohead", Size: 1 << 20}, nil},
+	}
+	for i, tt := range tests {
+		ms := rules.Match(tt.c)
+		if len(ms) != len(tt.exp) {
+			t.Errorf("%d: expected %v, got %v", i, tt.exp, ms)
+			continue
+		}
+		for j, m := range ms {
+			if m.Rule.Name != tt.exp[j] {
+				t.Errorf("%d: expected %v, got %v", i, tt.exp, ms)
+			}
+		}
+	}
+}
+
+func TestRuleSink(t *testing.T) {
+	events := make(chan whatapi.Event, 1)
+	s := whatapi.RuleSink{
+		Rules: whatapi.Rules{{Name: "flac", Format: []string{"FLAC"}}},
+		Next:  whatapi.ChanSink(events),
+	}
+	for _, n := range []whatapi.NotificationTorrent{{TorrentID: 1, Format: "MP3"}, {TorrentID: 2, Format: "FLAC"}} {
+		err := s.Deliver(context.Background(), whatapi.Event{Kind: whatapi.EventNotification, ID: n.TorrentID, Payload: n})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(events)
+	got := []whatapi.Event{}
+	for e := range events {
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0].ID != 2 || got[0].Kind != whatapi.EventRuleMatch {
+		t.Errorf("expected a rule match for torrent 2, got %v", got)
+	}
+}
+
+func TestRuleSinkArtist(t *testing.T) {
+	rules := whatapi.Rules{{Name: "radiohead", Artist: "Radiohead"}}
+	n := whatapi.NotificationTorrent{TorrentID: 1, GroupID: 42}
+	e := whatapi.Event{Kind: whatapi.EventNotification, ID: n.TorrentID, Payload: n}
+	events := make(chan whatapi.Event, 1)
+	if err := (whatapi.RuleSink{Rules: rules, Next: whatapi.ChanSink(events)}).Deliver(context.Background(), e); err == nil {
+		t.Error("expected an artist rule without a client to be refused")
+	}
+
+	var group string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") == "index" {
+			rw.Write([]byte(`{"status":"success","response":{"authkey":"a","passkey":"p"}}`))
+			return
+		}
+		group = r.URL.Query().Get("id")
+		rw.Write([]byte(`{"status":"success","response":{"group":{"id":42,"name":"OK Computer","musicInfo":{"artists":[{"id":5,"name":"Radiohead"}]}},"torrents":[]}}`))
+	}))
+	defer srv.Close()
+	c, err := whatapi.NewClient(srv.URL, "agent", whatapi.WithRateLimit(0, 0), whatapi.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Login("user", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = (whatapi.RuleSink{Rules: rules, Next: whatapi.ChanSink(events), Client: c}).Deliver(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+	got := []whatapi.Event{}
+	for e := range events {
+		got = append(got, e)
+	}
+	if group != "42" {
+		t.Errorf("expected the notified group to be looked up, got %q", group)
+	}
+	if len(got) != 1 || got[0].Payload.(whatapi.RuleMatch).Candidate.Artist != "Radiohead" {
+		t.Errorf("expected a match with the group's artist, got %v", got)
+	}
+}
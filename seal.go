@@ -0,0 +1,66 @@
+package whatapi
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// saltSize is the size of the random salt the key is derived with.
+const saltSize = 16
+
+// sealKey derives an AES-256 key from secret with scrypt, at the cost
+// recommended for interactive logins, so that guessing a weak secret is
+// slow.
+func sealKey(secret, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(secret, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts plaintext with AES-GCM, under a key derived from secret.
+// The random salt and nonce are prefixed to the result.
+func Seal(secret, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := sealKey(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(append(salt, nonce...), nonce, plaintext, nil), nil
+}
+
+// Unseal decrypts the output of Seal.
+func Unseal(secret, sealed []byte) ([]byte, error) {
+	if len(sealed) < saltSize {
+		return nil, ErrSealed
+	}
+	gcm, err := sealKey(secret, sealed[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[saltSize:]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrSealed
+	}
+	b, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrSealed
+	}
+	return b, nil
+}
@@ -0,0 +1,41 @@
+package whatapi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	secret, plain := []byte("hunter2"), []byte(`{"authKey":"a","passKey":"p"}`)
+	sealed, err := Seal(secret, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plain) {
+		t.Error("expected the plaintext hidden")
+	}
+	again, _ := Seal(secret, plain)
+	if bytes.Equal(sealed, again) {
+		t.Error("expected a new salt and nonce each time")
+	}
+	if b, err := Unseal(secret, sealed); err != nil || !bytes.Equal(b, plain) {
+		t.Errorf("expected the plaintext back, got %q, %v", b, err)
+	}
+
+	if _, err := Unseal([]byte("hunter3"), sealed); !errors.Is(err, ErrSealed) {
+		t.Errorf("expected the wrong secret to fail, got %v", err)
+	}
+	for _, i := range []int{0, saltSize, len(sealed) - 1} {
+		tampered := append([]byte{}, sealed...)
+		tampered[i] ^= 1
+		if _, err := Unseal(secret, tampered); !errors.Is(err, ErrSealed) {
+			t.Errorf("expected a change at %d to fail, got %v", i, err)
+		}
+	}
+	for _, n := range []int{0, saltSize + 1} {
+		if _, err := Unseal(secret, sealed[:n]); !errors.Is(err, ErrSealed) {
+			t.Errorf("expected %d bytes to fail, got %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// searchText normalises s for a search: composed into Unicode NFC, as the
+// site stores names, so that "é" typed as "e" and a combining accent
+// finds the same as "é", with zero width spaces and byte order marks,
+// often pasted along with a name, dropped, and surrounding space trimmed.
+// Nothing else invisible is dropped: zero width joiners, non-joiners and
+// word joiners change how some scripts read and break.
+func searchText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\u200b', '\ufeff':
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(norm.NFC.String(s))
+}
+
+// searchTexts normalises each of ss, as searchText does, dropping those
+// left empty.
+func searchTexts(ss []string) []string {
+	r := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s = searchText(s); s != "" {
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// TorrentSearchOptions are the typed parameters of a torrent search.
+type TorrentSearchOptions struct {
+	// Categories limits the search to these categories. Nil means all.
+	Categories []Category
+	Tags       []string
+	// TagsAll matches torrents with all of Tags rather than any.
+	TagsAll bool
+	// Page is the page of results to return, from 1. Zero means the
+	// first page.
+	Page int
+
+	// The rest only apply to the music category.
+	ArtistName  string
+	GroupName   string
+	RecordLabel string
+	Year        int
+	ReleaseType int
+	Format      string
+	Encoding    string
+	Media       string
+}
+
+func (o TorrentSearchOptions) musicFilters() bool {
+	return o.ArtistName != "" || o.GroupName != "" || o.RecordLabel != "" ||
+		o.Year != 0 || o.ReleaseType != 0 ||
+		o.Format != "" || o.Encoding != "" || o.Media != ""
+}
+
+func (o TorrentSearchOptions) params() (url.Values, error) {
+	params := url.Values{}
+	music := len(o.Categories) == 0
+	for _, c := range o.Categories {
+		params.Set("filter_cat["+strconv.Itoa(int(c))+"]", "1")
+		music = music || c == CategoryMusic
+	}
+	if o.musicFilters() && !music {
+		return nil, errMusicFilter
+	}
+	if tags := searchTexts(o.Tags); len(tags) > 0 {
+		params.Set("taglist", strings.Join(tags, ","))
+		if o.TagsAll {
+			params.Set("tags_type", "1")
+		} else {
+			params.Set("tags_type", "0")
+		}
+	}
+	if o.Page > 0 {
+		params.Set("page", strconv.Itoa(o.Page))
+	}
+	set := func(k, v string) {
+		if v = searchText(v); v != "" {
+			params.Set(k, v)
+		}
+	}
+	set("artistname", o.ArtistName)
+	set("groupname", o.GroupName)
+	set("recordlabel", o.RecordLabel)
+	if o.Year != 0 {
+		params.Set("year", strconv.Itoa(o.Year))
+	}
+	if o.ReleaseType != 0 {
+		params.Set("releasetype", strconv.Itoa(o.ReleaseType))
+	}
+	set("format", o.Format)
+	set("encoding", o.Encoding)
+	set("media", o.Media)
+	return params, nil
+}
+
+// SearchTorrentsWith retrieves torrent search results using the provided search string and typed options. Music-only filters are rejected when the categories searched exclude music. The search string and text options are normalised to Unicode NFC.
+func (w *ClientStruct) SearchTorrentsWith(searchStr string, opts TorrentSearchOptions) (TorrentSearch, error) {
+	params, err := opts.params()
+	if err != nil {
+		return TorrentSearch{}, err
+	}
+	return w.SearchTorrents(searchText(searchStr), params)
+}
@@ -0,0 +1,84 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSearchText(t *testing.T) {
+	for _, c := range []struct{ in, want string }{
+		{"Sigur Rós", "Sigur Rós"},
+		{"Sigur Ro\u0301s", "Sigur Rós"},
+		{"\ufeffБорис Гребенщиков ", "Борис Гребенщиков"},
+		{"坂本\u200b龍一", "坂本龍一"},
+		{"Ha\u0308n\u0303", "Häñ"},
+		{"\u200b", ""},
+		// the non-joiner is part of the Persian word
+		{"می\u200cخواهم", "می\u200cخواهم"},
+		{"AC\u2060/\u2060DC", "AC\u2060/\u2060DC"},
+	} {
+		if got := searchText(c.in); got != c.want {
+			t.Errorf("searchText(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSearchTorrentsWithNonASCII(t *testing.T) {
+	var query url.Values
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[]}}`))
+	})
+	_, err := c.SearchTorrentsWith("Bjo\u0308rk\u200b", TorrentSearchOptions{
+		ArtistName: "Bjo\u0308rk",
+		GroupName:  " Homogenic",
+		Tags:       []string{"e\u0301lectronique", "\u200b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{
+		"searchstr":  "Björk",
+		"artistname": "Björk",
+		"groupname":  "Homogenic",
+		"taglist":    "électronique",
+	} {
+		if got := query.Get(k); got != want {
+			t.Errorf("expected %s %q, got %q", k, want, got)
+		}
+	}
+}
+
+func TestTorrentSearchOptionsParams(t *testing.T) {
+	p, err := TorrentSearchOptions{
+		Categories: []Category{CategoryMusic, CategoryComics},
+		Tags:       []string{"rock", "pop"},
+		TagsAll:    true,
+		Year:       1997,
+	}.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{
+		"filter_cat[1]": "1",
+		"filter_cat[7]": "1",
+		"taglist":       "rock,pop",
+		"tags_type":     "1",
+		"year":          "1997",
+	} {
+		if got := p.Get(k); got != want {
+			t.Errorf("expected %s %q, got %q", k, want, got)
+		}
+	}
+	if p, _ = (TorrentSearchOptions{Tags: []string{"rock"}}).params(); p.Get("tags_type") != "0" {
+		t.Errorf("expected any of the tags to match, got tags_type %q", p.Get("tags_type"))
+	}
+	if _, err = (TorrentSearchOptions{Year: 1997}).params(); err != nil {
+		t.Errorf("expected music filters to be allowed searching all categories, got %v", err)
+	}
+	_, err = TorrentSearchOptions{Categories: []Category{CategoryEBooks}, ArtistName: "Radiohead"}.params()
+	if err != errMusicFilter {
+		t.Errorf("expected errMusicFilter, got %v", err)
+	}
+}
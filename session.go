@@ -0,0 +1,180 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+)
+
+// session is whether the client is logged in, and the keys of its
+// session, shared by the client and its clones. A relogin replaces them
+// while other requests are reading them, so they are only used through
+// its methods.
+type session struct {
+	mu       sync.RWMutex
+	loggedIn bool
+	authkey  string
+	passkey  string
+	// username is who the session is for, which names the client's
+	// Namespace.
+	username string
+}
+
+func (s *session) isLoggedIn() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loggedIn
+}
+
+func (s *session) setLoggedIn(loggedIn bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loggedIn = loggedIn
+}
+
+func (s *session) user() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.username
+}
+
+func (s *session) setUser(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username = username
+}
+
+func (s *session) keys() (authkey, passkey string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.authkey, s.passkey
+}
+
+func (s *session) setKeys(authkey, passkey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authkey, s.passkey = authkey, passkey
+}
+
+// sessionJar is a cookie jar that can be emptied while requests are using
+// it, which replacing the client's jar can't be.
+type sessionJar struct {
+	mu  sync.RWMutex
+	jar *cookiejar.Jar
+}
+
+func newSessionJar() (*sessionJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionJar{jar: jar}, nil
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	j.jar.SetCookies(u, cookies)
+}
+
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.jar.Cookies(u)
+}
+
+// reset empties the jar.
+func (j *sessionJar) reset() error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = jar
+	return nil
+}
+
+// WithKeyPersistence stores the authkey and passkey in the cache database
+// alongside the session cookies, encrypted by Seal with secret, so that
+// Login can resume the session without asking the site for them. They are
+// trusted until a request finds the session expired, when they are
+// forgotten; call RefreshKeys to check them sooner. It has no effect
+// unless the client is cached. A nil secret, the default, turns
+// persistence off.
+func WithKeyPersistence(secret []byte) Option {
+	return func(w *ClientStruct) error {
+		w.keySecret = secret
+		return nil
+	}
+}
+
+type sessionKeys struct {
+	AuthKey string `json:"authKey"`
+	PassKey string `json:"passKey"`
+}
+
+// loadKeys restores persisted keys, and reports whether there were any.
+// Keys that can't be unsealed, as after the secret changes, are reported
+// as errStaleKeys.
+func (w *ClientStruct) loadKeys(ctx context.Context) (bool, error) {
+	if w.db == nil || w.keySecret == nil {
+		return false, nil
+	}
+	var sealed []byte
+	err := retryBusy(ctx, func() error {
+		return w.db.QueryRowContext(ctx, `SELECT keys FROM sessionkeys WHERE namespace=? AND url=?`,
+			w.Namespace(), w.baseURL.String()).Scan(&sealed)
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	b, err := Unseal(w.keySecret, sealed)
+	if err != nil {
+		return false, errStaleKeys
+	}
+	var k sessionKeys
+	if err := json.Unmarshal(b, &k); err != nil {
+		return false, errStaleKeys
+	}
+	w.session.setKeys(k.AuthKey, k.PassKey)
+	return true, nil
+}
+
+func (w *ClientStruct) saveKeys() error {
+	if w.db == nil || w.keySecret == nil {
+		return nil
+	}
+	authkey, passkey := w.session.keys()
+	b, err := json.Marshal(sessionKeys{authkey, passkey})
+	if err != nil {
+		return err
+	}
+	sealed, err := Seal(w.keySecret, b)
+	if err != nil {
+		return err
+	}
+	return writeCache(context.Background(), w.db, func() error {
+		_, err := w.db.Exec(`REPLACE INTO sessionkeys (namespace, url, keys) VALUES(?,?,?)`,
+			w.Namespace(), w.baseURL.String(), sealed)
+		return err
+	})
+}
+
+func (w *ClientStruct) clearKeys() error {
+	if w.db == nil {
+		return nil
+	}
+	return writeCache(context.Background(), w.db, func() error {
+		_, err := w.db.Exec(`DELETE FROM sessionkeys WHERE namespace=? AND url=?`,
+			w.Namespace(), w.baseURL.String())
+		return err
+	})
+}
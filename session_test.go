@@ -0,0 +1,119 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// persistingClient returns a client of the site at baseURL that keeps its
+// keys, sealed with secret, in db, and takes itself to be user.
+func persistingClient(t *testing.T, baseURL string, db *sql.DB, secret string, opts ...Option) *ClientStruct {
+	t.Helper()
+	c := loggedInClient(t, baseURL, append([]Option{WithKeyPersistence([]byte(secret))}, opts...)...)
+	if err := c.PersistSession(db); err != nil {
+		t.Fatal(err)
+	}
+	c.session.setUser("user")
+	return c
+}
+
+func TestPersistedKeys(t *testing.T) {
+	db := openCache(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := persistingClient(t, srv.URL, db, "secret")
+	c.session.setKeys("auth", "pass")
+	if err := c.saveKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := persistingClient(t, srv.URL, db, "secret")
+	if ok, err := d.loadKeys(context.Background()); err != nil || !ok {
+		t.Fatalf("expected the keys to load, got %v %v", ok, err)
+	}
+	if a, p := d.session.keys(); a != "auth" || p != "pass" {
+		t.Errorf("bad keys %s %s", a, p)
+	}
+
+	d = persistingClient(t, srv.URL, db, "other")
+	if _, err := d.loadKeys(context.Background()); err != errStaleKeys {
+		t.Errorf("expected keys sealed with another secret to be stale, got %v", err)
+	}
+
+	if err := c.clearKeys(); err != nil {
+		t.Fatal(err)
+	}
+	d = persistingClient(t, srv.URL, db, "secret")
+	if ok, err := d.loadKeys(context.Background()); err != nil || ok {
+		t.Errorf("expected cleared keys to be gone, got %v %v", ok, err)
+	}
+}
+
+func TestResumeTrustsPersistedKeys(t *testing.T) {
+	db := openCache(t)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}))
+	defer srv.Close()
+	c := persistingClient(t, srv.URL, db, "secret")
+	c.session.setKeys("auth", "pass")
+	if err := c.saveKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := persistingClient(t, srv.URL, db, "secret")
+	d.session.setLoggedIn(false)
+	if err := d.Login("user", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 0 {
+		t.Errorf("expected the session to resume without asking the site, got %d requests", requests)
+	}
+	if !d.session.isLoggedIn() {
+		t.Error("expected the client to be logged in")
+	}
+	if a, p := d.session.keys(); a != "auth" || p != "pass" {
+		t.Errorf("expected the persisted keys, got %s %s", a, p)
+	}
+}
+
+func TestExpiredSessionClearsKeys(t *testing.T) {
+	db := openCache(t)
+	// the session has expired, and logging in again fails
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`<html><form action="login.php"><input type="password" name="password"></form></html>`))
+	}))
+	defer srv.Close()
+	c := persistingClient(t, srv.URL, db, "secret")
+	c.session.setKeys("auth", "pass")
+	if err := c.saveKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := persistingClient(t, srv.URL, db, "secret")
+	d.session.setLoggedIn(false)
+	if err := d.Login("user", "pw"); err != nil {
+		t.Fatalf("expected the persisted session to be resumed, got %v", err)
+	}
+	if _, err := d.GetTorrent(1, url.Values{}); err != ErrSessionExpired {
+		t.Fatalf("expected the session to have expired, got %v", err)
+	}
+	if ok, err := d.loadKeys(context.Background()); err != nil || ok {
+		t.Errorf("expected the persisted keys to be cleared, got %v %v", ok, err)
+	}
+
+	d = persistingClient(t, srv.URL, db, "secret")
+	d.session.setLoggedIn(false)
+	if err := d.Login("user", "pw"); err == nil {
+		t.Fatal("expected the login to fail")
+	}
+	if d.session.isLoggedIn() {
+		t.Error("expected the client not to be logged in")
+	}
+}
@@ -0,0 +1,65 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// signedDownloadURL gets a download URL for the torrent with the provided
+// id from the site, on forks whose download URLs are signed or expire.
+// It asks the CapDownloadToken action if the site has one, and otherwise
+// reads the profile's DownloadURLField from the torrent. Neither is
+// answered from a cache, as the URLs may only be good once.
+func (w *ClientStruct) signedDownloadURL(id int, useToken bool) (string, error) {
+	ctx := WithCacheDirectives(w.context(), NoCache)
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(id))
+	var signed string
+	if action, err := w.profile.action(CapDownloadToken); err == nil {
+		resp := struct {
+			GenericResponse
+			Response struct {
+				URL string `json:"url"`
+			} `json:"response"`
+		}{}
+		if err = w.DoContext(ctx, action, params, &resp); err != nil {
+			return "", err
+		}
+		if err = checkResponseStatus(resp.Status, resp.Error); err != nil {
+			return "", err
+		}
+		signed = resp.Response.URL
+	} else {
+		resp := struct {
+			GenericResponse
+			Response struct {
+				Torrent map[string]json.RawMessage `json:"torrent"`
+			} `json:"response"`
+		}{}
+		if err = w.DoContext(ctx, "torrent", params, &resp); err != nil {
+			return "", err
+		}
+		if err = checkResponseStatus(resp.Status, resp.Error); err != nil {
+			return "", err
+		}
+		raw, ok := resp.Response.Torrent[w.profile.DownloadURLField]
+		if !ok || json.Unmarshal(raw, &signed) != nil {
+			return "", errRequestFailedReason("torrent has no " + w.profile.DownloadURLField)
+		}
+	}
+	if signed == "" {
+		return "", errRequestFailedReason("site sent no download URL")
+	}
+	// the URL may be relative to the site
+	u, err := w.baseURL.Parse(signed)
+	if err != nil {
+		return "", err
+	}
+	if useToken {
+		q := u.Query()
+		q.Set("usetoken", "1")
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,37 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSignedDownloadURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("action") {
+		case "torrent":
+			rw.Write([]byte(`{"status":"success","response":{"torrent":{"id":1,"downloadUrl":"/dl/1?sig=abc"}}}`))
+		case "dltoken":
+			rw.Write([]byte(`{"status":"success","response":{"url":"https://dl.example.com/1?t=xyz"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		profile SiteProfile
+		exp     string
+	}{
+		{SiteProfile{DownloadURLField: "downloadUrl"}, srv.URL + "/dl/1?sig=abc&usetoken=1"},
+		{SiteProfile{Actions: map[Capability]string{CapDownloadToken: "dltoken"}},
+			"https://dl.example.com/1?t=xyz&usetoken=1"},
+	} {
+		w := loggedInClient(t, srv.URL, WithSiteProfile(tc.profile))
+		u, err := w.createDownloadURL(1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tc.exp {
+			t.Errorf("expected %s, got %s", tc.exp, u)
+		}
+	}
+}
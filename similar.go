@@ -0,0 +1,117 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// ArtistNode is an artist in an ArtistGraph.
+type ArtistNode struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Depth is the number of similarity hops from the seed artist.
+	Depth int `json:"depth"`
+}
+
+// ArtistEdge is a similarity link between two artists, weighted by the
+// site's similarity score.
+type ArtistEdge struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Score int `json:"score"`
+}
+
+// ArtistGraph is a graph of similar artists.
+type ArtistGraph struct {
+	Seed  int
+	Nodes map[int]ArtistNode
+	Edges []ArtistEdge
+}
+
+// CrawlSimilarArtists walks the similar artists of seed breadth first, up
+// to depth hops away, asking for at most limit similar artists of each.
+// Every request goes through c, so it is subject to c's rate limit and
+// cache.
+func CrawlSimilarArtists(c ArtistAPI, seed, depth, limit int) (*ArtistGraph, error) {
+	a, err := c.GetArtist(seed, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	g := &ArtistGraph{
+		Seed:  seed,
+		Nodes: map[int]ArtistNode{seed: {ID: seed, Name: a.Name()}},
+	}
+	linked := map[[2]int]bool{}
+	queue := []int{seed}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		d := g.Nodes[id].Depth
+		if d >= depth {
+			continue
+		}
+		similar, err := c.GetSimilarArtists(id, limit)
+		if err != nil {
+			return g, err
+		}
+		for _, s := range similar {
+			if _, ok := g.Nodes[s.ID]; !ok {
+				g.Nodes[s.ID] = ArtistNode{ID: s.ID, Name: s.Name, Depth: d + 1}
+				queue = append(queue, s.ID)
+			}
+			if linked[[2]int{s.ID, id}] || linked[[2]int{id, s.ID}] {
+				continue
+			}
+			linked[[2]int{id, s.ID}] = true
+			g.Edges = append(g.Edges, ArtistEdge{From: id, To: s.ID, Score: s.Score})
+		}
+	}
+	return g, nil
+}
+
+func (g *ArtistGraph) sortedNodes() []ArtistNode {
+	nodes := make([]ArtistNode, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Depth != nodes[j].Depth {
+			return nodes[i].Depth < nodes[j].Depth
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// MarshalJSON encodes the graph as its seed with lists of nodes and edges.
+func (g *ArtistGraph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Seed  int          `json:"seed"`
+		Nodes []ArtistNode `json:"nodes"`
+		Edges []ArtistEdge `json:"edges"`
+	}{g.Seed, g.sortedNodes(), g.Edges})
+}
+
+// WriteDOT writes the graph in GraphViz DOT format, with edges labelled by
+// score.
+func (g *ArtistGraph) WriteDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "graph similar {"); err != nil {
+		return err
+	}
+	for _, n := range g.sortedNodes() {
+		if _, err := fmt.Fprintf(w, "  %d [label=%s];\n", n.ID, strconv.Quote(n.Name)); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "  %d -- %d [weight=%d, label=%d];\n", e.From, e.To, e.Score, e.Score); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
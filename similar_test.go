@@ -0,0 +1,85 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// similarSite serves artists 1 to 4, where 1 is similar to 2 and 3, 2 to
+// 1 and 3, and 3 to 4.
+type similarSite struct {
+	ArtistAPI
+	asked []int
+}
+
+var similarTo = map[int]string{
+	1: `[{"id":2,"name":"Two","score":50},{"id":3,"name":"Three","score":20}]`,
+	2: `[{"id":1,"name":"One","score":50},{"id":3,"name":"Three","score":10}]`,
+	3: `[{"id":4,"name":"Four","score":5}]`,
+	4: `[]`,
+}
+
+func (s *similarSite) GetArtist(id int, params url.Values) (Artist, error) {
+	a := Artist{}
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{"id":%d,"name":"One"}`, id)), &a)
+	return a, err
+}
+
+func (s *similarSite) GetSimilarArtists(id, limit int) (SimilarArtists, error) {
+	s.asked = append(s.asked, id)
+	var sa SimilarArtists
+	err := json.Unmarshal([]byte(similarTo[id]), &sa)
+	return sa, err
+}
+
+func TestCrawlSimilarArtists(t *testing.T) {
+	s := &similarSite{}
+	g, err := CrawlSimilarArtists(s, 1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.asked) != 1 || len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Errorf("expected one hop, got asked %v, %+v", s.asked, g)
+	}
+	if n := g.Nodes[3]; n.Name != "Three" || n.Depth != 1 {
+		t.Errorf("bad node %+v", n)
+	}
+
+	s = &similarSite{}
+	g, err = CrawlSimilarArtists(s, 1, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 is two hops away, so its own similar artists aren't asked for
+	if fmt.Sprint(s.asked) != "[1 2 3]" {
+		t.Errorf("expected 1, 2 and 3 to be asked for, got %v", s.asked)
+	}
+	if n := g.Nodes[4]; n.Depth != 2 {
+		t.Errorf("bad node %+v", n)
+	}
+	// the link back from 2 to 1 isn't repeated
+	exp := []ArtistEdge{{1, 2, 50}, {1, 3, 20}, {2, 3, 10}, {3, 4, 5}}
+	if fmt.Sprint(g.Edges) != fmt.Sprint(exp) {
+		t.Errorf("expected edges %v, got %v", exp, g.Edges)
+	}
+
+	b, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), `{"seed":1,"nodes":[{"id":1,"name":"One","depth":0},{"id":2,"name":"Two","depth":1}`) {
+		t.Errorf("bad JSON %s", b)
+	}
+	var dot strings.Builder
+	if err = g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"graph similar {\n", `  4 [label="Four"];`, "  2 -- 3 [weight=10, label=10];\n", "}\n"} {
+		if !strings.Contains(dot.String(), line) {
+			t.Errorf("expected %q in\n%s", line, dot.String())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package whatapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+
+// FormatSize formats a size in bytes as the site does, in 1024 based
+// units with two decimal places, or three from terabytes up, as in
+// "1.21 GB".
+func FormatSize(size int64) string {
+	s := float64(size)
+	steps := 0
+	for ; math.Abs(s) >= 1024 && steps < len(sizeUnits)-1; steps++ {
+		s /= 1024
+	}
+	decimals := 2
+	if steps >= 4 {
+		decimals = 3
+	}
+	n := strconv.FormatFloat(s, 'f', decimals, 64)
+	// thousands separators, which only sizes under 1024 need
+	if i := strings.IndexByte(n, '.'); i > 3 && n[0] != '-' {
+		n = n[:i-3] + "," + n[i-3:]
+	}
+	return n + " " + sizeUnits[steps]
+}
+
+// ParseSize parses a size formatted as the site does, such as "1.21 GB"
+// or "1,000.00 B", into bytes. Units are 1024 based whether written as KB
+// or KiB, and a bare number is bytes. Sizes that are negative, not
+// finite or too big for an int64 are refused.
+func ParseSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.Replace(strings.TrimSpace(s), ",", "", -1))
+	t = strings.Replace(t, "IB", "B", 1)
+	unit := 0
+	for i := len(sizeUnits) - 1; i >= 0; i-- {
+		if strings.HasSuffix(t, sizeUnits[i]) {
+			t = strings.TrimSpace(strings.TrimSuffix(t, sizeUnits[i]))
+			unit = i
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(t, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("could not parse size %q", s)
+	}
+	n = math.Round(n * math.Pow(1024, float64(unit)))
+	// float64(math.MaxInt64) rounds up to 1<<63, which doesn't fit
+	if n < 0 || n >= float64(math.MaxInt64) {
+		return 0, fmt.Errorf("size %q is out of range", s)
+	}
+	return int64(n), nil
+}
+
+// ByteSize is a size in bytes that decodes from JSON either as a number or
+// as a string such as "2 GB". See ParseSize.
+type ByteSize int64
+
+// UnmarshalJSON decodes a number of bytes or a size string.
+func (s *ByteSize) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		var n int64
+		if err := json.Unmarshal(b, &n); err != nil {
+			return err
+		}
+		*s = ByteSize(n)
+		return nil
+	}
+	n, err := ParseSize(str)
+	*s = ByteSize(n)
+	return err
+}
@@ -0,0 +1,70 @@
+package whatapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestFormatSize(t *testing.T) {
+	for _, c := range []struct {
+		size int64
+		exp  string
+	}{
+		{0, "0.00 B"},
+		{1000, "1,000.00 B"},
+		{1024, "1.00 KB"},
+		{1299227607, "1.21 GB"},
+		{3 << 40, "3.000 TB"},
+	} {
+		if s := whatapi.FormatSize(c.size); s != c.exp {
+			t.Errorf("expected FormatSize(%d) to be %q, got %q", c.size, c.exp, s)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for _, c := range []struct {
+		s   string
+		exp int64
+	}{
+		{"1,000.00 B", 1000},
+		{"1.00 KB", 1024},
+		{"2 MiB", 2 << 20},
+		{"1.5GB", 3 << 29},
+		{"512", 512},
+		{"-0 B", 0},
+		{"7.5 EB", 15 << 59},
+	} {
+		n, err := whatapi.ParseSize(c.s)
+		if err != nil || n != c.exp {
+			t.Errorf("expected ParseSize(%q) to be %d, got %d, %v", c.s, c.exp, n, err)
+		}
+	}
+	for _, s := range []string{"lots", "", "inf", "-Inf GB", "NaN", "-1 KB", "1e400", "8 EB", "9223372036854775808"} {
+		if n, err := whatapi.ParseSize(s); err == nil {
+			t.Errorf("expected ParseSize(%q) to fail, got %d", s, n)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for _, c := range []struct {
+		json string
+		exp  whatapi.ByteSize
+	}{
+		{`1000`, 1000},
+		{`"2 GB"`, 2 << 30},
+		{`"1.5 KB"`, 1536},
+	} {
+		var s whatapi.ByteSize
+		if err := json.Unmarshal([]byte(c.json), &s); err != nil || s != c.exp {
+			t.Errorf("expected %s to decode to %d, got %d, %v", c.json, c.exp, s, err)
+		}
+	}
+	var s whatapi.ByteSize
+	if err := json.Unmarshal([]byte(`"lots"`), &s); err == nil {
+		t.Error("expected a bad size to fail")
+	}
+}
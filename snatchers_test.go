@@ -0,0 +1,73 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+var snatchersProfile = SiteProfile{
+	Name: "test",
+	Actions: map[Capability]string{
+		CapSnatchers: "torrentsnatchers",
+		CapPeers:     "torrentpeers",
+	},
+}
+
+func TestGetTorrentSnatchers(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":2,"pages":3,"snatchers":[{"userId":5,"username":"alice","time":"2020-01-02 03:04:05","seeding":true}]}}`))
+	}, WithSiteProfile(snatchersProfile))
+	s, err := c.GetTorrentSnatchers(12, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=torrentsnatchers&id=12&page=2"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if s.CurrentPage != 2 || s.Pages != 3 || len(s.Snatchers) != 1 ||
+		s.Snatchers[0].Username != "alice" || !s.Snatchers[0].Seeding {
+		t.Errorf("bad snatchers %+v", s)
+	}
+}
+
+func TestGetTorrentPeers(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"peers":[{"userId":7,"username":"bob","connectable":true,"uploaded":1024,"percent":0.5,"client":"qBittorrent"}]}}`))
+	}, WithSiteProfile(snatchersProfile))
+	p, err := c.GetTorrentPeers(12, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=torrentpeers&id=12&page=1"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if len(p.Peers) != 1 || p.Peers[0].Username != "bob" || !p.Peers[0].Connectable ||
+		p.Peers[0].Uploaded != 1024 || p.Peers[0].Percent != 0.5 {
+		t.Errorf("bad peers %+v", p)
+	}
+}
+
+func TestSnatchersUnsupported(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}, WithSiteProfile(GazelleProfile))
+	if _, err := c.GetTorrentSnatchers(12, 1); err == nil {
+		t.Error("expected snatchers to be unsupported")
+	}
+	if _, err := c.GetTorrentPeers(12, 1); err == nil {
+		t.Error("expected peers to be unsupported")
+	}
+}
+
+func TestSnatchersFailure(t *testing.T) {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"failure","error":"bad id"}`))
+	}, WithSiteProfile(snatchersProfile))
+	if _, err := c.GetTorrentSnatchers(0, 1); err == nil || err.Error() != "Request failed: bad id" {
+		t.Errorf("expected the site's error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package whatapi
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The site's orders of media, formats and encodings, as it lists the
+// torrents of a group. Anything else comes after them.
+var (
+	MediaOrder    = []string{"CD", "DVD", "Vinyl", "Soundboard", "SACD", "DAT", "Cassette", "WEB", "Blu-Ray"}
+	FormatOrder   = []string{"MP3", "FLAC", "Ogg Vorbis", "AAC", "AC3", "DTS"}
+	EncodingOrder = []string{
+		"Lossless", "24bit Lossless", "V0 (VBR)", "V1 (VBR)", "V2 (VBR)",
+		"320", "256", "192", "160", "128", "96", "64",
+		"APS (VBR)", "APX (VBR)", "q8.x (VBR)", "Other",
+	}
+)
+
+// indexOf returns the position of s in order, or len(order) if it isn't
+// there.
+func indexOf(order []string, s string) int {
+	for i, o := range order {
+		if o == s {
+			return i
+		}
+	}
+	return len(order)
+}
+
+// seeders returns the seeders of t, or -1 if it doesn't report them.
+func seeders(t Torrent) int {
+	if s, ok := t.(TorrentStats); ok {
+		return s.SeederCount()
+	}
+	return -1
+}
+
+// uploaded returns when t was uploaded, or the zero time if it doesn't
+// say.
+func uploaded(t Torrent) time.Time {
+	if s, ok := t.(TorrentStats); ok {
+		if u, err := s.UploadTime(); err == nil {
+			return u
+		}
+	}
+	return time.Time{}
+}
+
+// SortBySeeders sorts ts most seeded first. Torrents that don't report
+// their seeders come last.
+func SortBySeeders[T Torrent](ts []T) {
+	sort.SliceStable(ts, func(i, j int) bool { return seeders(ts[i]) > seeders(ts[j]) })
+}
+
+// SortBySize sorts ts largest first, as the site sorts by size.
+func SortBySize[T Torrent](ts []T) {
+	sort.SliceStable(ts, func(i, j int) bool { return ts[i].FileSize() > ts[j].FileSize() })
+}
+
+// SortByTime sorts ts newest first. Torrents that don't say when they
+// were uploaded come last.
+func SortByTime[T Torrent](ts []T) {
+	sort.SliceStable(ts, func(i, j int) bool { return uploaded(ts[i]).After(uploaded(ts[j])) })
+}
+
+// SortByPreference sorts ts by the position of their format and encoding
+// in prefs, such as TranscodeEncodings, most preferred first. Torrents
+// whose format and encoding aren't in prefs come last, and otherwise
+// equal torrents keep their order.
+func SortByPreference[T Torrent](ts []T, prefs [][2]string) {
+	rank := func(t Torrent) int {
+		for i, p := range prefs {
+			if p[0] == t.Format() && p[1] == t.Encoding() {
+				return i
+			}
+		}
+		return len(prefs)
+	}
+	sort.SliceStable(ts, func(i, j int) bool { return rank(ts[i]) < rank(ts[j]) })
+}
+
+// SortByEdition sorts ts as the site lists a group's torrents: the
+// original release first, then remasters by year, with those of no year
+// last, then by title, record label, catalogue number and media, and
+// within each edition by format and encoding.
+func SortByEdition[T Torrent](ts []T) {
+	sort.SliceStable(ts, func(i, j int) bool { return editionLess(ts[i], ts[j]) })
+}
+
+func editionLess(a, b Torrent) bool {
+	if a.Remastered() != b.Remastered() {
+		return !a.Remastered()
+	}
+	if ay, by := a.RemasterYear(), b.RemasterYear(); ay != by {
+		if ay == 0 || by == 0 {
+			return by == 0
+		}
+		return ay < by
+	}
+	if a.RemasterTitle() != b.RemasterTitle() {
+		return a.RemasterTitle() < b.RemasterTitle()
+	}
+	if al, bl := remasterLabel(a), remasterLabel(b); al != bl {
+		return al < bl
+	}
+	if an, bn := remasterNumber(a), remasterNumber(b); an != bn {
+		return an < bn
+	}
+	if am, bm := indexOf(MediaOrder, a.Media()), indexOf(MediaOrder, b.Media()); am != bm {
+		return am < bm
+	}
+	if af, bf := indexOf(FormatOrder, a.Format()), indexOf(FormatOrder, b.Format()); af != bf {
+		return af < bf
+	}
+	return indexOf(EncodingOrder, a.Encoding()) < indexOf(EncodingOrder, b.Encoding())
+}
+
+func remasterLabel(t Torrent) string {
+	if r, ok := t.(TorrentRecordLabel); ok {
+		return r.RemasterRecordLabel()
+	}
+	return ""
+}
+
+func remasterNumber(t Torrent) string {
+	if r, ok := t.(TorrentCatalogueNumber); ok {
+		return r.RemasterCatalogueNumber()
+	}
+	return ""
+}
+
+// SortResultsBySeeders sorts search results most seeded first, counting
+// all of a group's torrents.
+func SortResultsBySeeders(rs []TorrentSearchResultStruct) {
+	seeders := func(r TorrentSearchResultStruct) int {
+		if r.Grouped() {
+			return r.TotalSeeders
+		}
+		return r.Seeders
+	}
+	sort.SliceStable(rs, func(i, j int) bool { return seeders(rs[i]) > seeders(rs[j]) })
+}
+
+// SortResultsBySize sorts search results largest first, by a group's
+// largest torrent.
+func SortResultsBySize(rs []TorrentSearchResultStruct) {
+	size := func(r TorrentSearchResultStruct) int64 {
+		if r.Grouped() {
+			return r.MaxSize
+		}
+		return r.Size
+	}
+	sort.SliceStable(rs, func(i, j int) bool { return size(rs[i]) > size(rs[j]) })
+}
+
+// SortResultsByTime sorts search results most recently uploaded to first,
+// as the site orders them by default.
+func SortResultsByTime(rs []TorrentSearchResultStruct) {
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].uploaded().After(rs[j].uploaded()) })
+}
+
+// uploaded returns when a torrent was last uploaded to the group, which
+// the site gives as a Unix time, or the zero time if it doesn't say.
+func (ts TorrentSearchResultStruct) uploaded() time.Time {
+	if s, err := strconv.ParseInt(ts.GroupTime, 10, 64); err == nil {
+		return time.Unix(s, 0)
+	}
+	if t, err := time.Parse(timeLayout, ts.GroupTime); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,68 @@
+package whatapi
+
+import "testing"
+
+func torrentIDs(ts []SearchTorrentStruct) []int {
+	r := []int{}
+	for _, t := range ts {
+		r = append(r, t.ID())
+	}
+	return r
+}
+
+func TestSortTorrents(t *testing.T) {
+	ts := []SearchTorrentStruct{
+		{TorrentID: 1, FormatF: "MP3", EncodingF: "320", MediaF: "CD", Size: 100, Seeders: 3, Time: "2020-01-02 00:00:00"},
+		{TorrentID: 2, FormatF: "FLAC", EncodingF: "Lossless", MediaF: "CD", Size: 400, Seeders: 9, Time: "2021-01-02 00:00:00",
+			RemasteredF: true, RemasterYearF: 2010},
+		{TorrentID: 3, FormatF: "FLAC", EncodingF: "24bit Lossless", MediaF: "Vinyl", Size: 900, Seeders: 1, Time: "bad",
+			RemasteredF: true},
+		{TorrentID: 4, FormatF: "FLAC", EncodingF: "Lossless", MediaF: "CD", Size: 300, Seeders: 9, Time: "2019-01-02 00:00:00"},
+		{TorrentID: 5, FormatF: "MP3", EncodingF: "V0 (VBR)", MediaF: "CD", Size: 150, Seeders: 5, Time: "2022-01-02 00:00:00",
+			RemasteredF: true, RemasterYearF: 2010},
+	}
+	for _, c := range []struct {
+		name string
+		sort func([]SearchTorrentStruct)
+		want []int
+	}{
+		{"seeders", SortBySeeders[SearchTorrentStruct], []int{2, 4, 5, 1, 3}},
+		{"size", SortBySize[SearchTorrentStruct], []int{3, 2, 4, 5, 1}},
+		{"time", SortByTime[SearchTorrentStruct], []int{5, 2, 1, 4, 3}},
+		{"preference", func(ts []SearchTorrentStruct) { SortByPreference(ts, TranscodeEncodings) }, []int{2, 4, 5, 1, 3}},
+		{"edition", SortByEdition[SearchTorrentStruct], []int{1, 4, 5, 2, 3}},
+	} {
+		sorted := append([]SearchTorrentStruct{}, ts...)
+		c.sort(sorted)
+		if got := torrentIDs(sorted); !equalInts(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSortResults(t *testing.T) {
+	rs := []TorrentSearchResultStruct{
+		{GroupID: 1, TotalSeeders: 5, MaxSize: 10, GroupTime: "1339117820", Torrents: []SearchTorrentStruct{{}}},
+		{GroupID: 2, TorrentID: 20, Seeders: 8, Size: 30, GroupTime: "2021-01-02 00:00:00"},
+		{GroupID: 3, TotalSeeders: 1, MaxSize: 20, GroupTime: "1600000000", Torrents: []SearchTorrentStruct{{}}},
+	}
+	ids := func() []int {
+		r := []int{}
+		for _, g := range rs {
+			r = append(r, g.ID())
+		}
+		return r
+	}
+	SortResultsBySeeders(rs)
+	if got := ids(); !equalInts(got, []int{2, 1, 3}) {
+		t.Errorf("seeders: got %v", got)
+	}
+	SortResultsBySize(rs)
+	if got := ids(); !equalInts(got, []int{2, 3, 1}) {
+		t.Errorf("size: got %v", got)
+	}
+	SortResultsByTime(rs)
+	if got := ids(); !equalInts(got, []int{2, 3, 1}) {
+		t.Errorf("time: got %v", got)
+	}
+}
@@ -0,0 +1,171 @@
+package whatapi
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+// statsWeight is how much each request moves the rolling averages.
+const statsWeight = 0.2
+
+// ActionStats describes the requests a client has sent the site for an
+// action. The averages are exponentially weighted, so they follow how the
+// site is doing now rather than over the client's lifetime. Responses
+// served from a cache aren't counted.
+type ActionStats struct {
+	Requests int
+	Failures int
+	// Latency is the rolling average time to get a response.
+	Latency time.Duration
+	// FailureRate is the rolling fraction of requests that failed,
+	// from 0 to 1.
+	FailureRate float64
+	// BodySize is the rolling average response size in bytes.
+	BodySize float64
+}
+
+type statsTracker struct {
+	mu      sync.Mutex
+	actions map[string]ActionStats
+}
+
+// statsKey is the ajax action u is for, or for other pages the page.
+func statsKey(u *url.URL) string {
+	if a := u.Query().Get("action"); a != "" && path.Base(u.Path) == "ajax.php" {
+		return a
+	}
+	return path.Base(u.Path)
+}
+
+func (t *statsTracker) record(key string, latency time.Duration, size int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.actions == nil {
+		t.actions = map[string]ActionStats{}
+	}
+	// an expired session says nothing about how the site is coping
+	failed := 0.0
+	if err != nil && err != ErrSessionExpired {
+		failed = 1
+	}
+	s, ok := t.actions[key]
+	s.Requests++
+	s.Failures += int(failed)
+	if !ok {
+		s.Latency, s.FailureRate, s.BodySize = latency, failed, float64(size)
+	} else {
+		s.Latency += time.Duration(statsWeight * float64(latency-s.Latency))
+		s.FailureRate += statsWeight * (failed - s.FailureRate)
+		s.BodySize += statsWeight * (float64(size) - s.BodySize)
+	}
+	t.actions[key] = s
+}
+
+func (t *statsTracker) snapshot() map[string]ActionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := make(map[string]ActionStats, len(t.actions))
+	for k, s := range t.actions {
+		m[k] = s
+	}
+	return m
+}
+
+// Stats returns the statistics of the requests the client has sent, by ajax action, or by page for requests outside ajax.php. Clones share their statistics.
+func (w *ClientStruct) Stats() map[string]ActionStats {
+	return w.stats.snapshot()
+}
+
+// Governor adapts the number of requests a crawler keeps in flight to how
+// the site is coping, going by the client's Stats for an action. It allows
+// one more request in flight for each round of requests the site keeps up
+// with, and halves them when responses slow to more than twice the
+// fastest rolling latency seen, or when more than a tenth of requests
+// fail.
+type Governor struct {
+	client   Client
+	action   string
+	min, max int
+
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	good     int // requests done well since the limit last changed
+	baseline time.Duration
+	released chan struct{}
+}
+
+// NewGovernor returns a governor for requests for action sent through c,
+// allowing between min and max of them in flight, starting at min.
+func NewGovernor(c Client, action string, min, max int) *Governor {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &Governor{client: c, action: action, min: min, max: max, limit: min,
+		released: make(chan struct{})}
+}
+
+// Limit returns how many requests may be in flight now.
+func (g *Governor) Limit() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limit
+}
+
+// Acquire waits until another request may be sent, or ctx is done. Each
+// successful Acquire must be followed by a Release once the request is
+// done.
+func (g *Governor) Acquire(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		if g.inFlight < g.limit {
+			g.inFlight++
+			g.mu.Unlock()
+			return nil
+		}
+		released := g.released
+		g.mu.Unlock()
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees the slot of a finished request, and adapts the limit.
+func (g *Governor) Release() {
+	s := g.client.Stats()[g.action]
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+	g.adapt(s)
+	close(g.released)
+	g.released = make(chan struct{})
+}
+
+func (g *Governor) adapt(s ActionStats) {
+	if s.Requests == 0 {
+		return
+	}
+	if g.baseline == 0 || s.Latency < g.baseline {
+		g.baseline = s.Latency
+	}
+	if s.Latency > 2*g.baseline || s.FailureRate > 0.1 {
+		if g.limit /= 2; g.limit < g.min {
+			g.limit = g.min
+		}
+		g.good = 0
+		return
+	}
+	if g.good++; g.good >= g.limit && g.limit < g.max {
+		g.limit++
+		g.good = 0
+	}
+}
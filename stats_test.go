@@ -0,0 +1,49 @@
+package whatapi
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestStatsTracker(t *testing.T) {
+	st := &statsTracker{}
+	st.record("browse", 100*time.Millisecond, 1000, nil)
+	st.record("browse", 200*time.Millisecond, 2000, errors.New("boom"))
+	st.record("browse", 100*time.Millisecond, 0, ErrSessionExpired)
+	s := st.snapshot()["browse"]
+	if s.Requests != 3 || s.Failures != 1 {
+		t.Errorf("expected 3 requests and 1 failure, got %+v", s)
+	}
+	if s.Latency <= 100*time.Millisecond || s.Latency >= 200*time.Millisecond {
+		t.Errorf("expected the latency to be between the samples, got %s", s.Latency)
+	}
+	if s.FailureRate <= 0 || s.FailureRate >= 1 {
+		t.Errorf("expected a partial failure rate, got %v", s.FailureRate)
+	}
+	u, _ := url.Parse("https://example.com/ajax.php?action=browse")
+	l, _ := url.Parse("https://example.com/login.php")
+	if statsKey(u) != "browse" || statsKey(l) != "login.php" {
+		t.Errorf("unexpected keys %q and %q", statsKey(u), statsKey(l))
+	}
+}
+
+func TestGovernorAdapt(t *testing.T) {
+	g := NewGovernor(nil, "browse", 1, 4)
+	fast := ActionStats{Requests: 1, Latency: 100 * time.Millisecond}
+	for i := 0; i < 10; i++ {
+		g.adapt(fast)
+	}
+	if g.limit != 4 {
+		t.Errorf("expected the limit to grow to 4, got %d", g.limit)
+	}
+	g.adapt(ActionStats{Requests: 1, Latency: time.Second})
+	if g.limit != 2 {
+		t.Errorf("expected a slow response to halve the limit, got %d", g.limit)
+	}
+	g.adapt(ActionStats{Requests: 1, Latency: 100 * time.Millisecond, FailureRate: 0.5})
+	if g.limit != 1 {
+		t.Errorf("expected failures to halve the limit, got %d", g.limit)
+	}
+}
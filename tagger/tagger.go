@@ -0,0 +1,222 @@
+// Package tagger turns whatapi torrent metadata into the album and track
+// metadata that tagging tools such as beets apply to files. The JSON field
+// names are beets' own, so an Album can be handed to a beets plugin as is.
+package tagger
+
+import (
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charles-haynes/whatapi"
+	"github.com/charles-haynes/whatapi/tags"
+)
+
+// The roles an artist can have on a release.
+const (
+	RoleMain      = "main"
+	RoleGuest     = "guest"
+	RoleComposer  = "composer"
+	RoleConductor = "conductor"
+	RoleDJ        = "dj"
+	RoleRemixer   = "remixer"
+	RoleProducer  = "producer"
+)
+
+// Artist is an artist credited on a release.
+type Artist struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// Track is an audio file in a torrent, with what its path says about it.
+type Track struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Disc   int    `json:"disc"`
+	Number int    `json:"track"`
+	Title  string `json:"title"`
+	// Artist is the track's artist if the file name gives one, as on
+	// compilations, and otherwise the album artist.
+	Artist string `json:"artist"`
+}
+
+// Album is a torrent's release, normalized for tagging.
+type Album struct {
+	GroupID      int      `json:"group_id"`
+	TorrentID    int      `json:"torrent_id"`
+	Title        string   `json:"album"`
+	AlbumArtist  string   `json:"albumartist"`
+	Artists      []Artist `json:"artists"`
+	Compilation  bool     `json:"comp"`
+	AlbumType    string   `json:"albumtype"`
+	Label        string   `json:"label"`
+	CatalogNum   string   `json:"catalognum"`
+	Year         int      `json:"year"`
+	OriginalYear int      `json:"original_year"`
+	Edition      string   `json:"albumdisambig"`
+	Media        string   `json:"media"`
+	Format       string   `json:"format"`
+	Encoding     string   `json:"encoding"`
+	Genres       []string `json:"genres"`
+	DiscTotal    int      `json:"disctotal"`
+	Tracks       []Track  `json:"tracks"`
+}
+
+// Fetch gets the torrent id and its group from c and returns its album.
+func Fetch(c whatapi.TorrentAPI, id int) (Album, error) {
+	t, err := c.GetTorrent(id, url.Values{})
+	if err != nil {
+		return Album{}, err
+	}
+	return New(t.Group, t.Torrent)
+}
+
+// New returns the album of torrent t in group g. The edition's label,
+// catalogue number and year, if the torrent has them, take the place of
+// the group's. It fails only if t's file list can't be parsed.
+func New(g whatapi.GroupStruct, t whatapi.TorrentStruct) (Album, error) {
+	a := Album{
+		GroupID:      g.ID(),
+		TorrentID:    t.ID(),
+		Title:        g.Name(),
+		AlbumArtist:  g.Artist(),
+		Artists:      artists(g.MusicInfo),
+		AlbumType:    strings.ToLower(whatapi.ReleaseTypeString(g.ReleaseType())),
+		Label:        html.UnescapeString(g.RecordLabel()),
+		CatalogNum:   g.CatalogueNumber(),
+		Year:         g.Year(),
+		OriginalYear: g.Year(),
+		Edition:      html.UnescapeString(t.RemasterTitle()),
+		Media:        t.Media(),
+		Format:       t.Format(),
+		Encoding:     t.Encoding(),
+	}
+	a.Compilation = a.AlbumType == "compilation" || a.AlbumArtist == "VA"
+	if t.Remastered() {
+		if l := t.RemasterRecordLabel(); l != "" {
+			a.Label = html.UnescapeString(l)
+		}
+		if n := t.RemasterCatalogueNumber(); n != "" {
+			a.CatalogNum = html.UnescapeString(n)
+		}
+		if y := t.RemasterYear(); y != 0 {
+			a.Year = y
+		}
+	}
+	for _, tag := range g.Tags() {
+		a.Genres = append(a.Genres, tags.Normalize(tag))
+	}
+	files, err := t.ParseFileList()
+	if err != nil {
+		return Album{}, err
+	}
+	a.Tracks = Tracks(files, a.AlbumArtist, a.Compilation)
+	for _, tr := range a.Tracks {
+		if tr.Disc > a.DiscTotal {
+			a.DiscTotal = tr.Disc
+		}
+	}
+	return a, nil
+}
+
+// artists lists the credits in m, main artists first.
+func artists(m whatapi.MusicInfo) []Artist {
+	var r []Artist
+	for _, c := range []struct {
+		role    string
+		artists []whatapi.MusicInfoStruct
+	}{
+		{RoleMain, m.Artists},
+		{RoleDJ, m.DJ},
+		{RoleGuest, m.With},
+		{RoleComposer, m.Composers},
+		{RoleConductor, m.Conductor},
+		{RoleRemixer, m.RemixedBy},
+		{RoleProducer, m.Producer},
+	} {
+		for _, a := range c.artists {
+			r = append(r, Artist{ID: a.ID, Name: html.UnescapeString(a.Name), Role: c.role})
+		}
+	}
+	return r
+}
+
+var audioExtensions = map[string]bool{
+	".flac": true, ".mp3": true, ".m4a": true, ".ogg": true, ".opus": true,
+	".wav": true, ".aiff": true, ".ape": true, ".wv": true, ".dsf": true,
+	".aac": true,
+}
+
+var (
+	// discDir matches directories such as "CD1", "Disc 2" or "disk_3".
+	discDir = regexp.MustCompile(`(?i)^(?:cd|dis[ck])[\s_.-]*(\d+)\b`)
+	// trackName matches "1-03 Title", "03. Title" or "03 - Title".
+	trackName = regexp.MustCompile(`^(?:(\d+)-(\d+)|(\d+))[\s.)_-]+(.*)$`)
+)
+
+// Tracks infers the tracks of a release from the audio files among files.
+// Discs come from directories named like "CD2" or from numbers like
+// "2-05", and track numbers from the start of each file name; files
+// without one are numbered in order. On compilations a file name of the
+// form "Artist - Title" gives the track's artist, and otherwise every
+// track is by albumArtist.
+func Tracks(files []whatapi.FileStruct, albumArtist string, compilation bool) []Track {
+	tracks := []Track{}
+	for _, f := range files {
+		p := f.Name()
+		if !audioExtensions[strings.ToLower(path.Ext(p))] {
+			continue
+		}
+		tracks = append(tracks, track(p, f.Size, albumArtist, compilation))
+	}
+	sort.SliceStable(tracks, func(i, j int) bool {
+		a, b := tracks[i], tracks[j]
+		if a.Disc != b.Disc {
+			return a.Disc < b.Disc
+		}
+		if a.Number != b.Number && a.Number != 0 && b.Number != 0 {
+			return a.Number < b.Number
+		}
+		return a.Path < b.Path
+	})
+	next := map[int]int{}
+	for i := range tracks {
+		if tracks[i].Number == 0 {
+			tracks[i].Number = next[tracks[i].Disc] + 1
+		}
+		next[tracks[i].Disc] = tracks[i].Number
+	}
+	return tracks
+}
+
+func track(p string, size int64, albumArtist string, compilation bool) Track {
+	t := Track{Path: p, Size: size, Disc: 1, Artist: albumArtist}
+	dir, name := path.Split(p)
+	if m := discDir.FindStringSubmatch(path.Base(dir)); m != nil {
+		t.Disc, _ = strconv.Atoi(m[1])
+	}
+	name = strings.TrimSuffix(name, path.Ext(name))
+	t.Title = name
+	if m := trackName.FindStringSubmatch(name); m != nil {
+		if m[1] != "" {
+			t.Disc, _ = strconv.Atoi(m[1])
+			t.Number, _ = strconv.Atoi(m[2])
+		} else {
+			t.Number, _ = strconv.Atoi(m[3])
+		}
+		t.Title = m[4]
+	}
+	if compilation {
+		if i := strings.Index(t.Title, " - "); i > 0 {
+			t.Artist, t.Title = t.Title[:i], t.Title[i+3:]
+		}
+	}
+	t.Title = strings.TrimSpace(strings.TrimLeft(t.Title, "-. "))
+	return t
+}
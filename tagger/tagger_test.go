@@ -0,0 +1,117 @@
+package tagger
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func files(names ...string) []whatapi.FileStruct {
+	f := []whatapi.FileStruct{}
+	for _, n := range names {
+		f = append(f, whatapi.FileStruct{NameF: n, Size: 1})
+	}
+	return f
+}
+
+func TestTracks(t *testing.T) {
+	got := Tracks(files(
+		"CD2/01. Third.flac",
+		"CD1/02 - Second.flac",
+		"CD1/01 - First.flac",
+		"CD1/folder.jpg",
+		"album.log",
+	), "Artist", false)
+	want := []Track{
+		{Path: "CD1/01 - First.flac", Size: 1, Disc: 1, Number: 1, Title: "First", Artist: "Artist"},
+		{Path: "CD1/02 - Second.flac", Size: 1, Disc: 1, Number: 2, Title: "Second", Artist: "Artist"},
+		{Path: "CD2/01. Third.flac", Size: 1, Disc: 2, Number: 1, Title: "Third", Artist: "Artist"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tracks, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("track %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	for _, c := range []struct {
+		name string
+		comp bool
+		want Track
+	}{
+		{"2-05 Title.mp3", false, Track{Disc: 2, Number: 5, Title: "Title", Artist: "A"}},
+		{"07_Title.m4a", false, Track{Disc: 1, Number: 7, Title: "Title", Artist: "A"}},
+		{"03 - Some One - Song.flac", true, Track{Disc: 1, Number: 3, Title: "Song", Artist: "Some One"}},
+		{"03 - Song - Live.flac", false, Track{Disc: 1, Number: 3, Title: "Song - Live", Artist: "A"}},
+		{"Disc 3/Untitled.flac", false, Track{Disc: 3, Number: 1, Title: "Untitled", Artist: "A"}},
+	} {
+		got := Tracks(files(c.name), "A", c.comp)
+		c.want.Path, c.want.Size = c.name, 1
+		if len(got) != 1 || got[0] != c.want {
+			t.Errorf("Tracks(%q) = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+// site answers GetTorrent with a remastered compilation.
+type site struct {
+	whatapi.TorrentAPI
+}
+
+func (site) GetTorrent(id int, params url.Values) (whatapi.GetTorrentStruct, error) {
+	return whatapi.GetTorrentStruct{
+		Group: whatapi.GroupStruct{
+			IDF:              5,
+			NameF:            "Now &amp; Then",
+			YearF:            1990,
+			RecordLabelF:     "Old Label",
+			CatalogueNumberF: "OLD 1",
+			ReleaseTypeF:     7,
+			MusicInfo: whatapi.MusicInfo{
+				Artists: []whatapi.MusicInfoStruct{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}, {ID: 3, Name: "C"}},
+				DJ:      []whatapi.MusicInfoStruct{{ID: 4, Name: "Mixer"}},
+			},
+			TagsF: []string{"hip.hop", "electronica"},
+		},
+		Torrent: whatapi.TorrentStruct{
+			IDF:                      id,
+			MediaF:                   "CD",
+			FormatF:                  "FLAC",
+			EncodingF:                "Lossless",
+			RemasteredF:              true,
+			RemasterYearF:            2005,
+			RemasterTitleF:           "Deluxe",
+			RemasterRecordLabelF:     "New Label",
+			RemasterCatalogueNumberF: "NEW 2",
+			FileList:                 "01 - A - One.flac{{{10}}}|||02 - B - Two.flac{{{20}}}|||cover.jpg{{{5}}}",
+		},
+	}, nil
+}
+
+func TestFetch(t *testing.T) {
+	a, err := Fetch(site{}, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.GroupID != 5 || a.TorrentID != 9 || a.Title != "Now & Then" || a.AlbumArtist != "Mixer" ||
+		!a.Compilation || a.AlbumType != "compilation" {
+		t.Errorf("bad album %+v", a)
+	}
+	if a.Label != "New Label" || a.CatalogNum != "NEW 2" || a.Year != 2005 || a.OriginalYear != 1990 ||
+		a.Edition != "Deluxe" || a.Media != "CD" || a.Format != "FLAC" {
+		t.Errorf("expected the edition's release details, got %+v", a)
+	}
+	if len(a.Genres) != 2 || a.Genres[0] != "hip hop" || a.Genres[1] != "electronic" {
+		t.Errorf("bad genres %v", a.Genres)
+	}
+	if len(a.Artists) != 4 || a.Artists[0] != (Artist{ID: 1, Name: "A", Role: RoleMain}) ||
+		a.Artists[3] != (Artist{ID: 4, Name: "Mixer", Role: RoleDJ}) {
+		t.Errorf("bad artists %+v", a.Artists)
+	}
+	if a.DiscTotal != 1 || len(a.Tracks) != 2 || a.Tracks[1].Artist != "B" || a.Tracks[1].Title != "Two" {
+		t.Errorf("bad tracks %+v", a.Tracks)
+	}
+}
@@ -0,0 +1,166 @@
+// Package tags normalizes Gazelle tags and relates them through a small
+// curated genre hierarchy.
+package tags
+
+import "strings"
+
+// aliases maps normalized spellings to the canonical tag.
+var aliases = map[string]string{
+	"hiphop":            "hip hop",
+	"hip-hop":           "hip hop",
+	"rap":               "hip hop",
+	"rnb":               "rhythm and blues",
+	"r&b":               "rhythm and blues",
+	"r and b":           "rhythm and blues",
+	"drum n bass":       "drum and bass",
+	"drum & bass":       "drum and bass",
+	"dnb":               "drum and bass",
+	"electronica":       "electronic",
+	"idm":               "intelligent dance music",
+	"post-rock":         "post rock",
+	"post-punk":         "post punk",
+	"lofi":              "lo fi",
+	"lo-fi":             "lo fi",
+	"synthpop":          "synth pop",
+	"synth-pop":         "synth pop",
+	"shoegazing":        "shoegaze",
+	"singer songwriter": "singer-songwriter",
+	"classic rock":      "rock",
+	"alt rock":          "alternative rock",
+	"alternative":       "alternative rock",
+}
+
+// parents is the genre hierarchy, mapping a canonical tag to its parent.
+var parents = map[string]string{
+	"alternative rock":        "rock",
+	"indie rock":              "rock",
+	"post rock":               "rock",
+	"punk":                    "rock",
+	"post punk":               "punk",
+	"hardcore":                "punk",
+	"shoegaze":                "alternative rock",
+	"progressive rock":        "rock",
+	"psychedelic rock":        "rock",
+	"garage rock":             "rock",
+	"metal":                   "rock",
+	"black metal":             "metal",
+	"death metal":             "metal",
+	"doom metal":              "metal",
+	"heavy metal":             "metal",
+	"thrash metal":            "metal",
+	"house":                   "electronic",
+	"techno":                  "electronic",
+	"ambient":                 "electronic",
+	"drum and bass":           "electronic",
+	"dubstep":                 "electronic",
+	"trance":                  "electronic",
+	"intelligent dance music": "electronic",
+	"synth pop":               "pop",
+	"indie pop":               "pop",
+	"dream pop":               "pop",
+	"trap":                    "hip hop",
+	"boom bap":                "hip hop",
+	"soul":                    "rhythm and blues",
+	"funk":                    "rhythm and blues",
+	"bebop":                   "jazz",
+	"free jazz":               "jazz",
+	"fusion":                  "jazz",
+	"baroque":                 "classical",
+	"romantic":                "classical",
+	"opera":                   "classical",
+	"bluegrass":               "country",
+	"americana":               "country",
+	"reggae":                  "caribbean",
+	"dub":                     "reggae",
+	"ska":                     "caribbean",
+}
+
+// Normalize converts a tag as Gazelle stores it ("hip.hop") or as a user
+// types it ("Hip-Hop") to its canonical form ("hip hop").
+func Normalize(tag string) string {
+	t := strings.ToLower(strings.TrimSpace(tag))
+	t = strings.NewReplacer(".", " ", "_", " ").Replace(t)
+	t = strings.Join(strings.Fields(t), " ")
+	if a, ok := aliases[t]; ok {
+		return a
+	}
+	if a, ok := aliases[strings.Replace(t, " ", "", -1)]; ok {
+		return a
+	}
+	return t
+}
+
+// Gazelle converts a tag to the dotted form Gazelle uses in searches.
+func Gazelle(tag string) string {
+	return strings.Replace(Normalize(tag), " ", ".", -1)
+}
+
+// Parent returns the parent genre of tag, or "" if it has none.
+func Parent(tag string) string {
+	return parents[Normalize(tag)]
+}
+
+// Ancestors returns the chain of parent genres of tag, nearest first.
+func Ancestors(tag string) []string {
+	a := []string{}
+	seen := map[string]bool{}
+	for p := Parent(tag); p != "" && !seen[p]; p = parents[p] {
+		seen[p] = true
+		a = append(a, p)
+	}
+	return a
+}
+
+// IsA reports whether tag is genre or one of its descendants.
+func IsA(tag, genre string) bool {
+	genre = Normalize(genre)
+	if Normalize(tag) == genre {
+		return true
+	}
+	for _, a := range Ancestors(tag) {
+		if a == genre {
+			return true
+		}
+	}
+	return false
+}
+
+// Similarity scores how alike two tag lists are, from 0 to 1. Tags that
+// match exactly count fully, and a tag that is a parent genre of one of
+// the other list's tags counts half.
+func Similarity(a, b []string) float64 {
+	expand := func(ts []string) (map[string]bool, map[string]bool) {
+		exact, genres := map[string]bool{}, map[string]bool{}
+		for _, t := range ts {
+			n := Normalize(t)
+			exact[n] = true
+			genres[n] = true
+			for _, p := range Ancestors(n) {
+				genres[p] = true
+			}
+		}
+		return exact, genres
+	}
+	ea, ga := expand(a)
+	eb, gb := expand(b)
+	union := map[string]bool{}
+	for t := range ea {
+		union[t] = true
+	}
+	for t := range eb {
+		union[t] = true
+	}
+	if len(union) == 0 {
+		return 0
+	}
+	score := 0.0
+	for t := range union {
+		switch {
+		case ea[t] && eb[t]:
+			score++
+		case ea[t] && gb[t], eb[t] && ga[t]:
+			score += 0.5
+		}
+	}
+	return score / float64(len(union))
+}
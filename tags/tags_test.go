@@ -0,0 +1,115 @@
+package tags
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, exp := range map[string]string{
+		"hip.hop":           "hip hop",
+		"Hip-Hop":           "hip hop",
+		"  HIPHOP ":         "hip hop",
+		"rap":               "hip hop",
+		"r&b":               "rhythm and blues",
+		"R.and.B":           "rhythm and blues",
+		"drum_n_bass":       "drum and bass",
+		"dnb":               "drum and bass",
+		"lo-fi":             "lo fi",
+		"lo.fi":             "lo fi",
+		"singer.songwriter": "singer-songwriter",
+		"post.rock":         "post rock",
+		"synth  pop":        "synth pop",
+		"indie.rock":        "indie rock",
+		"something.else":    "something else",
+		"":                  "",
+		"alternative":       "alternative rock",
+		"classic rock":      "rock",
+		"IDM":               "intelligent dance music",
+	} {
+		if got := Normalize(in); got != exp {
+			t.Errorf("Normalize(%q) = %q, expected %q", in, got, exp)
+		}
+	}
+}
+
+func TestGazelle(t *testing.T) {
+	for in, exp := range map[string]string{
+		"Hip-Hop":     "hip.hop",
+		"R&B":         "rhythm.and.blues",
+		"indie rock":  "indie.rock",
+		"electronica": "electronic",
+	} {
+		if got := Gazelle(in); got != exp {
+			t.Errorf("Gazelle(%q) = %q, expected %q", in, got, exp)
+		}
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	for in, exp := range map[string]string{
+		"shoegazing":  "alternative rock,rock",
+		"black.metal": "metal,rock",
+		"dub":         "reggae,caribbean",
+		"rock":        "",
+		"unknown":     "",
+	} {
+		if got := strings.Join(Ancestors(in), ","); got != exp {
+			t.Errorf("Ancestors(%q) = %q, expected %q", in, got, exp)
+		}
+	}
+	if got := Parent("post-punk"); got != "punk" {
+		t.Errorf("expected post punk's parent to be punk, got %q", got)
+	}
+}
+
+func TestIsA(t *testing.T) {
+	for _, c := range []struct {
+		tag, genre string
+		exp        bool
+	}{
+		{"rock", "rock", true},
+		{"Hip-Hop", "rap", true},
+		{"shoegaze", "rock", true},
+		{"shoegaze", "alternative", true},
+		{"death.metal", "rock", true},
+		{"trap", "hip.hop", true},
+		{"rock", "metal", false},
+		{"jazz", "rock", false},
+		{"house", "techno", false},
+		{"unknown", "rock", false},
+	} {
+		if got := IsA(c.tag, c.genre); got != c.exp {
+			t.Errorf("IsA(%q, %q) = %v, expected %v", c.tag, c.genre, got, c.exp)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	for _, c := range []struct {
+		a, b []string
+		exp  float64
+	}{
+		{[]string{"rock"}, []string{"rock"}, 1},
+		{[]string{"hip.hop"}, []string{"Rap"}, 1},
+		{[]string{"rock"}, []string{"jazz"}, 0},
+		{nil, nil, 0},
+		{[]string{"rock"}, nil, 0},
+		// rock is the parent of indie rock, half a match of two tags
+		{[]string{"indie.rock"}, []string{"rock"}, 0.25},
+		// rock matches exactly, and is indie rock's parent too
+		{[]string{"rock", "indie.rock"}, []string{"rock"}, 0.5},
+		// siblings share a parent neither list has
+		{[]string{"house"}, []string{"techno"}, 0},
+		{[]string{"shoegaze", "dream.pop"}, []string{"shoegaze", "alternative"}, (1 + 0.5) / 3},
+	} {
+		ab, ba := Similarity(c.a, c.b), Similarity(c.b, c.a)
+		if math.Abs(ab-c.exp) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, expected %v", c.a, c.b, ab, c.exp)
+		}
+		if ab != ba {
+			t.Errorf("Similarity(%q, %q) = %v, but the other way round it is %v", c.a, c.b, ab, ba)
+		}
+	}
+}
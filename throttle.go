@@ -0,0 +1,124 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Failed logins back off from minLoginBackoff, doubling with each
+// failure up to maxLoginBackoff, so a client with a bad password can't
+// get its user or address banned.
+const (
+	minLoginBackoff = time.Minute
+	maxLoginBackoff = 24 * time.Hour
+)
+
+// LoginCooldownError is returned by Login while it is backing off after
+// failed logins. It wraps ErrLoginCooldown.
+type LoginCooldownError struct {
+	Failures int
+	// Until is when the next login may be tried.
+	Until time.Time
+}
+
+func (e *LoginCooldownError) Error() string {
+	return ErrLoginCooldown.Error() + " until " + e.Until.Format(time.RFC3339)
+}
+
+func (e *LoginCooldownError) Unwrap() error {
+	return ErrLoginCooldown
+}
+
+// loginBackoff returns how long to wait after failures failed logins.
+func loginBackoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	d := minLoginBackoff
+	for i := 1; i < failures && d < maxLoginBackoff; i++ {
+		d *= 2
+	}
+	if d > maxLoginBackoff {
+		d = maxLoginBackoff
+	}
+	return d
+}
+
+// loginThrottle counts failed logins to a site. The count is kept in the
+// cache database, if there is one, so that it survives restarts and is
+// shared by every client of the site using it, as the site bans by
+// address and not only by user.
+type loginThrottle struct {
+	mu       sync.Mutex
+	failures int
+	last     time.Time
+}
+
+// load refreshes the count from the cache database.
+func (t *loginThrottle) load(w *ClientStruct) error {
+	if w.db == nil {
+		return nil
+	}
+	var failures int
+	var last time.Time
+	err := retryBusy(context.Background(), func() error {
+		return w.db.QueryRow(`SELECT failures, last FROM loginattempts WHERE url=?`,
+			w.baseURL.String()).Scan(&failures, &last)
+	})
+	if err == sql.ErrNoRows {
+		failures, last, err = 0, time.Time{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	t.failures, t.last = failures, last
+	return nil
+}
+
+// check returns a LoginCooldownError if it is too soon to try again.
+func (t *loginThrottle) check(w *ClientStruct, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(w); err != nil {
+		return err
+	}
+	until := t.last.Add(loginBackoff(t.failures))
+	if t.failures > 0 && now.Before(until) {
+		return &LoginCooldownError{Failures: t.failures, Until: until}
+	}
+	return nil
+}
+
+// failed reports whether the last login failed.
+func (t *loginThrottle) failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failures > 0
+}
+
+// record counts a failed login, or clears the count after a good one.
+func (t *loginThrottle) record(w *ClientStruct, ok bool, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ok {
+		t.failures, t.last = 0, time.Time{}
+	} else {
+		t.failures++
+		t.last = now
+	}
+	if w.db == nil {
+		return nil
+	}
+	return writeCache(context.Background(), w.db, func() error {
+		var err error
+		if ok {
+			_, err = w.db.Exec(`DELETE FROM loginattempts WHERE url=?`, w.baseURL.String())
+		} else {
+			_, err = w.db.Exec(`REPLACE INTO loginattempts (url, failures, last) VALUES(?,?,?)`,
+				w.baseURL.String(), t.failures, t.last)
+		}
+		return err
+	})
+}
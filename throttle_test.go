@@ -0,0 +1,43 @@
+package whatapi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	for failures, exp := range map[int]time.Duration{
+		0: 0, 1: time.Minute, 2: 2 * time.Minute, 5: 16 * time.Minute, 20: 24 * time.Hour,
+	} {
+		if d := loginBackoff(failures); d != exp {
+			t.Errorf("loginBackoff(%d) = %s, expected %s", failures, d, exp)
+		}
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	w := &ClientStruct{}
+	th := &loginThrottle{}
+	now := time.Now()
+	if err := th.check(w, now); err != nil {
+		t.Fatal(err)
+	}
+	th.record(w, false, now)
+	th.record(w, false, now)
+	err := th.check(w, now.Add(time.Minute))
+	var cool *LoginCooldownError
+	if !errors.As(err, &cool) || !errors.Is(err, ErrLoginCooldown) || cool.Failures != 2 {
+		t.Fatalf("expected a cooldown after 2 failures, got %v", err)
+	}
+	if err = th.check(w, now.Add(2*time.Minute)); err != nil {
+		t.Errorf("expected the cooldown to be over, got %v", err)
+	}
+	if !th.failed() {
+		t.Error("expected the throttle to remember the failure")
+	}
+	th.record(w, true, now)
+	if th.failed() {
+		t.Error("expected a good login to clear the failures")
+	}
+}
@@ -0,0 +1,99 @@
+package whatapi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WithTLSConfig makes the client use c for its TLS connections, for
+// example to trust a private CA with c.RootCAs, keeping the rest of its
+// transport. The transport must be an *http.Transport.
+func WithTLSConfig(c *tls.Config) Option {
+	return editTransport(func(t *http.Transport) { t.TLSClientConfig = c.Clone() })
+}
+
+// PinError is returned when the site's certificate chain has none of the
+// keys pinned with WithPinnedKeys. It wraps ErrPinMismatch.
+type PinError struct {
+	Host string
+	// Keys are the SPKIHash of each certificate the site presented.
+	Keys []string
+}
+
+func (e *PinError) Error() string {
+	return ErrPinMismatch.Error() + " for " + e.Host + ", got " + strings.Join(e.Keys, ", ")
+}
+
+func (e *PinError) Unwrap() error {
+	return ErrPinMismatch
+}
+
+// SPKIHash returns the pin of cert's public key, the base64 encoded
+// SHA-256 hash of its subject public key info, as WithPinnedKeys takes.
+// It is the same as the hash in an HPKP "pin-sha256" directive.
+func SPKIHash(cert *x509.Certificate) string {
+	h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// WithPinnedKeys makes the client refuse to talk to its site unless a
+// certificate in the verified chain to the site's certificate has one of
+// the public keys pins, given as SPKIHash returns them, with or without a
+// "sha256/" prefix. Certificates the site presents that aren't in the
+// chain don't count, and a client that skips verification never
+// connects. Pin a backup key, or the CA's, so the site can renew its
+// certificate. Other hosts, such as the image hosts FetchImage uses,
+// aren't pinned. Give it after any WithTLSConfig, which would otherwise
+// replace it.
+func WithPinnedKeys(pins ...string) Option {
+	pinned := map[string]bool{}
+	for _, p := range pins {
+		pinned[strings.TrimPrefix(p, "sha256/")] = true
+	}
+	return func(w *ClientStruct) error {
+		host := w.baseURL.Hostname()
+		serverName := host
+		if net.ParseIP(host) != nil {
+			// TLS doesn't name IP addresses, so any host reached by
+			// address is taken for the site
+			serverName = ""
+		}
+		return editTransport(func(t *http.Transport) {
+			c := &tls.Config{}
+			if t.TLSClientConfig != nil {
+				c = t.TLSClientConfig.Clone()
+			}
+			verify := c.VerifyConnection
+			c.VerifyConnection = func(cs tls.ConnectionState) error {
+				if verify != nil {
+					if err := verify(cs); err != nil {
+						return err
+					}
+				}
+				if !strings.EqualFold(cs.ServerName, serverName) {
+					return nil
+				}
+				// only the verified chains are the site's; a peer can
+				// present any certificate alongside them
+				for _, chain := range cs.VerifiedChains {
+					for _, cert := range chain {
+						if pinned[SPKIHash(cert)] {
+							return nil
+						}
+					}
+				}
+				e := &PinError{Host: host}
+				for _, cert := range cs.PeerCertificates {
+					e.Keys = append(e.Keys, SPKIHash(cert))
+				}
+				return e
+			}
+			t.TLSClientConfig = c
+		})(w)
+	}
+}
@@ -0,0 +1,122 @@
+package whatapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPinnedKeys(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	trust := WithTLSConfig(&tls.Config{RootCAs: roots})
+
+	c := loggedInClient(t, srv.URL, trust)
+	if _, err := c.GetTorrent(1, url.Values{}); err != nil {
+		t.Fatalf("expected the custom CA to be trusted, got %v", err)
+	}
+
+	c = loggedInClient(t, srv.URL, trust, WithPinnedKeys("sha256/"+SPKIHash(srv.Certificate())))
+	if _, err := c.GetTorrent(1, url.Values{}); err != nil {
+		t.Fatalf("expected the pinned key to be accepted, got %v", err)
+	}
+
+	c = loggedInClient(t, srv.URL, trust, WithPinnedKeys("AAAA"))
+	_, err := c.GetTorrent(1, url.Values{})
+	var pin *PinError
+	if !errors.As(err, &pin) || !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("expected a PinError, got %v", err)
+	}
+	if len(pin.Keys) != 1 || pin.Keys[0] != SPKIHash(srv.Certificate()) {
+		t.Errorf("bad keys %v", pin.Keys)
+	}
+}
+
+// newCert returns a certificate for key, signed by parent and its key,
+// or self-signed if parent is nil.
+func newCert(t *testing.T, key *ecdsa.PrivateKey, serial int64, ca bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "cert " + strconv.FormatInt(serial, 10)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestPinnedKeysOnlyInVerifiedChain(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	caKey, leafKey, foreignKey := newKey(), newKey(), newKey()
+	ca := newCert(t, caKey, 1, true, nil, nil)
+	leaf := newCert(t, leafKey, 2, false, ca, caKey)
+	// a certificate the site presents, but that doesn't sign its own
+	foreign := newCert(t, foreignKey, 3, true, nil, nil)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Raw, foreign.Raw},
+		PrivateKey:  leafKey,
+	}}}
+	srv.StartTLS()
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	roots.AddCert(foreign)
+	trust := WithTLSConfig(&tls.Config{RootCAs: roots})
+
+	c := loggedInClient(t, srv.URL, trust, WithPinnedKeys(SPKIHash(foreign)))
+	_, err := c.GetTorrent(1, url.Values{})
+	var pin *PinError
+	if !errors.As(err, &pin) {
+		t.Fatalf("expected a presented key outside the chain to be refused, got %v", err)
+	}
+	if len(pin.Keys) != 2 || pin.Keys[0] != SPKIHash(leaf) || pin.Keys[1] != SPKIHash(foreign) {
+		t.Errorf("bad keys %v", pin.Keys)
+	}
+
+	c = loggedInClient(t, srv.URL, trust, WithPinnedKeys(SPKIHash(ca)))
+	if _, err := c.GetTorrent(1, url.Values{}); err != nil {
+		t.Fatalf("expected the CA's key, which the site doesn't present, to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package whatapi
+
+import "sync"
+
+// TokenPolicy decides which downloads are worth a freeleech token.
+type TokenPolicy struct {
+	// MaxSize is the largest torrent a token is spent on, for sites
+	// where tokens only cover torrents up to a size. Zero means any size.
+	MaxSize int64
+	// MinRatioImpact is how far a download must lower the ratio before
+	// a token is spent on it. A download that would leave the account
+	// below its required ratio always gets a token if one is left.
+	MinRatioImpact float64
+	// Reserve is the number of tokens never spent automatically.
+	Reserve int
+	// Table, if not nil, is used to look up the required ratio after
+	// each download, as in Account.PlanDownload.
+	Table RequiredRatioTable
+}
+
+// TokenDecision is what TokenBudget decided for one download.
+type TokenDecision struct {
+	URL       string
+	UseToken  bool
+	Plan      DownloadPlan // the effect of the download without a token
+	Remaining int          // tokens left after the download
+}
+
+// TokenBudget spends an account's freeleech tokens on the downloads that
+// need them most, keeping track of the tokens left and of the ratio as
+// downloads are made. It is safe for concurrent use.
+type TokenBudget struct {
+	c      Client
+	policy TokenPolicy
+
+	mu      sync.Mutex
+	account Account
+	tokens  int
+}
+
+// NewTokenBudget reads the account's stats and tokens from c.
+func NewTokenBudget(c Client, policy TokenPolicy) (*TokenBudget, error) {
+	a, err := c.GetAccountInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &TokenBudget{
+		c:       c,
+		policy:  policy,
+		account: a,
+		tokens:  a.UserStats.FreeleechTokens,
+	}, nil
+}
+
+// Tokens returns the number of tokens left.
+func (b *TokenBudget) Tokens() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// worthIt reports whether a token should be spent on a download of size
+// bytes with leech status s, and the plan for downloading it without one.
+func (b *TokenBudget) worthIt(size int64, s LeechStatus) (bool, DownloadPlan) {
+	p := b.account.PlanDownload(size, b.policy.Table)
+	switch {
+	case s.Free(), b.tokens <= b.policy.Reserve:
+		return false, p
+	case b.policy.MaxSize > 0 && size > b.policy.MaxSize:
+		return false, p
+	case p.NeedsToken:
+		return true, p
+	}
+	if b.account.UserStats.Downloaded == 0 {
+		// the ratio is infinite, so any download would lower it by an
+		// infinite amount; only the required ratio above can call for a
+		// token
+		return false, p
+	}
+	before := ratio(b.account.UserStats.Uploaded, b.account.UserStats.Downloaded)
+	return before-p.Ratio >= b.policy.MinRatioImpact, p
+}
+
+// UseTokenIfWorthIt decides whether to spend a token on t and returns the
+// download URL to fetch it with. The budget counts the download as made:
+// a token is taken if one is used, otherwise the torrent's size is added
+// to the downloaded total used to plan later downloads.
+func (b *TokenBudget) UseTokenIfWorthIt(t Torrent) (TokenDecision, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	use, plan := b.worthIt(t.FileSize(), t.LeechStatus())
+	d := TokenDecision{UseToken: use, Plan: plan}
+	var err error
+	if use {
+		d.URL, err = b.c.CreateTokenDownloadURL(t.ID())
+	} else {
+		d.URL, err = b.c.CreateDownloadURL(t.ID())
+	}
+	if err != nil {
+		return TokenDecision{}, err
+	}
+	if use {
+		b.tokens--
+	} else if !t.LeechStatus().Free() {
+		b.account.UserStats.Downloaded += t.FileSize()
+	}
+	d.Remaining = b.tokens
+	return d, nil
+}
@@ -0,0 +1,49 @@
+package whatapi
+
+import "testing"
+
+func TestTokenWorthIt(t *testing.T) {
+	var a Account
+	a.UserStats.Uploaded = 20 * gib
+	a.UserStats.Downloaded = 10 * gib
+	a.UserStats.RequiredRatio = 0.6
+	for _, tc := range []struct {
+		policy TokenPolicy
+		tokens int
+		size   int64
+		status LeechStatus
+		exp    bool
+	}{
+		{TokenPolicy{MinRatioImpact: 0.1}, 1, gib, LeechNormal, true},
+		{TokenPolicy{MinRatioImpact: 0.5}, 1, gib, LeechNormal, false},
+		{TokenPolicy{MinRatioImpact: 0.5}, 1, 30 * gib, LeechNormal, true}, // below required
+		{TokenPolicy{}, 1, gib, LeechFree, false},
+		{TokenPolicy{}, 0, gib, LeechNormal, false},
+		{TokenPolicy{Reserve: 1}, 1, gib, LeechNormal, false},
+		{TokenPolicy{MaxSize: gib / 2}, 1, gib, LeechNormal, false},
+	} {
+		b := &TokenBudget{policy: tc.policy, account: a, tokens: tc.tokens}
+		if got, _ := b.worthIt(tc.size, tc.status); got != tc.exp {
+			t.Errorf("%+v with %d tokens, %d bytes, %s: expected %t", tc.policy, tc.tokens, tc.size, tc.status, tc.exp)
+		}
+	}
+}
+
+func TestTokenWorthItNothingDownloaded(t *testing.T) {
+	var a Account
+	a.UserStats.Uploaded = 20 * gib
+	a.UserStats.RequiredRatio = 0.6
+	for _, tc := range []struct {
+		size int64
+		exp  bool
+	}{
+		{gib, false},
+		{0, false},
+		{40 * gib, true}, // below required
+	} {
+		b := &TokenBudget{policy: TokenPolicy{MinRatioImpact: 0.1}, account: a, tokens: 1}
+		if got, _ := b.worthIt(tc.size, LeechNormal); got != tc.exp {
+			t.Errorf("%d bytes with nothing downloaded: expected %t", tc.size, tc.exp)
+		}
+	}
+}
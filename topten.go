@@ -0,0 +1,69 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// topTenLimits are the list lengths Gazelle's top10 action allows; it
+// treats any other limit as 10.
+var topTenLimits = []int{10, 100, 250}
+
+// TopTorrent is a torrent in a top ten list, with its place in the list
+// counting from 1.
+type TopTorrent struct {
+	Rank int
+	TopTenResult
+}
+
+// TopTorrentsDay retrieves the up to limit most active torrents uploaded
+// in the last day.
+func (w *ClientStruct) TopTorrentsDay(limit int) ([]TopTorrent, error) {
+	return w.topTorrents("day", limit)
+}
+
+// TopTorrentsWeek retrieves the up to limit most active torrents uploaded
+// in the last week.
+func (w *ClientStruct) TopTorrentsWeek(limit int) ([]TopTorrent, error) {
+	return w.topTorrents("week", limit)
+}
+
+// TopTorrentsOverall retrieves the up to limit most active torrents of
+// all time.
+func (w *ClientStruct) TopTorrentsOverall(limit int) ([]TopTorrent, error) {
+	return w.topTorrents("overall", limit)
+}
+
+// topTorrents retrieves the top10 list for details, asking for the
+// shortest list the site allows that holds limit torrents.
+func (w *ClientStruct) topTorrents(details string, limit int) ([]TopTorrent, error) {
+	ask := topTenLimits[len(topTenLimits)-1]
+	for _, l := range topTenLimits {
+		if l >= limit {
+			ask = l
+			break
+		}
+	}
+	lists, err := w.GetTopTenTorrents(url.Values{
+		"details": {details},
+		"limit":   {strconv.Itoa(ask)},
+	})
+	if err != nil || len(lists) == 0 {
+		return nil, err
+	}
+	results := lists[0].Results
+	for _, l := range lists {
+		if l.Tag == details {
+			results = l.Results
+			break
+		}
+	}
+	if limit < len(results) {
+		results = results[:limit]
+	}
+	top := make([]TopTorrent, len(results))
+	for i, r := range results {
+		top[i] = TopTorrent{Rank: i + 1, TopTenResult: r}
+	}
+	return top, nil
+}
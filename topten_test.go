@@ -0,0 +1,34 @@
+package whatapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTopTorrents(t *testing.T) {
+	var query string
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"status":"success","response":[{"caption":"Most Active Torrents Uploaded in the Past Week","tag":"week","limit":100,"results":[
+{"torrentId":1,"groupId":10,"artist":"Radiohead","groupName":"OK Computer","format":"FLAC","encoding":"Lossless","seeders":40},
+{"torrentId":2,"groupId":11,"artist":"Portishead","groupName":"Dummy","format":"MP3","encoding":"V0 (VBR)","seeders":30},
+{"torrentId":3,"groupId":12,"artist":"Massive Attack","groupName":"Mezzanine","format":"FLAC","encoding":"24bit Lossless","seeders":20}]}]}`))
+	})
+	top, err := c.TopTorrentsWeek(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=top10&details=week&limit=10&type=torrents"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+	if len(top) != 2 || top[0].Rank != 1 || top[0].TorrentID != 1 || top[0].Name() != "OK Computer" ||
+		top[1].Rank != 2 || top[1].FormatEncoding() != "MP3 V0 (VBR)" {
+		t.Errorf("bad top torrents %+v", top)
+	}
+	if _, err := c.TopTorrentsOverall(101); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "action=top10&details=overall&limit=250&type=torrents"; query != exp {
+		t.Errorf("expected query %s, got %s", exp, query)
+	}
+}
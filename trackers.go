@@ -0,0 +1,152 @@
+package whatapi
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Trackers holds clients for several trackers by name, and runs requests
+// against all of them at once.
+type Trackers struct {
+	mu      sync.RWMutex
+	clients map[string]Client
+}
+
+// NewTrackers returns an empty Trackers.
+func NewTrackers() *Trackers {
+	return &Trackers{clients: map[string]Client{}}
+}
+
+// Add adds c as the client for the tracker called name, replacing any
+// client already added under that name.
+func (t *Trackers) Add(name string, c Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clients[name] = c
+}
+
+// Get returns the client for the tracker called name.
+func (t *Trackers) Get(name string) (Client, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	c, ok := t.clients[name]
+	return c, ok
+}
+
+// Names returns the names of the trackers, in order.
+func (t *Trackers) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.clients))
+	for n := range t.clients {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TrackerErrors maps tracker names to the errors their requests failed
+// with.
+type TrackerErrors map[string]error
+
+func (e TrackerErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for n := range e {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = n + ": " + e[n].Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// each calls f with every tracker's client concurrently, and collects the
+// errors it returns.
+func (t *Trackers) each(f func(name string, c Client) error) error {
+	t.mu.RLock()
+	clients := make(map[string]Client, len(t.clients))
+	for n, c := range t.clients {
+		clients[n] = c
+	}
+	t.mu.RUnlock()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = TrackerErrors{}
+	)
+	for n, c := range clients {
+		wg.Add(1)
+		go func(n string, c Client) {
+			defer wg.Done()
+			if err := f(n, c); err != nil {
+				mu.Lock()
+				errs[n] = err
+				mu.Unlock()
+			}
+		}(n, c)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// TrackerSearchResult is a torrent search result with the tracker it
+// came from.
+type TrackerSearchResult struct {
+	Tracker string
+	TorrentSearchResultStruct
+}
+
+// SearchTorrents runs the same search on every tracker, and returns the
+// first page of results from each, ordered by tracker name. Trackers that
+// fail are reported in a TrackerErrors alongside the other results.
+func (t *Trackers) SearchTorrents(searchStr string, opts TorrentSearchOptions) ([]TrackerSearchResult, error) {
+	var mu sync.Mutex
+	results := []TrackerSearchResult{}
+	err := t.each(func(name string, c Client) error {
+		s, err := c.SearchTorrentsWith(searchStr, opts)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range s.Results {
+			results = append(results, TrackerSearchResult{Tracker: name, TorrentSearchResultStruct: r})
+		}
+		return nil
+	})
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Tracker < results[j].Tracker })
+	return results, err
+}
+
+// TrackerTorrent is a torrent with the tracker it was found on.
+type TrackerTorrent struct {
+	Tracker string
+	GetTorrentStruct
+}
+
+// FindByHash looks for the torrent with the provided info hash on every
+// tracker, ordered by tracker name. A tracker that doesn't have it
+// reports an error in the returned TrackerErrors, as the site does.
+func (t *Trackers) FindByHash(hash string) ([]TrackerTorrent, error) {
+	var mu sync.Mutex
+	found := []TrackerTorrent{}
+	err := t.each(func(name string, c Client) error {
+		r, err := c.GetTorrentByHash(hash)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		found = append(found, TrackerTorrent{Tracker: name, GetTorrentStruct: r})
+		return nil
+	})
+	sort.Slice(found, func(i, j int) bool { return found[i].Tracker < found[j].Tracker })
+	return found, err
+}
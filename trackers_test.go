@@ -0,0 +1,78 @@
+package whatapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// trackerServer returns a client of a fake tracker whose searches find
+// one group with id groupID, and which has a torrent only if hasHash.
+func trackerServer(t *testing.T, groupID int, hasHash bool) *ClientStruct {
+	c, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("action") {
+		case "browse":
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[{"groupId":` +
+				strconv.Itoa(groupID) + `,"groupName":"album"}]}}`))
+		case "torrent":
+			if !hasHash || r.URL.Query().Get("hash") != "ABCDEF" {
+				rw.Write([]byte(`{"status":"failure","error":"bad hash parameter"}`))
+				return
+			}
+			rw.Write([]byte(`{"status":"success","response":{"group":{"id":` + strconv.Itoa(groupID) +
+				`},"torrent":{"id":` + strconv.Itoa(groupID*10) + `}}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	})
+	return c
+}
+
+func TestTrackersNames(t *testing.T) {
+	tr := NewTrackers()
+	red, ops := &ClientStruct{}, &ClientStruct{}
+	tr.Add("red", red)
+	tr.Add("ops", ops)
+	if exp, got := []string{"ops", "red"}, tr.Names(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("expected names %v, got %v", exp, got)
+	}
+	if c, ok := tr.Get("red"); !ok || c != red {
+		t.Errorf("expected red client, got %v %v", c, ok)
+	}
+	if _, ok := tr.Get("btn"); ok {
+		t.Error("expected no client for btn")
+	}
+}
+
+func TestTrackersSearchTorrents(t *testing.T) {
+	tr := NewTrackers()
+	tr.Add("red", trackerServer(t, 1, true))
+	tr.Add("ops", trackerServer(t, 2, false))
+	results, err := tr.SearchTorrents("album", TorrentSearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 ||
+		results[0].Tracker != "ops" || results[0].GroupID != 2 ||
+		results[1].Tracker != "red" || results[1].GroupID != 1 {
+		t.Errorf("bad results %+v", results)
+	}
+}
+
+func TestTrackersFindByHash(t *testing.T) {
+	tr := NewTrackers()
+	tr.Add("red", trackerServer(t, 1, true))
+	tr.Add("ops", trackerServer(t, 2, false))
+	found, err := tr.FindByHash("abcdef")
+	if len(found) != 1 || found[0].Tracker != "red" || found[0].Torrent.ID() != 10 {
+		t.Errorf("bad torrents %+v", found)
+	}
+	errs, ok := err.(TrackerErrors)
+	if !ok || len(errs) != 1 || errs["ops"] == nil {
+		t.Fatalf("expected an error from ops only, got %v", err)
+	}
+	if exp := "ops: " + errs["ops"].Error(); err.Error() != exp {
+		t.Errorf("expected error %q, got %q", exp, err.Error())
+	}
+}
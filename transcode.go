@@ -0,0 +1,101 @@
+package whatapi
+
+// Edition identifies an edition of a release: torrents of the same edition
+// differ only in format and encoding.
+type Edition struct {
+	Media           string
+	Remastered      bool
+	Year            int
+	Title           string
+	RecordLabel     string
+	CatalogueNumber string
+}
+
+// EditionOf returns the edition of the torrent.
+func EditionOf(t TorrentStruct) Edition {
+	return Edition{
+		Media:           t.Media(),
+		Remastered:      t.Remastered(),
+		Year:            t.RemasterYear(),
+		Title:           t.RemasterTitle(),
+		RecordLabel:     t.RemasterRecordLabel(),
+		CatalogueNumber: t.RemasterCatalogueNumber(),
+	}
+}
+
+// The format and encoding pairs of the transcodes an edition should have.
+var (
+	EncodingFLAC = [2]string{"FLAC", "Lossless"}
+	EncodingV0   = [2]string{"MP3", "V0 (VBR)"}
+	Encoding320  = [2]string{"MP3", "320"}
+)
+
+// TranscodeEncodings are the format and encoding pairs every edition with
+// a lossless source should have, in the site's order.
+var TranscodeEncodings = [][2]string{EncodingFLAC, EncodingV0, Encoding320}
+
+// TranscodeCandidate is an edition missing some transcodes, with the
+// torrent they should be made from.
+type TranscodeCandidate struct {
+	Edition Edition
+	Source  TorrentStruct
+	// Missing is the format and encoding pairs the edition lacks.
+	Missing [][2]string
+}
+
+// betterSource reports whether a makes a better transcode source than b:
+// 16 bit over 24 bit, then the better log, then the more seeded.
+func betterSource(a, b TorrentStruct) bool {
+	if (a.Encoding() == EncodingFLAC[1]) != (b.Encoding() == EncodingFLAC[1]) {
+		return a.Encoding() == EncodingFLAC[1]
+	}
+	if a.LogScore != b.LogScore {
+		return a.LogScore > b.LogScore
+	}
+	return a.Seeders > b.Seeders
+}
+
+// TranscodeCandidates returns the editions of g that have a FLAC torrent
+// to transcode from but lack one of the TranscodeEncodings, in the order
+// the editions first appear in g. A 24 bit FLAC counts as a source for the
+// missing 16 bit FLAC.
+func TranscodeCandidates(g TorrentGroup) []TranscodeCandidate {
+	type edition struct {
+		have   map[[2]string]bool
+		source *TorrentStruct
+	}
+	editions := map[Edition]*edition{}
+	order := []Edition{}
+	for i := range g.Torrent {
+		t := &g.Torrent[i]
+		k := EditionOf(*t)
+		e, ok := editions[k]
+		if !ok {
+			e = &edition{have: map[[2]string]bool{}}
+			editions[k] = e
+			order = append(order, k)
+		}
+		e.have[[2]string{t.Format(), t.Encoding()}] = true
+		if t.Format() == "FLAC" && (e.source == nil || betterSource(*t, *e.source)) {
+			e.source = t
+		}
+	}
+
+	candidates := []TranscodeCandidate{}
+	for _, k := range order {
+		e := editions[k]
+		if e.source == nil {
+			continue
+		}
+		missing := [][2]string{}
+		for _, enc := range TranscodeEncodings {
+			if !e.have[enc] {
+				missing = append(missing, enc)
+			}
+		}
+		if len(missing) > 0 {
+			candidates = append(candidates, TranscodeCandidate{Edition: k, Source: *e.source, Missing: missing})
+		}
+	}
+	return candidates
+}
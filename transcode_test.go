@@ -0,0 +1,33 @@
+package whatapi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestTranscodeCandidates(t *testing.T) {
+	tor := func(id int, media, format, encoding string) whatapi.TorrentStruct {
+		return whatapi.TorrentStruct{IDF: id, MediaF: media, FormatF: format, EncodingF: encoding}
+	}
+	g := whatapi.TorrentGroup{Torrent: []whatapi.TorrentStruct{
+		tor(1, "CD", "FLAC", "Lossless"),
+		tor(2, "CD", "MP3", "320"),
+		tor(3, "Vinyl", "FLAC", "24bit Lossless"),
+		tor(4, "WEB", "MP3", "V0 (VBR)"),
+		tor(5, "Vinyl", "FLAC", "Lossless"),
+	}}
+	c := whatapi.TranscodeCandidates(g)
+	if len(c) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(c))
+	}
+	if c[0].Edition.Media != "CD" || c[0].Source.ID() != 1 ||
+		!reflect.DeepEqual(c[0].Missing, [][2]string{whatapi.EncodingV0}) {
+		t.Errorf("unexpected CD candidate %+v", c[0])
+	}
+	if c[1].Edition.Media != "Vinyl" || c[1].Source.ID() != 5 ||
+		!reflect.DeepEqual(c[1].Missing, [][2]string{whatapi.EncodingV0, whatapi.Encoding320}) {
+		t.Errorf("unexpected Vinyl candidate %+v", c[1])
+	}
+}
@@ -0,0 +1,79 @@
+package whatapi
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// The connection pool settings of a new client's transport. Go's default
+// transport keeps only two idle connections per host, so concurrent
+// downloads and prefetches keep opening and closing connections to the
+// site, and each new one costs a TLS handshake.
+const (
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 2 * time.Minute
+)
+
+// HTTPVersion chooses the HTTP version of a client's connections.
+type HTTPVersion int
+
+const (
+	// HTTPAuto uses HTTP/2 with sites that offer it, and HTTP/1.1 with
+	// the rest.
+	HTTPAuto HTTPVersion = iota
+	// HTTP1 only uses HTTP/1.1, for sites whose HTTP/2 is broken.
+	HTTP1
+	// HTTP2 uses HTTP/2 even over a transport with a custom dialer or
+	// TLS configuration, with which Go otherwise falls back to HTTP/1.1.
+	HTTP2
+)
+
+// newTransport returns the transport of a new client.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	t.IdleConnTimeout = DefaultIdleConnTimeout
+	return t
+}
+
+// WithConnectionPool makes the client keep up to maxIdle idle connections
+// to each host, each for up to idleTimeout, in place of the defaults
+// DefaultMaxIdleConnsPerHost and DefaultIdleConnTimeout. A maxIdle of 0
+// means Go's default of 2, and an idleTimeout of 0 keeps idle connections
+// until the site closes them. The transport must be an *http.Transport.
+func WithConnectionPool(maxIdle int, idleTimeout time.Duration) Option {
+	return editTransport(func(t *http.Transport) {
+		t.MaxIdleConnsPerHost = maxIdle
+		t.IdleConnTimeout = idleTimeout
+	})
+}
+
+// WithHTTPVersion makes the client use HTTP version v. The transport
+// must be an *http.Transport.
+func WithHTTPVersion(v HTTPVersion) Option {
+	return editTransport(func(t *http.Transport) {
+		switch v {
+		case HTTP1:
+			t.ForceAttemptHTTP2 = false
+			// a non-nil empty map turns HTTP/2 off
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			if t.TLSClientConfig != nil {
+				// nor offer it, as a config made for HTTP/2 does
+				c := t.TLSClientConfig.Clone()
+				c.NextProtos = nil
+				for _, p := range t.TLSClientConfig.NextProtos {
+					if p != "h2" {
+						c.NextProtos = append(c.NextProtos, p)
+					}
+				}
+				t.TLSClientConfig = c
+			}
+		case HTTP2:
+			t.ForceAttemptHTTP2 = true
+			t.TLSNextProto = nil
+		default:
+			t.ForceAttemptHTTP2 = true
+		}
+	})
+}
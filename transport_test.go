@@ -0,0 +1,49 @@
+package whatapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func transportOf(t *testing.T, opts ...Option) *http.Transport {
+	t.Helper()
+	c, err := NewClient("https://example.com", "agent", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*ClientStruct).client.Transport.(*http.Transport)
+}
+
+func TestConnectionPool(t *testing.T) {
+	tr := transportOf(t)
+	if tr.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || tr.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("expected the default pool, got %d, %s", tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+	tr = transportOf(t, WithConnectionPool(4, time.Minute))
+	if tr.MaxIdleConnsPerHost != 4 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("bad pool %d, %s", tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+}
+
+func TestHTTPVersion(t *testing.T) {
+	var protos []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		protos = append(protos, r.Proto)
+		rw.Write([]byte(`{"status":"success","response":{}}`))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	for _, v := range []HTTPVersion{HTTP1, HTTP2} {
+		c := loggedInClient(t, srv.URL, WithTransport(srv.Client().Transport), WithHTTPVersion(v))
+		if _, err := c.GetTorrent(1, url.Values{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(protos) != 2 || protos[0] != "HTTP/1.1" || protos[1] != "HTTP/2.0" {
+		t.Errorf("expected HTTP/1.1 then HTTP/2, got %v", protos)
+	}
+}
@@ -0,0 +1,221 @@
+package whatapi
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// UploadArtist is an artist credit on an uploaded group. Importance uses
+// the same numbering as GroupExt.Importance.
+type UploadArtist struct {
+	Name       string
+	Importance int
+}
+
+// UploadFile is a file attached to an upload.
+type UploadFile struct {
+	Name string
+	Data []byte
+}
+
+// UploadForm holds the fields of Gazelle's upload.php form. Which fields
+// are used depends on the Category.
+type UploadForm struct {
+	Category Category
+	Torrent  UploadFile
+	// GroupID adds a music torrent to an existing group, in which case
+	// the group fields are not needed.
+	GroupID int
+
+	Title           string
+	Artists         []UploadArtist
+	Year            int
+	RecordLabel     string
+	CatalogueNumber string
+	ReleaseType     int
+
+	Remaster                bool
+	RemasterYear            int
+	RemasterTitle           string
+	RemasterRecordLabel     string
+	RemasterCatalogueNumber string
+
+	Scene        bool
+	Format       string
+	Bitrate      string
+	OtherBitrate string
+	VBR          bool
+	Media        string
+	LogFiles     []UploadFile
+
+	Tags               []string
+	Image              string
+	AlbumDescription   string
+	ReleaseDescription string
+	// Description is the description used by categories other than
+	// Music, Audiobooks and Comedy.
+	Description string
+}
+
+// Validate checks that the fields required for the form's category are
+// present.
+func (f UploadForm) Validate() error {
+	missing := []string{}
+	need := func(ok bool, field string) {
+		if !ok {
+			missing = append(missing, field)
+		}
+	}
+	need(len(f.Torrent.Data) > 0, "Torrent")
+	switch f.Category {
+	case CategoryMusic:
+		if f.GroupID == 0 {
+			need(f.Title != "", "Title")
+			need(len(f.Artists) > 0, "Artists")
+			need(f.Year != 0, "Year")
+			need(f.ReleaseType != 0, "ReleaseType")
+			need(len(f.Tags) > 0, "Tags")
+			need(f.AlbumDescription != "", "AlbumDescription")
+		}
+		if f.Remaster {
+			need(f.RemasterYear != 0, "RemasterYear")
+		}
+		need(f.Format != "", "Format")
+		need(f.Bitrate != "", "Bitrate")
+		need(f.Bitrate != "Other" || f.OtherBitrate != "", "OtherBitrate")
+		need(f.Media != "", "Media")
+	case CategoryAudiobooks, CategoryComedy:
+		need(f.Title != "", "Title")
+		need(f.Year != 0, "Year")
+		need(f.Format != "", "Format")
+		need(f.Bitrate != "", "Bitrate")
+		need(len(f.Tags) > 0, "Tags")
+		need(f.AlbumDescription != "", "AlbumDescription")
+	case CategoryApplications, CategoryEBooks, CategoryELearningVideos, CategoryComics:
+		need(f.Title != "", "Title")
+		need(len(f.Tags) > 0, "Tags")
+		need(f.Description != "", "Description")
+	default:
+		return fmt.Errorf("invalid upload category %d", f.Category)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("upload form for %s is missing %s",
+			f.Category, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Fields returns the form's text fields under their upload.php names.
+func (f UploadForm) Fields() url.Values {
+	v := url.Values{}
+	set := func(k, s string) {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	setInt := func(k string, i int) {
+		if i != 0 {
+			v.Set(k, strconv.Itoa(i))
+		}
+	}
+	check := func(k string, b bool) {
+		if b {
+			v.Set(k, "1")
+		}
+	}
+	v.Set("submit", "true")
+	v.Set("type", strconv.Itoa(int(f.Category)-1))
+	setInt("groupid", f.GroupID)
+	set("title", f.Title)
+	for _, a := range f.Artists {
+		v.Add("artists[]", a.Name)
+		v.Add("importance[]", strconv.Itoa(a.Importance))
+	}
+	setInt("year", f.Year)
+	set("record_label", f.RecordLabel)
+	set("catalogue_number", f.CatalogueNumber)
+	setInt("releasetype", f.ReleaseType)
+	if f.Remaster {
+		v.Set("remaster", "on")
+		setInt("remaster_year", f.RemasterYear)
+		set("remaster_title", f.RemasterTitle)
+		set("remaster_record_label", f.RemasterRecordLabel)
+		set("remaster_catalogue_number", f.RemasterCatalogueNumber)
+	}
+	check("scene", f.Scene)
+	set("format", f.Format)
+	set("bitrate", f.Bitrate)
+	set("other_bitrate", f.OtherBitrate)
+	check("vbr", f.VBR)
+	set("media", f.Media)
+	set("tags", strings.Join(f.Tags, ", "))
+	set("image", f.Image)
+	set("album_desc", f.AlbumDescription)
+	set("release_desc", f.ReleaseDescription)
+	set("desc", f.Description)
+	return v
+}
+
+// Multipart renders the form, with authkey, as a multipart/form-data body
+// and returns it with its content type.
+func (f UploadForm) Multipart(authkey string) ([]byte, string, error) {
+	if err := f.Validate(); err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fields := f.Fields()
+	fields.Set("auth", authkey)
+	for k, vs := range fields {
+		for _, s := range vs {
+			if err := mw.WriteField(k, s); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	attach := func(field string, uf UploadFile) error {
+		fw, err := mw.CreateFormFile(field, uf.Name)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(uf.Data)
+		return err
+	}
+	if err := attach("file_input", f.Torrent); err != nil {
+		return nil, "", err
+	}
+	for _, l := range f.LogFiles {
+		if err := attach("logfiles[]", l); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
+
+// CreateUploadRequest validates f and builds the POST request that would
+// upload it, without sending it.
+func (w *ClientStruct) CreateUploadRequest(f UploadForm) (*http.Request, error) {
+	u, authkey, err := w.CreateUploadURL()
+	if err != nil {
+		return nil, err
+	}
+	body, contentType, err := f.Multipart(authkey)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", w.agent())
+	return req, nil
+}
@@ -0,0 +1,137 @@
+package whatapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func validMusicUpload() UploadForm {
+	return UploadForm{
+		Category:         CategoryMusic,
+		Torrent:          UploadFile{Name: "a.torrent", Data: []byte("d4:infodee")},
+		Title:            "Album",
+		Artists:          []UploadArtist{{Name: "Artist", Importance: 1}},
+		Year:             1999,
+		ReleaseType:      1,
+		Format:           "FLAC",
+		Bitrate:          "Lossless",
+		Media:            "CD",
+		Tags:             []string{"rock"},
+		AlbumDescription: "tracklist",
+	}
+}
+
+func validOtherUpload(c Category) UploadForm {
+	return UploadForm{
+		Category:    c,
+		Torrent:     UploadFile{Name: "a.torrent", Data: []byte("d4:infodee")},
+		Title:       "Title",
+		Tags:        []string{"tag"},
+		Description: "about it",
+	}
+}
+
+func TestUploadValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		form    func() UploadForm
+		missing string // empty for a valid form
+	}{
+		{"music", validMusicUpload, ""},
+		{"no torrent", func() UploadForm { f := validMusicUpload(); f.Torrent.Data = nil; return f }, "Torrent"},
+		{"no title", func() UploadForm { f := validMusicUpload(); f.Title = ""; return f }, "Title"},
+		{"no artists", func() UploadForm { f := validMusicUpload(); f.Artists = nil; return f }, "Artists"},
+		{"no year", func() UploadForm { f := validMusicUpload(); f.Year = 0; return f }, "Year"},
+		{"no release type", func() UploadForm { f := validMusicUpload(); f.ReleaseType = 0; return f }, "ReleaseType"},
+		{"no tags", func() UploadForm { f := validMusicUpload(); f.Tags = nil; return f }, "Tags"},
+		{"no album description", func() UploadForm { f := validMusicUpload(); f.AlbumDescription = ""; return f }, "AlbumDescription"},
+		{"existing group", func() UploadForm {
+			f := validMusicUpload()
+			f.GroupID, f.Title, f.Artists, f.Year, f.ReleaseType, f.Tags, f.AlbumDescription = 5, "", nil, 0, 0, nil, ""
+			return f
+		}, ""},
+		{"remaster without year", func() UploadForm { f := validMusicUpload(); f.Remaster = true; return f }, "RemasterYear"},
+		{"remaster", func() UploadForm { f := validMusicUpload(); f.Remaster, f.RemasterYear = true, 2009; return f }, ""},
+		{"no format", func() UploadForm { f := validMusicUpload(); f.Format = ""; return f }, "Format"},
+		{"no bitrate", func() UploadForm { f := validMusicUpload(); f.Bitrate = ""; return f }, "Bitrate"},
+		{"other bitrate unset", func() UploadForm { f := validMusicUpload(); f.Bitrate = "Other"; return f }, "OtherBitrate"},
+		{"other bitrate", func() UploadForm { f := validMusicUpload(); f.Bitrate, f.OtherBitrate = "Other", "96"; return f }, ""},
+		{"no media", func() UploadForm { f := validMusicUpload(); f.Media = ""; return f }, "Media"},
+		{"audiobook", func() UploadForm {
+			f := validMusicUpload()
+			f.Category, f.Artists, f.Media, f.ReleaseType = CategoryAudiobooks, nil, "", 0
+			return f
+		}, ""},
+		{"audiobook without format", func() UploadForm {
+			f := validMusicUpload()
+			f.Category, f.Format = CategoryAudiobooks, ""
+			return f
+		}, "Format"},
+		{"comedy without album description", func() UploadForm {
+			f := validMusicUpload()
+			f.Category, f.AlbumDescription = CategoryComedy, ""
+			return f
+		}, "AlbumDescription"},
+		{"application", func() UploadForm { return validOtherUpload(CategoryApplications) }, ""},
+		{"e-book without title", func() UploadForm { f := validOtherUpload(CategoryEBooks); f.Title = ""; return f }, "Title"},
+		{"comic without tags", func() UploadForm { f := validOtherUpload(CategoryComics); f.Tags = nil; return f }, "Tags"},
+		{"e-learning video without description", func() UploadForm {
+			f := validOtherUpload(CategoryELearningVideos)
+			f.Description = ""
+			return f
+		}, "Description"},
+	} {
+		err := tc.form().Validate()
+		switch {
+		case tc.missing == "" && err != nil:
+			t.Errorf("%s: expected valid form, got %v", tc.name, err)
+		case tc.missing != "" && (err == nil || !strings.Contains(err.Error(), "missing "+tc.missing)):
+			t.Errorf("%s: expected %s to be missing, got %v", tc.name, tc.missing, err)
+		}
+	}
+}
+
+func TestUploadValidateCategory(t *testing.T) {
+	f := validMusicUpload()
+	f.Category = 0
+	if err := f.Validate(); err == nil || !strings.Contains(err.Error(), "invalid upload category") {
+		t.Errorf("expected an invalid category, got %v", err)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	f := validMusicUpload()
+	f.LogFiles = []UploadFile{{Name: "rip.log", Data: []byte("log")}}
+	body, contentType, err := f.Multipart("auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, exp := range map[string]string{"auth": "auth", "type": "0", "title": "Album", "artists[]": "Artist", "tags": "rock"} {
+		if v := form.Value[k]; len(v) != 1 || v[0] != exp {
+			t.Errorf("expected %s %q, got %q", k, exp, v)
+		}
+	}
+	if fh := form.File["logfiles[]"]; len(fh) != 1 || fh[0].Filename != "rip.log" {
+		t.Errorf("bad log files %v", fh)
+	} else if r, err := fh[0].Open(); err != nil {
+		t.Error(err)
+	} else if b, _ := ioutil.ReadAll(r); string(b) != "log" {
+		t.Errorf("bad log file contents %q", b)
+	}
+	f.Title = ""
+	if _, _, err = f.Multipart("auth"); err == nil {
+		t.Error("expected an invalid form not to be rendered")
+	}
+}
@@ -0,0 +1,79 @@
+package whatapi
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// URLBuilder builds request URLs for a Gazelle site.
+type URLBuilder struct {
+	// Base is the site's URL. Its path is the directory the site is
+	// served from, with or without a trailing slash, and its query
+	// parameters are kept in every URL built.
+	Base url.URL
+}
+
+// NewURLBuilder returns a builder for the site at base.
+func NewURLBuilder(base string) (URLBuilder, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return URLBuilder{}, err
+	}
+	return URLBuilder{Base: *u}, nil
+}
+
+// Build returns the URL of endpoint with the action and params. A relative
+// endpoint, such as "ajax.php", is resolved against the base path, an
+// absolute one replaces it. Empty actions are left out. Spaces in the query
+// are escaped as %20, not +, which some forks don't decode, and parameter
+// keys containing spaces are rejected.
+func (b URLBuilder) Build(endpoint, action string, params url.Values) (string, error) {
+	u := b.Base
+	if strings.HasPrefix(endpoint, "/") {
+		u.Path = endpoint
+	} else {
+		u.Path = path.Join("/", u.Path, endpoint)
+	}
+	u.RawPath = ""
+	query := u.Query()
+	if action != "" {
+		query.Set("action", action)
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "" || strings.ContainsAny(k, " \t\r\n") {
+			return "", errBadParamKey(k)
+		}
+		query[k] = append([]string(nil), params[k]...)
+	}
+	// Encode escapes a literal + as %2B, so any + left is a space.
+	u.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
+	if debugMode {
+		fmt.Println(u.String())
+	}
+	return u.String(), nil
+}
+
+func buildURL(u url.URL, path, action string, params url.Values) (string, error) {
+	return URLBuilder{Base: u}.Build(path, action, params)
+}
+
+// ajaxURL returns the URL of action on the site's API, with params, at
+// the path the site profile gives ajax.php.
+func (w *ClientStruct) ajaxURL(action string, params url.Values) (string, error) {
+	return buildURL(w.baseURL, w.profile.path("ajax.php"), action, params)
+}
+
+// PageURL returns the URL of the site's page at endpoint, such as
+// "torrents.php", with params, at the path the site profile gives it, for
+// links to the site.
+func (w *ClientStruct) PageURL(endpoint string, params url.Values) (string, error) {
+	return buildURL(w.baseURL, w.profile.path(endpoint), "", params)
+}
@@ -0,0 +1,76 @@
+package whatapi_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/charles-haynes/whatapi"
+)
+
+func TestURLBuilder(t *testing.T) {
+	tests := []struct {
+		base, endpoint, action string
+		params                 url.Values
+		exp                    string
+	}{
+		{"https://example.com", "ajax.php", "index", nil,
+			"https://example.com/ajax.php?action=index"},
+		{"https://example.com/", "ajax.php", "index", nil,
+			"https://example.com/ajax.php?action=index"},
+		{"https://example.com/gazelle", "ajax.php", "", nil,
+			"https://example.com/gazelle/ajax.php"},
+		{"https://example.com/gazelle/", "ajax.php", "", nil,
+			"https://example.com/gazelle/ajax.php"},
+		{"https://example.com/gazelle/", "/api.php", "", nil,
+			"https://example.com/api.php"},
+		{"https://example.com/?site=1", "ajax.php", "browse", nil,
+			"https://example.com/ajax.php?action=browse&site=1"},
+		{"https://example.com/", "ajax.php", "browse",
+			url.Values{"searchstr": {"Björk a+b"}},
+			"https://example.com/ajax.php?action=browse&searchstr=Bj%C3%B6rk%20a%2Bb"},
+		{"https://example.com/", "ajax.php", "",
+			url.Values{"tags[]": {"rock", "pop"}},
+			"https://example.com/ajax.php?tags%5B%5D=rock&tags%5B%5D=pop"},
+	}
+	for _, tc := range tests {
+		b, err := whatapi.NewURLBuilder(tc.base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := b.Build(tc.endpoint, tc.action, tc.params)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error %v", tc.base, tc.endpoint, err)
+			continue
+		}
+		if got != tc.exp {
+			t.Errorf("%s %s: expected %s, got %s", tc.base, tc.endpoint, tc.exp, got)
+		}
+	}
+}
+
+func TestURLBuilderBadKey(t *testing.T) {
+	b, err := whatapi.NewURLBuilder("https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.Build("ajax.php", "", url.Values{"search str": {"x"}}); err == nil {
+		t.Error("expected an error for a key with a space")
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	c, err := whatapi.NewClient("https://site.example/sub/", "agent",
+		whatapi.WithSiteProfile(whatapi.SiteProfile{Paths: map[string]string{"torrents.php": "t.php"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for endpoint, exp := range map[string]string{
+		"torrents.php": "https://site.example/sub/t.php?id=1",
+		"artist.php":   "https://site.example/sub/artist.php?id=1",
+	} {
+		u, err := c.PageURL(endpoint, url.Values{"id": {"1"}})
+		if err != nil || u != exp {
+			t.Errorf("expected %s, got %s, %v", exp, u, err)
+		}
+	}
+}
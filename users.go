@@ -0,0 +1,67 @@
+package whatapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// UsersIter steps through every result of a user search, fetching pages
+// as needed.
+type UsersIter struct {
+	resultsIter[UserSearchResult]
+}
+
+// SearchUsersIter returns an iterator over all of the user search results for the provided search string.
+func (w *ClientStruct) SearchUsersIter(searchStr string) *UsersIter {
+	params := url.Values{}
+	return &UsersIter{resultsIter[UserSearchResult]{
+		fetch: func(page int) ([]UserSearchResult, int, error) {
+			params.Set("page", strconv.Itoa(page))
+			s, err := w.SearchUsers(searchStr, params)
+			return s.Results, s.Pages, err
+		},
+		id: func(r UserSearchResult) int { return r.UserID },
+	}}
+}
+
+// UserSearchOptions are the options of SearchUsersAll.
+type UserSearchOptions struct {
+	// Limit caps the number of results. Zero means no limit.
+	Limit int
+	// HydrateUsers fetches each user's profile after the search.
+	HydrateUsers bool
+	// Concurrency is the number of profiles fetched at once, at least 1.
+	// Requests still share the client's rate limit.
+	Concurrency int
+}
+
+// UserSearchHit is a user search result, with the user's profile if it
+// was asked for.
+type UserSearchHit struct {
+	UserSearchResult
+	Profile *User
+	// Err is the error fetching the profile, if any.
+	Err error
+}
+
+// SearchUsersAll retrieves every user search result for the provided search string, with their profiles if opts.HydrateUsers is set. A profile that can't be fetched is reported in its hit rather than failing the search.
+func (w *ClientStruct) SearchUsersAll(searchStr string, opts UserSearchOptions) ([]UserSearchHit, error) {
+	it := w.SearchUsersIter(searchStr)
+	hits := []UserSearchHit{}
+	for (opts.Limit == 0 || len(hits) < opts.Limit) && it.Next() {
+		hits = append(hits, UserSearchHit{UserSearchResult: it.Result()})
+	}
+	if err := it.Err(); err != nil || !opts.HydrateUsers {
+		return hits, err
+	}
+
+	parallel(len(hits), opts.Concurrency, func(i int) {
+		u, err := w.GetUser(hits[i].UserID)
+		if err != nil {
+			hits[i].Err = err
+			return
+		}
+		hits[i].Profile = &u
+	})
+	return hits, nil
+}
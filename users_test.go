@@ -0,0 +1,78 @@
+package whatapi
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestSearchUsersAllWithoutCurrentPage(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		pages map[string]string
+		exp   int
+	}{
+		{"empty page", map[string]string{
+			"1": `[{"userId":1},{"userId":2}]`,
+			"2": `[{"userId":3}]`,
+			"3": `[]`,
+		}, 3},
+		{"repeated page", map[string]string{
+			"1": `[{"userId":1},{"userId":2}]`,
+			"2": `[{"userId":1},{"userId":2}]`,
+		}, 2},
+	} {
+		requests := 0
+		w, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+			if requests++; requests > 10 {
+				t.Errorf("%s: didn't stop paging", c.name)
+				rw.Write([]byte(`{"status":"success","response":{"results":[]}}`))
+				return
+			}
+			rw.Write([]byte(`{"status":"success","response":{"results":` + c.pages[r.URL.Query().Get("page")] + `}}`))
+		})
+		hits, err := w.SearchUsersAll("agent", UserSearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != c.exp {
+			t.Errorf("%s: expected %d hits, got %+v", c.name, c.exp, hits)
+		}
+	}
+}
+
+func TestSearchUsersAllHydrates(t *testing.T) {
+	w, _ := newTestClient(t, func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "usersearch":
+			rw.Write([]byte(`{"status":"success","response":{"currentPage":1,"pages":1,"results":[{"userId":1,"username":"one"},{"userId":2,"username":"two"},{"userId":3,"username":"gone"}]}}`))
+		case "user":
+			if q.Get("id") == "3" {
+				rw.Write([]byte(`{"status":"failure","error":"no such user"}`))
+				return
+			}
+			rw.Write([]byte(`{"status":"success","response":{"username":"user` + q.Get("id") + `"}}`))
+		}
+	})
+	hits, err := w.SearchUsersAll("agent", UserSearchOptions{HydrateUsers: true, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 hits, got %+v", hits)
+	}
+	for _, h := range hits[:2] {
+		if h.Err != nil || h.Profile == nil || h.Profile.Username != "user"+strconv.Itoa(h.UserID) {
+			t.Errorf("expected user %d's profile, got %+v", h.UserID, h)
+		}
+	}
+	if hits[2].Err == nil || hits[2].Profile != nil {
+		t.Errorf("expected the missing profile to be reported in its hit, got %+v", hits[2])
+	}
+
+	hits, err = w.SearchUsersAll("agent", UserSearchOptions{Limit: 1})
+	if err != nil || len(hits) != 1 || hits[0].Profile != nil {
+		t.Errorf("expected one hit without a profile, got %+v, %v", hits, err)
+	}
+}
@@ -3,7 +3,7 @@ package whatapi
 import (
 	"errors"
 	"fmt"
-	"net/url"
+	"sync"
 )
 
 var (
@@ -11,33 +11,102 @@ var (
 	errRequestFailed       = errors.New("Request failed")
 	errRequestFailedLogin  = errors.New("Request failed: not logged in")
 	errRequestFailedReason = func(err string) error { return fmt.Errorf("Request failed: %s", err) }
+	errNoCache             = errors.New("Request failed: client has no cache")
+	errNotDecorator        = errors.New("Request failed: client can't be decorated")
+	errStaleKeys           = errors.New("Request failed: persisted keys can't be unsealed")
+	errRuleNeedsClient     = errors.New("Request failed: artist rules need a client to look up notified artists")
+	errNotTorrent          = errors.New("Request failed: response is not a torrent file")
+	errUnsupported         = func(c Capability) error { return fmt.Errorf("Request failed: %s not supported by this site", c) }
+	errMusicFilter         = errors.New("Request failed: music filters need the music category")
+	errBadTorrentName      = func(name string) error { return fmt.Errorf("Save failed: bad torrent file name %q", name) }
+	errBadParamKey         = func(k string) error { return fmt.Errorf("Request failed: bad parameter name %q", k) }
 	debugMode              = false
 )
 
-func buildURL(u url.URL, path, action string, params url.Values) (string, error) {
-	u.Path = path
-	query := make(url.Values)
-	if action != "" {
-		query.Set("action", action)
-	}
-	for param, values := range params {
-		for _, value := range values {
-			query.Set(param, value)
-		}
-	}
-	u.RawQuery = query.Encode()
-	if debugMode {
-		fmt.Println(u.String())
-	}
-	return u.String(), nil
+var (
+	// ErrResponseTooLarge is returned for a response larger than the
+	// client's limit for it. See WithMaxResponseSize.
+	ErrResponseTooLarge = errors.New("Request failed: response too large")
+	// ErrUnexpectedHTML is returned when the site sends an HTML page
+	// where JSON was expected, which usually means the session expired.
+	ErrUnexpectedHTML = errors.New("Request failed: got an HTML page instead of JSON")
+	// ErrSessionExpired is returned when the site sends its login page,
+	// or redirects to it, because the session has expired.
+	ErrSessionExpired = errors.New("Request failed: session expired")
+	// ErrTooManyPages is returned by FetchAll when there are more pages
+	// than it is allowed to fetch.
+	ErrTooManyPages = errors.New("Request failed: too many pages")
+	// ErrCloudflareChallenge is wrapped in the BlockedError returned
+	// when Cloudflare answers with a challenge page.
+	ErrCloudflareChallenge = errors.New("Request failed: Cloudflare challenge")
+	// ErrSiteMaintenance is wrapped in the BlockedError returned when
+	// the site answers with a maintenance page.
+	ErrSiteMaintenance = errors.New("Request failed: site is down for maintenance")
+	// ErrNotCached is returned for a request made with the CacheOnly
+	// directive when there is no cached response.
+	ErrNotCached = errors.New("Request failed: response is not cached")
+	// ErrLoginCooldown is wrapped in the LoginCooldownError returned
+	// when Login is backing off after failed logins.
+	ErrLoginCooldown = errors.New("Login failed: too many failed logins, backing off")
+	// ErrSealed is returned by Unseal for data that is too short, or can't
+	// be opened with the secret given: it was sealed with another secret,
+	// or has been changed since.
+	ErrSealed = errors.New("Unseal failed: wrong secret or damaged data")
+	// ErrRedirectLoop and ErrTooManyRedirects are wrapped in the
+	// RedirectError returned when a request is redirected in a loop, or
+	// more times than the client allows.
+	ErrRedirectLoop     = errors.New("Request failed: redirect loop")
+	ErrTooManyRedirects = errors.New("Request failed: too many redirects")
+	// ErrPinMismatch is wrapped in the PinError returned when the site's
+	// certificates don't have a key pinned with WithPinnedKeys.
+	ErrPinMismatch = errors.New("Request failed: certificate doesn't match pinned keys")
+)
+
+// statusError is the error of a response whose status isn't success, as
+// when what was asked for doesn't exist.
+type statusError string
+
+func (e statusError) Error() string {
+	return "Request failed: " + string(e)
+}
+
+// failedStatus reports whether err is the site answering with a failure
+// status, rather than not answering.
+func failedStatus(err error) bool {
+	var s statusError
+	return err == errRequestFailed || errors.As(err, &s)
 }
 
 func checkResponseStatus(status, errorStr string) error {
 	if status != "success" {
 		if errorStr != "" {
-			return errRequestFailedReason(errorStr)
+			return statusError(errorStr)
 		}
 		return errRequestFailed
 	}
 	return nil
 }
+
+// parallel calls f for each of 0 to n-1, from up to workers goroutines at
+// once, and returns when all the calls have.
+func parallel(n, workers int, f func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,287 @@
+package whatapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The kinds of Event a Watcher raises.
+const (
+	EventNotification = "notification"
+	EventAnnouncement = "announcement"
+	EventBlogPost     = "blog"
+	// EventCollageAddition is raised for each entry added to a collage
+	// the watcher watches. See Watcher.WatchCollages.
+	EventCollageAddition = "collage"
+	// EventArtistRelease is raised for each torrent group new to an
+	// artist the user has bookmarked. See
+	// Watcher.WatchBookmarkedArtists.
+	EventArtistRelease = "release"
+)
+
+// Event is something new on the site: a torrent notification, an
+// announcement, a blog post, an addition to a collage or a release by a
+// bookmarked artist.
+type Event struct {
+	Kind string `json:"kind"`
+	// ID is the torrent id of a notification, the id of the
+	// announcement or blog post, the position of an addition in its
+	// collage, counting from 1, or the group id of a release. It
+	// increases with each new event of a kind.
+	ID    int       `json:"id"`
+	Title string    `json:"title"`
+	Time  time.Time `json:"time"`
+	// Payload is the item from the site the event was raised for: a
+	// NotificationTorrent, an announcement or blog post, a
+	// CollageAddition or an ArtistRelease.
+	Payload interface{} `json:"payload"`
+}
+
+// Sink receives the events a Watcher raises. A sink that returns an error
+// is given the same event again on the next poll, so delivery is at least
+// once and sinks should tolerate repeats.
+type Sink interface {
+	Deliver(ctx context.Context, e Event) error
+}
+
+// WebhookSink POSTs each event as JSON to URL.
+type WebhookSink struct {
+	URL string
+	// Client makes the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// Deliver posts e, and fails unless the response status is 2xx.
+func (s WebhookSink) Deliver(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// JSONSink writes each event to a writer as a line of JSON, for example to
+// os.Stdout to pipe events into another program.
+type JSONSink struct {
+	mu sync.Mutex
+	e  *json.Encoder
+}
+
+// NewJSONSink returns a sink writing to w.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{e: json.NewEncoder(w)}
+}
+
+// Deliver writes e.
+func (s *JSONSink) Deliver(ctx context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.e.Encode(e)
+}
+
+// ChanSink sends each event on a channel, waiting for a receiver.
+type ChanSink chan<- Event
+
+// Deliver sends e, or fails if ctx is done first.
+func (s ChanSink) Deliver(ctx context.Context, e Event) error {
+	select {
+	case s <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Watcher polls the site for new notifications, announcements and blog
+// posts, and delivers them to its sinks in the order they happened. It
+// remembers the last event of each kind delivered, in the cache database
+// if it has one, so a restarted watcher carries on where it stopped. The
+// first poll with no cursor delivers everything the site returns.
+//
+// Responses come through the client, so a cached client should cache for
+// less than the poll interval.
+type Watcher struct {
+	client   Client
+	db       *sql.DB
+	name     string
+	sinks    []Sink
+	cursor   map[string]int
+	collages []int
+	artists  bool
+}
+
+// NewWatcher returns a watcher polling c and delivering to sinks. Cursors
+// are kept in db under name, so several watchers can share a database;
+// with a nil db they are only kept in memory. db must have been through
+// MigrateCache, as Cache does.
+func NewWatcher(c Client, db *sql.DB, name string, sinks ...Sink) *Watcher {
+	return &Watcher{client: c, db: db, name: name, sinks: sinks, cursor: map[string]int{}}
+}
+
+// Run polls every interval until ctx is done, then returns ctx's error.
+// Errors from a poll are passed to onErr, if it isn't nil, and the watcher
+// carries on.
+func (wa *Watcher) Run(ctx context.Context, interval time.Duration, onErr func(error)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := wa.Poll(ctx); err != nil && onErr != nil {
+			onErr(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Poll fetches the site once and delivers any new events. It stops at the
+// first event a sink fails to take, so that event and those after it are
+// delivered again on the next poll.
+func (wa *Watcher) Poll(ctx context.Context) error {
+	n, err := wa.client.GetNotificationsPage(NotificationsOptions{})
+	if err != nil {
+		return err
+	}
+	events := []Event{}
+	for _, t := range n.Results {
+		tm, _ := t.Time()
+		events = append(events, Event{
+			Kind:    EventNotification,
+			ID:      t.TorrentID,
+			Title:   t.GroupName,
+			Time:    tm,
+			Payload: t,
+		})
+	}
+	if err = wa.deliver(ctx, EventNotification, events); err != nil {
+		return err
+	}
+	a, err := wa.client.GetAnnouncements()
+	if err != nil {
+		return err
+	}
+	events = []Event{}
+	for _, n := range a.Announcements {
+		tm, _ := time.Parse(timeLayout, n.NewsTime)
+		events = append(events, Event{
+			Kind:    EventAnnouncement,
+			ID:      n.NewsID,
+			Title:   n.Title,
+			Time:    tm,
+			Payload: n,
+		})
+	}
+	if err = wa.deliver(ctx, EventAnnouncement, events); err != nil {
+		return err
+	}
+	events = []Event{}
+	for _, b := range a.BlogPosts {
+		tm, _ := time.Parse(timeLayout, b.BlogTime)
+		events = append(events, Event{
+			Kind:    EventBlogPost,
+			ID:      b.BlogID,
+			Title:   b.Title,
+			Time:    tm,
+			Payload: b,
+		})
+	}
+	if err = wa.deliver(ctx, EventBlogPost, events); err != nil {
+		return err
+	}
+	for _, id := range wa.collages {
+		if err = wa.pollCollage(ctx, id); err != nil {
+			return err
+		}
+	}
+	if wa.artists {
+		return wa.pollBookmarkedArtists(ctx)
+	}
+	return nil
+}
+
+// deliver sends the events newer than the cursor named cursor to every
+// sink, oldest first, advancing the cursor after each one all the sinks
+// took.
+func (wa *Watcher) deliver(ctx context.Context, cursor string, events []Event) error {
+	last, _, err := wa.loadCursor(ctx, cursor)
+	if err != nil {
+		return err
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	for _, e := range events {
+		if e.ID <= last {
+			continue
+		}
+		for _, s := range wa.sinks {
+			if err = s.Deliver(ctx, e); err != nil {
+				return err
+			}
+		}
+		last = e.ID
+		if err = wa.saveCursor(ctx, cursor, last); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadCursor returns the cursor named kind, and reports whether it has
+// been saved before.
+func (wa *Watcher) loadCursor(ctx context.Context, kind string) (int, bool, error) {
+	if last, ok := wa.cursor[kind]; ok || wa.db == nil {
+		return last, ok, nil
+	}
+	var last int
+	err := retryBusy(ctx, func() error {
+		return wa.db.QueryRowContext(ctx,
+			`SELECT last FROM watchcursors WHERE name=? AND kind=?`,
+			wa.name, kind).Scan(&last)
+	})
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	wa.cursor[kind] = last
+	return last, true, nil
+}
+
+func (wa *Watcher) saveCursor(ctx context.Context, kind string, last int) error {
+	wa.cursor[kind] = last
+	if wa.db == nil {
+		return nil
+	}
+	return writeCache(ctx, wa.db, func() error {
+		_, err := wa.db.ExecContext(ctx,
+			`REPLACE INTO watchcursors VALUES(?,?,?)`, wa.name, kind, last)
+		return err
+	})
+}
@@ -0,0 +1,270 @@
+package whatapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// watchedClient is a site with the notifications in torrents and one
+// announcement.
+type watchedClient struct {
+	Client
+	torrents []int
+}
+
+func (c *watchedClient) GetNotificationsPage(opts NotificationsOptions) (Notifications, error) {
+	n := Notifications{}
+	for _, id := range c.torrents {
+		n.Results = append(n.Results, NotificationTorrent{TorrentID: id, GroupName: "Album"})
+	}
+	return n, nil
+}
+
+func (c *watchedClient) GetAnnouncements() (Announcements, error) {
+	a := Announcements{}
+	err := json.Unmarshal([]byte(`{"announcements":[{"newsId":7,"title":"News","newsTime":"2020-01-01 00:00:00"}]}`), &a)
+	return a, err
+}
+
+// flakySink records the events it takes, and refuses the event with the
+// id in failOn once.
+type flakySink struct {
+	failOn    int
+	delivered []int
+}
+
+func (s *flakySink) Deliver(ctx context.Context, e Event) error {
+	if e.ID == s.failOn {
+		s.failOn = 0
+		return errors.New("sink unavailable")
+	}
+	s.delivered = append(s.delivered, e.ID)
+	return nil
+}
+
+func openCache(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err = MigrateCache(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func cursorOf(t *testing.T, db *sql.DB, name, kind string) int {
+	t.Helper()
+	var last int
+	err := db.QueryRow(`SELECT last FROM watchcursors WHERE name=? AND kind=?`, name, kind).Scan(&last)
+	if err != nil && err != sql.ErrNoRows {
+		t.Fatal(err)
+	}
+	return last
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWatcherRedelivers(t *testing.T) {
+	db := openCache(t)
+	c := &watchedClient{torrents: []int{3, 1, 2}}
+	sink := &flakySink{failOn: 2}
+	ctx := context.Background()
+
+	if err := NewWatcher(c, db, "test", sink).Poll(ctx); err == nil {
+		t.Error("expected the sink's error")
+	}
+	if !equalInts(sink.delivered, []int{1}) {
+		t.Errorf("expected delivery to stop at the failed event, got %v", sink.delivered)
+	}
+	if last := cursorOf(t, db, "test", EventNotification); last != 1 {
+		t.Errorf("expected the cursor at the last event taken, got %d", last)
+	}
+
+	// a restarted watcher carries on from the failed event
+	if err := NewWatcher(c, db, "test", sink).Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !equalInts(sink.delivered, []int{1, 2, 3, 7}) {
+		t.Errorf("expected the failed event delivered again, got %v", sink.delivered)
+	}
+	if last := cursorOf(t, db, "test", EventNotification); last != 3 {
+		t.Errorf("expected the cursor moved on, got %d", last)
+	}
+
+	// and then only delivers what is new
+	sink.delivered = nil
+	c.torrents = append(c.torrents, 4)
+	if err := NewWatcher(c, db, "test", sink).Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !equalInts(sink.delivered, []int{4}) {
+		t.Errorf("expected only the new event, got %v", sink.delivered)
+	}
+
+	// a watcher by another name has its own cursors
+	other := &flakySink{}
+	if err := NewWatcher(c, db, "other", other).Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !equalInts(other.delivered, []int{1, 2, 3, 4, 7}) {
+		t.Errorf("expected every event, got %v", other.delivered)
+	}
+}
+
+func TestWatcherInMemory(t *testing.T) {
+	c := &watchedClient{torrents: []int{1, 2}}
+	sink := &flakySink{failOn: 1}
+	wa := NewWatcher(c, nil, "test", sink)
+	ctx := context.Background()
+	if err := wa.Poll(ctx); err == nil {
+		t.Error("expected the sink's error")
+	}
+	if err := wa.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := wa.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !equalInts(sink.delivered, []int{1, 2, 7}) {
+		t.Errorf("expected each event once after the failure, got %v", sink.delivered)
+	}
+}
+
+// collageSite is a site with a collage of groups, and nothing else new.
+type collageSite struct {
+	watchedClient
+	groups []int
+}
+
+func (c *collageSite) GetCollageAll(id int) (Collage, error) {
+	col := Collage{ID: id, Name: "Best of"}
+	for _, g := range c.groups {
+		col.TorrentGroups = append(col.TorrentGroups, CollageGroup{ID: g, Name: "Album"})
+	}
+	return col, nil
+}
+
+func TestWatchCollages(t *testing.T) {
+	db := openCache(t)
+	c := &collageSite{groups: []int{30, 10}}
+	ch := make(chan Event, 10)
+	ctx := context.Background()
+	poll := func() []int {
+		t.Helper()
+		if err := NewWatcher(c, db, "test", ChanSink(ch)).WatchCollages(4).Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+		var added []int
+		for len(ch) > 0 {
+			if e := <-ch; e.Kind == EventCollageAddition {
+				a := e.Payload.(CollageAddition)
+				if a.CollageID != 4 || a.CollageName != "Best of" {
+					t.Errorf("bad addition %+v", a)
+				}
+				added = append(added, a.Group.ID)
+			}
+		}
+		return added
+	}
+	if added := poll(); !equalInts(added, []int{30, 10}) {
+		t.Errorf("expected every entry on the first poll, got %v", added)
+	}
+	c.groups = append(c.groups, 5, 20)
+	if added := poll(); !equalInts(added, []int{5, 20}) {
+		t.Errorf("expected the new entries, got %v", added)
+	}
+	if last := cursorOf(t, db, "test", "collage/4"); last != 4 {
+		t.Errorf("expected the cursor at the last position, got %d", last)
+	}
+	c.groups = c.groups[2:]
+	if added := poll(); len(added) != 0 {
+		t.Errorf("expected nothing new after removals, got %v", added)
+	}
+	c.groups = append(c.groups, 40)
+	if added := poll(); !equalInts(added, []int{40}) {
+		t.Errorf("expected an entry added after removals, got %v", added)
+	}
+}
+
+// artistSite is a site with bookmarked artists and their groups, and
+// nothing else new.
+type artistSite struct {
+	watchedClient
+	groups map[int][]int
+}
+
+func (c *artistSite) GetArtistBookmarks() (ArtistBookmarks, error) {
+	b := ArtistBookmarks{}
+	for id := range c.groups {
+		b.Artists = append(b.Artists, ArtistID{ID: strconv.Itoa(id), Name: "Artist &amp; Co"})
+	}
+	return b, nil
+}
+
+func (c *artistSite) GetArtist(id int, params url.Values) (Artist, error) {
+	a := Artist{ID: id}
+	for _, g := range c.groups[id] {
+		a.TorrentGroup = append(a.TorrentGroup, ArtistGroupStruct{GroupID: g, GroupNameF: "Album"})
+	}
+	return a, nil
+}
+
+func TestWatchBookmarkedArtists(t *testing.T) {
+	db := openCache(t)
+	c := &artistSite{groups: map[int][]int{7: {30, 10}}}
+	ch := make(chan Event, 10)
+	ctx := context.Background()
+	poll := func() []int {
+		t.Helper()
+		if err := NewWatcher(c, db, "test", ChanSink(ch)).WatchBookmarkedArtists().Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+		var released []int
+		for len(ch) > 0 {
+			if e := <-ch; e.Kind == EventArtistRelease {
+				r := e.Payload.(ArtistRelease)
+				if r.ArtistName != "Artist & Co" || r.Group.GroupID != e.ID {
+					t.Errorf("bad release %+v", r)
+				}
+				released = append(released, r.ArtistID*100+e.ID)
+			}
+		}
+		return released
+	}
+	if released := poll(); len(released) != 0 {
+		t.Errorf("expected no back catalogue on the first poll, got %v", released)
+	}
+	if last := cursorOf(t, db, "test", "release/7"); last != 30 {
+		t.Errorf("expected the cursor at the latest group, got %d", last)
+	}
+	c.groups[7] = append(c.groups[7], 20, 40)
+	c.groups[8] = []int{35}
+	if released := poll(); !equalInts(released, []int{740}) {
+		t.Errorf("expected only the new group, got %v", released)
+	}
+	c.groups[8] = append(c.groups[8], 50)
+	if released := poll(); !equalInts(released, []int{850}) {
+		t.Errorf("expected the new group of a new bookmark, got %v", released)
+	}
+}
@@ -2,10 +2,12 @@
 package whatapi
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
@@ -31,9 +33,46 @@ func (p PSList) String() string {
 	return "whatapi PSList v1.0"
 }
 
+// Option configures a ClientStruct as it is created by NewClient.
+type Option func(*ClientStruct) error
+
+// WithSiteProfile overrides the site profile NewClient would otherwise
+// choose based on the host name of the URL.
+func WithSiteProfile(p SiteProfile) Option {
+	return func(w *ClientStruct) error {
+		w.profile = p
+		return nil
+	}
+}
+
+// WithRateLimit limits the client to n requests in any period. The
+// default is the 5 requests per 10 seconds Gazelle asks of API users. An
+// n of 0 disables rate limiting.
+func WithRateLimit(n int, period time.Duration) Option {
+	return func(w *ClientStruct) error {
+		w.limiter = nil
+		if n > 0 {
+			w.limiter = newRateLimiter(n, period)
+		}
+		return nil
+	}
+}
+
+// WithTransport sends the client's requests through rt instead of
+// http.DefaultTransport, for proxies, custom TLS settings or tests.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(w *ClientStruct) error {
+		// copy, so clones don't change the transport of their parent
+		c := *w.client
+		c.Transport = rt
+		w.client = &c
+		return nil
+	}
+}
+
 //NewClient creates a new client for the What.CD API using the provided URL.
-func NewClient(ur, agent string) (Client, error) {
-	cookieJar, err := cookiejar.New(nil)
+func NewClient(ur, agent string, opts ...Option) (Client, error) {
+	cookieJar, err := newSessionJar()
 	if err != nil {
 		return nil, err
 	}
@@ -41,43 +80,57 @@ func NewClient(ur, agent string) (Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ClientStruct{
+	client := &http.Client{
+		Jar:           cookieJar,
+		Transport:     newTransport(),
+		CheckRedirect: checkRedirect(DefaultMaxRedirects),
+	}
+	w := &ClientStruct{
 		baseURL:   *u,
 		userAgent: agent,
-		client:    &http.Client{Jar: cookieJar},
-		db:        nil,
-		cacheFor:  0,
-	}, nil
+		client:    client,
+		profile:   ProfileFor(u.Hostname()),
+		limiter:   newRateLimiter(5, 10*time.Second),
+		session:   &session{},
+		throttle:  &loginThrottle{},
+		stats:     &statsTracker{},
+	}
+	w.outer = w
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
 }
 
 // Cache caches requests and responses from a What.CD API client using
 // the provided sql db as a cache. It returns cached responses newer
-// than the cacheFor duration. It initialises the cache if needed.
-func Cache(whatAPI Client, db *sql.DB, cacheFor time.Duration) (Client, error) {
-	_, err := db.Exec(`
-CREATE TABLE IF NOT EXISTS urlcache (
-    requesturl TEXT PRIMARY KEY NOT NULL,
-    body       TEXT NOT NULL,
-    timestamp  DATETIME NOT NULL
-) WITHOUT ROWID;
-
-CREATE TABLE IF NOT EXISTS cookies (
-    url    TEXT PRIMARY KEY NOT NULL,
-    cookie TEXT NOT NULL
-) WITHOUT ROWID;
-`)
+// than the cacheFor duration. It initialises or upgrades the cache schema
+// if needed, and persists the client's session in db too. whatAPI must be
+// a Decorator, such as a client made by NewClient or another decorator.
+// The cache wraps a clone of whatAPI, as Clone makes, so whatAPI itself
+// is left uncached, but the clone keeps whatAPI's rate limit, so that
+// using both doesn't exceed it. Clients for different users or sites can share one
+// db, each sees only what it stored itself.
+func Cache(whatAPI Client, db *sql.DB, cacheFor time.Duration, opts ...CacheOption) (Client, error) {
+	d, ok := whatAPI.(Decorator)
+	if !ok {
+		return nil, errNotDecorator
+	}
+	inner, err := d.Clone(sharingRateLimit)
 	if err != nil {
 		return nil, err
 	}
-	w, ok := whatAPI.(*ClientStruct)
-	if !ok {
-		return nil,
-			fmt.Errorf("can only wrap ClientStruct at this time")
+	if err = inner.PersistSession(db); err != nil {
+		return nil, err
+	}
+	c := &cachingClient{Decorator: inner, db: db, cacheFor: cacheFor}
+	for _, opt := range opts {
+		opt(c)
 	}
-	wCopy := *w
-	wCopy.db = db
-	wCopy.cacheFor = cacheFor
-	return &wCopy, nil
+	inner.SetOuter(c)
+	return c, nil
 }
 
 type Group interface {
@@ -90,6 +143,9 @@ type Group interface {
 	String() string
 }
 
+// maxReleaseType is the highest release type ReleaseTypeString names.
+const maxReleaseType = 21
+
 func ReleaseTypeString(r int) string {
 	s := map[int]string{
 		1:  "Album",
@@ -126,6 +182,16 @@ type GroupExt interface {
 	Artists() []string
 	Importance() []int
 	WikiBody() string
+	// MainArtists and the other role accessors return the artists
+	// credited in each role. Artists returns the names of them all.
+	MainArtists() []MusicInfoStruct
+	With() []MusicInfoStruct
+	RemixedBy() []MusicInfoStruct
+	Composers() []MusicInfoStruct
+	Conductors() []MusicInfoStruct
+	DJs() []MusicInfoStruct
+	Producers() []MusicInfoStruct
+	CreditedArtists(roles ...Role) []MusicInfoStruct
 }
 
 func oneOrTwoMusicInfos(mi []MusicInfoStruct) string {
@@ -144,23 +210,22 @@ func GroupString(g Group) string {
 		if t != "classical" {
 			continue
 		}
-		gs, ok := g.(GroupStruct)
+		ge, ok := g.(GroupExt)
 		if !ok {
 			break
 		}
-		mi := gs.MusicInfo
 		s := []string{}
-		if i := oneOrTwoMusicInfos(mi.Composers); i != "" {
+		if i := oneOrTwoMusicInfos(ge.Composers()); i != "" {
 			s = append(s, i, "-")
 		}
-		s = append(s, gs.Name(), "-")
-		if i := oneOrTwoMusicInfos(mi.Artists); i != "" {
+		s = append(s, ge.Name(), "-")
+		if i := oneOrTwoMusicInfos(ge.MainArtists()); i != "" {
 			s = append(s, i)
 		}
-		if i := oneOrTwoMusicInfos(mi.Conductor); i != "" {
+		if i := oneOrTwoMusicInfos(ge.Conductors()); i != "" {
 			s = append(s, i)
 		}
-		s = append(s, fmt.Sprintf("(%4d)", gs.Year()))
+		s = append(s, fmt.Sprintf("(%4d)", ge.Year()))
 		return strings.Join(s, " ")
 	}
 	return fmt.Sprintf("%s - %s (%4d)", g.Artist(), g.Name(), g.Year())
@@ -179,6 +244,35 @@ type Torrent interface {
 	String() string
 	FileCount() int
 	FileSize() int64
+	LeechStatus() LeechStatus
+}
+
+// TorrentLog is implemented by torrents that report their rip log score
+// and whether they have a cue sheet.
+type TorrentLog interface {
+	HasLog() bool
+	HasCueSheet() bool
+	RipLogScore() int
+}
+
+// TorrentStats is implemented by torrents that report their peers and
+// snatches, when they were uploaded, and whether they are reported or
+// trumpable. Not every response says whether a torrent is reported or
+// trumpable, so IsReported and IsTrumpable also report whether it is
+// known.
+type TorrentStats interface {
+	SeederCount() int
+	LeecherCount() int
+	SnatchCount() int
+	IsReported() (reported, known bool)
+	IsTrumpable() (trumpable, known bool)
+	UploadTime() (time.Time, error)
+}
+
+// Paginated is implemented by list responses that come a page at a time.
+type Paginated interface {
+	PageNumber() int
+	PageCount() int
 }
 
 type TorrentCatalogueNumber interface {
@@ -194,7 +288,7 @@ type TorrentFiles interface {
 	TorrentRecordLabel
 	TorrentCatalogueNumber
 	FilePath() string
-	Files() ([]FileStruct, error)
+	Files() (FileList, error)
 }
 
 type TorrentExt interface {
@@ -221,36 +315,182 @@ func TorrentString(t Torrent) string {
 	return s
 }
 
-//Client represents a client for the What.CD API.
-type Client interface {
-	GetJSON(requestURL string, responseObj interface{}) error
-	Do(action string, params url.Values, result interface{}) error
-	CreateDownloadURL(id int) (string, error)
-	CreateUploadURL() (url.URL, string, error)
+// Authenticator logs a client in and out, and reads the account it is
+// logged in to.
+type Authenticator interface {
 	Login(username, password string) error
+	LoginWithAPIKey(username, key string) error
 	Logout() error
 	GetAccount() error
-	GetMailbox(params url.Values) (Mailbox, error)
-	GetConversation(id int) (Conversation, error)
-	GetNotifications(params url.Values) (Notifications, error)
+	GetAccountInfo() (Account, error)
+	RefreshKeys() (KeyChange, error)
+}
+
+// TorrentAPI reads, downloads and uploads torrents, and the top tens
+// they appear in.
+type TorrentAPI interface {
+	CreateDownloadURL(id int) (string, error)
+	CreateTokenDownloadURL(id int) (string, error)
+	DownloadTorrent(id int, useToken bool) ([]byte, error)
+	FetchImage(ctx context.Context, imageURL string) (Image, error)
+	SaveTorrents(ids []int, dir string, naming NamingFunc, opts SaveOptions) ([]SaveResult, error)
+	CreateUploadURL() (url.URL, string, error)
+	CreateUploadRequest(f UploadForm) (*http.Request, error)
+	GetTorrent(id int, params url.Values) (GetTorrentStruct, error)
+	GetRipLogs(id int) (RipLogs, error)
+	GetTorrentByHash(hash string) (GetTorrentStruct, error)
+	GetTorrentGroup(id int, params url.Values) (TorrentGroup, error)
+	GetTorrentGroupWith(id int, opts TorrentGroupOptions) (TorrentGroup, error)
+	GetTorrentGroupChanges(id int) (TorrentGroup, GroupDiff, error)
+	GetTorrentSnatchers(torrentID, page int) (TorrentSnatchers, error)
+	GetTorrentPeers(torrentID, page int) (TorrentPeers, error)
+	GetTopTenTorrents(params url.Values) (TopTenTorrents, error)
+	GetTopTenTags(params url.Values) (TopTenTags, error)
+	TopTorrentsDay(limit int) ([]TopTorrent, error)
+	TopTorrentsWeek(limit int) ([]TopTorrent, error)
+	TopTorrentsOverall(limit int) ([]TopTorrent, error)
+}
+
+// ArtistAPI reads artists and the artists similar to them.
+type ArtistAPI interface {
+	GetArtist(id int, params url.Values) (Artist, error)
+	GetArtistLazy(id int, opts ArtistOptions) (*LazyArtist, error)
+	GetArtistByName(name string, params url.Values) (Artist, int, error)
+	GetSimilarArtists(id, limit int) (SimilarArtists, error)
+}
+
+// CollageAPI reads collages, and creates, edits and subscribes to them.
+type CollageAPI interface {
+	GetCollage(id int) (Collage, error)
+	GetCollageAll(id int) (Collage, error)
+	CollageEntries(id int) *CollageIter
+	AddToCollage(collageID, groupID int) error
+	RemoveFromCollage(collageID, groupID int) error
+	SubscribeCollage(id int) error
+	UnsubscribeCollage(id int) error
+	CreateCollage(name, description string, category int, tags []string) (int, error)
+}
+
+// ModerationAPI edits torrents and merges their groups, and reads and
+// resolves reports, for users the site allows to.
+type ModerationAPI interface {
+	EditTorrent(torrentID int, fields TorrentEdit) error
+	SetRemaster(torrentID int, r RemasterInfo) error
+	MergeGroups(fromID, toID int) error
+	GetReports(category ReportCategory, page int) (Reports, error)
+	GetTorrentReports(page int) (Reports, error)
+	ResolveReport(reportID int, comment string) error
+}
+
+// ForumAPI reads the forums, announcements and comments, and posts
+// comments.
+type ForumAPI interface {
 	GetAnnouncements() (Announcements, error)
 	GetSubscriptions(params url.Values) (Subscriptions, error)
 	GetCategories() (Categories, error)
 	GetForum(id int, params url.Values) (Forum, error)
 	GetThread(id int, params url.Values) (Thread, error)
+	GetTorrentComments(groupID, page int) (Comments, error)
+	GetArtistComments(artistID, page int) (Comments, error)
+	AddComment(page CommentPage, id int, body string) error
+}
+
+// UserAPI reads users, and the logged in user's friends, bookmarks,
+// bonus points and seeding.
+type UserAPI interface {
+	GetUser(id int) (User, error)
+	GetUserPosts(userID, page int) (UserPosts, error)
+	GetUserComments(userID, page int) (UserComments, error)
+	GetFriends() ([]Friend, error)
+	AddFriend(userID int) error
+	RemoveFriend(userID int) error
 	GetArtistBookmarks() (ArtistBookmarks, error)
 	GetTorrentBookmarks() (TorrentBookmarks, error)
-	GetArtist(id int, params url.Values) (Artist, error)
-	GetRequest(id int, params url.Values) (Request, error)
-	GetTorrent(id int, params url.Values) (GetTorrentStruct, error)
-	GetTorrentGroup(id int, params url.Values) (TorrentGroup, error)
+	BonusStore() (BonusStore, error)
+	GetSeedingReport() (SeedingReport, error)
+	GetUserTorrents(userID int, list string, limit, offset int) ([]UserTorrent, error)
+	FindDuplicates(userID int, lists ...string) ([]Duplicate, error)
+	GetTopTenUsers(params url.Values) (TopTenUsers, error)
+}
+
+// SearchAPI searches torrents, requests and users, and reads requests.
+type SearchAPI interface {
 	SearchTorrents(searchStr string, params url.Values) (TorrentSearch, error)
+	SearchTorrentsWith(searchStr string, opts TorrentSearchOptions) (TorrentSearch, error)
+	GetRequest(id int, params url.Values) (Request, error)
 	SearchRequests(searchStr string, params url.Values) (RequestsSearch, error)
+	SearchRequestsIter(searchStr string, opts RequestsOptions) *RequestsIter
+	TopBounties(limit int) ([]RequestsSearchResult, error)
+	GetRequestsForArtist(artistID int) ([]RequestsSearchResult, error)
+	GetRequestsForGroup(groupID int) ([]RequestsSearchResult, error)
 	SearchUsers(searchStr string, params url.Values) (UserSearch, error)
-	GetTopTenTorrents(params url.Values) (TopTenTorrents, error)
-	GetTopTenTags(params url.Values) (TopTenTags, error)
-	GetTopTenUsers(params url.Values) (TopTenUsers, error)
-	GetSimilarArtists(id, limit int) (SimilarArtists, error)
+	SearchUsersIter(searchStr string) *UsersIter
+	SearchUsersAll(searchStr string, opts UserSearchOptions) ([]UserSearchHit, error)
+	FindAlbum(artist, album string, year int, opts AlbumOptions) ([]AlbumCandidate, error)
+}
+
+// InboxAPI reads the logged in user's private messages and torrent
+// notifications, and manages which events notify them.
+type InboxAPI interface {
+	GetMailbox(params url.Values) (Mailbox, error)
+	GetConversation(id int) (Conversation, error)
+	GetNotifications(params url.Values) (Notifications, error)
+	GetNotificationsPage(opts NotificationsOptions) (Notifications, error)
+	GetUnreadCounts() (UnreadCounts, error)
+	MarkNotificationsRead(torrentIDs ...int) error
+	GetNotificationSettings() (NotificationSettings, error)
+	UpdateNotificationSettings(s NotificationSettings) error
+}
+
+// Client represents a client for the What.CD API. It is the union of the
+// APIs above and the requests, caching and rate limiting they go through,
+// so that code needing only one of them can ask for just that.
+type Client interface {
+	Authenticator
+	TorrentAPI
+	ArtistAPI
+	CollageAPI
+	ModerationAPI
+	ForumAPI
+	UserAPI
+	SearchAPI
+	InboxAPI
+	Namespace() string
+	PageURL(endpoint string, params url.Values) (string, error)
+	GetJSON(requestURL string, responseObj interface{}) error
+	GetJSONContext(ctx context.Context, requestURL string, responseObj interface{}) error
+	Do(action string, params url.Values, result interface{}) error
+	DoContext(ctx context.Context, action string, params url.Values, result interface{}) error
+	DoPost(ctx context.Context, action string, params url.Values, result interface{}) error
+	Capabilities() []Capability
+	Prefetch(urls []string) <-chan error
+	PrefetchProgress(urls []string, p Progress) <-chan error
+	PrefetchAction(action string, paramSets []url.Values) <-chan error
+	CacheMaintenance(ctx context.Context) (MaintenanceReport, error)
+	Stats() map[string]ActionStats
+	WithContext(ctx context.Context) Client
+}
+
+// Decorator is a client that decorators, such as the caching client Cache
+// makes, can wrap. Every request it makes goes through FetchContext or
+// PostContext, which a decorator intercepts, and its own methods send
+// their requests through the outermost decorator, set with SetOuter.
+// Clients made by NewClient and Cache are Decorators.
+//
+// A decorator embeds the Decorator it wraps, overrides FetchContext and
+// PostContext, and overrides Clone to wrap the clone of the client it
+// wraps, calling SetOuter on that clone.
+type Decorator interface {
+	Client
+	FetchContext(ctx context.Context, requestURL string) ([]byte, error)
+	PostContext(ctx context.Context, requestURL string, form url.Values) ([]byte, error)
+	SetOuter(c Decorator)
+	PersistSession(db *sql.DB) error
+	// Cached returns the cached response to requestURL, expired or
+	// not, and when it was fetched, or a nil response if there isn't
+	// one.
+	Cached(ctx context.Context, requestURL string) ([]byte, time.Time, error)
+	Clone(opts ...Option) (Decorator, error)
 }
 
 //ClientStruct represents a client for the What.CD API.
@@ -258,11 +498,26 @@ type ClientStruct struct {
 	baseURL   url.URL
 	userAgent string
 	client    *http.Client
-	authkey   string
-	passkey   string
-	loggedIn  bool
+	session   *session
 	db        *sql.DB
-	cacheFor  time.Duration
+	outer     Decorator
+	profile   SiteProfile
+	limiter   *rateLimiter
+	// budget is the rate limit shared with clones, see WithRateBudget
+	budget     *rateLimiter
+	background bool
+	keySecret  []byte
+	// maxResponseSize maps actions to their response size limits
+	maxResponseSize map[string]int64
+	relogin         *relogin
+	challenge       *challenge
+	throttle        *loginThrottle
+	stats           *statsTracker
+	keyChangeHook   func(KeyChange)
+	apiKey          string
+	// ctx is the context the client's methods make their requests
+	// with, set by WithContext
+	ctx context.Context
 }
 
 // Client gets the http client for low level requests
@@ -271,88 +526,267 @@ func (w ClientStruct) Client() *http.Client {
 }
 
 // doRequest exectutes an http.Request on this server and returns the results
-// or an error if the response was anything except 200
-func (w *ClientStruct) doRequest(req *http.Request) ([]byte, error) {
-	req.Header.Set("User-Agent", w.userAgent)
+// or an error if the response was anything except 200. Background requests
+// yield to any other requests waiting on the rate limit.
+func (w *ClientStruct) doRequest(req *http.Request, background bool) ([]byte, error) {
+	body, _, err := w.doRequestURL(req, background)
+	return body, err
+}
+
+// doRequestURL is doRequest that also returns the URL of the page the
+// response came from, after any redirects.
+func (w *ClientStruct) doRequestURL(req *http.Request, background bool) ([]byte, *url.URL, error) {
+	if w.challenge == nil {
+		return w.sendRequest(req, background)
+	}
+	generation := w.challenge.current()
+	retry := retryChallenged(req)
+	body, u, err := w.sendRequest(req, background)
+	if !isChallenge(err) || retry == nil {
+		return body, u, err
+	}
+	if err = w.challenge.solve(w, req, generation); err != nil {
+		return nil, nil, err
+	}
+	countRetry(req.Context())
+	return w.sendRequest(retry, background)
+}
+
+// sendRequest sends req once, for doRequestURL.
+func (w *ClientStruct) sendRequest(req *http.Request, background bool) ([]byte, *url.URL, error) {
+	background = background || w.background
+	w.limiter.wait(background)
+	w.budget.wait(background)
+	req.Header.Set("User-Agent", w.agent())
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", w.authorization())
+	}
+	start := time.Now()
+	body, u, err := w.roundTrip(req)
+	w.stats.record(statsKey(req.URL), time.Since(start), len(body), err)
+	return body, u, err
+}
+
+// roundTrip sends req and checks the response, for sendRequest.
+func (w *ClientStruct) roundTrip(req *http.Request) ([]byte, *url.URL, error) {
 	resp, err := w.client.Do(req)
+	// rather than wrapped in a *url.Error
+	var redirect *RedirectError
+	if errors.As(err, &redirect) {
+		return nil, nil, redirect
+	}
+	var pin *PinError
+	if errors.As(err, &pin) {
+		return nil, nil, pin
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, errRequestFailedReason("Status Code " + resp.Status)
+		page, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxBlockedPageSize))
+		if err := blocked(resp, page); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errRequestFailedReason("Status Code " + resp.Status)
+	}
+	if req.URL.Path != resp.Request.URL.Path && strings.HasSuffix(resp.Request.URL.Path, w.profile.path("login.php")) {
+		// redirected to the login page
+		return nil, nil, ErrSessionExpired
+	}
+	body, err := readLimited(resp.Body, w.maxSize(req.URL.String()))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := blocked(resp, body); err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Request.URL, nil
+}
+
+// postForm posts params, with the authkey, to the page at path. It is for
+// site actions that have no ajax equivalent, and are never cached.
+func (w *ClientStruct) postForm(path string, params url.Values) ([]byte, error) {
+	body, _, err := w.postFormURL(path, params)
+	return body, err
+}
+
+// getPage retrieves the site's page at endpoint with params, for what the
+// API doesn't return.
+func (w *ClientStruct) getPage(endpoint string, params url.Values) ([]byte, error) {
+	if !w.session.isLoggedIn() {
+		return nil, errRequestFailedLogin
 	}
-	body, err := ioutil.ReadAll(resp.Body)
+	pageURL, err := w.PageURL(endpoint, params)
 	if err != nil {
 		return nil, err
 	}
-	return body, nil
+	req, err := http.NewRequestWithContext(w.context(), "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return w.doRequest(req, false)
 }
 
-func (w *ClientStruct) updateCache(requestURL string, body []byte) error {
-	if w.db == nil {
-		return nil
+// postFormURL is postForm that also returns the URL of the page the site
+// redirected to, which often holds the id of what the action created.
+func (w *ClientStruct) postFormURL(path string, params url.Values) ([]byte, *url.URL, error) {
+	if !w.session.isLoggedIn() {
+		return nil, nil, errRequestFailedLogin
 	}
-	res, err := w.db.Exec(
-		"REPLACE INTO urlcache (requesturl, body, timestamp) "+
-			"VALUES(?,?, datetime('now'))",
-		requestURL, body)
+	authkey, _ := w.session.keys()
+	params.Set("auth", authkey)
+	postURL, err := buildURL(w.baseURL, w.profile.path(path), "", nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	rows, err := res.RowsAffected()
+	req, err := http.NewRequest("POST", postURL, strings.NewReader(params.Encode()))
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	if rows != 1 {
-		return fmt.Errorf(
-			"INSERT affected %d rows, expected 1", rows)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return w.doRequestURL(req, false)
+}
+
+//Namespace identifies the client's user and site, and keys everything the client stores in a cache database, so that clients for different users or sites can share one.
+func (w *ClientStruct) Namespace() string {
+	return w.session.user() + "@" + w.baseURL.String()
+}
+
+//SetOuter makes c, a decorator wrapping this client, the client this client's own methods send their requests through.
+func (w *ClientStruct) SetOuter(c Decorator) {
+	w.outer = c
+}
+
+//PersistSession stores the client's cookies and keys in db, so that a later client can reuse the session without logging in again.
+func (w *ClientStruct) PersistSession(db *sql.DB) error {
+	if err := MigrateCache(db); err != nil {
+		return err
 	}
+	w.db = db
 	return nil
 }
 
-func (w *ClientStruct) cachedResponse(requestURL string) (body []byte, err error) {
-	if w.db == nil {
-		return nil, nil
+//FetchContext sends a HTTP GET request to the API and returns the raw response body. It is the request decorators such as Cache intercept.
+func (w *ClientStruct) FetchContext(ctx context.Context, requestURL string) ([]byte, error) {
+	if !w.session.isLoggedIn() && reloginAllowed(ctx) {
+		return nil, errRequestFailedLogin
+	}
+	if directivesFrom(ctx).only {
+		// there is no cache to answer from
+		return nil, ErrNotCached
+	}
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := w.doRequest(req.WithContext(ctx), isBackground(ctx))
+	if err != nil {
+		return nil, err
 	}
+	if looksLikeHTML(body) {
+		if isLoginPage(body, w.profile.path("login.php")) {
+			return nil, ErrSessionExpired
+		}
+		return nil, ErrUnexpectedHTML
+	}
+	setResponseInfo(ctx, false, time.Now())
+	return body, nil
+}
 
-	var timestamp time.Time
-	err = w.db.QueryRow(
-		"SELECT body, timestamp FROM urlcache WHERE requesturl = ?", requestURL).
-		Scan(&body, &timestamp)
+//PostContext sends form, with the authkey added, to requestURL as a HTTP POST and returns the response body.
+func (w *ClientStruct) PostContext(ctx context.Context, requestURL string, form url.Values) ([]byte, error) {
+	if !w.session.isLoggedIn() {
+		return nil, errRequestFailedLogin
+	}
+	params := url.Values{}
+	for k, v := range form {
+		params[k] = v
+	}
+	authkey, _ := w.session.keys()
+	params.Set("auth", authkey)
+	req, err := http.NewRequest("POST", requestURL, strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
-	if body == nil || len(body) == 0 || time.Since(timestamp) > w.cacheFor {
-		return nil, sql.ErrNoRows
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, err := w.doRequest(req.WithContext(ctx), isBackground(ctx))
+	if err != nil {
+		return nil, err
 	}
-	return body, err
+	if looksLikeHTML(body) {
+		if isLoginPage(body, w.profile.path("login.php")) {
+			return nil, ErrSessionExpired
+		}
+		return nil, ErrUnexpectedHTML
+	}
+	setResponseInfo(ctx, false, time.Now())
+	return body, nil
+}
+
+// WithContext returns a view of the client whose methods, typed ones
+// such as GetTorrent included, make their requests with ctx, so that the
+// directives of WithCacheDirectives and the ResponseInfo of
+// WithResponseInfo reach them too. The view shares the client's session,
+// rate limit and decorators, so its requests are cached as the client's
+// are; call Clone on the client itself, not on the view.
+func (w *ClientStruct) WithContext(ctx context.Context) Client {
+	c := *w
+	c.ctx = ctx
+	return &c
+}
+
+// context returns the context the client's methods make their requests
+// with.
+func (w *ClientStruct) context() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
 }
 
 //GetJSON sends a HTTP GET request to the API and decodes the JSON response into responseObj.
 func (w *ClientStruct) GetJSON(requestURL string, responseObj interface{}) (err error) {
-	if !w.loggedIn {
+	return w.GetJSONContext(w.context(), requestURL, responseObj)
+}
+
+//GetJSONContext is GetJSON with a context that cancels both the HTTP request and any cache database operations.
+func (w *ClientStruct) GetJSONContext(ctx context.Context, requestURL string, responseObj interface{}) (err error) {
+	return w.getJSON(ctx, w.outer, requestURL, responseObj)
+}
+
+func (w *ClientStruct) getJSON(ctx context.Context, f Decorator, requestURL string, responseObj interface{}) error {
+	return w.fetchJSON(ctx, requestURL, responseObj, func() ([]byte, error) {
+		return f.FetchContext(ctx, requestURL)
+	})
+}
+
+// fetchJSON gets the response to requestURL with fetch, logging in again
+// and retrying if the session has expired, and decodes it into
+// responseObj.
+func (w *ClientStruct) fetchJSON(ctx context.Context, requestURL string, responseObj interface{}, fetch func() ([]byte, error)) error {
+	if !w.session.isLoggedIn() && reloginAllowed(ctx) {
 		return errRequestFailedLogin
 	}
-
-	body, err := w.cachedResponse(requestURL)
-	switch {
-	case w.db == nil || err == sql.ErrNoRows:
-		req, err := http.NewRequest("GET", requestURL, nil)
-		if err != nil {
-			return err
-		}
-		if body, err = w.doRequest(req); err != nil {
-			return err
-		}
-		if err = w.updateCache(requestURL, body); err != nil {
-			return err
+	relogin := w.relogin != nil && reloginAllowed(ctx)
+	var generation int
+	if relogin {
+		generation = w.relogin.current()
+	}
+	body, err := fetch()
+	if err == ErrSessionExpired && relogin {
+		if err = w.relogin.login(w, generation); err == nil {
+			body, err = fetch()
 		}
-	case err != nil:
+	}
+	if err == ErrSessionExpired {
+		// the persisted keys went with the session, so that the next
+		// Login starts a new one rather than trusting them again
+		w.clearKeys()
+	}
+	if err != nil {
 		return err
-	default:
-		break
 	}
 
 	var st GenericResponse
@@ -363,26 +797,20 @@ func (w *ClientStruct) GetJSON(requestURL string, responseObj interface{}) (err
 	if err := checkResponseStatus(st.Status, st.Error); err != nil {
 		return err
 	}
-	switch ro := responseObj.(type) {
-	case *ArtistResponse: // hack around orpheus bug in get artist
-		err := json.Unmarshal(body, ro)
-		if err != nil {
-			body = bytes.ReplaceAll(
-				body,
-				[]byte(`"extendedArtists":false`),
-				[]byte(`"extendedArtists":{}`))
-		}
-	case *TopTenTorrentsResponse: // hack around orpheus bug in top 10
-		err := json.Unmarshal(body, ro)
-		if err != nil {
-			body = bytes.ReplaceAll(
-				body,
-				[]byte(`"artist":false`),
-				[]byte(`"artist":""`))
-		}
-	default:
+	if err := json.Unmarshal(body, responseObj); err == nil {
+		return nil
+	}
+	// retry with the site's known bad values patched out
+	body = w.profile.fixup(actionOf(requestURL), body)
+	err = json.Unmarshal(body, responseObj)
+	if err == nil || w.profile.Strict {
+		return err
+	}
+	// and then with values of the wrong type converted
+	if body, cerr := coerce(body, responseObj); cerr == nil {
+		return json.Unmarshal(body, responseObj)
 	}
-	return json.Unmarshal(body, responseObj)
+	return err
 }
 
 type GenericResponse struct {
@@ -391,45 +819,105 @@ type GenericResponse struct {
 }
 
 func (w ClientStruct) Do(action string, params url.Values, result interface{}) error {
-	requestURL, err := buildURL(w.baseURL, "ajax.php", action, params)
+	return w.DoContext(w.context(), action, params, result)
+}
+
+//DoContext is Do with a context that cancels both the HTTP request and any cache database operations.
+func (w ClientStruct) DoContext(ctx context.Context, action string, params url.Values, result interface{}) error {
+	requestURL, err := w.ajaxURL(action, params)
 	if err != nil {
 		return err
 	}
-	return w.GetJSON(requestURL, result)
+	return w.GetJSONContext(ctx, requestURL, result)
+}
+
+//DoPost posts params to the provided ajax.php action and decodes the JSON response into result. Responses are only cached for actions the cache has been told are reads, with WithCachedPosts.
+func (w *ClientStruct) DoPost(ctx context.Context, action string, params url.Values, result interface{}) error {
+	requestURL, err := w.ajaxURL(action, nil)
+	if err != nil {
+		return err
+	}
+	return w.fetchJSON(ctx, requestURL, result, func() ([]byte, error) {
+		return w.outer.PostContext(ctx, requestURL, params)
+	})
 }
 
 //CreateDownloadURL constructs a download URL using the provided torrent id.
-func (w ClientStruct) CreateDownloadURL(id int) (string, error) {
-	if !w.loggedIn {
+func (w *ClientStruct) CreateDownloadURL(id int) (string, error) {
+	return w.createDownloadURL(id, false)
+}
+
+//CreateTokenDownloadURL constructs a download URL that spends a freeleech
+// token on the torrent when it is fetched.
+func (w *ClientStruct) CreateTokenDownloadURL(id int) (string, error) {
+	return w.createDownloadURL(id, true)
+}
+
+func (w *ClientStruct) createDownloadURL(id int, useToken bool) (string, error) {
+	if !w.session.isLoggedIn() {
 		return "", errRequestFailedLogin
 	}
+	if w.profile.Supports(CapDownloadToken) || w.profile.DownloadURLField != "" {
+		return w.signedDownloadURL(id, useToken)
+	}
 
 	params := url.Values{}
 	params.Set("action", "download")
 	params.Set("id", strconv.Itoa(id))
-	params.Set("authkey", w.authkey)
-	params.Set("torrent_pass", w.passkey)
-	downloadURL, err := buildURL(w.baseURL, "torrents.php", "", params)
+	authkey, passkey := w.session.keys()
+	params.Set("authkey", authkey)
+	params.Set("torrent_pass", passkey)
+	if useToken {
+		params.Set("usetoken", "1")
+	}
+	downloadURL, err := buildURL(w.baseURL, w.profile.path("torrents.php"), "", params)
 	if err != nil {
 		return "", err
 	}
 	return downloadURL, nil
 }
 
+//DownloadTorrent fetches the .torrent file for the provided torrent id,
+// spending a freeleech token on it if useToken is set.
+func (w *ClientStruct) DownloadTorrent(id int, useToken bool) ([]byte, error) {
+	downloadURL, err := w.createDownloadURL(id, useToken)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := w.doRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 || body[0] != 'd' {
+		return nil, errNotTorrent
+	}
+	return body, nil
+}
+
 //CreateUploadURL constructs an upload URL for this tracker, and returns the
 // url and autheky
 func (w ClientStruct) CreateUploadURL() (u url.URL, a string, err error) {
-	if !w.loggedIn {
+	if !w.session.isLoggedIn() {
 		return u, a, errRequestFailedLogin
 	}
 
-	a = w.authkey
-	u = w.baseURL
-	u.Path = "upload.php"
-	return u, a, err
+	a, _ = w.session.keys()
+	uploadURL, err := buildURL(w.baseURL, w.profile.path("upload.php"), "", nil)
+	if err != nil {
+		return u, a, err
+	}
+	p, err := url.Parse(uploadURL)
+	if err != nil {
+		return u, a, err
+	}
+	return *p, a, nil
 }
 
-func (w *ClientStruct) getCookies() error {
+func (w *ClientStruct) getCookies(ctx context.Context) error {
 	if w.db == nil {
 		return nil
 	}
@@ -437,8 +925,10 @@ func (w *ClientStruct) getCookies() error {
 		c  []byte
 		cs []*http.Cookie
 	)
-	err := w.db.QueryRow(`SELECT cookie FROM cookies WHERE url=?`,
-		w.baseURL.String()).Scan(&c)
+	err := retryBusy(ctx, func() error {
+		return w.db.QueryRowContext(ctx, `SELECT cookie FROM cookies WHERE namespace=? AND url=?`,
+			w.Namespace(), w.baseURL.String()).Scan(&c)
+	})
 	if err == sql.ErrNoRows {
 		return nil
 	}
@@ -453,7 +943,11 @@ func (w *ClientStruct) getCookies() error {
 }
 
 func (w *ClientStruct) clearCookies() (err error) {
-	w.client.Jar, err = cookiejar.New(nil)
+	if j, ok := w.client.Jar.(*sessionJar); ok {
+		err = j.reset()
+	} else {
+		w.client.Jar, err = cookiejar.New(nil)
+	}
 	if err != nil {
 		return err
 	}
@@ -470,60 +964,109 @@ func (w *ClientStruct) saveCookies() error {
 	if err != nil {
 		return err
 	}
-	_, err = w.db.Exec(`REPLACE INTO cookies VALUES(?,?)`,
-		w.baseURL.String(), c)
-	return err
+	return writeCache(context.Background(), w.db, func() error {
+		_, err := w.db.Exec(`REPLACE INTO cookies (namespace, url, cookie) VALUES(?,?,?)`,
+			w.Namespace(), w.baseURL.String(), c)
+		return err
+	})
 }
 
-//Login logs in to the API using the provided credentials.
+//Login logs in to the API using the provided credentials. A client made
+// with WithAPIKey has no session to start, so Login only fetches the
+// account's keys and ignores the password.
 func (w *ClientStruct) Login(username, password string) error {
+	w.session.setUser(username)
+	return w.login(username, password)
+}
+
+// login is Login for the user the client is already named for, as a
+// relogin does while other requests are using the client.
+func (w *ClientStruct) login(username, password string) error {
+	if w.apiKey != "" {
+		if err := w.GetAccount(); err != nil {
+			return err
+		}
+		w.session.setLoggedIn(true)
+		return nil
+	}
 	if w.db != nil {
-		err := w.getCookies() // sets cookie jar
+		err := w.getCookies(context.Background()) // sets cookie jar
 		if err != nil {
 			return err
 		}
-		// can get account without posting a login?
-		err = w.GetAccount()
-		if err == nil {
-			w.loggedIn = true
+		persisted, err := w.loadKeys(context.Background())
+		if err != nil && err != errStaleKeys {
+			return err
+		}
+		// can the session be resumed without posting a login? Keys
+		// persisted with it are trusted without asking the site; if
+		// the session has expired the first request finds out, and
+		// forgets them
+		if persisted {
+			w.session.setLoggedIn(true)
 			return nil
 		}
-		// nope, clear cookies and log in fresh
+		if err = w.GetAccount(); err == nil {
+			w.session.setLoggedIn(true)
+			return w.saveKeys()
+		}
+		// nope, forget the session and log in fresh
+		w.session.setKeys("", "")
+		if err = w.clearKeys(); err != nil {
+			return err
+		}
 		err = w.clearCookies()
 		if err != nil {
 			return err
 		}
 	}
+	if err := w.throttle.check(w, time.Now()); err != nil {
+		return err
+	}
 	params := url.Values{}
 	params.Set("username", username)
 	params.Set("password", password)
 
 	reqBody := strings.NewReader(params.Encode())
-	req, err := http.NewRequest("POST", w.baseURL.String()+"login.php", reqBody)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", w.userAgent)
-	resp, err := w.client.Do(req)
+	loginURL, err := buildURL(w.baseURL, w.profile.path("login.php"), "", nil)
 	if err != nil {
 		return err
 	}
-
-	defer resp.Body.Close()
-	if !strings.Contains(resp.Request.URL.String(), "index") {
+	var chain []*url.URL
+	req, err := http.NewRequestWithContext(recordRedirects(context.Background(), &chain), "POST", loginURL, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, _, err = w.doRequestURL(req, false); err != nil {
+		return err
+	}
+	if !w.loginSucceeded(chain) {
+		if err := w.throttle.record(w, false, time.Now()); err != nil {
+			return err
+		}
 		return errLoginFailed
 	}
-	w.loggedIn = true
+	if err := w.throttle.record(w, true, time.Now()); err != nil {
+		return err
+	}
+	w.session.setLoggedIn(true)
 	err = w.GetAccount()
 	if err != nil {
 		return err
 	}
 	err = w.saveCookies()
-	return err
+	if err != nil {
+		return err
+	}
+	return w.saveKeys()
 }
 
-//Logout logs out of the API, ending the current session.
+//Logout logs out of the API, ending the current session, and forgets the user, so that the client's Namespace is no longer theirs.
 func (w *ClientStruct) Logout() error {
-	params := url.Values{"auth": {w.authkey}}
-	requestURL, err := buildURL(w.baseURL, "logout.php", "", params)
+	authkey, _ := w.session.keys()
+	params := url.Values{"auth": {authkey}}
+	requestURL, err := buildURL(w.baseURL, w.profile.path("logout.php"), "", params)
 	if err != nil {
 		return err
 	}
@@ -531,22 +1074,23 @@ func (w *ClientStruct) Logout() error {
 	if err != nil {
 		return err
 	}
-	w.loggedIn, w.authkey, w.passkey = false, "", ""
-	return nil
+	err = w.clearKeys()
+	w.session.setLoggedIn(false)
+	w.session.setKeys("", "")
+	w.session.setUser("")
+	return err
 }
 
 //GetAccount retrieves account information for the current user.
 func (w *ClientStruct) GetAccount() error {
 	account := AccountResponse{}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "index", url.Values{})
+	requestURL, err := w.ajaxURL("index", url.Values{})
 	if err != nil {
 		return err
 	}
-	// don't cache login results
-	db, loggedIn := w.db, w.loggedIn
-	w.db, w.loggedIn = nil, true
-	err = w.GetJSON(requestURL, &account)
-	w.db, w.loggedIn = db, loggedIn
+	// don't cache login results. Login calls this before the client is
+	// logged in, and it mustn't log in again itself
+	err = w.getJSON(withoutRelogin(context.Background()), w, requestURL, &account)
 	if err != nil {
 		return err
 	}
@@ -554,14 +1098,14 @@ func (w *ClientStruct) GetAccount() error {
 	if err != nil {
 		return err
 	}
-	w.authkey, w.passkey = account.Response.AuthKey, account.Response.PassKey
+	w.session.setKeys(account.Response.AuthKey, account.Response.PassKey)
 	return nil
 }
 
 //GetMailbox retrieves mailbox information for the current user using the provided parameters.
 func (w *ClientStruct) GetMailbox(params url.Values) (Mailbox, error) {
 	mailbox := MailboxResponse{}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "inbox", params)
+	requestURL, err := w.ajaxURL("inbox", params)
 	if err != nil {
 		return mailbox.Response, err
 	}
@@ -578,7 +1122,7 @@ func (w *ClientStruct) GetConversation(id int) (Conversation, error) {
 	params := url.Values{}
 	params.Set("type", "viewconv")
 	params.Set("id", strconv.Itoa(id))
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "inbox", params)
+	requestURL, err := w.ajaxURL("inbox", params)
 	if err != nil {
 		return conversation.Response, err
 	}
@@ -592,7 +1136,7 @@ func (w *ClientStruct) GetConversation(id int) (Conversation, error) {
 //GetNotifications retrieves notification information using the specifed parameters.
 func (w *ClientStruct) GetNotifications(params url.Values) (Notifications, error) {
 	notifications := NotificationsResponse{}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "notifications", params)
+	requestURL, err := w.ajaxURL("notifications", params)
 	if err != nil {
 		return notifications.Response, err
 	}
@@ -607,7 +1151,7 @@ func (w *ClientStruct) GetNotifications(params url.Values) (Notifications, error
 func (w *ClientStruct) GetAnnouncements() (Announcements, error) {
 	params := url.Values{}
 	announcements := AnnouncementsResponse{}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "announcements", params)
+	requestURL, err := w.ajaxURL("announcements", params)
 	if err != nil {
 		return announcements.Response, err
 	}
@@ -621,7 +1165,7 @@ func (w *ClientStruct) GetAnnouncements() (Announcements, error) {
 //GetSubscriptions retrieves forum subscription information for the current user using the provided parameters.
 func (w *ClientStruct) GetSubscriptions(params url.Values) (Subscriptions, error) {
 	subscriptions := SubscriptionsResponse{}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "subscriptions", params)
+	requestURL, err := w.ajaxURL("subscriptions", params)
 	if err != nil {
 		return subscriptions.Response, err
 	}
@@ -637,7 +1181,7 @@ func (w *ClientStruct) GetCategories() (Categories, error) {
 	categories := CategoriesResponse{}
 	params := url.Values{}
 	params.Set("type", "main")
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "forum", params)
+	requestURL, err := w.ajaxURL("forum", params)
 	if err != nil {
 		return categories.Response, err
 	}
@@ -653,7 +1197,7 @@ func (w *ClientStruct) GetForum(id int, params url.Values) (Forum, error) {
 	forum := ForumResponse{}
 	params.Set("type", "viewforum")
 	params.Set("forumid", strconv.Itoa(id))
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "forum", params)
+	requestURL, err := w.ajaxURL("forum", params)
 	if err != nil {
 		return forum.Response, err
 	}
@@ -669,7 +1213,7 @@ func (w *ClientStruct) GetThread(id int, params url.Values) (Thread, error) {
 	thread := ThreadResponse{}
 	params.Set("type", "viewthread")
 	params.Set("threadid", strconv.Itoa(id))
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "forum", params)
+	requestURL, err := w.ajaxURL("forum", params)
 	if err != nil {
 		return thread.Response, err
 	}
@@ -685,7 +1229,7 @@ func (w *ClientStruct) GetArtistBookmarks() (ArtistBookmarks, error) {
 	artistBookmarks := ArtistBookmarksResponse{}
 	params := url.Values{}
 	params.Set("type", "artists")
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "bookmarks", params)
+	requestURL, err := w.ajaxURL("bookmarks", params)
 	if err != nil {
 		return artistBookmarks.Response, err
 	}
@@ -701,7 +1245,7 @@ func (w *ClientStruct) GetTorrentBookmarks() (TorrentBookmarks, error) {
 	torrentBookmarks := TorrentBookmarksResponse{}
 	params := url.Values{}
 	params.Set("type", "torrents")
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "bookmarks", params)
+	requestURL, err := w.ajaxURL("bookmarks", params)
 	if err != nil {
 		return torrentBookmarks.Response, err
 	}
@@ -712,13 +1256,14 @@ func (w *ClientStruct) GetTorrentBookmarks() (TorrentBookmarks, error) {
 	return torrentBookmarks.Response, checkResponseStatus(torrentBookmarks.Status, torrentBookmarks.Error)
 }
 
-//GetArtist retrieves artist information using the provided artist id and parameters.
+//GetArtist retrieves artist information using the provided artist id and parameters. A non-zero id takes precedence over an artistname in params, which is only looked up when id is 0.
 func (w *ClientStruct) GetArtist(id int, params url.Values) (Artist, error) {
 	artist := ArtistResponse{}
-	if _, ok := params["artistname"]; !ok || id != 0 {
+	if _, byName := params["artistname"]; id != 0 || !byName {
+		params.Del("artistname")
 		params.Set("id", strconv.Itoa(id))
 	}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "artist", params)
+	requestURL, err := w.ajaxURL("artist", params)
 	if err != nil {
 		return artist.Response, err
 	}
@@ -733,7 +1278,7 @@ func (w *ClientStruct) GetArtist(id int, params url.Values) (Artist, error) {
 func (w *ClientStruct) GetRequest(id int, params url.Values) (Request, error) {
 	request := RequestResponse{}
 	params.Set("id", strconv.Itoa(id))
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "request", params)
+	requestURL, err := w.ajaxURL("request", params)
 	if err != nil {
 		return request.Response, err
 	}
@@ -744,13 +1289,29 @@ func (w *ClientStruct) GetRequest(id int, params url.Values) (Request, error) {
 	return request.Response, checkResponseStatus(request.Status, request.Error)
 }
 
+//GetUser retrieves the profile of the user with the provided user id.
+func (w *ClientStruct) GetUser(id int) (User, error) {
+	user := UserResponse{}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(id))
+	requestURL, err := w.ajaxURL("user", params)
+	if err != nil {
+		return user.Response, err
+	}
+	err = w.GetJSON(requestURL, &user)
+	if err != nil {
+		return user.Response, err
+	}
+	return user.Response, checkResponseStatus(user.Status, user.Error)
+}
+
 //GetTorrent retrieves torrent information using the provided torrent id and parameters.
 func (w *ClientStruct) GetTorrent(id int, params url.Values) (GetTorrentStruct, error) {
 	torrent := TorrentResponse{}
 	if _, ok := params["hash"]; !ok || id != 0 {
 		params.Set("id", strconv.Itoa(id))
 	}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "torrent", params)
+	requestURL, err := w.ajaxURL("torrent", params)
 	if err != nil {
 		return torrent.Response, err
 	}
@@ -761,13 +1322,20 @@ func (w *ClientStruct) GetTorrent(id int, params url.Values) (GetTorrentStruct,
 	return torrent.Response, checkResponseStatus(torrent.Status, torrent.Error)
 }
 
+//GetTorrentByHash retrieves torrent information using the provided info hash.
+func (w *ClientStruct) GetTorrentByHash(hash string) (GetTorrentStruct, error) {
+	params := url.Values{}
+	params.Set("hash", strings.ToUpper(hash))
+	return w.GetTorrent(0, params)
+}
+
 //GetTorrentGroup retrieves torrent group information using the provided torrent group id and parameters.
 func (w *ClientStruct) GetTorrentGroup(id int, params url.Values) (TorrentGroup, error) {
 	torrentGroup := TorrentGroupResponse{}
 	if _, ok := params["hash"]; !ok || id != 0 {
 		params.Set("id", strconv.Itoa(id))
 	}
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "torrentgroup", params)
+	requestURL, err := w.ajaxURL("torrentgroup", params)
 	if err != nil {
 		return torrentGroup.Response, err
 	}
@@ -782,7 +1350,7 @@ func (w *ClientStruct) GetTorrentGroup(id int, params url.Values) (TorrentGroup,
 func (w *ClientStruct) SearchTorrents(searchStr string, params url.Values) (TorrentSearch, error) {
 	torrentSearch := TorrentSearchResponse{}
 	params.Set("searchstr", searchStr)
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "browse", params)
+	requestURL, err := w.ajaxURL("browse", params)
 	if err != nil {
 		return torrentSearch.Response, err
 	}
@@ -797,7 +1365,7 @@ func (w *ClientStruct) SearchTorrents(searchStr string, params url.Values) (Torr
 func (w *ClientStruct) SearchRequests(searchStr string, params url.Values) (RequestsSearch, error) {
 	requestsSearch := RequestsSearchResponse{}
 	params.Set("search", searchStr)
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "requests", params)
+	requestURL, err := w.ajaxURL("requests", params)
 	if err != nil {
 		return requestsSearch.Response, err
 	}
@@ -812,7 +1380,7 @@ func (w *ClientStruct) SearchRequests(searchStr string, params url.Values) (Requ
 func (w *ClientStruct) SearchUsers(searchStr string, params url.Values) (UserSearch, error) {
 	userSearch := UserSearchResponse{}
 	params.Set("search", searchStr)
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "usersearch", params)
+	requestURL, err := w.ajaxURL("usersearch", params)
 	if err != nil {
 		return userSearch.Response, err
 	}
@@ -827,7 +1395,7 @@ func (w *ClientStruct) SearchUsers(searchStr string, params url.Values) (UserSea
 func (w *ClientStruct) GetTopTenTorrents(params url.Values) (TopTenTorrents, error) {
 	topTenTorrents := TopTenTorrentsResponse{}
 	params.Set("type", "torrents")
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "top10", params)
+	requestURL, err := w.ajaxURL("top10", params)
 	if err != nil {
 		return topTenTorrents.Response, err
 	}
@@ -842,7 +1410,7 @@ func (w *ClientStruct) GetTopTenTorrents(params url.Values) (TopTenTorrents, err
 func (w *ClientStruct) GetTopTenTags(params url.Values) (TopTenTags, error) {
 	topTenTags := TopTenTagsResponse{}
 	params.Set("type", "tags")
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "top10", params)
+	requestURL, err := w.ajaxURL("top10", params)
 	if err != nil {
 		return topTenTags.Response, err
 	}
@@ -857,7 +1425,7 @@ func (w *ClientStruct) GetTopTenTags(params url.Values) (TopTenTags, error) {
 func (w *ClientStruct) GetTopTenUsers(params url.Values) (TopTenUsers, error) {
 	topTenUsers := TopTenUsersResponse{}
 	params.Set("type", "users")
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "top10", params)
+	requestURL, err := w.ajaxURL("top10", params)
 	if err != nil {
 		return topTenUsers.Response, err
 	}
@@ -874,7 +1442,7 @@ func (w *ClientStruct) GetSimilarArtists(id, limit int) (SimilarArtists, error)
 	params := url.Values{}
 	params.Set("id", strconv.Itoa(id))
 	params.Set("limit", strconv.Itoa(limit))
-	requestURL, err := buildURL(w.baseURL, "ajax.php", "similar_artists", params)
+	requestURL, err := w.ajaxURL("similar_artists", params)
 	if err != nil {
 		return similarArtists, err
 	}
@@ -884,3 +1452,94 @@ func (w *ClientStruct) GetSimilarArtists(id, limit int) (SimilarArtists, error)
 	}
 	return similarArtists, nil
 }
+
+//GetTorrentSnatchers retrieves a page of the users who have snatched the torrent with the provided id, on sites that expose it.
+func (w *ClientStruct) GetTorrentSnatchers(torrentID, page int) (TorrentSnatchers, error) {
+	snatchers := TorrentSnatchersResponse{}
+	action, err := w.profile.action(CapSnatchers)
+	if err != nil {
+		return snatchers.Response, err
+	}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(torrentID))
+	params.Set("page", strconv.Itoa(page))
+	requestURL, err := w.ajaxURL(action, params)
+	if err != nil {
+		return snatchers.Response, err
+	}
+	err = w.GetJSON(requestURL, &snatchers)
+	if err != nil {
+		return snatchers.Response, err
+	}
+	return snatchers.Response, checkResponseStatus(snatchers.Status, snatchers.Error)
+}
+
+//GetTorrentPeers retrieves a page of the current peers for the torrent with the provided id, on sites that expose it.
+func (w *ClientStruct) GetTorrentPeers(torrentID, page int) (TorrentPeers, error) {
+	peers := TorrentPeersResponse{}
+	action, err := w.profile.action(CapPeers)
+	if err != nil {
+		return peers.Response, err
+	}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(torrentID))
+	params.Set("page", strconv.Itoa(page))
+	requestURL, err := w.ajaxURL(action, params)
+	if err != nil {
+		return peers.Response, err
+	}
+	err = w.GetJSON(requestURL, &peers)
+	if err != nil {
+		return peers.Response, err
+	}
+	return peers.Response, checkResponseStatus(peers.Status, peers.Error)
+}
+
+//GetTorrentComments retrieves a page of comments on the torrent group with the provided id.
+func (w *ClientStruct) GetTorrentComments(groupID, page int) (Comments, error) {
+	comments := CommentsResponse{}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(groupID))
+	params.Set("page", strconv.Itoa(page))
+	requestURL, err := w.ajaxURL("tcomments", params)
+	if err != nil {
+		return comments.Response, err
+	}
+	err = w.GetJSON(requestURL, &comments)
+	if err != nil {
+		return comments.Response, err
+	}
+	return comments.Response, checkResponseStatus(comments.Status, comments.Error)
+}
+
+//GetArtistComments retrieves a page of comments on the artist with the provided id, on sites that expose it.
+func (w *ClientStruct) GetArtistComments(artistID, page int) (Comments, error) {
+	comments := CommentsResponse{}
+	action, err := w.profile.action(CapArtistComments)
+	if err != nil {
+		return comments.Response, err
+	}
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(artistID))
+	params.Set("page", strconv.Itoa(page))
+	requestURL, err := w.ajaxURL(action, params)
+	if err != nil {
+		return comments.Response, err
+	}
+	err = w.GetJSON(requestURL, &comments)
+	if err != nil {
+		return comments.Response, err
+	}
+	return comments.Response, checkResponseStatus(comments.Status, comments.Error)
+}
+
+//AddComment posts a comment with the provided BBCode body on the page of the provided kind and id.
+func (w *ClientStruct) AddComment(page CommentPage, id int, body string) error {
+	params := url.Values{}
+	params.Set("action", "take_post")
+	params.Set("page", string(page))
+	params.Set("pageid", strconv.Itoa(id))
+	params.Set("body", body)
+	_, err := w.postForm("comments.php", params)
+	return err
+}